@@ -7,6 +7,7 @@ package cmd
 import (
 	"errors"
 	"fmt"
+	"io"
 	"path/filepath"
 
 	"github.com/spf13/cobra"
@@ -16,7 +17,6 @@ import (
 	"github.com/elastic/elastic-package/internal/docs"
 	"github.com/elastic/elastic-package/internal/files"
 	"github.com/elastic/elastic-package/internal/logger"
-	"github.com/elastic/elastic-package/internal/packages"
 )
 
 const buildLongDescription = `Use this command to build a package.
@@ -27,6 +27,10 @@ Built packages are served up by the Elastic Package Registry running locally (se
 
 Built packages can also be published to the global package registry service.
 
+Builds are incremental: the contents of the package files used in each build are recorded in the "build/cache/" folder, so packages that haven't changed are not built again, and only changed files are processed in the following builds. Changes in the package manifest, the build manifest, the README templates, the links definitions file, the license or the version of elastic-package cause a full build. Use the --full-rebuild flag to ignore the results of previous builds.
+
+Use the --sbom flag to create a CycloneDX SBOM next to the zipped package. It lists the version of elastic-package, the ECS schema used to resolve external fields and the fields imported from it, the files included through links with their checksums, and the container images used by the service deployers in "_dev/deploy".
+
 For details on how to enable dependency management, see the [HOWTO guide](https://github.com/elastic/elastic-package/blob/main/docs/howto/dependency_management.md).`
 
 func setupBuildCommand() *cobraext.Command {
@@ -40,6 +44,9 @@ func setupBuildCommand() *cobraext.Command {
 	cmd.Flags().Bool(cobraext.BuildZipFlagName, true, cobraext.BuildZipFlagDescription)
 	cmd.Flags().Bool(cobraext.SignPackageFlagName, false, cobraext.SignPackageFlagDescription)
 	cmd.Flags().Bool(cobraext.BuildSkipValidationFlagName, false, cobraext.BuildSkipValidationFlagDescription)
+	cmd.Flags().Bool(cobraext.BuildFullRebuildFlagName, false, cobraext.BuildFullRebuildFlagDescription)
+	cmd.Flags().Bool(cobraext.BuildSBOMFlagName, false, cobraext.BuildSBOMFlagDescription)
+	addMultiPackageFlags(cmd)
 	return cobraext.NewCommand(cmd, cobraext.ContextPackage)
 }
 
@@ -49,11 +56,17 @@ func buildCommandAction(cmd *cobra.Command, args []string) error {
 	createZip, _ := cmd.Flags().GetBool(cobraext.BuildZipFlagName)
 	signPackage, _ := cmd.Flags().GetBool(cobraext.SignPackageFlagName)
 	skipValidation, _ := cmd.Flags().GetBool(cobraext.BuildSkipValidationFlagName)
+	fullRebuild, _ := cmd.Flags().GetBool(cobraext.BuildFullRebuildFlagName)
+	createSBOM, _ := cmd.Flags().GetBool(cobraext.BuildSBOMFlagName)
 
 	if signPackage && !createZip {
 		return errors.New("can't sign the unzipped package, please use also the --zip switch")
 	}
 
+	if createSBOM && !createZip {
+		return errors.New("can't create the SBOM of the unzipped package, please use also the --zip switch")
+	}
+
 	if signPackage {
 		err := files.VerifySignerConfiguration()
 		if err != nil {
@@ -61,39 +74,59 @@ func buildCommandAction(cmd *cobra.Command, args []string) error {
 		}
 	}
 
-	packageRoot, err := packages.MustFindPackageRoot()
-	if err != nil {
-		return fmt.Errorf("locating package root failed: %w", err)
-	}
-
 	buildDir, err := builder.BuildDirectory()
 	if err != nil {
 		return fmt.Errorf("can't prepare build directory: %w", err)
 	}
 	logger.Debugf("Use build directory: %s", buildDir)
 
-	targets, err := docs.UpdateReadmes(packageRoot, buildDir)
+	err = runPackageAction(cmd, func(cmd *cobra.Command, packageRoot string, w io.Writer) error {
+		return buildPackage(cmd, w, builder.BuildOptions{
+			PackageRoot:    packageRoot,
+			BuildDir:       buildDir,
+			CreateZip:      createZip,
+			SignPackage:    signPackage,
+			SkipValidation: skipValidation,
+			FullRebuild:    fullRebuild,
+			CreateSBOM:     createSBOM,
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	cmd.Println("Done")
+	return nil
+}
+
+// buildPackage renders the README files of the package and builds it. README files are not
+// rendered again if the package hasn't changed since the previous build.
+func buildPackage(cmd *cobra.Command, w io.Writer, options builder.BuildOptions) error {
+	upToDate, err := builder.UpToDate(options)
 	if err != nil {
-		return fmt.Errorf("updating files failed: %w", err)
+		return fmt.Errorf("checking previous build failed: %w", err)
 	}
+	if upToDate {
+		logger.Debugf("Package hasn't changed since the previous build, README files are not rendered (path: %s)", options.PackageRoot)
+	} else {
+		targets, err := docs.UpdateReadmes(options.PackageRoot, options.BuildDir)
+		if err != nil {
+			return fmt.Errorf("updating files failed: %w", err)
+		}
 
-	for _, target := range targets {
-		fileName := filepath.Base(target)
-		cmd.Printf("%s file rendered: %s\n", fileName, target)
+		for _, target := range targets {
+			fileName := filepath.Base(target)
+			fmt.Fprintf(w, "%s file rendered: %s\n", fileName, target)
+		}
 	}
 
-	target, err := builder.BuildPackage(cmd.Context(), builder.BuildOptions{
-		PackageRoot:    packageRoot,
-		BuildDir:       buildDir,
-		CreateZip:      createZip,
-		SignPackage:    signPackage,
-		SkipValidation: skipValidation,
-	})
+	target, err := builder.BuildPackage(cmd.Context(), options)
 	if err != nil {
 		return fmt.Errorf("building package failed: %w", err)
 	}
-	cmd.Printf("Package built: %s\n", target)
-
-	cmd.Println("Done")
+	fmt.Fprintf(w, "Package built: %s\n", target)
+	if options.CreateSBOM {
+		fmt.Fprintf(w, "SBOM created: %s\n", builder.SBOMPath(target))
+	}
 	return nil
 }
@@ -5,6 +5,7 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -16,6 +17,9 @@ import (
 	"github.com/elastic/elastic-package/internal/cobraext"
 	"github.com/elastic/elastic-package/internal/packages"
 	"github.com/elastic/elastic-package/internal/packages/changelog"
+	"github.com/elastic/elastic-package/internal/packages/diff"
+	"github.com/elastic/elastic-package/internal/registry"
+	"github.com/elastic/elastic-package/internal/tui"
 )
 
 const changelogLongDescription = `Use this command to work with the changelog of the package.
@@ -32,6 +36,20 @@ last entry in the current version
 Alternatively, you can start a new version indicating the specific version, or if it should
 be the next major, minor or patch version.`
 
+const changelogSuggestLongDescription = `Use this command to get a suggestion of changelog entries for the changes in the package.
+
+The package in the working directory is compared with its last released version, that is by default
+the latest version published in the registry, or the latest git tag of the package if it is not
+published yet. Git tags of the package are named <name>-<version> or <name>/v<version>, other tags
+are only used if the package is in the root of the repository. Another version can be selected with
+--from, as a git reference (a tag or a commit), a package directory or archive, or a version published in the registry.
+
+Changes are classified with the same rules as the diff command. A changelog entry is suggested for
+each type of change found, together with the new version, that increases the major, minor or patch
+version depending on the changes. If confirmed, the entries are added to the changelog and the
+version of the package is updated. The link of the entries is given with --link, it is asked
+for if missing, and it is required when the entries are applied with --yes.`
+
 func setupChangelogCommand() *cobraext.Command {
 	addChangelogCmd := &cobra.Command{
 		Use:   "add",
@@ -49,12 +67,24 @@ func setupChangelogCommand() *cobraext.Command {
 	addChangelogCmd.Flags().String(cobraext.ChangelogAddLinkFlagName, "", cobraext.ChangelogAddLinkFlagDescription)
 	addChangelogCmd.MarkFlagRequired(cobraext.ChangelogAddLinkFlagName)
 
+	suggestChangelogCmd := &cobra.Command{
+		Use:   "suggest",
+		Short: "Suggest changelog entries for the changes since the last release",
+		Long:  changelogSuggestLongDescription,
+		Args:  cobra.NoArgs,
+		RunE:  changelogSuggestCmd,
+	}
+	suggestChangelogCmd.Flags().String(cobraext.ChangelogSuggestFromFlagName, "", cobraext.ChangelogSuggestFromFlagDescription)
+	suggestChangelogCmd.Flags().String(cobraext.ChangelogAddLinkFlagName, "", cobraext.ChangelogSuggestLinkFlagDescription)
+	suggestChangelogCmd.Flags().BoolP(cobraext.ChangelogSuggestYesFlagName, "y", false, cobraext.ChangelogSuggestYesFlagDescription)
+
 	cmd := &cobra.Command{
 		Use:   "changelog",
 		Short: "Utilities to work with the changelog of the package",
 		Long:  changelogLongDescription,
 	}
 	cmd.AddCommand(addChangelogCmd)
+	cmd.AddCommand(suggestChangelogCmd)
 
 	return cobraext.NewCommand(cmd, cobraext.ContextPackage)
 }
@@ -108,6 +138,124 @@ func changelogAddCmd(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+func changelogSuggestCmd(cmd *cobra.Command, args []string) error {
+	packageRoot, err := packages.MustFindPackageRoot()
+	if err != nil {
+		return fmt.Errorf("locating package root failed: %w", err)
+	}
+	manifest, err := packages.ReadPackageManifestFromPackageRoot(packageRoot)
+	if err != nil {
+		return fmt.Errorf("reading package manifest failed (path: %s): %w", packageRoot, err)
+	}
+
+	from, _ := cmd.Flags().GetString(cobraext.ChangelogSuggestFromFlagName)
+	link, _ := cmd.Flags().GetString(cobraext.ChangelogAddLinkFlagName)
+	yes, _ := cmd.Flags().GetBool(cobraext.ChangelogSuggestYesFlagName)
+	if yes && link == "" {
+		return fmt.Errorf("flag %q is required when using %q", cobraext.ChangelogAddLinkFlagName, cobraext.ChangelogSuggestYesFlagName)
+	}
+
+	workDir, err := os.MkdirTemp("", "elastic-package-changelog-")
+	if err != nil {
+		return fmt.Errorf("can't prepare a temporary directory: %w", err)
+	}
+	defer os.RemoveAll(workDir)
+
+	releasedRoot, err := resolveReleasedPackage(cmd, from, packageRoot, manifest.Name, workDir)
+	if err != nil {
+		return fmt.Errorf("can't find released package: %w", err)
+	}
+
+	report, err := diff.Packages(releasedRoot, packageRoot)
+	if err != nil {
+		return fmt.Errorf("comparing packages failed: %w", err)
+	}
+	printDiffReport(cmd.OutOrStdout(), report)
+
+	suggestion, err := diff.Suggest(report, link)
+	if err != nil {
+		return fmt.Errorf("failed to suggest changelog entries: %w", err)
+	}
+	if suggestion == nil {
+		return nil
+	}
+
+	fmt.Fprintf(cmd.OutOrStdout(), "\nSuggested version: %s (%s bump from %s)\n", bold.Sprint(suggestion.Revision.Version), suggestion.Bump, report.OldVersion)
+	fmt.Fprintln(cmd.OutOrStdout(), "Suggested changelog entries:")
+	for _, entry := range suggestion.Revision.Changes {
+		fmt.Fprintf(cmd.OutOrStdout(), "  - type: %s\n    description: %s\n", entry.Type, entry.Description)
+		if entry.Link != "" {
+			fmt.Fprintf(cmd.OutOrStdout(), "    link: %s\n", entry.Link)
+		}
+	}
+
+	if !yes {
+		err = tui.AskOne(tui.NewConfirm("Add the suggested entries to the changelog?", false), &yes)
+		if err != nil {
+			return err
+		}
+		if !yes {
+			return nil
+		}
+	}
+
+	if link == "" {
+		err = tui.AskOne(tui.NewInput("Link to the pull request", ""), &link, tui.Required)
+		if err != nil {
+			return err
+		}
+		for i := range suggestion.Revision.Changes {
+			suggestion.Revision.Changes[i].Link = link
+		}
+	}
+
+	err = patchChangelogFile(packageRoot, suggestion.Revision)
+	if err != nil {
+		return err
+	}
+
+	err = setManifestVersion(packageRoot, suggestion.Revision.Version)
+	if err != nil {
+		return err
+	}
+
+	cmd.Printf("Changelog updated, package version is now %s\n", suggestion.Revision.Version)
+	return nil
+}
+
+// resolveReleasedPackage returns the root directory of the released version of the package. It
+// can be given as a git reference, a package directory or archive, or a version published in
+// the registry. It defaults to the latest published version, or to the latest git tag of the
+// package if it is not published.
+func resolveReleasedPackage(cmd *cobra.Command, from, packageRoot, name, workDir string) (string, error) {
+	switch {
+	case from == "":
+		releasedRoot, err := diff.ResolveSource(name, workDir, registry.Production)
+		if !errors.Is(err, diff.ErrNotPublished) {
+			return releasedRoot, err
+		}
+		tag, tagErr := diff.LatestGitTag(cmd.Context(), packageRoot, name)
+		if tagErr != nil {
+			return "", fmt.Errorf("%w, and %w", err, tagErr)
+		}
+		cmd.Printf("Package %s is not published, comparing with git tag %s\n", name, tag)
+		return diff.ResolveGitSource(cmd.Context(), tag, packageRoot, workDir)
+	case fileExists(from):
+		return diff.ResolveSource(from, workDir, registry.Production)
+	case diff.IsGitReference(cmd.Context(), packageRoot, from):
+		return diff.ResolveGitSource(cmd.Context(), from, packageRoot, workDir)
+	}
+	if _, err := semver.NewVersion(from); err == nil {
+		from = name + "@" + from
+	}
+	return diff.ResolveSource(from, workDir, registry.Production)
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
 func changelogCmdVersion(nextMode, packageRoot string) (*semver.Version, error) {
 	revisions, err := changelog.ReadChangelogFromPackageRoot(packageRoot)
 	if err != nil {
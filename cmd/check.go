@@ -6,15 +6,24 @@ package cmd
 
 import (
 	"fmt"
+	"io"
+	"strings"
 
 	"github.com/spf13/cobra"
 
+	"github.com/elastic/elastic-package/internal/builder"
 	"github.com/elastic/elastic-package/internal/cobraext"
+	"github.com/elastic/elastic-package/internal/docs"
+	"github.com/elastic/elastic-package/internal/packages/lintrules"
 )
 
 const checkLongDescription = `Use this command to verify if the package is correct in terms of formatting, validation and building.
 
-It will execute the lint and build commands all at once, in that order.`
+It will execute the lint and build commands all at once, in that order.
+
+Use --all or --packages to check multiple packages of the repository.
+
+Use the "ecs" subcommand to get suggestions to align the fields of the package with ECS.`
 
 func setupCheckCommand() *cobraext.Command {
 	cmd := &cobra.Command{
@@ -22,18 +31,61 @@ func setupCheckCommand() *cobraext.Command {
 		Short: "Check the package",
 		Long:  checkLongDescription,
 		Args:  cobra.NoArgs,
-		RunE: func(cmd *cobra.Command, args []string) error {
-			err := cobraext.ComposeCommands(cmd, args,
-				setupLintCommand(),
-				setupBuildCommand(),
-			)
-			if err != nil {
-				return fmt.Errorf("checking package failed: %w", err)
-			}
-			return nil
-		},
+		RunE:  checkCommandAction,
 	}
 	cmd.PersistentFlags().BoolP(cobraext.FailFastFlagName, "f", true, cobraext.FailFastFlagDescription)
+	addMultiPackageFlags(cmd)
+
+	checkECSCmd := &cobra.Command{
+		Use:   "ecs",
+		Short: "Check the alignment of the package fields with ECS",
+		Long:  checkECSLongDescription,
+		Args:  cobra.NoArgs,
+		RunE:  checkECSCommandAction,
+	}
+	checkECSCmd.Flags().String(cobraext.CheckECSFormatFlagName, checkECSHumanFormat, fmt.Sprintf(cobraext.CheckECSFormatFlagDescription, strings.Join(checkECSFormats, ",")))
+	cmd.AddCommand(checkECSCmd)
 
 	return cobraext.NewCommand(cmd, cobraext.ContextPackage)
 }
+
+func checkCommandAction(cmd *cobra.Command, args []string) error {
+	all, _ := cmd.Flags().GetBool(cobraext.AllPackagesFlagName)
+	patterns, _ := cmd.Flags().GetStringSlice(cobraext.PackagesFlagName)
+	if !all && len(patterns) == 0 {
+		err := cobraext.ComposeCommands(cmd, args,
+			setupLintCommand(),
+			setupBuildCommand(),
+		)
+		if err != nil {
+			return fmt.Errorf("checking package failed: %w", err)
+		}
+		return nil
+	}
+
+	cmd.Println("Check the packages")
+	buildDir, err := builder.BuildDirectory()
+	if err != nil {
+		return fmt.Errorf("can't prepare build directory: %w", err)
+	}
+	rules, err := lintrules.LoadRepositoryRules()
+	if err != nil {
+		return fmt.Errorf("loading lint rules failed: %w", err)
+	}
+	err = runPackageAction(cmd, func(cmd *cobra.Command, packageRoot string, w io.Writer) error {
+		err := lintPackage(packageRoot, rules, docs.CheckLinksOptions{}, w)
+		if err != nil {
+			return err
+		}
+		return buildPackage(cmd, w, builder.BuildOptions{
+			PackageRoot: packageRoot,
+			BuildDir:    buildDir,
+			CreateZip:   true,
+		})
+	})
+	if err != nil {
+		return fmt.Errorf("checking packages failed: %w", err)
+	}
+	cmd.Println("Done")
+	return nil
+}
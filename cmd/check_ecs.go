@@ -0,0 +1,108 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/elastic/elastic-package/internal/cobraext"
+	"github.com/elastic/elastic-package/internal/packages"
+	"github.com/elastic/elastic-package/internal/packages/ecsadvisor"
+)
+
+const checkECSLongDescription = `Use this command to get suggestions to align the fields of the package with ECS.
+
+The field definitions of each data stream, and the values of the fields in the results of pipeline tests, are compared with the ECS schema the package depends on. The command reports:
+- custom fields that could be replaced by ECS fields,
+- custom fields that duplicate the values of ECS fields, and could be aliases,
+- custom fields whose values could be copied to ECS fields not set in the results, with copy_to,
+- fields defined locally that could be imported from ECS,
+- ECS fields defined or used with types different to the ones in ECS,
+- missing categorization fields (event.kind, event.category and event.type).
+
+The report can be printed in JSON format to track the alignment of the package over time.`
+
+const (
+	checkECSHumanFormat = "human"
+	checkECSJSONFormat  = "json"
+)
+
+var checkECSFormats = []string{checkECSHumanFormat, checkECSJSONFormat}
+
+func checkECSCommandAction(cmd *cobra.Command, args []string) error {
+	format, err := cmd.Flags().GetString(cobraext.CheckECSFormatFlagName)
+	if err != nil {
+		return cobraext.FlagParsingError(err, cobraext.CheckECSFormatFlagName)
+	}
+	if !slices.Contains(checkECSFormats, format) {
+		return cobraext.FlagParsingError(fmt.Errorf("unsupported format %q, supported formats: %s", format, strings.Join(checkECSFormats, ",")), cobraext.CheckECSFormatFlagName)
+	}
+
+	packageRoot, err := packages.MustFindPackageRoot()
+	if err != nil {
+		return fmt.Errorf("locating package root failed: %w", err)
+	}
+
+	report, err := ecsadvisor.Check(packageRoot)
+	if err != nil {
+		return fmt.Errorf("checking ECS alignment failed: %w", err)
+	}
+
+	switch format {
+	case checkECSJSONFormat:
+		return printECSReportJSON(cmd.OutOrStdout(), report)
+	default:
+		printECSReport(cmd.OutOrStdout(), report)
+		return nil
+	}
+}
+
+var ecsFindingTitles = map[string]string{
+	ecsadvisor.KindEquivalent:     "Custom fields with ECS equivalents",
+	ecsadvisor.KindAlias:          "Custom fields duplicating ECS fields",
+	ecsadvisor.KindCopyTo:         "Custom fields that could be copied to ECS fields",
+	ecsadvisor.KindExternal:       "Fields that could be imported from ECS",
+	ecsadvisor.KindType:           "Fields with types different to ECS",
+	ecsadvisor.KindValue:          "Fields with values not matching ECS types",
+	ecsadvisor.KindCategorization: "Missing categorization fields",
+}
+
+func printECSReport(w io.Writer, report *ecsadvisor.Report) {
+	fmt.Fprintf(w, "ECS alignment of package %s %s (ECS %s)\n", bold.Sprint(report.Name), report.Version, report.ECSVersion)
+	if len(report.Findings) == 0 {
+		fmt.Fprintln(w, "No suggestions found")
+		return
+	}
+
+	for _, kind := range ecsadvisor.Kinds {
+		findings := report.FindingsOfKind(kind)
+		if len(findings) == 0 {
+			continue
+		}
+		fmt.Fprintf(w, "\n%s (%d):\n", cyan.Sprint(ecsFindingTitles[kind]), len(findings))
+		for _, finding := range findings {
+			if finding.DataStream != "" {
+				fmt.Fprintf(w, "  - [%s] %s\n", finding.DataStream, finding.Description)
+			} else {
+				fmt.Fprintf(w, "  - %s\n", finding.Description)
+			}
+		}
+	}
+}
+
+func printECSReportJSON(w io.Writer, report *ecsadvisor.Report) error {
+	if report.Findings == nil {
+		report.Findings = []ecsadvisor.Finding{}
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(report)
+}
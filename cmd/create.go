@@ -12,7 +12,7 @@ import (
 
 const createLongDescription = `Use this command to create a new package or add more data streams.
 
-The command can help bootstrap the first draft of a package using embedded package template. It can be used to extend the package with more data streams.
+The command can help bootstrap the first draft of a package using embedded package template. It can be used to extend the package with more data streams, and to create the field definitions of data streams from sample documents.
 
 For details on how to create a new package, review the [HOWTO guide](https://github.com/elastic/elastic-package/blob/main/docs/howto/create_new_package.md).`
 
@@ -33,6 +33,17 @@ func setupCreateCommand() *cobraext.Command {
 		RunE:  createDataStreamCommandAction,
 	}
 
+	createFieldsCmd := &cobra.Command{
+		Use:   "fields",
+		Short: "Create field definitions from sample documents",
+		Long:  createFieldsLongDescription,
+		Args:  cobra.NoArgs,
+		RunE:  createFieldsCommandAction,
+	}
+	createFieldsCmd.Flags().String(cobraext.CreateFieldsFromFlagName, "", cobraext.CreateFieldsFromFlagDescription)
+	createFieldsCmd.Flags().StringP(cobraext.DataStreamFlagName, "d", "", cobraext.CreateFieldsDataStreamFlagDescription)
+	createFieldsCmd.MarkFlagRequired(cobraext.CreateFieldsFromFlagName)
+
 	cmd := &cobra.Command{
 		Use:   "create",
 		Short: "Create package resources",
@@ -40,6 +51,7 @@ func setupCreateCommand() *cobraext.Command {
 	}
 	cmd.AddCommand(createPackageCmd)
 	cmd.AddCommand(createDataStreamCmd)
+	cmd.AddCommand(createFieldsCmd)
 
 	return cobraext.NewCommand(cmd, cobraext.ContextGlobal)
 }
@@ -0,0 +1,177 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+
+	"github.com/elastic/elastic-package/internal/cobraext"
+	"github.com/elastic/elastic-package/internal/fields"
+	"github.com/elastic/elastic-package/internal/packages"
+	"github.com/elastic/elastic-package/internal/packages/buildmanifest"
+)
+
+const createFieldsLongDescription = `Use this command to create the field definitions of a data stream from sample documents.
+
+The documents can be read from ndjson or json files, or from a directory, where ndjson files and the results of pipeline tests ("*-expected.json") are used. The type of each field is inferred from its values: booleans, long and double numbers, IPs, dates, geo points, and keyword or text strings. Objects are defined as groups of fields.
+
+If the package depends on ECS, fields defined in ECS are imported as external fields. Fields already defined in other files of the fields directory are not included.
+
+The field definitions are written to the "fields/fields.yml" file of the data stream selected with the --data-stream flag, or the data stream in the working directory. Field definitions of input packages are written to the "fields" directory of the package.`
+
+const createFieldsFile = "fields.yml"
+
+func createFieldsCommandAction(cmd *cobra.Command, args []string) error {
+	cmd.Println("Create field definitions from sample documents")
+
+	from, err := cmd.Flags().GetString(cobraext.CreateFieldsFromFlagName)
+	if err != nil {
+		return cobraext.FlagParsingError(err, cobraext.CreateFieldsFromFlagName)
+	}
+	dataStream, err := cmd.Flags().GetString(cobraext.DataStreamFlagName)
+	if err != nil {
+		return cobraext.FlagParsingError(err, cobraext.DataStreamFlagName)
+	}
+
+	packageRoot, found, err := packages.FindPackageRoot()
+	if err != nil {
+		return fmt.Errorf("locating package root failed: %w", err)
+	}
+	if !found {
+		return errors.New("package root not found, you can only create fields in the package context")
+	}
+
+	fieldsDir, err := createFieldsDir(packageRoot, dataStream)
+	if err != nil {
+		return err
+	}
+	fieldsPath := filepath.Join(fieldsDir, createFieldsFile)
+	if _, err := os.Stat(fieldsPath); err == nil {
+		return fmt.Errorf("fields file %s already exists, remove it to create it again", fieldsPath)
+	}
+
+	docs, err := fields.LoadSampleDocuments(from)
+	if err != nil {
+		return fmt.Errorf("reading sample documents failed (path: %s): %w", from, err)
+	}
+	if len(docs) == 0 {
+		return fmt.Errorf("no sample documents found in %s", from)
+	}
+
+	var options fields.InferOptions
+	options.DependencyManager, err = createFieldsDependencyManager(packageRoot)
+	if err != nil {
+		return err
+	}
+	options.Skip, err = definedFieldNames(fieldsDir)
+	if err != nil {
+		return err
+	}
+
+	inferrer := fields.NewFieldsInferrer()
+	for _, doc := range docs {
+		inferrer.Add(doc)
+	}
+	inferred := inferrer.Fields(options)
+	if len(inferred) == 0 {
+		cmd.Println("All fields in the sample documents are already defined")
+		return nil
+	}
+
+	d, err := yaml.Marshal(inferred)
+	if err != nil {
+		return fmt.Errorf("encoding field definitions failed: %w", err)
+	}
+	err = os.MkdirAll(fieldsDir, 0755)
+	if err != nil {
+		return fmt.Errorf("creating fields directory failed (path: %s): %w", fieldsDir, err)
+	}
+	err = os.WriteFile(fieldsPath, d, 0644)
+	if err != nil {
+		return fmt.Errorf("writing fields file failed (path: %s): %w", fieldsPath, err)
+	}
+
+	cmd.Printf("Fields of %d documents written to %s\n", len(docs), fieldsPath)
+	return nil
+}
+
+// createFieldsDir returns the fields directory of the selected data stream, of the data stream
+// in the working directory, or of the package for input packages.
+func createFieldsDir(packageRoot, dataStream string) (string, error) {
+	if dataStream != "" {
+		dataStreamRoot := filepath.Join(packageRoot, "data_stream", dataStream)
+		if _, err := os.Stat(filepath.Join(dataStreamRoot, packages.DataStreamManifestFile)); err != nil {
+			return "", fmt.Errorf("data stream %q not found: %w", dataStream, err)
+		}
+		return filepath.Join(dataStreamRoot, "fields"), nil
+	}
+
+	workDir, err := os.Getwd()
+	if err != nil {
+		return "", fmt.Errorf("locating working directory failed: %w", err)
+	}
+	dataStreamRoot, found, err := packages.FindDataStreamRootForPath(workDir)
+	if err != nil {
+		return "", fmt.Errorf("locating data stream root failed: %w", err)
+	}
+	if found {
+		return filepath.Join(dataStreamRoot, "fields"), nil
+	}
+
+	manifest, err := packages.ReadPackageManifestFromPackageRoot(packageRoot)
+	if err != nil {
+		return "", fmt.Errorf("reading package manifest failed (path: %s): %w", packageRoot, err)
+	}
+	if manifest.Type != "input" {
+		return "", fmt.Errorf("data stream not found, select it with --%s or run the command from its directory", cobraext.DataStreamFlagName)
+	}
+	return filepath.Join(packageRoot, "fields"), nil
+}
+
+func createFieldsDependencyManager(packageRoot string) (*fields.DependencyManager, error) {
+	bm, ok, err := buildmanifest.ReadBuildManifest(packageRoot)
+	if err != nil {
+		return nil, fmt.Errorf("can't read build manifest: %w", err)
+	}
+	if !ok || !bm.HasDependencies() {
+		return nil, nil
+	}
+	fdm, err := fields.CreateFieldDependencyManager(bm.Dependencies)
+	if err != nil {
+		return nil, fmt.Errorf("can't create field dependency manager: %w", err)
+	}
+	return fdm, nil
+}
+
+// definedFieldNames returns the names of the fields defined in the files of the fields directory.
+func definedFieldNames(fieldsDir string) ([]string, error) {
+	definitions, err := fields.ReadFieldsFromDir(fieldsDir)
+	if err != nil {
+		return nil, fmt.Errorf("reading field definitions failed (path: %s): %w", fieldsDir, err)
+	}
+	return flattenFieldNames("", definitions), nil
+}
+
+func flattenFieldNames(prefix string, definitions []fields.FieldDefinition) []string {
+	var names []string
+	for _, definition := range definitions {
+		name := definition.Name
+		if prefix != "" {
+			name = prefix + "." + name
+		}
+		if definition.Type == "group" || (definition.Type == "" && len(definition.Fields) > 0) {
+			names = append(names, flattenFieldNames(name, definition.Fields)...)
+			continue
+		}
+		names = append(names, name)
+	}
+	return names
+}
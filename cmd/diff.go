@@ -0,0 +1,144 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/elastic/elastic-package/internal/cobraext"
+	"github.com/elastic/elastic-package/internal/packages/diff"
+	"github.com/elastic/elastic-package/internal/registry"
+)
+
+const diffLongDescription = `Use this command to compare two versions of a package.
+
+Each version can be a package directory, a built package archive (*.zip), or a package
+published in the Package Registry, referenced as <name>@<version>. If the version is
+omitted, the latest published version is used.
+
+The command reports semantic changes, such as added and removed data streams, fields,
+variables or inputs, changed variable defaults and types, changes in ingest pipelines and
+in the Kibana version constraints. Each change is classified as a breaking change, an
+enhancement or a bugfix. Kibana version constraints that stop accepting any version accepted
+before are breaking changes.`
+
+const (
+	diffHumanFormat = "human"
+	diffJSONFormat  = "json"
+)
+
+var diffFormats = []string{diffHumanFormat, diffJSONFormat}
+
+func setupDiffCommand() *cobraext.Command {
+	cmd := &cobra.Command{
+		Use:   "diff <old> <new>",
+		Short: "Compare two versions of a package",
+		Long:  diffLongDescription,
+		Args:  cobra.ExactArgs(2),
+		RunE:  diffCommandAction,
+	}
+	cmd.Flags().String(cobraext.DiffFormatFlagName, diffHumanFormat, fmt.Sprintf(cobraext.DiffFormatFlagDescription, strings.Join(diffFormats, ",")))
+
+	return cobraext.NewCommand(cmd, cobraext.ContextGlobal)
+}
+
+func diffCommandAction(cmd *cobra.Command, args []string) error {
+	format, err := cmd.Flags().GetString(cobraext.DiffFormatFlagName)
+	if err != nil {
+		return cobraext.FlagParsingError(err, cobraext.DiffFormatFlagName)
+	}
+	if !slices.Contains(diffFormats, format) {
+		return cobraext.FlagParsingError(fmt.Errorf("unsupported format %q, supported formats: %s", format, strings.Join(diffFormats, ",")), cobraext.DiffFormatFlagName)
+	}
+
+	workDir, err := os.MkdirTemp("", "elastic-package-diff-")
+	if err != nil {
+		return fmt.Errorf("can't prepare a temporary directory: %w", err)
+	}
+	defer os.RemoveAll(workDir)
+
+	// Each version is extracted in its own directory, as both can have the same archive name.
+	oldWorkDir, newWorkDir := filepath.Join(workDir, "old"), filepath.Join(workDir, "new")
+	for _, dir := range []string{oldWorkDir, newWorkDir} {
+		err := os.Mkdir(dir, 0755)
+		if err != nil {
+			return fmt.Errorf("can't prepare a temporary directory: %w", err)
+		}
+	}
+
+	oldRoot, err := diff.ResolveSource(args[0], oldWorkDir, registry.Production)
+	if err != nil {
+		return fmt.Errorf("can't find old package: %w", err)
+	}
+	newRoot, err := diff.ResolveSource(args[1], newWorkDir, registry.Production)
+	if err != nil {
+		return fmt.Errorf("can't find new package: %w", err)
+	}
+
+	report, err := diff.Packages(oldRoot, newRoot)
+	if err != nil {
+		return fmt.Errorf("comparing packages failed: %w", err)
+	}
+
+	switch format {
+	case diffJSONFormat:
+		return printDiffReportJSON(cmd.OutOrStdout(), report)
+	default:
+		printDiffReport(cmd.OutOrStdout(), report)
+		return nil
+	}
+}
+
+var diffTypeTitles = map[string]string{
+	diff.Breaking:    "Breaking changes",
+	diff.Enhancement: "Enhancements",
+	diff.Bugfix:      "Bugfixes",
+}
+
+func printDiffReport(w io.Writer, report *diff.Report) {
+	fmt.Fprintf(w, "Changes in package %s from %s to %s\n", bold.Sprint(report.Name), report.OldVersion, report.NewVersion)
+	if len(report.Changes) == 0 {
+		fmt.Fprintln(w, "No changes found")
+		return
+	}
+
+	for _, changeType := range diff.Types {
+		changes := report.ChangesOfType(changeType)
+		if len(changes) == 0 {
+			continue
+		}
+		title := diffTypeTitles[changeType]
+		if changeType == diff.Breaking {
+			title = red.Sprint(title)
+		} else {
+			title = cyan.Sprint(title)
+		}
+		fmt.Fprintf(w, "\n%s (%d):\n", title, len(changes))
+		for _, change := range changes {
+			if change.DataStream != "" {
+				fmt.Fprintf(w, "  - [%s] %s\n", change.DataStream, change.Description)
+			} else {
+				fmt.Fprintf(w, "  - %s\n", change.Description)
+			}
+		}
+	}
+}
+
+func printDiffReportJSON(w io.Writer, report *diff.Report) error {
+	if report.Changes == nil {
+		report.Changes = []diff.Change{}
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(report)
+}
@@ -0,0 +1,75 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package cmd
+
+import (
+	"fmt"
+	"net"
+
+	"github.com/spf13/cobra"
+
+	"github.com/elastic/elastic-package/internal/cobraext"
+	"github.com/elastic/elastic-package/internal/docs"
+	"github.com/elastic/elastic-package/internal/logger"
+	"github.com/elastic/elastic-package/internal/packages"
+	"github.com/elastic/elastic-package/internal/signal"
+)
+
+const docsLongDescription = `Use this command to work with the documentation of the package.`
+
+const docsPreviewLongDescription = `Use this command to preview the documentation of the package.
+
+The documents of the package are rendered from their templates, as they are rendered when building the package, and converted to HTML. They are served by a local HTTP server, together with the icons, screenshots and sample events of the package.
+
+Pages are reloaded in the browser when any file of the package changes, like the templates, the field definitions or the sample events.`
+
+const defaultDocsPreviewAddress = "localhost:8090"
+
+func setupDocsCommand() *cobraext.Command {
+	previewCmd := &cobra.Command{
+		Use:   "preview",
+		Short: "Preview the package documentation",
+		Long:  docsPreviewLongDescription,
+		Args:  cobra.NoArgs,
+		RunE:  docsPreviewCommandAction,
+	}
+	previewCmd.Flags().String(cobraext.DocsPreviewAddressFlagName, defaultDocsPreviewAddress, cobraext.DocsPreviewAddressFlagDescription)
+
+	cmd := &cobra.Command{
+		Use:   "docs",
+		Short: "Work with the package documentation",
+		Long:  docsLongDescription,
+	}
+	cmd.AddCommand(previewCmd)
+
+	return cobraext.NewCommand(cmd, cobraext.ContextPackage)
+}
+
+func docsPreviewCommandAction(cmd *cobra.Command, args []string) error {
+	address, err := cmd.Flags().GetString(cobraext.DocsPreviewAddressFlagName)
+	if err != nil {
+		return cobraext.FlagParsingError(err, cobraext.DocsPreviewAddressFlagName)
+	}
+
+	packageRoot, err := packages.MustFindPackageRoot()
+	if err != nil {
+		return fmt.Errorf("locating package root failed: %w", err)
+	}
+
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return fmt.Errorf("listening on %s failed: %w", address, err)
+	}
+
+	ctx, stop := signal.Enable(cmd.Context(), logger.Info)
+	defer stop()
+
+	cmd.Printf("Documentation preview available at http://%s/ (press Ctrl+C to stop)\n", listener.Addr())
+	err = docs.NewPreviewServer(packageRoot).Serve(ctx, listener)
+	if err != nil {
+		return fmt.Errorf("serving documentation preview failed: %w", err)
+	}
+	return nil
+}
@@ -14,10 +14,11 @@ import (
 	"github.com/elastic/elastic-package/internal/elasticsearch"
 	"github.com/elastic/elastic-package/internal/install"
 	"github.com/elastic/elastic-package/internal/kibana"
+	"github.com/elastic/elastic-package/internal/packages"
 	"github.com/elastic/elastic-package/internal/stack"
 )
 
-const dumpLongDescription = `Use this command as an exploratory tool to dump resources from Elastic Stack (objects installed as part of package and agent policies).`
+const dumpLongDescription = `Use this command as an exploratory tool to dump resources from Elastic Stack (objects installed as part of package and agent policies), or the index templates computed from the package sources.`
 
 const dumpInstalledObjectsLongDescription = `Use this command to dump objects installed by Fleet as part of a package.
 
@@ -31,6 +32,12 @@ If no flag is provided, by default this command dumps all agent policies created
 
 If --package flag is provided, this command dumps all agent policies that the given package has been assigned to it.`
 
+const dumpMappingsLongDescription = `Use this command to dump the index templates that Fleet would install for the data streams of the package, without a running stack.
+
+The mappings, dynamic templates and settings are computed from the field definitions of the package, including the resolved external fields and the ECS dynamic templates imported when the package is built, and from the "elasticsearch" settings in the manifests. Component templates provided by the stack are not included.
+
+Dumped templates can be committed to see mapping changes in pull requests.`
+
 func setupDumpCommand() *cobraext.Command {
 	dumpInstalledObjectsCmd := &cobra.Command{
 		Use:   "installed-objects",
@@ -53,6 +60,14 @@ func setupDumpCommand() *cobraext.Command {
 	dumpAgentPoliciesCmd.Flags().StringP(cobraext.AgentPolicyFlagName, "", "", cobraext.AgentPolicyDescription)
 	dumpAgentPoliciesCmd.Flags().StringP(cobraext.PackageFlagName, cobraext.PackageFlagShorthand, "", cobraext.PackageFlagDescription)
 
+	dumpMappingsCmd := &cobra.Command{
+		Use:   "mappings",
+		Short: "Dump index templates computed from the package",
+		Long:  dumpMappingsLongDescription,
+		Args:  cobra.NoArgs,
+		RunE:  dumpMappingsCmdAction,
+	}
+
 	cmd := &cobra.Command{
 		Use:   "dump",
 		Short: "Dump package assets",
@@ -63,6 +78,7 @@ func setupDumpCommand() *cobraext.Command {
 
 	cmd.AddCommand(dumpInstalledObjectsCmd)
 	cmd.AddCommand(dumpAgentPoliciesCmd)
+	cmd.AddCommand(dumpMappingsCmd)
 
 	return cobraext.NewCommand(cmd, cobraext.ContextGlobal)
 }
@@ -198,3 +214,27 @@ func dumpAgentPoliciesCmdAction(cmd *cobra.Command, args []string) error {
 	}
 	return nil
 }
+
+func dumpMappingsCmdAction(cmd *cobra.Command, args []string) error {
+	outputPath, err := cmd.Flags().GetString(cobraext.DumpOutputFlagName)
+	if err != nil {
+		return cobraext.FlagParsingError(err, cobraext.DumpOutputFlagName)
+	}
+
+	packageRoot, err := packages.MustFindPackageRoot()
+	if err != nil {
+		return fmt.Errorf("locating package root failed: %w", err)
+	}
+
+	dumper := dump.NewMappingsDumper(packageRoot)
+	count, err := dumper.DumpAll(outputPath)
+	if err != nil {
+		return fmt.Errorf("dump failed: %w", err)
+	}
+	if count == 0 {
+		cmd.Printf("No index templates found for the package\n")
+		return nil
+	}
+	cmd.Printf("Dumped %d index templates to %s\n", count, outputPath)
+	return nil
+}
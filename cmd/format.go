@@ -5,19 +5,22 @@
 package cmd
 
 import (
-	"errors"
 	"fmt"
+	"io"
 
 	"github.com/spf13/cobra"
 
 	"github.com/elastic/elastic-package/internal/cobraext"
 	"github.com/elastic/elastic-package/internal/formatter"
-	"github.com/elastic/elastic-package/internal/packages"
 )
 
 const formatLongDescription = `Use this command to format the package files.
 
-The formatter supports JSON and YAML format, and skips "ingest_pipeline" directories as it's hard to correctly format Handlebars template files. Formatted files are being overwritten.`
+The formatter supports JSON and YAML format, and skips "ingest_pipeline" directories as it's hard to correctly format Handlebars template files.
+
+Use the --extended flag to also format ingest pipelines in YAML format, Handlebars templates and Markdown documents. Ingest pipelines in YAML format are formatted keeping the order of the keys of processors, and with multiline sources of script processors written as literal blocks when this doesn't change their values. Ingest pipelines in JSON format are not formatted, as they can contain Handlebars templates. Documents rendered from templates in "_dev/build/docs" are not formatted, their templates are formatted instead, so run "elastic-package build" after formatting them to render the documents again, otherwise "elastic-package lint" reports them as outdated.
+
+Formatted files are being overwritten. Use the --check flag to list the files that require updates without overwriting them, or the --diff flag to also print the required changes. In both cases the command fails if any file requires updates, which is useful in CI pipelines.`
 
 func setupFormatCommand() *cobraext.Command {
 	cmd := &cobra.Command{
@@ -28,6 +31,10 @@ func setupFormatCommand() *cobraext.Command {
 		RunE:  formatCommandAction,
 	}
 	cmd.Flags().BoolP(cobraext.FailFastFlagName, "f", false, cobraext.FailFastFlagDescription)
+	cmd.Flags().Bool(cobraext.FormatCheckFlagName, false, cobraext.FormatCheckFlagDescription)
+	cmd.Flags().Bool(cobraext.FormatDiffFlagName, false, cobraext.FormatDiffFlagDescription)
+	cmd.Flags().Bool(cobraext.FormatExtendedFlagName, false, cobraext.FormatExtendedFlagDescription)
+	addMultiPackageFlags(cmd)
 
 	return cobraext.NewCommand(cmd, cobraext.ContextPackage)
 }
@@ -35,24 +42,63 @@ func setupFormatCommand() *cobraext.Command {
 func formatCommandAction(cmd *cobra.Command, args []string) error {
 	cmd.Println("Format the package")
 
-	packageRoot, found, err := packages.FindPackageRoot()
+	err := runPackageAction(cmd, formatPackage)
 	if err != nil {
-		return fmt.Errorf("locating package root failed: %w", err)
-	}
-	if !found {
-		return errors.New("package root not found")
+		return err
 	}
 
+	cmd.Println("Done")
+	return nil
+}
+
+func formatPackage(cmd *cobra.Command, packageRoot string, w io.Writer) error {
 	ff, err := cmd.Flags().GetBool(cobraext.FailFastFlagName)
 	if err != nil {
 		return cobraext.FlagParsingError(err, cobraext.FailFastFlagName)
 	}
+	check, err := cmd.Flags().GetBool(cobraext.FormatCheckFlagName)
+	if err != nil {
+		return cobraext.FlagParsingError(err, cobraext.FormatCheckFlagName)
+	}
+	diff, err := cmd.Flags().GetBool(cobraext.FormatDiffFlagName)
+	if err != nil {
+		return cobraext.FlagParsingError(err, cobraext.FormatDiffFlagName)
+	}
+	extended, err := cmd.Flags().GetBool(cobraext.FormatExtendedFlagName)
+	if err != nil {
+		return cobraext.FlagParsingError(err, cobraext.FormatExtendedFlagName)
+	}
 
-	err = formatter.Format(packageRoot, ff)
+	options := formatter.Options{
+		FailFast: ff,
+		Extended: extended,
+	}
+	if check || diff {
+		return checkPackageFormat(packageRoot, options, diff, w)
+	}
+
+	err = formatter.Format(packageRoot, options)
 	if err != nil {
 		return fmt.Errorf("formatting the integration failed (path: %s, failFast: %t): %w", packageRoot, ff, err)
 	}
-
-	cmd.Println("Done")
 	return nil
 }
+
+func checkPackageFormat(packageRoot string, options formatter.Options, diff bool, w io.Writer) error {
+	changes, err := formatter.Check(packageRoot, options)
+	if err != nil {
+		return fmt.Errorf("checking the format of the integration failed (path: %s): %w", packageRoot, err)
+	}
+	if len(changes) == 0 {
+		return nil
+	}
+
+	for _, change := range changes {
+		if diff {
+			fmt.Fprint(w, change.Diff)
+		} else {
+			fmt.Fprintln(w, change.Path)
+		}
+	}
+	return fmt.Errorf("found %d files not formatted (path: %s)", len(changes), packageRoot)
+}
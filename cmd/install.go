@@ -20,7 +20,9 @@ import (
 
 const installLongDescription = `Use this command to install the package in Kibana.
 
-The command uses Kibana API to install the package in Kibana. The package must be exposed via the Package Registry or built locally in zip format so they can be installed using --zip parameter. Zip packages can be installed directly in Kibana >= 8.7.0. More details in this [HOWTO guide](https://github.com/elastic/elastic-package/blob/main/docs/howto/install_package.md).`
+The command uses Kibana API to install the package in Kibana. The package must be exposed via the Package Registry or built locally in zip format so they can be installed using --zip parameter. Zip packages can be installed directly in Kibana >= 8.7.0. More details in this [HOWTO guide](https://github.com/elastic/elastic-package/blob/main/docs/howto/install_package.md).
+
+If the "package.signature.required" setting of the profile is set to true, zip packages are only installed if they are signed, and their signature can be verified with the public key in the "package.signature.public_key" setting (see "elastic-package verify").`
 
 func setupInstallCommand() *cobraext.Command {
 	cmd := &cobra.Command{
@@ -82,11 +84,18 @@ func installCommandAction(cmd *cobra.Command, _ []string) error {
 		}
 	}
 
+	requireSignature, verifyOptions, err := signatureRequirement(profile)
+	if err != nil {
+		return err
+	}
+
 	installer, err := installer.NewForPackage(cmd.Context(), installer.Options{
-		Kibana:         kibanaClient,
-		RootPath:       packageRootPath,
-		SkipValidation: skipValidation,
-		ZipPath:        zipPathFile,
+		Kibana:           kibanaClient,
+		RootPath:         packageRootPath,
+		SkipValidation:   skipValidation,
+		ZipPath:          zipPathFile,
+		RequireSignature: requireSignature,
+		VerifyOptions:    verifyOptions,
 	})
 	if err != nil {
 		return fmt.Errorf("package installation failed: %w", err)
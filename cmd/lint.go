@@ -7,19 +7,31 @@ package cmd
 import (
 	"errors"
 	"fmt"
+	"io"
+	"strings"
 
 	"github.com/spf13/cobra"
 
 	"github.com/elastic/elastic-package/internal/cobraext"
 	"github.com/elastic/elastic-package/internal/docs"
 	"github.com/elastic/elastic-package/internal/logger"
-	"github.com/elastic/elastic-package/internal/packages"
+	"github.com/elastic/elastic-package/internal/packages/fieldusage"
+	"github.com/elastic/elastic-package/internal/packages/lintrules"
+	"github.com/elastic/elastic-package/internal/packages/pipelinelint"
 	"github.com/elastic/elastic-package/internal/validation"
 )
 
 const lintLongDescription = `Use this command to validate the contents of a package using the package specification (see: https://github.com/elastic/package-spec).
 
-The command ensures that the package is aligned with the package spec and the README file is up-to-date with its template (if present).`
+The command ensures that the package is aligned with the package spec and the README file is up-to-date with its template (if present).
+
+It also reports warnings about the usage of fields: fields declared in data streams but never produced or referenced by ingest pipelines, agent templates, Kibana assets, transforms or sample documents; fields produced by ingest pipelines but not declared; and fields used by Kibana assets that no data stream declares. Fields set outside of the package, like @timestamp, data_stream.* and the ones in agent.yml and base-fields.yml files, are not reported as unused.
+
+Links and images in the documentation of the package are checked too: relative references must point to files included in the built package, including the ones provided by link files, and keys of the links used in README templates must exist in the links map. Screenshots declared in the package manifest must exist and have the declared types. Anchors that don't match headings, and screenshots with sizes different to the declared ones, are reported as warnings, unless the --strict-docs flag is used. External URLs are only checked for their syntax, unless the --online flag is used.
+
+Repositories can define custom rules in a "lint_rules.yml" file in their root directory, or in the file set in the ELASTIC_PACKAGE_LINT_RULES_FILE_PATH environment variable. Rules check the YAML and JSON files of the package with declarative assertions or CEL expressions. Violations of rules with "error" severity make the command fail, violations of rules with "warning" severity are only reported.
+
+Ingest pipelines are analysed without installing them. Unreachable processors, references to pipelines that don't exist, grok patterns that don't compile and painless scripts or conditions with unbalanced brackets or unterminated strings make the command fail. Processors without tags, summarized for each pipeline, duplicate tags, pipelines without on_failure handlers, unknown grok patterns and conditions referencing fields that are not declared or set by the pipelines are reported as warnings.`
 
 func setupLintCommand() *cobraext.Command {
 	cmd := &cobra.Command{
@@ -28,10 +40,26 @@ func setupLintCommand() *cobraext.Command {
 		Long:  lintLongDescription,
 		Args:  cobra.NoArgs,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			err := cobraext.ComposeCommandActions(cmd, args,
-				lintCommandAction,
-				validateSourceCommandAction,
-			)
+			cmd.Println("Lint the package")
+			online, err := cmd.Flags().GetBool(cobraext.LintOnlineFlagName)
+			if err != nil {
+				return cobraext.FlagParsingError(err, cobraext.LintOnlineFlagName)
+			}
+			strictDocs, err := cmd.Flags().GetBool(cobraext.LintStrictDocsFlagName)
+			if err != nil {
+				return cobraext.FlagParsingError(err, cobraext.LintStrictDocsFlagName)
+			}
+			linksOptions := docs.CheckLinksOptions{
+				Online: online,
+				Strict: strictDocs,
+			}
+			rules, err := lintrules.LoadRepositoryRules()
+			if err != nil {
+				return fmt.Errorf("loading lint rules failed: %w", err)
+			}
+			err = runPackageAction(cmd, func(cmd *cobra.Command, packageRoot string, w io.Writer) error {
+				return lintPackage(packageRoot, rules, linksOptions, w)
+			})
 			if err != nil {
 				return err
 			}
@@ -39,20 +67,145 @@ func setupLintCommand() *cobraext.Command {
 			return nil
 		},
 	}
+	cmd.Flags().Bool(cobraext.LintOnlineFlagName, false, cobraext.LintOnlineFlagDescription)
+	cmd.Flags().Bool(cobraext.LintStrictDocsFlagName, false, cobraext.LintStrictDocsFlagDescription)
+	addMultiPackageFlags(cmd)
 
 	return cobraext.NewCommand(cmd, cobraext.ContextPackage)
 }
 
-func lintCommandAction(cmd *cobra.Command, args []string) error {
-	cmd.Println("Lint the package")
-	readmeFiles, err := docs.AreReadmesUpToDate()
+// lintPackage lints the package with the given custom rules. Links in the documentation are checked
+// with the given options.
+func lintPackage(packageRoot string, rules *lintrules.Rules, linksOptions docs.CheckLinksOptions, w io.Writer) error {
+	err := checkReadmesUpToDate(packageRoot, w)
+	if err != nil {
+		return err
+	}
+	err = validateSource(packageRoot)
+	if err != nil {
+		return err
+	}
+	err = checkLintRules(packageRoot, rules, w)
+	if err != nil {
+		return err
+	}
+	// Usage of fields is checked before analysing the pipelines, so failures reading the declared
+	// fields, like the ECS schema not being available, are reported only once.
+	fieldsReport, fieldsErr := fieldusage.Check(packageRoot)
+	pipelinesOptions := pipelinelint.CheckOptions{
+		SkipConditionFields: errors.Is(fieldsErr, fieldusage.ErrReadingDeclaredFields),
+	}
+	err = checkIngestPipelines(packageRoot, pipelinesOptions, w)
+	if err != nil {
+		return err
+	}
+	err = checkDocsLinks(packageRoot, linksOptions, w)
+	if err != nil {
+		return err
+	}
+	printFieldsUsage(fieldsReport, fieldsErr, w)
+	return nil
+}
+
+// checkLintRules checks the custom rules defined in the repository. Only violations of rules with
+// error severity make the lint fail.
+func checkLintRules(packageRoot string, rules *lintrules.Rules, w io.Writer) error {
+	violations, err := lintrules.Check(packageRoot, rules)
+	if err != nil {
+		return fmt.Errorf("checking lint rules failed: %w", err)
+	}
+	errorsCount := 0
+	for _, violation := range violations {
+		fmt.Fprintln(w, violation)
+		if violation.Severity == lintrules.SeverityError {
+			errorsCount++
+		}
+	}
+	if errorsCount > 0 {
+		return fmt.Errorf("found %d violations of lint rules", errorsCount)
+	}
+	return nil
+}
+
+// checkIngestPipelines analyses the ingest pipelines of the package. Only issues with error severity
+// make the lint fail.
+func checkIngestPipelines(packageRoot string, options pipelinelint.CheckOptions, w io.Writer) error {
+	issues, err := pipelinelint.Check(packageRoot, options)
+	if err != nil {
+		return fmt.Errorf("analysing ingest pipelines failed: %w", err)
+	}
+	errorsCount := 0
+	for _, issue := range issues {
+		fmt.Fprintln(w, issue)
+		if issue.Severity == pipelinelint.SeverityError {
+			errorsCount++
+		}
+	}
+	if errorsCount > 0 {
+		return fmt.Errorf("found %d errors in ingest pipelines", errorsCount)
+	}
+	return nil
+}
+
+// checkDocsLinks checks the links and images of the documentation. Only issues with error severity
+// make the lint fail.
+func checkDocsLinks(packageRoot string, options docs.CheckLinksOptions, w io.Writer) error {
+	issues, err := docs.CheckLinks(packageRoot, options)
+	if err != nil {
+		return fmt.Errorf("checking links in documentation failed: %w", err)
+	}
+	errorsCount := 0
+	for _, issue := range issues {
+		fmt.Fprintln(w, issue)
+		if issue.Severity == docs.SeverityError {
+			errorsCount++
+		}
+	}
+	if errorsCount > 0 {
+		return fmt.Errorf("found %d broken links or images in documentation", errorsCount)
+	}
+	return nil
+}
+
+// printFieldsUsage prints warnings about the fields with usage issues. These issues don't make
+// the lint fail.
+func printFieldsUsage(report *fieldusage.Report, err error, w io.Writer) {
+	if err != nil {
+		// Usage of fields is only reported as warnings, errors checking it, like the ECS schema
+		// not being available, don't make lint fail.
+		if errors.Is(err, fieldusage.ErrReadingDeclaredFields) {
+			fmt.Fprintf(w, "Warning: usage of fields not checked, including fields in conditions of ingest pipelines: %v\n", err)
+			return
+		}
+		fmt.Fprintf(w, "Warning: usage of fields not checked: %v\n", err)
+		return
+	}
+	for _, ds := range report.DataStreams {
+		location := "package"
+		if ds.DataStream != "" {
+			location = fmt.Sprintf("data stream %q", ds.DataStream)
+		}
+		if len(ds.Unused) > 0 {
+			fmt.Fprintf(w, "Warning: fields declared in %s but never produced or referenced: %s\n", location, strings.Join(ds.Unused, ", "))
+		}
+		if len(ds.Undeclared) > 0 {
+			fmt.Fprintf(w, "Warning: fields produced by ingest pipelines in %s but not declared: %s\n", location, strings.Join(ds.Undeclared, ", "))
+		}
+	}
+	if len(report.UndeclaredInKibana) > 0 {
+		fmt.Fprintf(w, "Warning: fields used by Kibana assets but not declared in any data stream: %s\n", strings.Join(report.UndeclaredInKibana, ", "))
+	}
+}
+
+func checkReadmesUpToDate(packageRoot string, w io.Writer) error {
+	readmeFiles, err := docs.AreReadmesUpToDate(packageRoot)
 	if err != nil {
 		for _, f := range readmeFiles {
 			if !f.UpToDate {
-				cmd.Printf("%s is outdated. Rebuild the package with 'elastic-package build'\n%s", f.FileName, f.Diff)
+				fmt.Fprintf(w, "%s is outdated. Rebuild the package with 'elastic-package build'\n%s", f.FileName, f.Diff)
 			}
 			if f.Error != nil {
-				cmd.Printf("check if %s is up-to-date failed: %s\n", f.FileName, f.Error)
+				fmt.Fprintf(w, "check if %s is up-to-date failed: %s\n", f.FileName, f.Error)
 			}
 		}
 		return fmt.Errorf("checking readme files are up-to-date failed: %w", err)
@@ -60,15 +213,8 @@ func lintCommandAction(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
-func validateSourceCommandAction(cmd *cobra.Command, args []string) error {
-	packageRootPath, found, err := packages.FindPackageRoot()
-	if !found {
-		return errors.New("package root not found")
-	}
-	if err != nil {
-		return fmt.Errorf("locating package root failed: %w", err)
-	}
-	errs, skipped := validation.ValidateAndFilterFromPath(packageRootPath)
+func validateSource(packageRoot string) error {
+	errs, skipped := validation.ValidateAndFilterFromPath(packageRoot)
 	if skipped != nil {
 		logger.Infof("Skipped errors: %v", skipped)
 	}
@@ -0,0 +1,193 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/elastic/elastic-package/internal/cobraext"
+	"github.com/elastic/elastic-package/internal/files"
+	"github.com/elastic/elastic-package/internal/logger"
+	"github.com/elastic/elastic-package/internal/packages"
+	"github.com/elastic/elastic-package/internal/packages/monorepo"
+)
+
+// packageAction is the action of a command on a single package, its output is written to the given writer.
+type packageAction func(cmd *cobra.Command, packageRoot string, w io.Writer) error
+
+// selectedPackageAction is like packageAction, but it also receives the data streams selected in
+// the package.
+type selectedPackageAction func(cmd *cobra.Command, pkg monorepo.Package, w io.Writer) error
+
+// addMultiPackageFlags adds the flags to run the command on multiple packages of the repository.
+func addMultiPackageFlags(cmd *cobra.Command) {
+	addMultiPackageFlagsWithParallelism(cmd, runtime.NumCPU())
+}
+
+// addStackMultiPackageFlags adds the flags to run the command on multiple packages of the
+// repository, for commands that use the Elastic stack. Packages are processed one at a time by
+// default, as they share the stack.
+func addStackMultiPackageFlags(cmd *cobra.Command) {
+	addMultiPackageFlagsWithParallelism(cmd, 1)
+}
+
+func addMultiPackageFlagsWithParallelism(cmd *cobra.Command, parallelism int) {
+	cmd.Flags().Bool(cobraext.AllPackagesFlagName, false, cobraext.AllPackagesFlagDescription)
+	cmd.Flags().StringSlice(cobraext.PackagesFlagName, nil, cobraext.PackagesGlobFlagDescription)
+	cmd.Flags().Int(cobraext.ParallelismFlagName, parallelism, cobraext.ParallelismFlagDescription)
+	cmd.MarkFlagsMutuallyExclusive(cobraext.AllPackagesFlagName, cobraext.PackagesFlagName)
+}
+
+// runPackageAction runs the action on the package in the working directory or, if requested
+// with the multi-package flags, on the selected packages of the repository.
+func runPackageAction(cmd *cobra.Command, action packageAction) error {
+	return runSelectedPackageAction(cmd, func(cmd *cobra.Command, pkg monorepo.Package, w io.Writer) error {
+		return action(cmd, pkg.Root, w)
+	})
+}
+
+// runSelectedPackageAction runs the action on the package in the working directory or, if
+// requested with the multi-package flags, on the selected packages of the repository. If the
+// command has the changed-since flag set, only the packages affected by the changes since the
+// given git reference are selected.
+func runSelectedPackageAction(cmd *cobra.Command, action selectedPackageAction) error {
+	all, _ := cmd.Flags().GetBool(cobraext.AllPackagesFlagName)
+	patterns, _ := cmd.Flags().GetStringSlice(cobraext.PackagesFlagName)
+	changedSince, _ := cmd.Flags().GetString(cobraext.ChangedSinceFlagName)
+	if !all && len(patterns) == 0 && changedSince == "" {
+		packageRoot, found, err := packages.FindPackageRoot()
+		if err != nil {
+			return fmt.Errorf("locating package root failed: %w", err)
+		}
+		if !found {
+			return errors.New("package root not found")
+		}
+		return action(cmd, monorepo.Package{Root: packageRoot}, cmd.OutOrStderr())
+	}
+
+	// Commands without the parallelism flag process packages sequentially.
+	parallelism := 1
+	if cmd.Flags().Lookup(cobraext.ParallelismFlagName) != nil {
+		var err error
+		parallelism, err = cmd.Flags().GetInt(cobraext.ParallelismFlagName)
+		if err != nil {
+			return cobraext.FlagParsingError(err, cobraext.ParallelismFlagName)
+		}
+		if parallelism < 1 {
+			return cobraext.FlagParsingError(errors.New("at least one package needs to be processed at a time"), cobraext.ParallelismFlagName)
+		}
+	}
+
+	repositoryRoot, err := files.FindRepositoryRootDirectory()
+	if err != nil {
+		return fmt.Errorf("locating repository root failed: %w", err)
+	}
+	pkgs, err := monorepo.Discover(repositoryRoot, patterns)
+	if err != nil {
+		return err
+	}
+	if len(pkgs) == 0 {
+		return errors.New("no packages found")
+	}
+
+	w := cmd.OutOrStderr()
+	if changedSince != "" {
+		changedFiles, err := monorepo.ChangedFiles(cmd.Context(), repositoryRoot, changedSince)
+		if err != nil {
+			return err
+		}
+		pkgs, err = monorepo.Affected(repositoryRoot, pkgs, changedFiles)
+		if err != nil {
+			return fmt.Errorf("looking for packages affected by changes failed: %w", err)
+		}
+		if len(pkgs) == 0 {
+			fmt.Fprintf(w, "No packages affected by changes since %s\n", changedSince)
+			return nil
+		}
+		for _, pkg := range pkgs {
+			dataStreams := "all data streams"
+			if len(pkg.DataStreams) > 0 {
+				dataStreams = "data streams: " + strings.Join(pkg.DataStreams, ", ")
+			}
+			fmt.Fprintf(w, "Package %s affected by changes (%s)\n", pkg.Name, dataStreams)
+		}
+	}
+
+	levels, err := monorepo.Order(repositoryRoot, pkgs)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(w, "Running on %d packages\n", len(pkgs))
+
+	// Log messages are included in the output of each package when they are processed one
+	// at a time. Otherwise they can't be attributed to a package, so they are written after
+	// the results of the packages instead of being interleaved with them.
+	var logs bytes.Buffer
+	var logOutput io.Writer
+	if parallelism > 1 {
+		logOutput = logger.SetOutput(&logs)
+	}
+
+	start := time.Now()
+	report := monorepo.Run(cmd.Context(), levels, parallelism,
+		func(ctx context.Context, pkg monorepo.Package, w io.Writer) error {
+			if parallelism == 1 {
+				previous := logger.SetOutput(w)
+				defer logger.SetOutput(previous)
+			}
+			return action(cmd, pkg, w)
+		},
+		func(result monorepo.Result) {
+			printPackageResult(w, result)
+		},
+	)
+	duration := time.Since(start)
+
+	if parallelism > 1 {
+		logger.SetOutput(logOutput)
+		if logs.Len() > 0 {
+			fmt.Fprintf(w, "\n=== %s\n", bold.Sprint("Log messages"))
+			fmt.Fprint(w, logs.String())
+		}
+	}
+	return printMultiPackageReport(w, report, duration)
+}
+
+func printPackageResult(w io.Writer, result monorepo.Result) {
+	status := cyan.Sprint("ok")
+	if result.Err != nil {
+		status = red.Sprint("failed")
+	}
+	fmt.Fprintf(w, "\n=== %s (%s): %s in %s\n", bold.Sprint(result.Package.Name), result.Package.Path, status, result.Duration.Round(time.Millisecond))
+	fmt.Fprint(w, result.Output)
+	if result.Err != nil {
+		fmt.Fprintf(w, "Error: %v\n", result.Err)
+	}
+}
+
+func printMultiPackageReport(w io.Writer, report *monorepo.Report, duration time.Duration) error {
+	failed := report.Failed()
+	fmt.Fprintf(w, "\n%d packages processed in %s: %d succeeded, %d failed\n",
+		len(report.Results), duration.Round(time.Millisecond), len(report.Results)-len(failed), len(failed))
+	if len(failed) == 0 {
+		return nil
+	}
+
+	fmt.Fprintln(w, red.Sprint("Failed packages:"))
+	for _, result := range failed {
+		fmt.Fprintf(w, "  - %s (%s): %v\n", result.Package.Name, result.Package.Path, result.Err)
+	}
+	return fmt.Errorf("%d of %d packages failed", len(failed), len(report.Results))
+}
@@ -0,0 +1,39 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package cmd
+
+import (
+	"runtime"
+	"strconv"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/elastic-package/internal/cobraext"
+)
+
+func TestParallelismDefaults(t *testing.T) {
+	cases := []struct {
+		name     string
+		cmd      *cobra.Command
+		expected int
+	}{
+		{name: "build", cmd: setupBuildCommand().Command, expected: runtime.NumCPU()},
+		{name: "test static", cmd: getTestRunnerStaticCommand(), expected: runtime.NumCPU()},
+		{name: "test asset", cmd: getTestRunnerAssetCommand(), expected: 1},
+		{name: "test pipeline", cmd: getTestRunnerPipelineCommand(), expected: 1},
+		{name: "test policy", cmd: getTestRunnerPolicyCommand(), expected: 1},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			flag := c.cmd.Flags().Lookup(cobraext.ParallelismFlagName)
+			require.NotNil(t, flag)
+			assert.Equal(t, strconv.Itoa(c.expected), flag.DefValue)
+		})
+	}
+}
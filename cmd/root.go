@@ -23,6 +23,8 @@ var commands = []*cobraext.Command{
 	setupCheckCommand(),
 	setupCleanCommand(),
 	setupCreateCommand(),
+	setupDiffCommand(),
+	setupDocsCommand(),
 	setupDumpCommand(),
 	setupEditCommand(),
 	setupExportCommand(),
@@ -37,6 +39,7 @@ var commands = []*cobraext.Command{
 	setupStatusCommand(),
 	setupTestCommand(),
 	setupUninstallCommand(),
+	setupVerifyCommand(),
 	setupVersionCommand(),
 }
 
@@ -5,6 +5,8 @@
 package cmd
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"strings"
 
@@ -16,7 +18,10 @@ import (
 
 	"github.com/elastic/elastic-package/internal/cobraext"
 	"github.com/elastic/elastic-package/internal/common"
+	"github.com/elastic/elastic-package/internal/dump"
 	"github.com/elastic/elastic-package/internal/install"
+	"github.com/elastic/elastic-package/internal/profile"
+	"github.com/elastic/elastic-package/internal/servicedeployer"
 	"github.com/elastic/elastic-package/internal/stack"
 )
 
@@ -49,6 +54,8 @@ To expose local packages in the Package Registry, build them first and boot up t
 
 For details on how to connect the service with the Elastic stack, see the [service command](https://github.com/elastic/elastic-package/blob/main/README.md#elastic-package-service).
 
+Docker Compose files placed in the stack/overrides directory of the profile, with .yml extension, are merged in lexical order over the generated Docker Compose file. They can be used to add services to the stack, or to modify the existing ones.
+
 You can customize your stack using profile settings, see [Elastic Package profiles](https://github.com/elastic/elastic-package/blob/main/README.md#elastic-package-profiles-1) section. These settings can be also overriden with the --parameter flag. Settings configured this way are not persisted.
 
 There are different providers supported, that can be selected with the --provider flag.
@@ -72,6 +79,18 @@ Relevant environment variables are:
 You can also provide these environment variables manually. In that case elastic-package commands will use these settings.
 `
 
+const stackImagesLongDescription = `Use this command to work with the stack in environments without access to Docker registries.
+
+The export subcommand pulls every image needed by the stack, the agent deployers and the Terraform deployer for a given stack version, and writes them to a bundle with a manifest. The import subcommand loads the images of a bundle and configures the profile to never pull images, so the stack can be booted up fully offline.
+
+To pull images again in the profile, remove the file stack/offline-images.json from the profile directory.`
+
+const stackUpgradeLongDescription = `Use this command to upgrade a running stack to a newer version, keeping its data.
+
+Services are upgraded one by one, starting with Elasticsearch, waiting for each one of them to be healthy before continuing. The upgrade is not started if the upgrade path is not supported, or if Elasticsearch reports critical deprecations. After the upgrade, Fleet setup is executed, and the packages that couldn't be reinstalled and the remaining deprecations are reported.
+
+Only the compose provider supports upgrades. Stacks created with previous versions of elastic-package may not keep their data in volumes, recreate them before upgrading.`
+
 func setupStackCommand() *cobraext.Command {
 	upCommand := &cobra.Command{
 		Use:   "up",
@@ -212,6 +231,53 @@ func setupStackCommand() *cobraext.Command {
 	}
 	updateCommand.Flags().StringP(cobraext.StackVersionFlagName, "", install.DefaultStackVersion, cobraext.StackVersionFlagDescription)
 
+	upgradeCommand := &cobra.Command{
+		Use:   "upgrade",
+		Short: "Upgrade a running stack to a newer version, keeping its data",
+		Long:  stackUpgradeLongDescription,
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			profile, err := cobraext.GetProfileFlag(cmd)
+			if err != nil {
+				return err
+			}
+
+			provider, err := cobraext.GetStackProviderFromProfile(cmd, profile, false)
+			if err != nil {
+				return err
+			}
+			upgrader, ok := provider.(stack.Upgrader)
+			if !ok {
+				return errors.New("the stack provider of this profile doesn't support upgrades")
+			}
+
+			stackVersion, err := cmd.Flags().GetString(cobraext.StackUpgradeToFlagName)
+			if err != nil {
+				return cobraext.FlagParsingError(err, cobraext.StackUpgradeToFlagName)
+			}
+
+			cmd.Printf("Upgrade the Elastic stack to %s\n", stackVersion)
+			report, err := upgrader.Upgrade(cmd.Context(), stack.Options{
+				StackVersion: stackVersion,
+				Profile:      profile,
+				Printer:      cmd,
+			})
+			if err != nil {
+				return fmt.Errorf("failed upgrading the stack: %w", err)
+			}
+
+			printUpgradeReport(cmd, report)
+			if len(report.FailedPackages) > 0 {
+				return fmt.Errorf("%d packages failed to be reinstalled after the upgrade", len(report.FailedPackages))
+			}
+
+			cmd.Println("Done")
+			return nil
+		},
+	}
+	upgradeCommand.Flags().String(cobraext.StackUpgradeToFlagName, "", cobraext.StackUpgradeToFlagDescription)
+	upgradeCommand.MarkFlagRequired(cobraext.StackUpgradeToFlagName)
+
 	shellInitCommand := &cobra.Command{
 		Use:   "shellinit",
 		Short: "Export environment variables",
@@ -263,6 +329,11 @@ func setupStackCommand() *cobraext.Command {
 				return err
 			}
 
+			bundle, err := cmd.Flags().GetBool(cobraext.StackDumpBundleFlagName)
+			if err != nil {
+				return cobraext.FlagParsingError(err, cobraext.StackDumpBundleFlagName)
+			}
+
 			target, err := provider.Dump(cmd.Context(), stack.DumpOptions{
 				Output:  output,
 				Profile: profile,
@@ -271,6 +342,16 @@ func setupStackCommand() *cobraext.Command {
 				return fmt.Errorf("dump failed: %w", err)
 			}
 
+			if bundle {
+				bundlePath, err := dumpSupportBundle(cmd.Context(), profile, output)
+				if err != nil {
+					return fmt.Errorf("support bundle failed: %w", err)
+				}
+				cmd.Printf("Path to support bundle: %s\n", bundlePath)
+				cmd.Println("Done")
+				return nil
+			}
+
 			cmd.Printf("Path to stack dump: %s\n", target)
 
 			cmd.Println("Done")
@@ -278,6 +359,7 @@ func setupStackCommand() *cobraext.Command {
 		},
 	}
 	dumpCommand.Flags().StringP(cobraext.StackDumpOutputFlagName, "", "elastic-stack-dump", cobraext.StackDumpOutputFlagDescription)
+	dumpCommand.Flags().Bool(cobraext.StackDumpBundleFlagName, false, cobraext.StackDumpBundleFlagDescription)
 
 	statusCommand := &cobra.Command{
 		Use:   "status",
@@ -304,9 +386,97 @@ func setupStackCommand() *cobraext.Command {
 
 			cmd.Println("Status of Elastic stack services:")
 			printStatus(cmd, servicesStatus)
+
+			overrides, err := stack.ComposeOverrides(profile)
+			if err != nil {
+				return err
+			}
+			if len(overrides) > 0 {
+				cmd.Println("Docker Compose overrides:")
+				for _, override := range overrides {
+					cmd.Printf(" - %s\n", override)
+				}
+			}
+			return nil
+		},
+	}
+
+	imagesExportCommand := &cobra.Command{
+		Use:   "export",
+		Short: "Export the Docker images required by a stack version to a bundle",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			stackVersion, err := cmd.Flags().GetString(cobraext.StackVersionFlagName)
+			if err != nil {
+				return cobraext.FlagParsingError(err, cobraext.StackVersionFlagName)
+			}
+
+			output, err := cmd.Flags().GetString(cobraext.StackImagesOutputFlagName)
+			if err != nil {
+				return cobraext.FlagParsingError(err, cobraext.StackImagesOutputFlagName)
+			}
+			if output == "" {
+				output = fmt.Sprintf("elastic-package-images-%s.tar.gz", stackVersion)
+			}
+
+			manifest, err := stack.ExportImages(stack.ExportImagesOptions{
+				StackVersion: stackVersion,
+				Output:       output,
+				ExtraImages:  servicedeployer.TerraformDeployerImages(),
+				Printer:      cmd,
+			})
+			if err != nil {
+				return fmt.Errorf("exporting images failed: %w", err)
+			}
+
+			cmd.Printf("Exported %d images to %s\n", len(manifest.Images), output)
+			cmd.Println("Done")
 			return nil
 		},
 	}
+	imagesExportCommand.Flags().StringP(cobraext.StackVersionFlagName, "", install.DefaultStackVersion, cobraext.StackVersionFlagDescription)
+	imagesExportCommand.Flags().StringP(cobraext.StackImagesOutputFlagName, "", "", cobraext.StackImagesOutputFlagDescription)
+
+	imagesImportCommand := &cobra.Command{
+		Use:   "import",
+		Short: "Import a bundle of Docker images and stop pulling images in the profile",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			input, err := cmd.Flags().GetString(cobraext.StackImagesInputFlagName)
+			if err != nil {
+				return cobraext.FlagParsingError(err, cobraext.StackImagesInputFlagName)
+			}
+
+			profile, err := cobraext.GetProfileFlag(cmd)
+			if err != nil {
+				return err
+			}
+
+			manifest, err := stack.ImportImages(stack.ImportImagesOptions{
+				Input:   input,
+				Profile: profile,
+				Printer: cmd,
+			})
+			if err != nil {
+				return fmt.Errorf("importing images failed: %w", err)
+			}
+
+			cmd.Printf("Profile %s configured to use offline images for stack version %s\n", profile.ProfileName, manifest.StackVersion)
+			cmd.Println("Done")
+			return nil
+		},
+	}
+	imagesImportCommand.Flags().StringP(cobraext.StackImagesInputFlagName, "", "", cobraext.StackImagesInputFlagDescription)
+	imagesImportCommand.MarkFlagRequired(cobraext.StackImagesInputFlagName)
+
+	imagesCommand := &cobra.Command{
+		Use:   "images",
+		Short: "Manage offline bundles of the stack Docker images",
+		Long:  stackImagesLongDescription,
+	}
+	imagesCommand.AddCommand(
+		imagesExportCommand,
+		imagesImportCommand)
 
 	cmd := &cobra.Command{
 		Use:   "stack",
@@ -318,13 +488,36 @@ func setupStackCommand() *cobraext.Command {
 		upCommand,
 		downCommand,
 		updateCommand,
+		upgradeCommand,
 		shellInitCommand,
 		dumpCommand,
-		statusCommand)
+		statusCommand,
+		imagesCommand)
 
 	return cobraext.NewCommand(cmd, cobraext.ContextGlobal)
 }
 
+// dumpSupportBundle adds diagnostics of the running stack to the dump in the output
+// directory, and archives everything in a zip file.
+func dumpSupportBundle(ctx context.Context, profile *profile.Profile, output string) (string, error) {
+	esClient, err := stack.NewElasticsearchClientFromProfile(profile)
+	if err != nil {
+		return "", fmt.Errorf("failed to initialize Elasticsearch client: %w", err)
+	}
+	kibanaClient, err := stack.NewKibanaClientFromProfile(profile)
+	if err != nil {
+		return "", fmt.Errorf("failed to initialize Kibana client: %w", err)
+	}
+
+	dumper := dump.NewSupportBundleDumper(esClient.API, kibanaClient, profile)
+	err = dumper.DumpAll(ctx, output)
+	if err != nil {
+		return "", err
+	}
+
+	return dump.ArchiveSupportBundle(ctx, output)
+}
+
 func availableServicesAsList() []string {
 	available := make([]string, len(availableServices))
 	i := 0
@@ -369,3 +562,22 @@ func printStatus(cmd *cobra.Command, servicesStatus []stack.ServiceStatus) {
 	}
 	table.Render()
 }
+
+func printUpgradeReport(cmd *cobra.Command, report *stack.UpgradeReport) {
+	cmd.Printf("Stack upgraded from %s to %s\n", report.FromVersion, report.ToVersion)
+	if len(report.Deprecations) > 0 {
+		cmd.Println("Deprecations reported by Elasticsearch:")
+		for _, d := range report.Deprecations {
+			cmd.Printf(" - [%s] %s: %s\n", d.Level, d.Resource, d.Message)
+			if d.URL != "" {
+				cmd.Printf("   %s\n", d.URL)
+			}
+		}
+	}
+	if len(report.FailedPackages) > 0 {
+		cmd.Println("Packages that failed to be reinstalled:")
+		for _, p := range report.FailedPackages {
+			cmd.Printf(" - %s-%s (%s)\n", p.Name, p.Version, p.Status)
+		}
+	}
+}
@@ -7,6 +7,7 @@ package cmd
 import (
 	"errors"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"slices"
@@ -20,6 +21,7 @@ import (
 	"github.com/elastic/elastic-package/internal/install"
 	"github.com/elastic/elastic-package/internal/logger"
 	"github.com/elastic/elastic-package/internal/packages"
+	"github.com/elastic/elastic-package/internal/packages/monorepo"
 	"github.com/elastic/elastic-package/internal/signal"
 	"github.com/elastic/elastic-package/internal/stack"
 	"github.com/elastic/elastic-package/internal/testrunner"
@@ -30,6 +32,7 @@ import (
 	"github.com/elastic/elastic-package/internal/testrunner/runners/policy"
 	"github.com/elastic/elastic-package/internal/testrunner/runners/static"
 	"github.com/elastic/elastic-package/internal/testrunner/runners/system"
+	"github.com/elastic/elastic-package/internal/testrunner/runners/upgradecompat"
 )
 
 const testLongDescription = `Use this command to run tests on a package. Currently, the following types of tests are available:
@@ -57,7 +60,13 @@ For details on how to configure and run system tests, review the [HOWTO guide](h
 #### Policy Tests
 These tests allow you to test different configuration options and the policies they generate, without needing to run a full scenario.
 
-For details on how to configure and run policy tests, review the [HOWTO guide](https://github.com/elastic/elastic-package/blob/main/docs/howto/policy_testing.md).`
+For details on how to configure and run policy tests, review the [HOWTO guide](https://github.com/elastic/elastic-package/blob/main/docs/howto/policy_testing.md).
+
+#### Upgrade Compatibility Tests
+These tests install a previous version of the package, create policies with the system test configurations, and check that the policies can be upgraded to the version being developed, and that the ingest pipelines and mappings of both versions process sample documents. Services and agents are not deployed, so they don't check that data keeps flowing from the monitored services.
+Upgrade compatibility tests are not run by default, they need to be run explicitly with the upgrade-compat subcommand.
+
+For details on how to run upgrade compatibility tests, review the [HOWTO guide](https://github.com/elastic/elastic-package/blob/main/docs/howto/upgrade_compat_testing.md).`
 
 func setupTestCommand() *cobraext.Command {
 	cmd := &cobra.Command{
@@ -68,7 +77,15 @@ func setupTestCommand() *cobraext.Command {
 			if len(args) > 0 {
 				return fmt.Errorf("unsupported test type: %s", args[0])
 			}
-			return cobraext.ComposeCommandsParentContext(parent, args, parent.Commands()...)
+			// Upgrade compatibility tests depend on previous versions of the package, so they are only run
+			// when requested explicitly.
+			var commands []*cobra.Command
+			for _, command := range parent.Commands() {
+				if command.Name() != string(upgradecompat.TestType) {
+					commands = append(commands, command)
+				}
+			}
+			return cobraext.ComposeCommandsParentContext(parent, args, commands...)
 		},
 	}
 
@@ -82,6 +99,8 @@ func setupTestCommand() *cobraext.Command {
 	// Keep it here for backwards compatibility
 	cmd.PersistentFlags().DurationP(cobraext.DeferCleanupFlagName, "", 0, cobraext.DeferCleanupFlagDescription)
 
+	cmd.PersistentFlags().String(cobraext.ChangedSinceFlagName, "", cobraext.ChangedSinceFlagDescription)
+
 	assetCmd := getTestRunnerAssetCommand()
 	cmd.AddCommand(assetCmd)
 
@@ -97,6 +116,9 @@ func setupTestCommand() *cobraext.Command {
 	policyCmd := getTestRunnerPolicyCommand()
 	cmd.AddCommand(policyCmd)
 
+	upgradeCompatCmd := getTestRunnerUpgradeCompatCommand()
+	cmd.AddCommand(upgradeCompatCmd)
+
 	return cobraext.NewCommand(cmd, cobraext.ContextPackage)
 }
 
@@ -109,6 +131,7 @@ func getTestRunnerAssetCommand() *cobra.Command {
 		RunE:  testRunnerAssetCommandAction,
 	}
 
+	addStackMultiPackageFlags(cmd)
 	return cmd
 }
 
@@ -145,46 +168,41 @@ func testRunnerAssetCommandAction(cmd *cobra.Command, args []string) error {
 		return cobraext.FlagParsingError(fmt.Errorf("coverage format not available: %s", testCoverageFormat), cobraext.TestCoverageFormatFlagName)
 	}
 
-	packageRootPath, found, err := packages.FindPackageRoot()
-	if !found {
-		return errors.New("package root not found")
-	}
-	if err != nil {
-		return fmt.Errorf("locating package root failed: %w", err)
-	}
-
-	manifest, err := packages.ReadPackageManifestFromPackageRoot(packageRootPath)
-	if err != nil {
-		return fmt.Errorf("reading package manifest failed (path: %s): %w", packageRootPath, err)
-	}
+	return runSelectedPackageAction(cmd, func(cmd *cobra.Command, pkg monorepo.Package, w io.Writer) error {
+		packageRootPath := pkg.Root
+		manifest, err := packages.ReadPackageManifestFromPackageRoot(packageRootPath)
+		if err != nil {
+			return fmt.Errorf("reading package manifest failed (path: %s): %w", packageRootPath, err)
+		}
 
-	ctx, stop := signal.Enable(cmd.Context(), logger.Info)
-	defer stop()
+		ctx, stop := signal.Enable(cmd.Context(), logger.Info)
+		defer stop()
 
-	kibanaClient, err := stack.NewKibanaClientFromProfile(profile)
-	if err != nil {
-		return fmt.Errorf("can't create Kibana client: %w", err)
-	}
+		kibanaClient, err := stack.NewKibanaClientFromProfile(profile)
+		if err != nil {
+			return fmt.Errorf("can't create Kibana client: %w", err)
+		}
 
-	globalTestConfig, err := testrunner.ReadGlobalTestConfig(packageRootPath)
-	if err != nil {
-		return fmt.Errorf("failed to read global config: %w", err)
-	}
+		globalTestConfig, err := testrunner.ReadGlobalTestConfig(packageRootPath)
+		if err != nil {
+			return fmt.Errorf("failed to read global config: %w", err)
+		}
 
-	runner := asset.NewAssetTestRunner(asset.AssetTestRunnerOptions{
-		PackageRootPath:  packageRootPath,
-		KibanaClient:     kibanaClient,
-		GlobalTestConfig: globalTestConfig.Asset,
-		WithCoverage:     testCoverage,
-		CoverageType:     testCoverageFormat,
-	})
+		runner := asset.NewAssetTestRunner(asset.AssetTestRunnerOptions{
+			PackageRootPath:  packageRootPath,
+			KibanaClient:     kibanaClient,
+			GlobalTestConfig: globalTestConfig.Asset,
+			WithCoverage:     testCoverage,
+			CoverageType:     testCoverageFormat,
+		})
 
-	results, err := testrunner.RunSuite(ctx, runner)
-	if err != nil {
-		return fmt.Errorf("error running package %s tests: %w", testType, err)
-	}
+		results, err := testrunner.RunSuite(ctx, runner)
+		if err != nil {
+			return fmt.Errorf("error running package %s tests: %w", testType, err)
+		}
 
-	return processResults(results, testType, reportFormat, reportOutput, packageRootPath, manifest.Name, manifest.Type, testCoverageFormat, testCoverage)
+		return processResults(results, testType, reportFormat, reportOutput, packageRootPath, manifest.Name, manifest.Type, testCoverageFormat, testCoverage)
+	})
 }
 
 func getTestRunnerStaticCommand() *cobra.Command {
@@ -199,6 +217,7 @@ func getTestRunnerStaticCommand() *cobra.Command {
 	cmd.Flags().BoolP(cobraext.FailOnMissingFlagName, "m", false, cobraext.FailOnMissingFlagDescription)
 	cmd.Flags().StringSliceP(cobraext.DataStreamsFlagName, "d", nil, cobraext.DataStreamsFlagDescription)
 
+	addMultiPackageFlags(cmd)
 	return cmd
 }
 
@@ -235,47 +254,43 @@ func testRunnerStaticCommandAction(cmd *cobra.Command, args []string) error {
 		return cobraext.FlagParsingError(fmt.Errorf("coverage format not available: %s", testCoverageFormat), cobraext.TestCoverageFormatFlagName)
 	}
 
-	packageRootPath, found, err := packages.FindPackageRoot()
-	if !found {
-		return errors.New("package root not found")
-	}
-	if err != nil {
-		return fmt.Errorf("locating package root failed: %w", err)
-	}
+	return runSelectedPackageAction(cmd, func(cmd *cobra.Command, pkg monorepo.Package, w io.Writer) error {
+		packageRootPath := pkg.Root
+		manifest, err := packages.ReadPackageManifestFromPackageRoot(packageRootPath)
+		if err != nil {
+			return fmt.Errorf("reading package manifest failed (path: %s): %w", packageRootPath, err)
+		}
 
-	manifest, err := packages.ReadPackageManifestFromPackageRoot(packageRootPath)
-	if err != nil {
-		return fmt.Errorf("reading package manifest failed (path: %s): %w", packageRootPath, err)
-	}
+		dataStreams, err := getPackageDataStreams(cmd, pkg)
+		if err != nil {
+			return err
+		}
 
-	dataStreams, err := getDataStreamsFlag(cmd, packageRootPath)
-	if err != nil {
-		return err
-	}
+		ctx, stop := signal.Enable(cmd.Context(), logger.Info)
+		defer stop()
 
-	ctx, stop := signal.Enable(cmd.Context(), logger.Info)
-	defer stop()
+		globalTestConfig, err := testrunner.ReadGlobalTestConfig(packageRootPath)
+		if err != nil {
+			return fmt.Errorf("failed to read global config: %w", err)
+		}
 
-	globalTestConfig, err := testrunner.ReadGlobalTestConfig(packageRootPath)
-	if err != nil {
-		return fmt.Errorf("failed to read global config: %w", err)
-	}
+		runner := static.NewStaticTestRunner(static.StaticTestRunnerOptions{
+			PackageRootPath:    packageRootPath,
+			DataStreams:        dataStreams,
+			FailOnMissingTests: failOnMissing,
+			GlobalTestConfig:   globalTestConfig.Static,
+			FieldsBudget:       globalTestConfig.FieldsBudget,
+			WithCoverage:       testCoverage,
+			CoverageType:       testCoverageFormat,
+		})
+
+		results, err := testrunner.RunSuite(ctx, runner)
+		if err != nil {
+			return err
+		}
 
-	runner := static.NewStaticTestRunner(static.StaticTestRunnerOptions{
-		PackageRootPath:    packageRootPath,
-		DataStreams:        dataStreams,
-		FailOnMissingTests: failOnMissing,
-		GlobalTestConfig:   globalTestConfig.Static,
-		WithCoverage:       testCoverage,
-		CoverageType:       testCoverageFormat,
+		return processResults(results, testType, reportFormat, reportOutput, packageRootPath, manifest.Name, manifest.Type, testCoverageFormat, testCoverage)
 	})
-
-	results, err := testrunner.RunSuite(ctx, runner)
-	if err != nil {
-		return err
-	}
-
-	return processResults(results, testType, reportFormat, reportOutput, packageRootPath, manifest.Name, manifest.Type, testCoverageFormat, testCoverage)
 }
 
 func getTestRunnerPipelineCommand() *cobra.Command {
@@ -291,6 +306,7 @@ func getTestRunnerPipelineCommand() *cobra.Command {
 	cmd.Flags().BoolP(cobraext.GenerateTestResultFlagName, "g", false, cobraext.GenerateTestResultFlagDescription)
 	cmd.Flags().StringSliceP(cobraext.DataStreamsFlagName, "d", nil, cobraext.DataStreamsFlagDescription)
 
+	addStackMultiPackageFlags(cmd)
 	return cmd
 }
 
@@ -342,60 +358,55 @@ func testRunnerPipelineCommandAction(cmd *cobra.Command, args []string) error {
 		return cobraext.FlagParsingError(err, cobraext.DeferCleanupFlagName)
 	}
 
-	packageRootPath, found, err := packages.FindPackageRoot()
-	if !found {
-		return errors.New("package root not found")
-	}
-	if err != nil {
-		return fmt.Errorf("locating package root failed: %w", err)
-	}
+	return runSelectedPackageAction(cmd, func(cmd *cobra.Command, pkg monorepo.Package, w io.Writer) error {
+		packageRootPath := pkg.Root
+		dataStreams, err := getPackageDataStreams(cmd, pkg)
+		if err != nil {
+			return err
+		}
 
-	dataStreams, err := getDataStreamsFlag(cmd, packageRootPath)
-	if err != nil {
-		return err
-	}
+		ctx, stop := signal.Enable(cmd.Context(), logger.Info)
+		defer stop()
 
-	ctx, stop := signal.Enable(cmd.Context(), logger.Info)
-	defer stop()
+		esClient, err := stack.NewElasticsearchClientFromProfile(profile)
+		if err != nil {
+			return fmt.Errorf("can't create Elasticsearch client: %w", err)
+		}
+		err = esClient.CheckHealth(ctx)
+		if err != nil {
+			return err
+		}
 
-	esClient, err := stack.NewElasticsearchClientFromProfile(profile)
-	if err != nil {
-		return fmt.Errorf("can't create Elasticsearch client: %w", err)
-	}
-	err = esClient.CheckHealth(ctx)
-	if err != nil {
-		return err
-	}
+		manifest, err := packages.ReadPackageManifestFromPackageRoot(packageRootPath)
+		if err != nil {
+			return fmt.Errorf("reading package manifest failed (path: %s): %w", packageRootPath, err)
+		}
 
-	manifest, err := packages.ReadPackageManifestFromPackageRoot(packageRootPath)
-	if err != nil {
-		return fmt.Errorf("reading package manifest failed (path: %s): %w", packageRootPath, err)
-	}
+		globalTestConfig, err := testrunner.ReadGlobalTestConfig(packageRootPath)
+		if err != nil {
+			return fmt.Errorf("failed to read global config: %w", err)
+		}
 
-	globalTestConfig, err := testrunner.ReadGlobalTestConfig(packageRootPath)
-	if err != nil {
-		return fmt.Errorf("failed to read global config: %w", err)
-	}
+		runner := pipeline.NewPipelineTestRunner(pipeline.PipelineTestRunnerOptions{
+			Profile:            profile,
+			PackageRootPath:    packageRootPath,
+			API:                esClient.API,
+			DataStreams:        dataStreams,
+			FailOnMissingTests: failOnMissing,
+			GenerateTestResult: generateTestResult,
+			WithCoverage:       testCoverage,
+			CoverageType:       testCoverageFormat,
+			DeferCleanup:       deferCleanup,
+			GlobalTestConfig:   globalTestConfig.Pipeline,
+		})
+
+		results, err := testrunner.RunSuite(ctx, runner)
+		if err != nil {
+			return err
+		}
 
-	runner := pipeline.NewPipelineTestRunner(pipeline.PipelineTestRunnerOptions{
-		Profile:            profile,
-		PackageRootPath:    packageRootPath,
-		API:                esClient.API,
-		DataStreams:        dataStreams,
-		FailOnMissingTests: failOnMissing,
-		GenerateTestResult: generateTestResult,
-		WithCoverage:       testCoverage,
-		CoverageType:       testCoverageFormat,
-		DeferCleanup:       deferCleanup,
-		GlobalTestConfig:   globalTestConfig.Pipeline,
+		return processResults(results, testType, reportFormat, reportOutput, packageRootPath, manifest.Name, manifest.Type, testCoverageFormat, testCoverage)
 	})
-
-	results, err := testrunner.RunSuite(ctx, runner)
-	if err != nil {
-		return err
-	}
-
-	return processResults(results, testType, reportFormat, reportOutput, packageRootPath, manifest.Name, manifest.Type, testCoverageFormat, testCoverage)
 }
 
 func getTestRunnerSystemCommand() *cobra.Command {
@@ -486,14 +497,6 @@ func testRunnerSystemCommandAction(cmd *cobra.Command, args []string) error {
 		return cobraext.FlagParsingError(err, cobraext.VariantFlagName)
 	}
 
-	packageRootPath, found, err := packages.FindPackageRoot()
-	if !found {
-		return errors.New("package root not found")
-	}
-	if err != nil {
-		return fmt.Errorf("locating package root failed: %w", err)
-	}
-
 	runSetup, err := cmd.Flags().GetBool(cobraext.SetupFlagName)
 	if err != nil {
 		return cobraext.FlagParsingError(err, cobraext.SetupFlagName)
@@ -507,6 +510,14 @@ func testRunnerSystemCommandAction(cmd *cobra.Command, args []string) error {
 		return cobraext.FlagParsingError(err, cobraext.NoProvisionFlagName)
 	}
 
+	changedSince, err := cmd.Flags().GetString(cobraext.ChangedSinceFlagName)
+	if err != nil {
+		return cobraext.FlagParsingError(err, cobraext.ChangedSinceFlagName)
+	}
+	if changedSince != "" && (runSetup || runTearDown || runTestsOnly) {
+		return fmt.Errorf("changed-since flag cannot be set with --setup, --tear-down or --no-provision")
+	}
+
 	configFileFlag, err := cmd.Flags().GetString(cobraext.ConfigFileFlagName)
 	if err != nil {
 		return cobraext.FlagParsingError(err, cobraext.ConfigFileFlagName)
@@ -522,75 +533,79 @@ func testRunnerSystemCommandAction(cmd *cobra.Command, args []string) error {
 		configFileFlag = absPath
 	}
 
-	dataStreams, err := getDataStreamsFlag(cmd, packageRootPath)
-	if err != nil {
-		return err
-	}
-
-	ctx, stop := signal.Enable(cmd.Context(), logger.Info)
-	defer stop()
-
-	kibanaClient, err := stack.NewKibanaClientFromProfile(profile)
-	if err != nil {
-		return fmt.Errorf("can't create Kibana client: %w", err)
-	}
-
-	esClient, err := stack.NewElasticsearchClientFromProfile(profile)
-	if err != nil {
-		return fmt.Errorf("can't create Elasticsearch client: %w", err)
-	}
-	err = esClient.CheckHealth(ctx)
-	if err != nil {
-		return err
-	}
-
 	if runTearDown || runTestsOnly {
 		if variantFlag != "" {
 			return fmt.Errorf("variant flag cannot be set with --tear-down or --no-provision")
 		}
 	}
 
-	manifest, err := packages.ReadPackageManifestFromPackageRoot(packageRootPath)
-	if err != nil {
-		return fmt.Errorf("reading package manifest failed (path: %s): %w", packageRootPath, err)
-	}
+	return runSelectedPackageAction(cmd, func(cmd *cobra.Command, pkg monorepo.Package, w io.Writer) error {
+		packageRootPath := pkg.Root
+		dataStreams, err := getPackageDataStreams(cmd, pkg)
+		if err != nil {
+			return err
+		}
 
-	globalTestConfig, err := testrunner.ReadGlobalTestConfig(packageRootPath)
-	if err != nil {
-		return fmt.Errorf("failed to read global config: %w", err)
-	}
+		ctx, stop := signal.Enable(cmd.Context(), logger.Info)
+		defer stop()
 
-	runner := system.NewSystemTestRunner(system.SystemTestRunnerOptions{
-		Profile:            profile,
-		PackageRootPath:    packageRootPath,
-		KibanaClient:       kibanaClient,
-		API:                esClient.API,
-		ESClient:           esClient,
-		ConfigFilePath:     configFileFlag,
-		RunSetup:           runSetup,
-		RunTearDown:        runTearDown,
-		RunTestsOnly:       runTestsOnly,
-		DataStreams:        dataStreams,
-		ServiceVariant:     variantFlag,
-		FailOnMissingTests: failOnMissing,
-		GenerateTestResult: generateTestResult,
-		DeferCleanup:       deferCleanup,
-		GlobalTestConfig:   globalTestConfig.System,
-		WithCoverage:       testCoverage,
-		CoverageType:       testCoverageFormat,
-	})
+		kibanaClient, err := stack.NewKibanaClientFromProfile(profile)
+		if err != nil {
+			return fmt.Errorf("can't create Kibana client: %w", err)
+		}
 
-	logger.Debugf("Running suite...")
-	results, err := testrunner.RunSuite(ctx, runner)
-	if err != nil {
-		return err
-	}
+		esClient, err := stack.NewElasticsearchClientFromProfile(profile)
+		if err != nil {
+			return fmt.Errorf("can't create Elasticsearch client: %w", err)
+		}
+		err = esClient.CheckHealth(ctx)
+		if err != nil {
+			return err
+		}
 
-	err = processResults(results, runner.Type(), reportFormat, reportOutput, packageRootPath, manifest.Name, manifest.Type, testCoverageFormat, testCoverage)
-	if err != nil {
-		return fmt.Errorf("failed to process results: %w", err)
-	}
-	return nil
+		manifest, err := packages.ReadPackageManifestFromPackageRoot(packageRootPath)
+		if err != nil {
+			return fmt.Errorf("reading package manifest failed (path: %s): %w", packageRootPath, err)
+		}
+
+		globalTestConfig, err := testrunner.ReadGlobalTestConfig(packageRootPath)
+		if err != nil {
+			return fmt.Errorf("failed to read global config: %w", err)
+		}
+
+		runner := system.NewSystemTestRunner(system.SystemTestRunnerOptions{
+			Profile:            profile,
+			PackageRootPath:    packageRootPath,
+			KibanaClient:       kibanaClient,
+			API:                esClient.API,
+			ESClient:           esClient,
+			ConfigFilePath:     configFileFlag,
+			RunSetup:           runSetup,
+			RunTearDown:        runTearDown,
+			RunTestsOnly:       runTestsOnly,
+			DataStreams:        dataStreams,
+			ServiceVariant:     variantFlag,
+			FailOnMissingTests: failOnMissing,
+			GenerateTestResult: generateTestResult,
+			DeferCleanup:       deferCleanup,
+			GlobalTestConfig:   globalTestConfig.System,
+			FieldsBudget:       globalTestConfig.FieldsBudget,
+			WithCoverage:       testCoverage,
+			CoverageType:       testCoverageFormat,
+		})
+
+		logger.Debugf("Running suite...")
+		results, err := testrunner.RunSuite(ctx, runner)
+		if err != nil {
+			return err
+		}
+
+		err = processResults(results, runner.Type(), reportFormat, reportOutput, packageRootPath, manifest.Name, manifest.Type, testCoverageFormat, testCoverage)
+		if err != nil {
+			return fmt.Errorf("failed to process results: %w", err)
+		}
+		return nil
+	})
 }
 
 func getTestRunnerPolicyCommand() *cobra.Command {
@@ -605,6 +620,7 @@ func getTestRunnerPolicyCommand() *cobra.Command {
 	cmd.Flags().BoolP(cobraext.FailOnMissingFlagName, "m", false, cobraext.FailOnMissingFlagDescription)
 	cmd.Flags().StringSliceP(cobraext.DataStreamsFlagName, "d", nil, cobraext.DataStreamsFlagDescription)
 	cmd.Flags().BoolP(cobraext.GenerateTestResultFlagName, "g", false, cobraext.GenerateTestResultFlagDescription)
+	addStackMultiPackageFlags(cmd)
 	return cmd
 }
 
@@ -651,54 +667,164 @@ func testRunnerPolicyCommandAction(cmd *cobra.Command, args []string) error {
 		return cobraext.FlagParsingError(fmt.Errorf("coverage format not available: %s", testCoverageFormat), cobraext.TestCoverageFormatFlagName)
 	}
 
-	packageRootPath, found, err := packages.FindPackageRoot()
-	if !found {
-		return errors.New("package root not found")
-	}
-	if err != nil {
-		return fmt.Errorf("locating package root failed: %w", err)
+	return runSelectedPackageAction(cmd, func(cmd *cobra.Command, pkg monorepo.Package, w io.Writer) error {
+		packageRootPath := pkg.Root
+		dataStreams, err := getPackageDataStreams(cmd, pkg)
+		if err != nil {
+			return err
+		}
+
+		ctx, stop := signal.Enable(cmd.Context(), logger.Info)
+		defer stop()
+
+		kibanaClient, err := stack.NewKibanaClientFromProfile(profile)
+		if err != nil {
+			return fmt.Errorf("can't create Kibana client: %w", err)
+		}
+
+		manifest, err := packages.ReadPackageManifestFromPackageRoot(packageRootPath)
+		if err != nil {
+			return fmt.Errorf("reading package manifest failed (path: %s): %w", packageRootPath, err)
+		}
+
+		globalTestConfig, err := testrunner.ReadGlobalTestConfig(packageRootPath)
+		if err != nil {
+			return fmt.Errorf("failed to read global config: %w", err)
+		}
+
+		runner := policy.NewPolicyTestRunner(policy.PolicyTestRunnerOptions{
+			PackageRootPath:    packageRootPath,
+			KibanaClient:       kibanaClient,
+			DataStreams:        dataStreams,
+			FailOnMissingTests: failOnMissing,
+			GenerateTestResult: generateTestResult,
+			GlobalTestConfig:   globalTestConfig.Policy,
+			WithCoverage:       testCoverage,
+			CoverageType:       testCoverageFormat,
+		})
+
+		results, err := testrunner.RunSuite(ctx, runner)
+		if err != nil {
+			return err
+		}
+
+		return processResults(results, testType, reportFormat, reportOutput, packageRootPath, manifest.Name, manifest.Type, testCoverageFormat, testCoverage)
+	})
+}
+
+func getTestRunnerUpgradeCompatCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "upgrade-compat",
+		Short: "Run upgrade compatibility tests",
+		Long:  "Run upgrade compatibility tests for the package, upgrading its policies and assets from a previous version to the version being developed, and checking that the ingest pipelines and mappings of both versions process sample documents. Services and agents are not deployed, documents are indexed directly in the data streams.",
+		Args:  cobra.NoArgs,
+		RunE:  testRunnerUpgradeCompatCommandAction,
 	}
 
-	dataStreams, err := getDataStreamsFlag(cmd, packageRootPath)
+	cmd.Flags().BoolP(cobraext.FailOnMissingFlagName, "m", false, cobraext.FailOnMissingFlagDescription)
+	cmd.Flags().StringSliceP(cobraext.DataStreamsFlagName, "d", nil, cobraext.DataStreamsFlagDescription)
+	cmd.Flags().String(cobraext.UpgradeFromVersionFlagName, "", cobraext.UpgradeFromVersionFlagDescription)
+	cmd.Flags().String(cobraext.UpgradeFromZipFlagName, "", cobraext.UpgradeFromZipFlagDescription)
+	cmd.MarkFlagsMutuallyExclusive(cobraext.UpgradeFromVersionFlagName, cobraext.UpgradeFromZipFlagName)
+	return cmd
+}
+
+func testRunnerUpgradeCompatCommandAction(cmd *cobra.Command, args []string) error {
+	cmd.Printf("Run upgrade compatibility tests for the package\n")
+	testType := upgradecompat.TestType
+
+	profile, err := cobraext.GetProfileFlag(cmd)
 	if err != nil {
 		return err
 	}
 
-	ctx, stop := signal.Enable(cmd.Context(), logger.Info)
-	defer stop()
+	failOnMissing, err := cmd.Flags().GetBool(cobraext.FailOnMissingFlagName)
+	if err != nil {
+		return cobraext.FlagParsingError(err, cobraext.FailOnMissingFlagName)
+	}
 
-	kibanaClient, err := stack.NewKibanaClientFromProfile(profile)
+	fromVersion, err := cmd.Flags().GetString(cobraext.UpgradeFromVersionFlagName)
 	if err != nil {
-		return fmt.Errorf("can't create Kibana client: %w", err)
+		return cobraext.FlagParsingError(err, cobraext.UpgradeFromVersionFlagName)
 	}
 
-	manifest, err := packages.ReadPackageManifestFromPackageRoot(packageRootPath)
+	fromZip, err := cmd.Flags().GetString(cobraext.UpgradeFromZipFlagName)
 	if err != nil {
-		return fmt.Errorf("reading package manifest failed (path: %s): %w", packageRootPath, err)
+		return cobraext.FlagParsingError(err, cobraext.UpgradeFromZipFlagName)
 	}
 
-	globalTestConfig, err := testrunner.ReadGlobalTestConfig(packageRootPath)
+	reportFormat, err := cmd.Flags().GetString(cobraext.ReportFormatFlagName)
 	if err != nil {
-		return fmt.Errorf("failed to read global config: %w", err)
+		return cobraext.FlagParsingError(err, cobraext.ReportFormatFlagName)
 	}
 
-	runner := policy.NewPolicyTestRunner(policy.PolicyTestRunnerOptions{
-		PackageRootPath:    packageRootPath,
-		KibanaClient:       kibanaClient,
-		DataStreams:        dataStreams,
-		FailOnMissingTests: failOnMissing,
-		GenerateTestResult: generateTestResult,
-		GlobalTestConfig:   globalTestConfig.Policy,
-		WithCoverage:       testCoverage,
-		CoverageType:       testCoverageFormat,
-	})
+	reportOutput, err := cmd.Flags().GetString(cobraext.ReportOutputFlagName)
+	if err != nil {
+		return cobraext.FlagParsingError(err, cobraext.ReportOutputFlagName)
+	}
 
-	results, err := testrunner.RunSuite(ctx, runner)
+	// Previous versions are selected for a single package, they can't be used with changes in
+	// multiple packages.
+	changedSince, err := cmd.Flags().GetString(cobraext.ChangedSinceFlagName)
 	if err != nil {
-		return err
+		return cobraext.FlagParsingError(err, cobraext.ChangedSinceFlagName)
+	}
+	if changedSince != "" && (fromVersion != "" || fromZip != "") {
+		return cobraext.FlagParsingError(fmt.Errorf("can't be used together with --%s or --%s", cobraext.UpgradeFromVersionFlagName, cobraext.UpgradeFromZipFlagName), cobraext.ChangedSinceFlagName)
 	}
 
-	return processResults(results, testType, reportFormat, reportOutput, packageRootPath, manifest.Name, manifest.Type, testCoverageFormat, testCoverage)
+	return runSelectedPackageAction(cmd, func(cmd *cobra.Command, pkg monorepo.Package, w io.Writer) error {
+		packageRootPath := pkg.Root
+		dataStreams, err := getPackageDataStreams(cmd, pkg)
+		if err != nil {
+			return err
+		}
+
+		ctx, stop := signal.Enable(cmd.Context(), logger.Info)
+		defer stop()
+
+		kibanaClient, err := stack.NewKibanaClientFromProfile(profile)
+		if err != nil {
+			return fmt.Errorf("can't create Kibana client: %w", err)
+		}
+
+		esClient, err := stack.NewElasticsearchClientFromProfile(profile)
+		if err != nil {
+			return fmt.Errorf("can't create Elasticsearch client: %w", err)
+		}
+		err = esClient.CheckHealth(ctx)
+		if err != nil {
+			return err
+		}
+
+		manifest, err := packages.ReadPackageManifestFromPackageRoot(packageRootPath)
+		if err != nil {
+			return fmt.Errorf("reading package manifest failed (path: %s): %w", packageRootPath, err)
+		}
+
+		globalTestConfig, err := testrunner.ReadGlobalTestConfig(packageRootPath)
+		if err != nil {
+			return fmt.Errorf("failed to read global config: %w", err)
+		}
+
+		runner := upgradecompat.NewUpgradeCompatTestRunner(upgradecompat.UpgradeCompatTestRunnerOptions{
+			PackageRootPath:    packageRootPath,
+			KibanaClient:       kibanaClient,
+			API:                esClient.API,
+			DataStreams:        dataStreams,
+			FailOnMissingTests: failOnMissing,
+			GlobalTestConfig:   globalTestConfig.UpgradeCompat,
+			FromVersion:        fromVersion,
+			FromZip:            fromZip,
+		})
+
+		results, err := testrunner.RunSuite(ctx, runner)
+		if err != nil {
+			return err
+		}
+
+		return processResults(results, testType, reportFormat, reportOutput, packageRootPath, manifest.Name, manifest.Type, "", false)
+	})
 }
 
 func processResults(results []testrunner.TestResult, testType testrunner.TestType, reportFormat, reportOutput, packageRootPath, packageName, packageType, testCoverageFormat string, testCoverage bool) error {
@@ -768,3 +894,16 @@ func getDataStreamsFlag(cmd *cobra.Command, packageRootPath string) ([]string, e
 	}
 	return dataStreams, nil
 }
+
+// getPackageDataStreams returns the data streams selected with the data streams flag or, if
+// none, the data streams selected in the package.
+func getPackageDataStreams(cmd *cobra.Command, pkg monorepo.Package) ([]string, error) {
+	dataStreams, err := getDataStreamsFlag(cmd, pkg.Root)
+	if err != nil {
+		return nil, err
+	}
+	if len(dataStreams) == 0 {
+		return pkg.DataStreams, nil
+	}
+	return dataStreams, nil
+}
@@ -0,0 +1,88 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package cmd
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/elastic/elastic-package/internal/cobraext"
+	"github.com/elastic/elastic-package/internal/files"
+	"github.com/elastic/elastic-package/internal/install"
+	"github.com/elastic/elastic-package/internal/packages/installer"
+	"github.com/elastic/elastic-package/internal/profile"
+)
+
+const (
+	configPackageSignatureRequired  = "package.signature.required"
+	configPackageSignaturePublicKey = "package.signature.public_key"
+)
+
+const verifyLongDescription = `Use this command to verify the signature of a zipped package.
+
+The detached signature in the "<zip>.sig" file, as created by "elastic-package build --sign", is verified against a public key or keyring, in armored or binary format. The public key can be provided with the --public-key flag, or with the "package.signature.public_key" setting of the profile.
+
+The command also checks that the package files are in a directory named after the name and the version of the package in its manifest, and that the signature was created for the same package.`
+
+func setupVerifyCommand() *cobraext.Command {
+	cmd := &cobra.Command{
+		Use:   "verify <zip>",
+		Short: "Verify the signature of a zipped package",
+		Long:  verifyLongDescription,
+		Args:  cobra.ExactArgs(1),
+		RunE:  verifyCommandAction,
+	}
+	cmd.Flags().String(cobraext.VerifyPublicKeyFlagName, "", cobraext.VerifyPublicKeyFlagDescription)
+	cmd.Flags().StringP(cobraext.ProfileFlagName, "p", "", fmt.Sprintf(cobraext.ProfileFlagDescription, install.ProfileNameEnvVar))
+
+	return cobraext.NewCommand(cmd, cobraext.ContextGlobal)
+}
+
+func verifyCommandAction(cmd *cobra.Command, args []string) error {
+	zipPath := args[0]
+
+	publicKey, err := cmd.Flags().GetString(cobraext.VerifyPublicKeyFlagName)
+	if err != nil {
+		return cobraext.FlagParsingError(err, cobraext.VerifyPublicKeyFlagName)
+	}
+	if publicKey == "" {
+		profile, err := cobraext.GetProfileFlag(cmd)
+		if err != nil {
+			return err
+		}
+		publicKey = profile.Config(configPackageSignaturePublicKey, "")
+	}
+	if publicKey == "" {
+		return fmt.Errorf("public key is required, please use the --%s flag or the %q profile setting", cobraext.VerifyPublicKeyFlagName, configPackageSignaturePublicKey)
+	}
+
+	manifest, err := installer.VerifyZipPackage(zipPath, files.VerifyOptions{PublicKeyPath: publicKey})
+	if errors.Is(err, files.ErrSignatureNotFound) {
+		return fmt.Errorf("package is not signed: %w", err)
+	}
+	if err != nil {
+		return fmt.Errorf("verification failed: %w", err)
+	}
+
+	cmd.Printf("Signature of package %s-%s verified: %s\n", manifest.Name, manifest.Version, zipPath)
+	return nil
+}
+
+// signatureRequirement returns true if the profile requires signed packages, and the options to
+// verify them.
+func signatureRequirement(profile *profile.Profile) (bool, files.VerifyOptions, error) {
+	options := files.VerifyOptions{
+		PublicKeyPath: profile.Config(configPackageSignaturePublicKey, ""),
+	}
+	if profile.Config(configPackageSignatureRequired, "false") != "true" {
+		return false, options, nil
+	}
+	if options.PublicKeyPath == "" {
+		return false, options, fmt.Errorf("signed packages are required by the profile, but %q is not set", configPackageSignaturePublicKey)
+	}
+	return true, options, nil
+}
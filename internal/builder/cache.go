@@ -0,0 +1,435 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package builder
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/elastic/elastic-package/internal/environment"
+	"github.com/elastic/elastic-package/internal/files"
+	"github.com/elastic/elastic-package/internal/logger"
+	"github.com/elastic/elastic-package/internal/packages"
+	"github.com/elastic/elastic-package/internal/packages/buildmanifest"
+	"github.com/elastic/elastic-package/internal/version"
+)
+
+const (
+	buildCacheDir = "cache"
+
+	// buildStateFormatVersion needs to be increased when the format of the build state changes,
+	// or when the build steps change in a way that invalidates previous builds.
+	buildStateFormatVersion = 1
+
+	// linkedFilePrefix is used in the keys of the hashes of included linked files, to distinguish
+	// them from the files copied from the package.
+	linkedFilePrefix = "link:"
+)
+
+// The links definitions file is located in the same way as in the docs package, that depends on this one.
+const linksDefinitionsFileName = "links_table.yml"
+
+var linksDefinitionsFileEnv = environment.WithElasticPackagePrefix("LINKS_FILE_PATH")
+
+// Files that affect all the build steps, the whole package is rebuilt when they change.
+var globalBuildInputs = []string{
+	packages.PackageManifestFile,
+	filepath.Join("_dev", "build", "build.yml"),
+}
+
+// readmeTemplatesPattern matches the templates of the README files rendered before building the
+// package. They are not copied to the package, but they are part of the build key so rendering
+// can be skipped when nothing changed.
+var readmeTemplatesPattern = filepath.Join("_dev", "build", "docs", "*.md")
+
+// buildState contains the inputs used in the last build of a package, it is used to skip the
+// build steps that don't need to be executed again.
+type buildState struct {
+	// Key is the hash of the inputs that affect the whole build.
+	Key string `json:"key"`
+
+	// Files contains the hashes of the contents of the files included in the package.
+	Files map[string]string `json:"files"`
+
+	Zipped    bool `json:"zipped,omitempty"`
+	Signed    bool `json:"signed,omitempty"`
+	Validated bool `json:"validated,omitempty"`
+}
+
+// buildChanges are the differences between the current inputs of the build and the ones used in
+// the last build.
+type buildChanges struct {
+	// full is set with the reason to rebuild the whole package.
+	full string
+
+	changed []string
+	removed []string
+}
+
+func (c buildChanges) empty() bool {
+	return c.full == "" && len(c.changed) == 0 && len(c.removed) == 0
+}
+
+// includes returns true if the file (relative to the package root) needs to be processed in
+// this build.
+func (c buildChanges) includes(path string) bool {
+	return c.full != "" || slices.Contains(c.changed, filepath.ToSlash(path))
+}
+
+// fileFilter selects files by their paths relative to the package root.
+type fileFilter func(path string) bool
+
+// filterFiles returns the files in the destination directory selected by the filter.
+func filterFiles(destinationDir string, paths []string, include fileFilter) []string {
+	var selected []string
+	for _, path := range paths {
+		rel, err := filepath.Rel(destinationDir, path)
+		if err != nil || !include(rel) {
+			continue
+		}
+		selected = append(selected, path)
+	}
+	return selected
+}
+
+// buildStatePath returns the path of the file with the build state of the package built in the
+// destination directory (<buildDir>/packages/<name>/<version>), that is stored in
+// <buildDir>/cache/packages/<name>/<version>.json.
+func buildStatePath(destinationDir string) string {
+	versionDir := filepath.Base(destinationDir)
+	nameDir := filepath.Dir(destinationDir)
+	packagesDir := filepath.Dir(nameDir)
+	buildDir := filepath.Dir(packagesDir)
+	return filepath.Join(buildDir, buildCacheDir, filepath.Base(packagesDir), filepath.Base(nameDir), versionDir+".json")
+}
+
+func readBuildState(path string) (*buildState, error) {
+	d, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var state buildState
+	err = json.Unmarshal(d, &state)
+	if err != nil {
+		logger.Debugf("Ignoring invalid build state (path: %s): %v", path, err)
+		return nil, nil
+	}
+	return &state, nil
+}
+
+func writeBuildState(path string, state *buildState) error {
+	d, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode build state: %w", err)
+	}
+	err = os.MkdirAll(filepath.Dir(path), 0755)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, d, 0644)
+}
+
+func removeBuildState(path string) error {
+	err := os.Remove(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// newBuildState collects the inputs of the build of the package.
+func newBuildState(options BuildOptions) (*buildState, error) {
+	key, err := buildKey(options)
+	if err != nil {
+		return nil, fmt.Errorf("failed to calculate build key: %w", err)
+	}
+
+	fileHashes, err := packageFileHashes(options.PackageRoot)
+	if err != nil {
+		return nil, fmt.Errorf("failed to calculate hashes of package files: %w", err)
+	}
+
+	return &buildState{
+		Key:   key,
+		Files: fileHashes,
+	}, nil
+}
+
+// changesSince returns the changes in the inputs of the build since the given previous state.
+func (s *buildState) changesSince(previous *buildState, destinationDir string) buildChanges {
+	if previous == nil {
+		return buildChanges{full: "no previous build found"}
+	}
+	if previous.Key != s.Key {
+		return buildChanges{full: "elastic-package version, package manifest, build manifest, README templates, links definitions, license, or local ECS or semantic conventions schemas changed"}
+	}
+	if _, err := os.Stat(destinationDir); err != nil {
+		return buildChanges{full: "built package not found"}
+	}
+
+	var changes buildChanges
+	for path, hash := range s.Files {
+		if previous.Files[path] != hash {
+			changes.changed = append(changes.changed, path)
+		}
+	}
+	for path := range previous.Files {
+		if _, found := s.Files[path]; found {
+			continue
+		}
+		if strings.HasPrefix(path, linkedFilePrefix) {
+			return buildChanges{full: fmt.Sprintf("linked file %s removed", strings.TrimPrefix(path, linkedFilePrefix))}
+		}
+		changes.removed = append(changes.removed, path)
+	}
+	slices.Sort(changes.changed)
+	slices.Sort(changes.removed)
+	return changes
+}
+
+// UpToDate returns true if the package has been built before and none of the inputs of the build
+// changed since then.
+func UpToDate(options BuildOptions) (bool, error) {
+	if options.FullRebuild {
+		return false, nil
+	}
+	destinationDir, err := BuildPackagesDirectory(options.PackageRoot, options.BuildDir)
+	if err != nil {
+		return false, fmt.Errorf("can't locate build directory: %w", err)
+	}
+	previous, err := readBuildState(buildStatePath(destinationDir))
+	if err != nil {
+		return false, fmt.Errorf("can't read state of previous build: %w", err)
+	}
+	if previous == nil {
+		return false, nil
+	}
+	state, err := newBuildState(options)
+	if err != nil {
+		return false, err
+	}
+	return state.changesSince(previous, destinationDir).empty(), nil
+}
+
+// buildKey calculates a hash of the inputs that affect all the build steps.
+func buildKey(options BuildOptions) (string, error) {
+	h := sha256.New()
+	fmt.Fprintf(h, "format: %d\n", buildStateFormatVersion)
+	fmt.Fprintf(h, "elastic-package: %s %s\n", version.Tag, version.CommitHash)
+
+	for _, path := range globalBuildInputs {
+		err := hashFile(h, path, filepath.Join(options.PackageRoot, path))
+		if err != nil {
+			return "", err
+		}
+	}
+
+	templates, err := filepath.Glob(filepath.Join(options.PackageRoot, readmeTemplatesPattern))
+	if err != nil {
+		return "", err
+	}
+	for _, path := range templates {
+		rel, err := filepath.Rel(options.PackageRoot, path)
+		if err != nil {
+			return "", err
+		}
+		err = hashFile(h, filepath.ToSlash(rel), path)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	// The links definitions file is used to render the README files.
+	linksPath, err := linksDefinitionsPath()
+	if err != nil {
+		return "", err
+	}
+	err = hashFile(h, "links", linksPath)
+	if err != nil {
+		return "", err
+	}
+
+	licensePath, err := repositoryLicensePath()
+	if err != nil {
+		return "", err
+	}
+	if licensePath != "" {
+		err := hashFile(h, licenseTextFileName, licensePath)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	// Local ECS and semantic conventions schemas can change without changes in the build manifest.
+	bm, ok, err := buildmanifest.ReadBuildManifest(options.PackageRoot)
+	if err != nil {
+		return "", fmt.Errorf("can't read build manifest: %w", err)
+	}
+	if ok && strings.HasPrefix(bm.Dependencies.ECS.Reference, "file://") {
+		path := strings.TrimPrefix(bm.Dependencies.ECS.Reference, "file://")
+		err := hashFile(h, "ecs", path)
+		if err != nil {
+			return "", err
+		}
+	}
+	if ok && strings.HasPrefix(bm.Dependencies.Semconv.Reference, "file://") {
+		path := strings.TrimPrefix(bm.Dependencies.Semconv.Reference, "file://")
+		err := hashFile(h, "semconv", path)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// linksDefinitionsPath returns the path of the links definitions file used to render README files,
+// that is set with an environment variable, or found in the root of the repository.
+func linksDefinitionsPath() (string, error) {
+	path, ok := os.LookupEnv(linksDefinitionsFileEnv)
+	if ok {
+		return path, nil
+	}
+	dir, err := files.FindRepositoryRootDirectory()
+	if err != nil {
+		return "", fmt.Errorf("locating links definitions file failed: %w", err)
+	}
+	return filepath.Join(dir, linksDefinitionsFileName), nil
+}
+
+// repositoryLicensePath returns the path of the license file that is copied to the package
+// if it doesn't include one, or an empty string if there is none.
+func repositoryLicensePath() (string, error) {
+	licenseFileName, userDefined := os.LookupEnv(repositoryLicenseEnv)
+	if !userDefined {
+		licenseFileName = licenseTextFileName
+	}
+	path, err := findRepositoryLicense(licenseFileName)
+	if !userDefined && errors.Is(err, os.ErrNotExist) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failure while looking for license %q in repository: %w", licenseFileName, err)
+	}
+	return path, nil
+}
+
+// hashFile writes the name and the contents of the file in the hash, missing files are
+// written as such.
+func hashFile(w io.Writer, name, path string) error {
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		fmt.Fprintf(w, "%s: missing\n", name)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	fmt.Fprintf(w, "%s:\n", name)
+	_, err = io.Copy(w, f)
+	return err
+}
+
+// packageFileHashes returns the hashes of the contents of the files copied to the built package,
+// and of the files included through links, indexed by their paths relative to the package root.
+func packageFileHashes(packageRoot string) (map[string]string, error) {
+	hashes := make(map[string]string)
+	err := files.WalkWithoutDev(packageRoot, func(path string) error {
+		h := sha256.New()
+		err := hashFile(h, path, filepath.Join(packageRoot, path))
+		if err != nil {
+			return err
+		}
+		hashes[filepath.ToSlash(path)] = hex.EncodeToString(h.Sum(nil))
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	linksFS, err := files.CreateLinksFSFromPath(packageRoot)
+	if err != nil {
+		return nil, fmt.Errorf("creating links filesystem failed: %w", err)
+	}
+	links, err := linksFS.ListLinkedFiles()
+	if err != nil {
+		return nil, fmt.Errorf("listing linked files failed: %w", err)
+	}
+	for _, l := range links {
+		rel, err := filepath.Rel(packageRoot, filepath.Join(l.WorkDir, l.LinkFilePath))
+		if err != nil {
+			return nil, err
+		}
+		hashes[linkedFilePrefix+filepath.ToSlash(rel)] = l.IncludedFileContentsChecksum
+	}
+	return hashes, nil
+}
+
+// copyChangedFiles copies the changed files from the package to the destination directory, and
+// deletes the removed ones.
+func copyChangedFiles(packageRoot, destinationDir string, changes buildChanges) error {
+	for _, path := range changes.changed {
+		if strings.HasPrefix(path, linkedFilePrefix) {
+			// Linked files are included in all builds.
+			continue
+		}
+		logger.Debugf("Copy changed file %s", path)
+		err := files.CopyFile(filepath.Join(packageRoot, filepath.FromSlash(path)), filepath.Join(destinationDir, filepath.FromSlash(path)))
+		if err != nil {
+			return err
+		}
+	}
+	for _, path := range changes.removed {
+		logger.Debugf("Remove deleted file %s", path)
+		err := os.Remove(filepath.Join(destinationDir, filepath.FromSlash(path)))
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+		err = removeEmptyParents(destinationDir, filepath.Dir(filepath.FromSlash(path)))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// removeEmptyParents removes the directory (relative to the destination directory) and its parents
+// while they are empty, so the result is the same as in a full build.
+func removeEmptyParents(destinationDir, dir string) error {
+	for ; dir != "." && dir != string(filepath.Separator); dir = filepath.Dir(dir) {
+		path := filepath.Join(destinationDir, dir)
+		entries, err := os.ReadDir(path)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if len(entries) > 0 {
+			return nil
+		}
+		logger.Debugf("Remove empty directory %s", dir)
+		err = os.Remove(path)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
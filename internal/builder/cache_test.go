@@ -0,0 +1,210 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package builder
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildStatePath(t *testing.T) {
+	destinationDir := filepath.Join("repo", "build", "packages", "apache", "1.2.3")
+	expected := filepath.Join("repo", "build", "cache", "packages", "apache", "1.2.3.json")
+	assert.Equal(t, expected, buildStatePath(destinationDir))
+}
+
+func TestBuildStateChangesSince(t *testing.T) {
+	destinationDir := t.TempDir()
+	previous := &buildState{
+		Key: "key",
+		Files: map[string]string{
+			"manifest.yml":                 "a",
+			"docs/README.md":               "b",
+			"kibana/dashboard/foo.json":    "c",
+			"link:fields/ecs.yml.link":     "d",
+			"data_stream/foo/manifest.yml": "e",
+		},
+	}
+
+	cases := []struct {
+		title    string
+		previous *buildState
+		current  *buildState
+		expected buildChanges
+	}{
+		{
+			title:    "no previous build",
+			current:  previous,
+			expected: buildChanges{full: "no previous build found"},
+		},
+		{
+			title:    "no changes",
+			previous: previous,
+			current:  previous,
+		},
+		{
+			title:    "different key",
+			previous: previous,
+			current:  &buildState{Key: "other", Files: previous.Files},
+			expected: buildChanges{full: "elastic-package version, package manifest, build manifest, README templates, links definitions, license, or local ECS or semantic conventions schemas changed"},
+		},
+		{
+			title:    "changed files",
+			previous: previous,
+			current: &buildState{
+				Key: "key",
+				Files: map[string]string{
+					"manifest.yml":              "a",
+					"docs/README.md":            "b2",
+					"kibana/dashboard/foo.json": "c",
+					"kibana/dashboard/bar.json": "f",
+					"link:fields/ecs.yml.link":  "d2",
+				},
+			},
+			expected: buildChanges{
+				changed: []string{"docs/README.md", "kibana/dashboard/bar.json", "link:fields/ecs.yml.link"},
+				removed: []string{"data_stream/foo/manifest.yml"},
+			},
+		},
+		{
+			title:    "removed linked file",
+			previous: previous,
+			current: &buildState{
+				Key: "key",
+				Files: map[string]string{
+					"manifest.yml":                 "a",
+					"docs/README.md":               "b",
+					"kibana/dashboard/foo.json":    "c",
+					"data_stream/foo/manifest.yml": "e",
+				},
+			},
+			expected: buildChanges{full: "linked file fields/ecs.yml.link removed"},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.title, func(t *testing.T) {
+			changes := c.current.changesSince(c.previous, destinationDir)
+			assert.Equal(t, c.expected, changes)
+		})
+	}
+}
+
+func TestBuildStateChangesSinceWithoutBuiltPackage(t *testing.T) {
+	state := &buildState{Key: "key"}
+	changes := state.changesSince(state, filepath.Join(t.TempDir(), "missing"))
+	assert.Equal(t, "built package not found", changes.full)
+}
+
+func TestBuildChangesIncludes(t *testing.T) {
+	changes := buildChanges{changed: []string{"kibana/dashboard/foo.json"}}
+	assert.True(t, changes.includes(filepath.Join("kibana", "dashboard", "foo.json")))
+	assert.False(t, changes.includes(filepath.Join("kibana", "dashboard", "bar.json")))
+
+	full := buildChanges{full: "no previous build found"}
+	assert.True(t, full.includes(filepath.Join("kibana", "dashboard", "bar.json")))
+}
+
+func TestWriteAndReadBuildState(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache", "packages", "foo", "1.0.0.json")
+	state := &buildState{
+		Key:       "key",
+		Files:     map[string]string{"manifest.yml": "a"},
+		Zipped:    true,
+		Validated: true,
+	}
+	require.NoError(t, writeBuildState(path, state))
+
+	read, err := readBuildState(path)
+	require.NoError(t, err)
+	assert.Equal(t, state, read)
+
+	require.NoError(t, removeBuildState(path))
+	read, err = readBuildState(path)
+	require.NoError(t, err)
+	assert.Nil(t, read)
+}
+
+func TestUpToDate(t *testing.T) {
+	repositoryRoot := t.TempDir()
+	require.NoError(t, os.Mkdir(filepath.Join(repositoryRoot, ".git"), 0o755))
+	t.Chdir(repositoryRoot)
+
+	packageRoot := filepath.Join(repositoryRoot, "packages", "foo")
+	templatePath := filepath.Join(packageRoot, "_dev", "build", "docs", "README.md")
+	require.NoError(t, os.MkdirAll(filepath.Dir(templatePath), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(packageRoot, "manifest.yml"), []byte("name: foo\nversion: 1.0.0\n"), 0o644))
+	require.NoError(t, os.WriteFile(templatePath, []byte("# Foo\n"), 0o644))
+
+	options := BuildOptions{
+		PackageRoot: packageRoot,
+		BuildDir:    filepath.Join(repositoryRoot, "build"),
+	}
+	require.NoError(t, os.MkdirAll(options.BuildDir, 0o755))
+	upToDate, err := UpToDate(options)
+	require.NoError(t, err)
+	assert.False(t, upToDate, "package never built")
+
+	destinationDir, err := BuildPackagesDirectory(options.PackageRoot, options.BuildDir)
+	require.NoError(t, err)
+	require.NoError(t, os.MkdirAll(destinationDir, 0o755))
+	state, err := newBuildState(options)
+	require.NoError(t, err)
+	require.NoError(t, writeBuildState(buildStatePath(destinationDir), state))
+
+	upToDate, err = UpToDate(options)
+	require.NoError(t, err)
+	assert.True(t, upToDate)
+
+	options.FullRebuild = true
+	upToDate, err = UpToDate(options)
+	require.NoError(t, err)
+	assert.False(t, upToDate, "full rebuild requested")
+	options.FullRebuild = false
+
+	require.NoError(t, os.WriteFile(templatePath, []byte("# Foo\n\nUpdated.\n"), 0o644))
+	upToDate, err = UpToDate(options)
+	require.NoError(t, err)
+	assert.False(t, upToDate, "README template changed")
+
+	linksPath := filepath.Join(repositoryRoot, "links_table.yml")
+	require.NoError(t, os.WriteFile(linksPath, []byte("links:\n  foo: https://example.com/foo\n"), 0o644))
+	t.Setenv(linksDefinitionsFileEnv, linksPath)
+	state, err = newBuildState(options)
+	require.NoError(t, err)
+	require.NoError(t, writeBuildState(buildStatePath(destinationDir), state))
+	upToDate, err = UpToDate(options)
+	require.NoError(t, err)
+	assert.True(t, upToDate)
+
+	require.NoError(t, os.WriteFile(linksPath, []byte("links:\n  foo: https://example.com/bar\n"), 0o644))
+	upToDate, err = UpToDate(options)
+	require.NoError(t, err)
+	assert.False(t, upToDate, "links definitions changed")
+}
+
+func TestCopyChangedFilesRemovesEmptyDirectories(t *testing.T) {
+	destinationDir := t.TempDir()
+	for _, path := range []string{
+		"data_stream/logs/manifest.yml",
+		"data_stream/logs/fields/base-fields.yml",
+		"data_stream/metrics/manifest.yml",
+	} {
+		path = filepath.Join(destinationDir, filepath.FromSlash(path))
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte("{}\n"), 0o644))
+	}
+
+	err := copyChangedFiles(t.TempDir(), destinationDir, buildChanges{
+		removed: []string{"data_stream/logs/fields/base-fields.yml", "data_stream/logs/manifest.yml"},
+	})
+	require.NoError(t, err)
+	assert.NoDirExists(t, filepath.Join(destinationDir, "data_stream", "logs"))
+	assert.FileExists(t, filepath.Join(destinationDir, "data_stream", "metrics", "manifest.yml"))
+}
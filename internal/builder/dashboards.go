@@ -30,12 +30,12 @@ var fieldsToEncode = []string{
 	panelsAttribute,
 }
 
-func encodeDashboards(destinationDir string) error {
+func encodeDashboards(destinationDir string, include fileFilter) error {
 	savedObjects, err := filepath.Glob(filepath.Join(destinationDir, "kibana", "*", "*"))
 	if err != nil {
 		return err
 	}
-	for _, file := range savedObjects {
+	for _, file := range filterFiles(destinationDir, savedObjects, include) {
 		data, err := os.ReadFile(file)
 		if err != nil {
 			return err
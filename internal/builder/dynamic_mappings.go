@@ -35,7 +35,7 @@ type ecsTemplates struct {
 	} `yaml:"mappings"`
 }
 
-func addDynamicMappings(packageRoot, destinationDir string) error {
+func addDynamicMappings(packageRoot, destinationDir string, include fileFilter) error {
 	packageManifest := filepath.Join(destinationDir, packages.PackageManifestFile)
 
 	m, err := packages.ReadPackageManifest(packageManifest)
@@ -60,7 +60,7 @@ func addDynamicMappings(packageRoot, destinationDir string) error {
 			return err
 		}
 
-		for _, datastream := range dataStreamManifests {
+		for _, datastream := range filterFiles(destinationDir, dataStreamManifests, include) {
 			contents, err := addDynamicMappingElements(datastream)
 			if err != nil {
 				return err
@@ -71,6 +71,9 @@ func addDynamicMappings(packageRoot, destinationDir string) error {
 			}
 		}
 	case "input":
+		if !include(packages.PackageManifestFile) {
+			return nil
+		}
 		contents, err := addDynamicMappingElements(packageManifest)
 		if err != nil {
 			return err
@@ -111,6 +114,38 @@ func shouldImportEcsMappings(specVersion, packageRoot string) (bool, error) {
 	return true, nil
 }
 
+// EcsDynamicTemplates returns the ECS dynamic templates that are added to the index templates
+// of the package when it is built, or nil if the package doesn't import ECS mappings.
+func EcsDynamicTemplates(packageRoot string) ([]map[string]interface{}, error) {
+	m, err := packages.ReadPackageManifestFromPackageRoot(packageRoot)
+	if err != nil {
+		return nil, err
+	}
+
+	shouldImport, err := shouldImportEcsMappings(m.SpecVersion, packageRoot)
+	if err != nil {
+		return nil, err
+	}
+	if !shouldImport {
+		return nil, nil
+	}
+
+	ecsMappings, err := loadEcsMappings()
+	if err != nil {
+		return nil, errors.New("can't load ecs mappings template")
+	}
+
+	templates := make([]map[string]interface{}, 0, len(ecsMappings.Mappings.DynamicTemplates))
+	for _, template := range ecsMappings.Mappings.DynamicTemplates {
+		renamed := make(map[string]interface{}, len(template))
+		for name, definition := range template {
+			renamed[fmt.Sprintf("%s-%s", prefixMapping, name)] = definition
+		}
+		templates = append(templates, renamed)
+	}
+	return templates, nil
+}
+
 func addDynamicMappingElements(path string) ([]byte, error) {
 	ecsMappings, err := loadEcsMappings()
 	if err != nil {
@@ -8,6 +8,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sync"
 
 	"gopkg.in/yaml.v3"
 
@@ -22,7 +23,33 @@ import (
 
 var semver3_0_0 = semver.MustParse("3.0.0")
 
-func resolveExternalFields(packageRoot, destinationDir string) error {
+// dependencyManagers caches the field dependency managers by their dependencies, so external
+// schemas are loaded only once when building multiple packages.
+var dependencyManagers = struct {
+	sync.Mutex
+	managers map[buildmanifest.Dependencies]*fields.DependencyManager
+}{managers: make(map[buildmanifest.Dependencies]*fields.DependencyManager)}
+
+func fieldDependencyManager(deps buildmanifest.Dependencies) (*fields.DependencyManager, error) {
+	dependencyManagers.Lock()
+	defer dependencyManagers.Unlock()
+
+	// Mappings are not relevant for the resolution of fields.
+	deps.ECS.ImportMappings = false
+	if fdm, found := dependencyManagers.managers[deps]; found {
+		logger.Debugf("Reusing field dependency manager")
+		return fdm, nil
+	}
+
+	fdm, err := fields.CreateFieldDependencyManager(deps)
+	if err != nil {
+		return nil, err
+	}
+	dependencyManagers.managers[deps] = fdm
+	return fdm, nil
+}
+
+func resolveExternalFields(packageRoot, destinationDir string, include fileFilter) error {
 	bm, ok, err := buildmanifest.ReadBuildManifest(packageRoot)
 	if err != nil {
 		return fmt.Errorf("can't read build manifest: %w", err)
@@ -37,27 +64,24 @@ func resolveExternalFields(packageRoot, destinationDir string) error {
 	}
 
 	logger.Debugf("Package has external dependencies defined")
-	fdm, err := fields.CreateFieldDependencyManager(bm.Dependencies)
-	if err != nil {
-		return fmt.Errorf("can't create field dependency manager: %w", err)
-	}
-
 	fieldsFiles, err := listAllFieldsFiles(destinationDir)
 	if err != nil {
 		return fmt.Errorf("failed to list fields files under \"%s\": %w", destinationDir, err)
 	}
+	fieldsFiles = filterFiles(destinationDir, fieldsFiles, include)
+	if len(fieldsFiles) == 0 {
+		logger.Debugf("No fields files to resolve")
+		return nil
+	}
 
-	manifest, err := packages.ReadPackageManifestFromPackageRoot(packageRoot)
+	fdm, err := fieldDependencyManager(bm.Dependencies)
 	if err != nil {
-		return fmt.Errorf("failed to read package manifest from \"%s\"", packageRoot)
+		return fmt.Errorf("can't create field dependency manager: %w", err)
 	}
-	sv, err := semver.NewVersion(manifest.SpecVersion)
+
+	options, err := injectFieldsOptions(packageRoot)
 	if err != nil {
-		return fmt.Errorf("failed to obtain spec version from package manifest in \"%s\"", packageRoot)
-	}
-	var options fields.InjectFieldsOptions
-	if !sv.LessThan(semver3_0_0) {
-		options.DisallowReusableECSFieldsAtTopLevel = true
+		return err
 	}
 
 	for _, file := range fieldsFiles {
@@ -85,6 +109,49 @@ func resolveExternalFields(packageRoot, destinationDir string) error {
 	return nil
 }
 
+// ResolveExternalFields resolves the external fields in the given field definitions, as they
+// are resolved when building the package. Definitions are returned unchanged if the package
+// doesn't have external dependencies.
+func ResolveExternalFields(packageRoot string, defs []common.MapStr) ([]common.MapStr, error) {
+	bm, ok, err := buildmanifest.ReadBuildManifest(packageRoot)
+	if err != nil {
+		return nil, fmt.Errorf("can't read build manifest: %w", err)
+	}
+	if !ok || !bm.HasDependencies() {
+		return defs, nil
+	}
+
+	fdm, err := fieldDependencyManager(bm.Dependencies)
+	if err != nil {
+		return nil, fmt.Errorf("can't create field dependency manager: %w", err)
+	}
+	options, err := injectFieldsOptions(packageRoot)
+	if err != nil {
+		return nil, err
+	}
+	defs, _, err = fdm.InjectFieldsWithOptions(defs, options)
+	if err != nil {
+		return nil, fmt.Errorf("can't resolve fields: %w", err)
+	}
+	return defs, nil
+}
+
+func injectFieldsOptions(packageRoot string) (fields.InjectFieldsOptions, error) {
+	var options fields.InjectFieldsOptions
+	manifest, err := packages.ReadPackageManifestFromPackageRoot(packageRoot)
+	if err != nil {
+		return options, fmt.Errorf("failed to read package manifest from \"%s\"", packageRoot)
+	}
+	sv, err := semver.NewVersion(manifest.SpecVersion)
+	if err != nil {
+		return options, fmt.Errorf("failed to obtain spec version from package manifest in \"%s\"", packageRoot)
+	}
+	if !sv.LessThan(semver3_0_0) {
+		options.DisallowReusableECSFieldsAtTopLevel = true
+	}
+	return options, nil
+}
+
 func listAllFieldsFiles(dir string) ([]string, error) {
 	patterns := []string{
 		// Package fields
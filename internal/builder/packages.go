@@ -32,6 +32,13 @@ type BuildOptions struct {
 	CreateZip      bool
 	SignPackage    bool
 	SkipValidation bool
+
+	// FullRebuild disables the reuse of previous builds, so all the build steps are executed
+	// for all the files.
+	FullRebuild bool
+
+	// CreateSBOM creates a CycloneDX SBOM next to the zipped package.
+	CreateSBOM bool
 }
 
 // BuildDirectory function locates the target build directory. If the directory doesn't exist, it will create it.
@@ -161,7 +168,8 @@ func FindBuildPackagesDirectory() (string, bool, error) {
 	return "", false, nil
 }
 
-// BuildPackage function builds the package.
+// BuildPackage function builds the package. The inputs of each build are recorded, so unchanged
+// packages aren't built again, and only changed files are processed in the following builds.
 func BuildPackage(ctx context.Context, options BuildOptions) (string, error) {
 	destinationDir, err := BuildPackagesDirectory(options.PackageRoot, options.BuildDir)
 	if err != nil {
@@ -169,63 +177,145 @@ func BuildPackage(ctx context.Context, options BuildOptions) (string, error) {
 	}
 	logger.Debugf("Build directory: %s\n", destinationDir)
 
+	state, err := newBuildState(options)
+	if err != nil {
+		return "", err
+	}
+
+	statePath := buildStatePath(destinationDir)
+	var previous *buildState
+	if options.FullRebuild {
+		logger.Debug("Full rebuild requested, previous build is ignored")
+	} else {
+		previous, err = readBuildState(statePath)
+		if err != nil {
+			return "", fmt.Errorf("can't read state of previous build: %w", err)
+		}
+	}
+	changes := state.changesSince(previous, destinationDir)
+
+	// The state is written again only if the build succeeds, so interrupted or failed builds
+	// are started from scratch.
+	err = removeBuildState(statePath)
+	if err != nil {
+		return "", fmt.Errorf("can't remove state of previous build: %w", err)
+	}
+
+	switch {
+	case changes.full != "":
+		logger.Debugf("Build the whole package: %s", changes.full)
+		err = copyPackage(options.PackageRoot, destinationDir)
+	case changes.empty():
+		logger.Debugf("Package hasn't changed since the previous build (path: %s)", options.PackageRoot)
+		state.Zipped = previous.Zipped
+		state.Signed = previous.Signed
+		state.Validated = previous.Validated
+	default:
+		logger.Debugf("Build changed files: %d changed, %d removed", len(changes.changed), len(changes.removed))
+		err = copyChangedFiles(options.PackageRoot, destinationDir, changes)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	if !changes.empty() {
+		err = processPackage(options.PackageRoot, destinationDir, changes)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	var target string
+	if options.CreateZip {
+		target, err = buildZippedPackage(ctx, options, destinationDir, state)
+	} else {
+		target, err = validateBuiltPackage(options, destinationDir, state)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	if options.CreateZip && options.CreateSBOM {
+		logger.Debug("Create SBOM of the zipped package")
+		err = writeSBOM(options, target)
+		if err != nil {
+			return "", fmt.Errorf("can't create SBOM: %w", err)
+		}
+	}
+
+	err = writeBuildState(statePath, state)
+	if err != nil {
+		return "", fmt.Errorf("can't write build state: %w", err)
+	}
+	return target, nil
+}
+
+func copyPackage(packageRoot, destinationDir string) error {
 	logger.Debugf("Clear target directory (path: %s)", destinationDir)
-	err = files.ClearDir(destinationDir)
+	err := files.ClearDir(destinationDir)
 	if err != nil {
-		return "", fmt.Errorf("clearing package contents failed: %w", err)
+		return fmt.Errorf("clearing package contents failed: %w", err)
 	}
 
-	logger.Debugf("Copy package content (source: %s)", options.PackageRoot)
-	err = files.CopyWithoutDev(options.PackageRoot, destinationDir)
+	logger.Debugf("Copy package content (source: %s)", packageRoot)
+	err = files.CopyWithoutDev(packageRoot, destinationDir)
 	if err != nil {
-		return "", fmt.Errorf("copying package contents failed: %w", err)
+		return fmt.Errorf("copying package contents failed: %w", err)
 	}
+	return nil
+}
 
+// processPackage executes the build steps on the files copied to the destination directory.
+// Only the changed files are processed, unless the whole package is being built.
+func processPackage(packageRoot, destinationDir string, changes buildChanges) error {
 	logger.Debug("Copy license file if needed")
-	err = copyLicenseTextFile(filepath.Join(destinationDir, licenseTextFileName))
+	err := copyLicenseTextFile(filepath.Join(destinationDir, licenseTextFileName))
 	if err != nil {
-		return "", fmt.Errorf("copying license text file: %w", err)
+		return fmt.Errorf("copying license text file: %w", err)
 	}
 
 	logger.Debug("Encode dashboards")
-	err = encodeDashboards(destinationDir)
+	err = encodeDashboards(destinationDir, changes.includes)
 	if err != nil {
-		return "", fmt.Errorf("encoding dashboards failed: %w", err)
+		return fmt.Errorf("encoding dashboards failed: %w", err)
 	}
 
 	logger.Debug("Resolve external fields")
-	err = resolveExternalFields(options.PackageRoot, destinationDir)
+	err = resolveExternalFields(packageRoot, destinationDir, changes.includes)
 	if err != nil {
-		return "", fmt.Errorf("resolving external fields failed: %w", err)
+		return fmt.Errorf("resolving external fields failed: %w", err)
 	}
 
-	err = addDynamicMappings(options.PackageRoot, destinationDir)
+	err = addDynamicMappings(packageRoot, destinationDir, changes.includes)
 	if err != nil {
-		return "", fmt.Errorf("adding dynamic mappings: %w", err)
+		return fmt.Errorf("adding dynamic mappings: %w", err)
 	}
 
 	logger.Debug("Include linked files")
-	linksFS, err := files.CreateLinksFSFromPath(options.PackageRoot)
+	linksFS, err := files.CreateLinksFSFromPath(packageRoot)
 	if err != nil {
-		return "", fmt.Errorf("creating links filesystem failed: %w", err)
+		return fmt.Errorf("creating links filesystem failed: %w", err)
 	}
 
 	links, err := linksFS.IncludeLinkedFiles(destinationDir)
 	if err != nil {
-		return "", fmt.Errorf("including linked files failed: %w", err)
+		return fmt.Errorf("including linked files failed: %w", err)
 	}
 	for _, l := range links {
 		logger.Debugf("Linked file included (path: %s)", l.TargetFilePath(destinationDir))
 	}
+	return nil
+}
 
-	if options.CreateZip {
-		return buildZippedPackage(ctx, options, destinationDir)
-	}
-
+func validateBuiltPackage(options BuildOptions, destinationDir string, state *buildState) (string, error) {
 	if options.SkipValidation {
 		logger.Debug("Skip validation of the built package")
 		return destinationDir, nil
 	}
+	if state.Validated {
+		logger.Debug("Built package hasn't changed since it was validated")
+		return destinationDir, nil
+	}
 
 	logger.Debugf("Validating built package (path: %s)", destinationDir)
 	errs, skipped := validation.ValidateAndFilterFromPath(destinationDir)
@@ -235,24 +325,34 @@ func BuildPackage(ctx context.Context, options BuildOptions) (string, error) {
 	if errs != nil {
 		return "", fmt.Errorf("invalid content found in built package: %w", errs)
 	}
+	state.Validated = true
 	return destinationDir, nil
 }
 
-func buildZippedPackage(ctx context.Context, options BuildOptions, destinationDir string) (string, error) {
-	logger.Debug("Build zipped package")
+func buildZippedPackage(ctx context.Context, options BuildOptions, destinationDir string, state *buildState) (string, error) {
 	zippedPackagePath, err := buildPackagesZipPath(options.PackageRoot)
 	if err != nil {
 		return "", fmt.Errorf("can't evaluate path for the zipped package: %w", err)
 	}
 
-	err = files.Zip(ctx, destinationDir, zippedPackagePath)
-	if err != nil {
-		return "", fmt.Errorf("can't compress the built package (compressed file path: %s): %w", zippedPackagePath, err)
+	if state.Zipped && fileExists(zippedPackagePath) {
+		logger.Debugf("Zipped package is up to date (path: %s)", zippedPackagePath)
+	} else {
+		logger.Debug("Build zipped package")
+		err = files.Zip(ctx, destinationDir, zippedPackagePath)
+		if err != nil {
+			return "", fmt.Errorf("can't compress the built package (compressed file path: %s): %w", zippedPackagePath, err)
+		}
+		state.Zipped = true
+		state.Signed = false
 	}
 
-	if options.SkipValidation {
+	switch {
+	case options.SkipValidation:
 		logger.Debug("Skip validation of the built .zip package")
-	} else {
+	case state.Validated:
+		logger.Debug("Built .zip package hasn't changed since it was validated")
+	default:
 		logger.Debugf("Validating built .zip package (path: %s)", zippedPackagePath)
 		errs, skipped := validation.ValidateAndFilterFromZip(zippedPackagePath)
 		if skipped != nil {
@@ -261,18 +361,29 @@ func buildZippedPackage(ctx context.Context, options BuildOptions, destinationDi
 		if errs != nil {
 			return "", fmt.Errorf("invalid content found in built zip package: %w", errs)
 		}
+		state.Validated = true
 	}
 
 	if options.SignPackage {
-		err := signZippedPackage(options, zippedPackagePath)
-		if err != nil {
-			return "", err
+		if state.Signed && fileExists(zippedPackagePath+".sig") {
+			logger.Debug("Zipped package is already signed")
+		} else {
+			err := signZippedPackage(options, zippedPackagePath)
+			if err != nil {
+				return "", err
+			}
+			state.Signed = true
 		}
 	}
 
 	return zippedPackagePath, nil
 }
 
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
 func signZippedPackage(options BuildOptions, zippedPackagePath string) error {
 	logger.Debug("Sign the package")
 	m, err := packages.ReadPackageManifestFromPackageRoot(options.PackageRoot)
@@ -0,0 +1,427 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package builder
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gopkg.in/yaml.v3"
+
+	"github.com/elastic/elastic-package/internal/common"
+	"github.com/elastic/elastic-package/internal/files"
+	"github.com/elastic/elastic-package/internal/packages"
+	"github.com/elastic/elastic-package/internal/packages/buildmanifest"
+	"github.com/elastic/elastic-package/internal/version"
+)
+
+const (
+	cycloneDXSpecVersion = "1.5"
+
+	sbomFileSuffix = ".cdx.json"
+
+	sbomPropertyPrefix        = "elastic-package:"
+	sbomLinkFileProperty      = sbomPropertyPrefix + "link-file"
+	sbomImportedFieldProperty = sbomPropertyPrefix + "imported-field"
+	sbomDeployFileProperty    = sbomPropertyPrefix + "deploy-file"
+)
+
+// cycloneDXBOM is a software bill of materials in the CycloneDX JSON format.
+type cycloneDXBOM struct {
+	BOMFormat    string               `json:"bomFormat"`
+	SpecVersion  string               `json:"specVersion"`
+	SerialNumber string               `json:"serialNumber"`
+	Version      int                  `json:"version"`
+	Metadata     cycloneDXMetadata    `json:"metadata"`
+	Components   []cycloneDXComponent `json:"components,omitempty"`
+}
+
+type cycloneDXMetadata struct {
+	Timestamp string             `json:"timestamp"`
+	Tools     cycloneDXTools     `json:"tools"`
+	Component cycloneDXComponent `json:"component"`
+}
+
+type cycloneDXTools struct {
+	Components []cycloneDXComponent `json:"components"`
+}
+
+type cycloneDXComponent struct {
+	Type               string                       `json:"type"`
+	BOMRef             string                       `json:"bom-ref,omitempty"`
+	Group              string                       `json:"group,omitempty"`
+	Name               string                       `json:"name"`
+	Version            string                       `json:"version,omitempty"`
+	Description        string                       `json:"description,omitempty"`
+	Hashes             []cycloneDXHash              `json:"hashes,omitempty"`
+	ExternalReferences []cycloneDXExternalReference `json:"externalReferences,omitempty"`
+	Properties         []cycloneDXProperty          `json:"properties,omitempty"`
+}
+
+type cycloneDXHash struct {
+	Algorithm string `json:"alg"`
+	Content   string `json:"content"`
+}
+
+type cycloneDXExternalReference struct {
+	Type string `json:"type"`
+	URL  string `json:"url"`
+}
+
+type cycloneDXProperty struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+func sha256Hash(content string) []cycloneDXHash {
+	if content == "" {
+		return nil
+	}
+	return []cycloneDXHash{{Algorithm: "SHA-256", Content: content}}
+}
+
+// SBOMPath returns the path of the SBOM of the zipped built package.
+func SBOMPath(zippedPackagePath string) string {
+	return strings.TrimSuffix(zippedPackagePath, ".zip") + sbomFileSuffix
+}
+
+// writeSBOM creates the CycloneDX SBOM of the zipped built package, next to it.
+func writeSBOM(options BuildOptions, zippedPackagePath string) error {
+	bom, err := newSBOM(options.PackageRoot, zippedPackagePath)
+	if err != nil {
+		return err
+	}
+
+	d, err := json.MarshalIndent(bom, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode SBOM: %w", err)
+	}
+	return os.WriteFile(SBOMPath(zippedPackagePath), append(d, '\n'), 0644)
+}
+
+func newSBOM(packageRoot, zippedPackagePath string) (*cycloneDXBOM, error) {
+	manifest, err := packages.ReadPackageManifestFromPackageRoot(packageRoot)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read package manifest: %w", err)
+	}
+
+	zipHash, err := fileSHA256(zippedPackagePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to calculate hash of the zipped package: %w", err)
+	}
+
+	bom := cycloneDXBOM{
+		BOMFormat:    "CycloneDX",
+		SpecVersion:  cycloneDXSpecVersion,
+		SerialNumber: "urn:uuid:" + uuid.NewString(),
+		Version:      1,
+		Metadata: cycloneDXMetadata{
+			Timestamp: time.Now().UTC().Format(time.RFC3339),
+			Tools: cycloneDXTools{
+				Components: []cycloneDXComponent{elasticPackageComponent()},
+			},
+			Component: cycloneDXComponent{
+				Type:        "application",
+				BOMRef:      manifest.Name,
+				Name:        manifest.Name,
+				Version:     manifest.Version,
+				Description: manifest.Description,
+				Hashes:      sha256Hash(zipHash),
+			},
+		},
+	}
+
+	linksFS, err := files.CreateLinksFSFromPath(packageRoot)
+	if err != nil {
+		return nil, fmt.Errorf("creating links filesystem failed: %w", err)
+	}
+	links, err := linksFS.ListLinkedFiles()
+	if err != nil {
+		return nil, fmt.Errorf("listing linked files failed: %w", err)
+	}
+
+	ecsComponent, err := ecsSBOMComponent(packageRoot, links)
+	if err != nil {
+		return nil, err
+	}
+	if ecsComponent != nil {
+		bom.Components = append(bom.Components, *ecsComponent)
+	}
+
+	linkedFiles, err := linkedFilesSBOMComponents(packageRoot, links)
+	if err != nil {
+		return nil, err
+	}
+	bom.Components = append(bom.Components, linkedFiles...)
+
+	images, err := imagesSBOMComponents(packageRoot)
+	if err != nil {
+		return nil, err
+	}
+	bom.Components = append(bom.Components, images...)
+
+	return &bom, nil
+}
+
+func elasticPackageComponent() cycloneDXComponent {
+	component := cycloneDXComponent{
+		Type:    "application",
+		Group:   "elastic",
+		Name:    "elastic-package",
+		Version: version.Tag,
+		ExternalReferences: []cycloneDXExternalReference{
+			{Type: "vcs", URL: "https://github.com/elastic/elastic-package"},
+		},
+	}
+	if component.Version == "" {
+		component.Version = "undefined"
+	}
+	if version.CommitHash != "" && version.CommitHash != "undefined" {
+		component.Properties = append(component.Properties, cycloneDXProperty{Name: sbomPropertyPrefix + "commit", Value: version.CommitHash})
+	}
+	return component
+}
+
+// ecsSBOMComponent describes the ECS schema used to resolve the external fields of the package,
+// with the fields imported from it. It returns nil if the package doesn't depend on ECS.
+func ecsSBOMComponent(packageRoot string, links []files.Link) (*cycloneDXComponent, error) {
+	bm, ok, err := buildmanifest.ReadBuildManifest(packageRoot)
+	if err != nil {
+		return nil, fmt.Errorf("can't read build manifest: %w", err)
+	}
+	if !ok || bm.Dependencies.ECS.Reference == "" {
+		return nil, nil
+	}
+
+	fdm, err := fieldDependencyManager(bm.Dependencies)
+	if err != nil {
+		return nil, fmt.Errorf("can't create field dependency manager: %w", err)
+	}
+	ecsVersion, source := fdm.ECSSchema()
+	component := cycloneDXComponent{
+		Type:    "data",
+		BOMRef:  "ecs",
+		Group:   "elastic",
+		Name:    "ecs",
+		Version: ecsVersion,
+	}
+	if strings.HasPrefix(source, "https://") {
+		component.ExternalReferences = []cycloneDXExternalReference{{Type: "distribution", URL: source}}
+	} else if source != "" {
+		hash, err := fileSHA256(source)
+		if err != nil {
+			return nil, fmt.Errorf("failed to calculate hash of ECS schema: %w", err)
+		}
+		component.Hashes = sha256Hash(hash)
+	}
+
+	imported, err := importedExternalFields(packageRoot, links)
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range imported["ecs"] {
+		component.Properties = append(component.Properties, cycloneDXProperty{Name: sbomImportedFieldProperty, Value: name})
+	}
+	return &component, nil
+}
+
+// importedExternalFields returns the names of the fields imported from external schemas, indexed
+// by schema, including the fields defined in the given linked files.
+func importedExternalFields(packageRoot string, links []files.Link) (map[string][]string, error) {
+	fieldsFiles, err := listAllFieldsFiles(packageRoot)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list fields files: %w", err)
+	}
+	for _, l := range links {
+		if filepath.Base(filepath.Dir(l.LinkFilePath)) != "fields" {
+			continue
+		}
+		fieldsFiles = append(fieldsFiles, filepath.Join(l.WorkDir, filepath.Dir(l.LinkFilePath), l.IncludedFilePath))
+	}
+
+	imported := make(map[string][]string)
+	for _, path := range fieldsFiles {
+		d, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		var defs []common.MapStr
+		err = yaml.Unmarshal(d, &defs)
+		if err != nil {
+			return nil, fmt.Errorf("can't unmarshal fields file %s: %w", path, err)
+		}
+		collectExternalFields(imported, "", defs)
+	}
+	for schema, names := range imported {
+		slices.Sort(names)
+		imported[schema] = slices.Compact(names)
+	}
+	return imported, nil
+}
+
+func collectExternalFields(imported map[string][]string, parent string, defs []common.MapStr) {
+	for _, def := range defs {
+		name, _ := def["name"].(string)
+		if parent != "" {
+			name = parent + "." + name
+		}
+		if external, ok := def["external"].(string); ok && external != "" {
+			imported[external] = append(imported[external], name)
+		}
+
+		children, _ := def["fields"].([]interface{})
+		var childDefs []common.MapStr
+		for _, child := range children {
+			if m, ok := child.(map[string]interface{}); ok {
+				childDefs = append(childDefs, m)
+			}
+		}
+		collectExternalFields(imported, name, childDefs)
+	}
+}
+
+// linkedFilesSBOMComponents describes the files included in the package through links. Files linked
+// from multiple locations are described by a single component, with a property for each link.
+func linkedFilesSBOMComponents(packageRoot string, links []files.Link) ([]cycloneDXComponent, error) {
+	if len(links) == 0 {
+		return nil, nil
+	}
+	repositoryRoot, err := files.FindRepositoryRootDirectory()
+	if err != nil {
+		return nil, fmt.Errorf("locating repository root failed: %w", err)
+	}
+
+	var components []cycloneDXComponent
+	indexes := make(map[string]int)
+	for _, l := range links {
+		linkPath := filepath.Join(l.WorkDir, l.LinkFilePath)
+		source, err := filepath.Rel(repositoryRoot, filepath.Join(filepath.Dir(linkPath), l.IncludedFilePath))
+		if err != nil {
+			return nil, err
+		}
+		source = filepath.ToSlash(source)
+		link, err := filepath.Rel(packageRoot, linkPath)
+		if err != nil {
+			return nil, err
+		}
+		property := cycloneDXProperty{Name: sbomLinkFileProperty, Value: filepath.ToSlash(link)}
+
+		if i, found := indexes[source]; found {
+			components[i].Properties = append(components[i].Properties, property)
+			continue
+		}
+		indexes[source] = len(components)
+		components = append(components, cycloneDXComponent{
+			Type:       "file",
+			BOMRef:     "file:" + source,
+			Name:       source,
+			Hashes:     sha256Hash(l.IncludedFileContentsChecksum),
+			Properties: []cycloneDXProperty{property},
+		})
+	}
+	return components, nil
+}
+
+// imagesSBOMComponents describes the container images used by the docker compose service
+// deployers of the package and of its data streams.
+func imagesSBOMComponents(packageRoot string) ([]cycloneDXComponent, error) {
+	var composeFiles []string
+	for _, pattern := range []string{
+		filepath.Join(packageRoot, "_dev", "deploy", "docker", "*.yml"),
+		filepath.Join(packageRoot, "data_stream", "*", "_dev", "deploy", "docker", "*.yml"),
+	} {
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, err
+		}
+		composeFiles = append(composeFiles, matches...)
+	}
+
+	usedIn := make(map[string][]string)
+	for _, composeFile := range composeFiles {
+		d, err := os.ReadFile(composeFile)
+		if err != nil {
+			return nil, err
+		}
+		var compose struct {
+			Services map[string]struct {
+				Image string `yaml:"image"`
+			} `yaml:"services"`
+		}
+		err = yaml.Unmarshal(d, &compose)
+		if err != nil {
+			return nil, fmt.Errorf("can't unmarshal docker compose file %s: %w", composeFile, err)
+		}
+		rel, err := filepath.Rel(packageRoot, composeFile)
+		if err != nil {
+			return nil, err
+		}
+		for _, service := range compose.Services {
+			if service.Image == "" || slices.Contains(usedIn[service.Image], filepath.ToSlash(rel)) {
+				continue
+			}
+			usedIn[service.Image] = append(usedIn[service.Image], filepath.ToSlash(rel))
+		}
+	}
+
+	images := make([]string, 0, len(usedIn))
+	for image := range usedIn {
+		images = append(images, image)
+	}
+	sort.Strings(images)
+
+	var components []cycloneDXComponent
+	for _, image := range images {
+		name, tag := splitImageReference(image)
+		component := cycloneDXComponent{
+			Type:    "container",
+			BOMRef:  "image:" + image,
+			Name:    name,
+			Version: tag,
+		}
+		for _, file := range usedIn[image] {
+			component.Properties = append(component.Properties, cycloneDXProperty{Name: sbomDeployFileProperty, Value: file})
+		}
+		components = append(components, component)
+	}
+	return components, nil
+}
+
+// splitImageReference splits an image reference in its name and its tag or digest.
+func splitImageReference(image string) (string, string) {
+	if name, digest, found := strings.Cut(image, "@"); found {
+		return name, digest
+	}
+	lastSlash := strings.LastIndex(image, "/")
+	if name, tag, found := strings.Cut(image[lastSlash+1:], ":"); found {
+		return image[:lastSlash+1] + name, tag
+	}
+	return image, ""
+}
+
+func fileSHA256(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	_, err = io.Copy(h, f)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
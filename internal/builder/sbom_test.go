@@ -0,0 +1,168 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package builder
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/elastic-package/internal/files"
+)
+
+func TestSBOMPath(t *testing.T) {
+	zipPath := filepath.Join("build", "packages", "apache-1.2.3.zip")
+	assert.Equal(t, filepath.Join("build", "packages", "apache-1.2.3.cdx.json"), SBOMPath(zipPath))
+}
+
+func TestSplitImageReference(t *testing.T) {
+	cases := []struct {
+		image   string
+		name    string
+		version string
+	}{
+		{image: "nginx", name: "nginx"},
+		{image: "nginx:1.25", name: "nginx", version: "1.25"},
+		{image: "docker.elastic.co/observability/apm-server:8.12.0", name: "docker.elastic.co/observability/apm-server", version: "8.12.0"},
+		{image: "localhost:5000/foo", name: "localhost:5000/foo"},
+		{image: "prom/prometheus:${PROMETHEUS_VERSION:-v2}", name: "prom/prometheus", version: "${PROMETHEUS_VERSION:-v2}"},
+		{image: "redis@sha256:abcd", name: "redis", version: "sha256:abcd"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.image, func(t *testing.T) {
+			name, version := splitImageReference(c.image)
+			assert.Equal(t, c.name, name)
+			assert.Equal(t, c.version, version)
+		})
+	}
+}
+
+func writeTestFile(t *testing.T, path, content string) {
+	t.Helper()
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+}
+
+func TestImportedExternalFields(t *testing.T) {
+	packageRoot := t.TempDir()
+	writeTestFile(t, filepath.Join(packageRoot, "fields", "ecs.yml"), `- name: ecs.version
+  external: ecs
+- name: host
+  type: group
+  fields:
+    - name: name
+      external: ecs
+    - name: custom
+      type: keyword
+`)
+	writeTestFile(t, filepath.Join(packageRoot, "data_stream", "logs", "fields", "ecs.yml"), `- external: ecs
+  name: host.name
+- external: ecs
+  name: message
+`)
+
+	imported, err := importedExternalFields(packageRoot, nil)
+	require.NoError(t, err)
+	assert.Equal(t, map[string][]string{
+		"ecs": {"ecs.version", "host.name", "message"},
+	}, imported)
+}
+
+func TestImagesSBOMComponents(t *testing.T) {
+	packageRoot := t.TempDir()
+	writeTestFile(t, filepath.Join(packageRoot, "_dev", "deploy", "docker", "docker-compose.yml"), `version: '2.3'
+services:
+  redis:
+    image: redis:7.2
+  custom:
+    build: .
+`)
+	writeTestFile(t, filepath.Join(packageRoot, "data_stream", "logs", "_dev", "deploy", "docker", "docker-compose.yml"), `version: '2.3'
+services:
+  redis:
+    image: redis:7.2
+  nginx:
+    image: nginx
+`)
+
+	components, err := imagesSBOMComponents(packageRoot)
+	require.NoError(t, err)
+	assert.Equal(t, []cycloneDXComponent{
+		{
+			Type:   "container",
+			BOMRef: "image:nginx",
+			Name:   "nginx",
+			Properties: []cycloneDXProperty{
+				{Name: sbomDeployFileProperty, Value: "data_stream/logs/_dev/deploy/docker/docker-compose.yml"},
+			},
+		},
+		{
+			Type:    "container",
+			BOMRef:  "image:redis:7.2",
+			Name:    "redis",
+			Version: "7.2",
+			Properties: []cycloneDXProperty{
+				{Name: sbomDeployFileProperty, Value: "_dev/deploy/docker/docker-compose.yml"},
+				{Name: sbomDeployFileProperty, Value: "data_stream/logs/_dev/deploy/docker/docker-compose.yml"},
+			},
+		},
+	}, components)
+}
+
+func TestLinkedFilesSBOMComponents(t *testing.T) {
+	repositoryRoot := t.TempDir()
+	require.NoError(t, os.Mkdir(filepath.Join(repositoryRoot, ".git"), 0o755))
+	t.Chdir(repositoryRoot)
+
+	packageRoot := filepath.Join(repositoryRoot, "packages", "foo")
+	links := []files.Link{
+		{
+			WorkDir:                      filepath.Join(packageRoot, "data_stream", "logs", "agent", "stream"),
+			LinkFilePath:                 "stream.yml.hbs.link",
+			IncludedFilePath:             "../../../../../../shared/stream.yml.hbs",
+			IncludedFileContentsChecksum: "abc",
+		},
+		{
+			WorkDir:                      filepath.Join(packageRoot, "data_stream", "metrics", "agent", "stream"),
+			LinkFilePath:                 "stream.yml.hbs.link",
+			IncludedFilePath:             "../../../../../../shared/stream.yml.hbs",
+			IncludedFileContentsChecksum: "abc",
+		},
+		{
+			WorkDir:                      filepath.Join(packageRoot, "data_stream", "logs", "fields"),
+			LinkFilePath:                 "ecs.yml.link",
+			IncludedFilePath:             "../../../../../shared/ecs.yml",
+			IncludedFileContentsChecksum: "def",
+		},
+	}
+
+	components, err := linkedFilesSBOMComponents(packageRoot, links)
+	require.NoError(t, err)
+	assert.Equal(t, []cycloneDXComponent{
+		{
+			Type:   "file",
+			BOMRef: "file:shared/stream.yml.hbs",
+			Name:   "shared/stream.yml.hbs",
+			Hashes: []cycloneDXHash{{Algorithm: "SHA-256", Content: "abc"}},
+			Properties: []cycloneDXProperty{
+				{Name: sbomLinkFileProperty, Value: "data_stream/logs/agent/stream/stream.yml.hbs.link"},
+				{Name: sbomLinkFileProperty, Value: "data_stream/metrics/agent/stream/stream.yml.hbs.link"},
+			},
+		},
+		{
+			Type:   "file",
+			BOMRef: "file:shared/ecs.yml",
+			Name:   "shared/ecs.yml",
+			Hashes: []cycloneDXHash{{Algorithm: "SHA-256", Content: "def"}},
+			Properties: []cycloneDXProperty{
+				{Name: sbomLinkFileProperty, Value: "data_stream/logs/fields/ecs.yml.link"},
+			},
+		},
+	}, components)
+}
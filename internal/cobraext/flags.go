@@ -31,6 +31,9 @@ const (
 	AgentPolicyFlagName    = "agent-policy"
 	AgentPolicyDescription = "name of the agent policy"
 
+	AllPackagesFlagName        = "all"
+	AllPackagesFlagDescription = "run the command on all the packages of the repository"
+
 	AllowSnapshotFlagName    = "allow-snapshot"
 	AllowSnapshotDescription = "allow to export dashboards from a Elastic stack SNAPSHOT version"
 
@@ -88,12 +91,21 @@ const (
 	BenchStreamTimestampFieldFlagName        = "timestamp-field"
 	BenchStreamTimestampFieldFlagDescription = "name of the field that's used in the generator config as `@timestamp`"
 
+	BuildFullRebuildFlagName        = "full-rebuild"
+	BuildFullRebuildFlagDescription = "build the whole package, ignoring the results of previous builds"
+
+	BuildSBOMFlagName        = "sbom"
+	BuildSBOMFlagDescription = "create a CycloneDX SBOM of the package next to the zipped package"
+
 	BuildSkipValidationFlagName        = "skip-validation"
 	BuildSkipValidationFlagDescription = "skip validation of the built package, use only if all validation issues have been acknowledged"
 
 	BuildZipFlagName        = "zip"
 	BuildZipFlagDescription = "archive the built package"
 
+	ChangedSinceFlagName        = "changed-since"
+	ChangedSinceFlagDescription = "run only the tests of the packages and data streams affected by the changes since the given git reference"
+
 	ChangelogAddNextFlagName        = "next"
 	ChangelogAddNextFlagDescription = "changelog entry is added in the next `major`, `minor` or `patch` version"
 
@@ -109,9 +121,25 @@ const (
 	ChangelogAddLinkFlagName        = "link"
 	ChangelogAddLinkFlagDescription = "link to the pull request or issue with more information about the changelog entry"
 
+	ChangelogSuggestFromFlagName        = "from"
+	ChangelogSuggestFromFlagDescription = "released version to compare with, as a git reference, a package directory or archive, or a version published in the registry (defaults to the latest published version, or to the latest git tag of the package, named <name>-<version> or <name>/v<version>, if the package is not published)"
+
+	ChangelogSuggestLinkFlagDescription = "link to the pull request or issue used in the suggested changelog entries (required with --yes)"
+
+	ChangelogSuggestYesFlagName        = "yes"
+	ChangelogSuggestYesFlagDescription = "apply the suggested changelog entries without asking for confirmation"
+
 	CheckConditionFlagName        = "check-condition"
 	CheckConditionFlagDescription = "check if the condition is met for the package, but don't install the package (e.g. kibana.version=7.10.0)"
 
+	CheckECSFormatFlagName        = "format"
+	CheckECSFormatFlagDescription = "output format (\"%s\")"
+
+	CreateFieldsDataStreamFlagDescription = "data stream to create the fields for (defaults to the data stream in the working directory)"
+
+	CreateFieldsFromFlagName        = "from"
+	CreateFieldsFromFlagDescription = "sample documents as a ndjson or json file, or a directory with ndjson files or pipeline test results"
+
 	DaemonModeFlagName        = "daemon"
 	DaemonModeFlagDescription = "daemon mode"
 
@@ -127,6 +155,12 @@ const (
 	DeferCleanupFlagName        = "defer-cleanup"
 	DeferCleanupFlagDescription = "defer test cleanup for debugging purposes"
 
+	DiffFormatFlagName        = "format"
+	DiffFormatFlagDescription = "output format (\"%s\")"
+
+	DocsPreviewAddressFlagName        = "address"
+	DocsPreviewAddressFlagDescription = "address where the documentation preview server listens"
+
 	DumpOutputFlagName        = "output"
 	DumpOutputFlagDescription = "path to directory where exported assets will be stored"
 
@@ -135,12 +169,29 @@ const (
 
 	FailFastFlagName                  = "fail-fast"
 	FailFastFlagDescription           = "fail immediately if any file requires updates (do not overwrite)"
+	FormatCheckFlagName               = "check"
+	FormatCheckFlagDescription        = "list files that require updates and fail if there is any (do not overwrite)"
+	FormatDiffFlagName                = "diff"
+	FormatDiffFlagDescription         = "print the changes required to format the files (implies --check)"
+	FormatExtendedFlagName            = "extended"
+	FormatExtendedFlagDescription     = "also format ingest pipelines in YAML format, Handlebars templates and Markdown documents"
 	GenerateTestResultFlagName        = "generate"
 	GenerateTestResultFlagDescription = "generate test result file"
 
+	LintOnlineFlagName        = "online"
+	LintOnlineFlagDescription = "request external URLs referenced in the documentation, instead of only checking their syntax"
+
+	LintStrictDocsFlagName        = "strict-docs"
+	LintStrictDocsFlagDescription = "report anchors that don't match any heading and screenshots with wrong sizes as errors, instead of warnings"
+
 	PackagesFlagName        = "packages"
 	PackagesFlagDescription = "whether to return packages names or complete paths for the linked files found"
 
+	PackagesGlobFlagDescription = "run the command on the packages of the repository whose name or path match the given glob patterns (comma-separated values)"
+
+	ParallelismFlagName        = "parallel"
+	ParallelismFlagDescription = "maximum number of packages processed at the same time when running on multiple packages"
+
 	IngestPipelineIDsFlagName        = "id"
 	IngestPipelineIDsFlagDescription = "Elasticsearch ingest pipeline IDs (comma-separated values)"
 
@@ -187,9 +238,21 @@ const (
 	StackVersionFlagName        = "version"
 	StackVersionFlagDescription = "stack version"
 
+	StackDumpBundleFlagName        = "bundle"
+	StackDumpBundleFlagDescription = "also collect diagnostics of Elasticsearch, Kibana and Fleet, with secrets redacted, and archive everything in a zip file"
+
 	StackDumpOutputFlagName        = "output"
 	StackDumpOutputFlagDescription = "output location for the stack dump"
 
+	StackImagesInputFlagName        = "input"
+	StackImagesInputFlagDescription = "path to the images bundle to import"
+
+	StackImagesOutputFlagName        = "output"
+	StackImagesOutputFlagDescription = "path of the images bundle to create (defaults to elastic-package-images-<version>.tar.gz)"
+
+	StackUpgradeToFlagName        = "to"
+	StackUpgradeToFlagDescription = "stack version to upgrade to"
+
 	StackUserParameterFlagName      = "parameter"
 	StackUserParameterFlagShorthand = "U"
 	StackUserParameterDescription   = "optional parameter for the stack provider, as key=value"
@@ -209,9 +272,18 @@ const (
 	TestCoverageFormatFlagName        = "coverage-format"
 	TestCoverageFormatFlagDescription = "set format for coverage reports: %s"
 
+	UpgradeFromVersionFlagName        = "from-version"
+	UpgradeFromVersionFlagDescription = "version of the package to upgrade from, defaults to the latest previous version in the registry"
+
+	UpgradeFromZipFlagName        = "from-zip"
+	UpgradeFromZipFlagDescription = "path to the zip package file (*.zip) of the version to upgrade from"
+
 	VariantFlagName        = "variant"
 	VariantFlagDescription = "service variant"
 
+	VerifyPublicKeyFlagName        = "public-key"
+	VerifyPublicKeyFlagDescription = "path to the public key, or keyring, used to verify signatures"
+
 	ConfigFileFlagName        = "config-file"
 	ConfigFileFlagDescription = "configuration file to setup service and test"
 
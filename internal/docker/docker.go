@@ -190,3 +190,35 @@ func Copy(containerName, containerPath, localPath string) error {
 	}
 	return nil
 }
+
+// Save function exports the selected images, with all their layers, to a tar archive.
+func Save(output string, images ...string) error {
+	args := []string{"save", "--output", output}
+	args = append(args, images...)
+	cmd := exec.Command("docker", args...)
+	errOutput := new(bytes.Buffer)
+	cmd.Stderr = errOutput
+
+	logger.Tracef("run command: %s", cmd)
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("could not save images (stderr=%q): %w", errOutput.String(), err)
+	}
+	return nil
+}
+
+// Load function imports the images contained in a tar archive created with Save.
+func Load(input string) error {
+	cmd := exec.Command("docker", "load", "--input", input)
+	errOutput := new(bytes.Buffer)
+	cmd.Stderr = errOutput
+
+	if logger.IsDebugMode() {
+		cmd.Stdout = os.Stdout
+	}
+
+	logger.Tracef("run command: %s", cmd)
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("could not load images (stderr=%q): %w", errOutput.String(), err)
+	}
+	return nil
+}
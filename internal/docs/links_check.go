@@ -0,0 +1,433 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package docs
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/jpeg" // Register JPEG decoder to check sizes of screenshots.
+	_ "image/png"  // Register PNG decoder to check sizes of screenshots.
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/text"
+	"gopkg.in/yaml.v3"
+
+	"github.com/elastic/elastic-package/internal/files"
+	"github.com/elastic/elastic-package/internal/packages"
+)
+
+var (
+	urlKeyPattern        = regexp.MustCompile(`{{-?\s*url\s+"([^"]+)"`)
+	htmlReferencePattern = regexp.MustCompile(`(?i)\b(?:href|src)\s*=\s*"([^"]*)"`)
+)
+
+// Severity is the severity of a problem found in the documentation of a package.
+type Severity string
+
+const (
+	// SeverityError is the severity of issues that make the lint fail.
+	SeverityError Severity = "error"
+
+	// SeverityWarning is the severity of issues that are only reported, unless strict checks
+	// are requested.
+	SeverityWarning Severity = "warning"
+)
+
+// LinkIssue is a problem found in a link or image reference of the documentation of a package.
+type LinkIssue struct {
+	// File is the path of the file with the reference, relative to the package root.
+	File string
+
+	// Target is the referenced link, image, or key of the links map.
+	Target string
+
+	Severity Severity
+	Message  string
+}
+
+func (i LinkIssue) String() string {
+	return fmt.Sprintf("%s: %s: %s (%s)", i.File, i.Severity, i.Message, i.Target)
+}
+
+// CheckLinksOptions contains the options to check the links of the documentation of a package.
+type CheckLinksOptions struct {
+	// Online enables requesting external URLs. Otherwise their syntax is checked only.
+	Online bool
+
+	// HTTPClient is the client used to request external URLs.
+	HTTPClient *http.Client
+
+	// Strict reports as errors the anchors that don't match any heading and the screenshots
+	// with sizes different to the declared ones. Otherwise they are reported as warnings.
+	Strict bool
+}
+
+type parsedDocument struct {
+	path       string
+	references []string
+	anchors    map[string]bool
+}
+
+// CheckLinks checks the links and image references of the rendered documents of a package, the keys
+// of the links used in their templates, and the screenshots declared in the package manifest.
+func CheckLinks(packageRoot string, options CheckLinksOptions) ([]LinkIssue, error) {
+	if options.HTTPClient == nil {
+		options.HTTPClient = &http.Client{Timeout: 10 * time.Second}
+	}
+
+	issues, err := checkURLKeys(packageRoot)
+	if err != nil {
+		return nil, err
+	}
+
+	documentPaths, err := filepath.Glob(filepath.Join(docsPath(packageRoot), "*.md"))
+	if err != nil {
+		return nil, fmt.Errorf("reading directory entries failed: %w", err)
+	}
+	var documentsOrder []*parsedDocument
+	documents := make(map[string]*parsedDocument)
+	for _, documentPath := range documentPaths {
+		content, err := os.ReadFile(documentPath)
+		if err != nil {
+			return nil, fmt.Errorf("reading document failed (path: %s): %w", documentPath, err)
+		}
+		document := parseDocument(content)
+		document.path = path.Join("docs", filepath.Base(documentPath))
+		documents[document.path] = document
+		documentsOrder = append(documentsOrder, document)
+	}
+
+	checker := linksChecker{
+		packageRoot:  packageRoot,
+		options:      options,
+		documents:    documents,
+		onlineStatus: make(map[string]string),
+	}
+	for _, document := range documentsOrder {
+		for _, reference := range document.references {
+			if message, warning := checker.check(document, reference); message != "" {
+				issues = append(issues, LinkIssue{File: document.path, Target: reference, Severity: options.severity(warning), Message: message})
+			}
+		}
+	}
+
+	screenshotIssues, err := checkScreenshots(packageRoot, options)
+	if err != nil {
+		return nil, err
+	}
+	return append(issues, screenshotIssues...), nil
+}
+
+// checkURLKeys checks that the keys of the links used in the templates of the documents exist
+// in the links map.
+func checkURLKeys(packageRoot string) ([]LinkIssue, error) {
+	linksMap, err := readLinksMap()
+	if err != nil {
+		return nil, err
+	}
+	templatePaths, err := filepath.Glob(filepath.Join(packageRoot, "_dev", "build", "docs", "*.md"))
+	if err != nil {
+		return nil, fmt.Errorf("reading directory entries failed: %w", err)
+	}
+
+	var issues []LinkIssue
+	for _, templatePath := range templatePaths {
+		template, err := os.ReadFile(templatePath)
+		if err != nil {
+			return nil, fmt.Errorf("reading template failed (path: %s): %w", templatePath, err)
+		}
+		reported := make(map[string]bool)
+		for _, match := range urlKeyPattern.FindAllSubmatch(template, -1) {
+			key := string(match[1])
+			if _, err := linksMap.Get(key); err == nil || reported[key] {
+				continue
+			}
+			reported[key] = true
+			issues = append(issues, LinkIssue{
+				File:     path.Join("_dev", "build", "docs", filepath.Base(templatePath)),
+				Target:   key,
+				Severity: SeverityError,
+				Message:  "key not found in the links map",
+			})
+		}
+	}
+	return issues, nil
+}
+
+// parseDocument collects the references to links and images, and the anchors of the headings of
+// a Markdown document.
+func parseDocument(content []byte) *parsedDocument {
+	document := parsedDocument{anchors: make(map[string]bool)}
+	slugs := make(map[string]int)
+	root := markdownRenderer.Parser().Parse(text.NewReader(content))
+	ast.Walk(root, func(node ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+		switch n := node.(type) {
+		case *ast.Heading:
+			slug := headingSlug(nodeText(n, content))
+			if count := slugs[slug]; count > 0 {
+				document.anchors[fmt.Sprintf("%s-%d", slug, count)] = true
+			} else {
+				document.anchors[slug] = true
+			}
+			slugs[slug]++
+		case *ast.Link:
+			document.references = append(document.references, string(n.Destination))
+		case *ast.Image:
+			document.references = append(document.references, string(n.Destination))
+		case *ast.RawHTML:
+			var raw bytes.Buffer
+			for i := 0; i < n.Segments.Len(); i++ {
+				segment := n.Segments.At(i)
+				raw.Write(segment.Value(content))
+			}
+			document.references = append(document.references, htmlReferences(raw.Bytes())...)
+		case *ast.HTMLBlock:
+			var raw bytes.Buffer
+			lines := n.Lines()
+			for i := 0; i < lines.Len(); i++ {
+				segment := lines.At(i)
+				raw.Write(segment.Value(content))
+			}
+			document.references = append(document.references, htmlReferences(raw.Bytes())...)
+		}
+		return ast.WalkContinue, nil
+	})
+	return &document
+}
+
+func htmlReferences(raw []byte) []string {
+	var references []string
+	for _, match := range htmlReferencePattern.FindAllSubmatch(raw, -1) {
+		references = append(references, string(match[1]))
+	}
+	return references
+}
+
+func nodeText(node ast.Node, source []byte) string {
+	var builder strings.Builder
+	for child := node.FirstChild(); child != nil; child = child.NextSibling() {
+		switch c := child.(type) {
+		case *ast.Text:
+			builder.Write(c.Segment.Value(source))
+		case *ast.String:
+			builder.Write(c.Value)
+		default:
+			builder.WriteString(nodeText(c, source))
+		}
+	}
+	return builder.String()
+}
+
+// headingSlug returns the anchor of a heading, as generated by GitHub and the Integrations UI.
+func headingSlug(heading string) string {
+	var builder strings.Builder
+	for _, r := range strings.ToLower(strings.TrimSpace(heading)) {
+		switch {
+		case unicode.IsLetter(r), unicode.IsDigit(r), r == '-', r == '_':
+			builder.WriteRune(r)
+		case r == ' ':
+			builder.WriteRune('-')
+		}
+	}
+	return builder.String()
+}
+
+type linksChecker struct {
+	packageRoot  string
+	options      CheckLinksOptions
+	documents    map[string]*parsedDocument
+	onlineStatus map[string]string
+}
+
+// severity returns the severity of an issue, warnings are reported as errors in strict checks.
+func (o CheckLinksOptions) severity(warning bool) Severity {
+	if warning && !o.Strict {
+		return SeverityWarning
+	}
+	return SeverityError
+}
+
+// check checks a reference in a document, and returns a message describing the problem if
+// there is any, and whether it is a warning.
+func (c *linksChecker) check(document *parsedDocument, reference string) (string, bool) {
+	if reference == "" {
+		return "empty link", false
+	}
+	if anchor, found := strings.CutPrefix(reference, "#"); found {
+		if !document.anchors[anchor] {
+			return "anchor doesn't match any heading", true
+		}
+		return "", false
+	}
+
+	u, err := url.Parse(reference)
+	if err != nil {
+		return fmt.Sprintf("invalid URL: %v", err), false
+	}
+	switch u.Scheme {
+	case "":
+		return c.checkFile(document, u)
+	case "http", "https":
+		if u.Host == "" {
+			return "URL without host", false
+		}
+		if c.options.Online {
+			return c.checkOnline(reference), false
+		}
+		return "", false
+	default:
+		return "", false
+	}
+}
+
+func (c *linksChecker) checkFile(document *parsedDocument, u *url.URL) (string, bool) {
+	target := u.Path
+	if target == "" {
+		return "", false
+	}
+	if strings.HasPrefix(target, "/") {
+		target = path.Clean(strings.TrimPrefix(target, "/"))
+	} else {
+		target = path.Join(path.Dir(document.path), target)
+	}
+	switch {
+	case target == ".." || strings.HasPrefix(target, "../"):
+		return "file outside of the package", false
+	case target == "_dev" || strings.HasPrefix(target, "_dev/"):
+		return "file not included in the built package", false
+	}
+
+	_, err := os.Stat(filepath.Join(c.packageRoot, filepath.FromSlash(target)))
+	if errors.Is(err, os.ErrNotExist) {
+		// Files can be provided by link files, that are replaced by the linked files in the
+		// built package.
+		_, err = readLinkedFile(c.packageRoot, target)
+	}
+	if errors.Is(err, os.ErrNotExist) {
+		return "file not found", false
+	}
+	if err != nil {
+		return fmt.Sprintf("can't read file: %v", err), false
+	}
+
+	if u.Fragment != "" {
+		if linked, found := c.documents[target]; found && !linked.anchors[u.Fragment] {
+			return "anchor doesn't match any heading", true
+		}
+	}
+	return "", false
+}
+
+func (c *linksChecker) checkOnline(reference string) string {
+	if message, found := c.onlineStatus[reference]; found {
+		return message
+	}
+	message := requestURL(c.options.HTTPClient, reference)
+	c.onlineStatus[reference] = message
+	return message
+}
+
+func requestURL(client *http.Client, reference string) string {
+	resp, err := client.Head(reference)
+	if err == nil && resp.StatusCode == http.StatusMethodNotAllowed {
+		resp.Body.Close()
+		resp, err = client.Get(reference)
+	}
+	if err != nil {
+		return fmt.Sprintf("request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= http.StatusBadRequest {
+		return fmt.Sprintf("unexpected status code %d", resp.StatusCode)
+	}
+	return ""
+}
+
+// checkScreenshots checks that the screenshots declared in the package manifest exist, and have
+// the declared sizes and types.
+func checkScreenshots(packageRoot string, options CheckLinksOptions) ([]LinkIssue, error) {
+	d, err := os.ReadFile(filepath.Join(packageRoot, packages.PackageManifestFile))
+	if err != nil {
+		return nil, fmt.Errorf("reading package manifest failed: %w", err)
+	}
+	var manifest previewManifest
+	err = yaml.Unmarshal(d, &manifest)
+	if err != nil {
+		return nil, fmt.Errorf("unmarshalling package manifest failed: %w", err)
+	}
+
+	var issues []LinkIssue
+	for _, screenshot := range manifest.Screenshots {
+		if message, warning := checkScreenshot(packageRoot, screenshot); message != "" {
+			issues = append(issues, LinkIssue{File: packages.PackageManifestFile, Target: screenshot.Src, Severity: options.severity(warning), Message: message})
+		}
+	}
+	return issues, nil
+}
+
+// checkScreenshot returns a message describing the problem with the screenshot if there is any,
+// and whether it is a warning.
+func checkScreenshot(packageRoot string, screenshot packageImage) (string, bool) {
+	target := strings.TrimPrefix(screenshot.Src, "/")
+	content, err := os.ReadFile(filepath.Join(packageRoot, filepath.FromSlash(target)))
+	if errors.Is(err, os.ErrNotExist) {
+		content, err = readLinkedFile(packageRoot, target)
+	}
+	if errors.Is(err, os.ErrNotExist) {
+		return "screenshot not found", false
+	}
+	if err != nil {
+		return fmt.Sprintf("can't read screenshot: %v", err), false
+	}
+
+	contentType := http.DetectContentType(content)
+	if path.Ext(screenshot.Src) == ".svg" {
+		contentType = "image/svg+xml"
+	}
+	if screenshot.Type != "" && screenshot.Type != contentType {
+		return fmt.Sprintf("screenshot type is %s, declared %s", contentType, screenshot.Type), false
+	}
+
+	if screenshot.Size == "" || contentType == "image/svg+xml" {
+		return "", false
+	}
+	config, _, err := image.DecodeConfig(bytes.NewReader(content))
+	if err != nil {
+		return fmt.Sprintf("can't decode screenshot: %v", err), false
+	}
+	if size := fmt.Sprintf("%dx%d", config.Width, config.Height); size != screenshot.Size {
+		return fmt.Sprintf("screenshot size is %s, declared %s", size, screenshot.Size), true
+	}
+	return "", false
+}
+
+// readLinkedFile reads a file of the package that is provided by a link file, as it is included
+// in the built package. It returns an error satisfying os.ErrNotExist if there is no link file.
+func readLinkedFile(packageRoot, target string) ([]byte, error) {
+	linkPath := filepath.FromSlash(target) + ".link"
+	_, err := os.Stat(filepath.Join(packageRoot, linkPath))
+	if err != nil {
+		return nil, err
+	}
+	linksFS, err := files.CreateLinksFSFromPath(packageRoot)
+	if err != nil {
+		return nil, fmt.Errorf("creating links filesystem failed: %w", err)
+	}
+	return linksFS.ReadFile(linkPath)
+}
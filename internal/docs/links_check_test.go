@@ -0,0 +1,180 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package docs
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"image"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckLinks(t *testing.T) {
+	var screenshot bytes.Buffer
+	require.NoError(t, png.Encode(&screenshot, image.NewRGBA(image.Rect(0, 0, 4, 2))))
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/ok" {
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	packageRoot := t.TempDir()
+	files := map[string]string{
+		"manifest.yml": `name: vendor
+screenshots:
+  - src: /img/overview.png
+    title: Overview
+    size: 4x2
+    type: image/png
+  - src: /img/other.png
+    title: Other
+    size: 600x600
+    type: image/png
+  - src: /img/missing.png
+    title: Missing
+    size: 600x600
+    type: image/png
+`,
+		"img/overview.png": screenshot.String(),
+		"img/other.png":    screenshot.String(),
+		"_dev/build/docs/README.md": `# Vendor
+{{ url "missing-key" }} {{ url "missing-key" "again" }}`,
+		"docs/README.md": `# Vendor
+
+## Set up the integration
+
+- [Setup](#set-up-the-integration)
+- [Broken anchor](#missing-section)
+- [Other document](OTHER.md#details)
+- [Broken anchor in other document](OTHER.md#missing)
+- [Missing document](MISSING.md)
+- [Development files](../_dev/build/docs/README.md)
+- [Outside of the package](../../other/manifest.yml)
+- [Online](` + server.URL + `/ok)
+- [Online broken](` + server.URL + `/broken)
+- [Invalid URL](https://)
+
+![Overview](../img/overview.png)
+<img src="../img/missing.png">
+`,
+		"docs/OTHER.md": `# Other
+
+## Details
+`,
+	}
+	for path, content := range files {
+		path = filepath.Join(packageRoot, filepath.FromSlash(path))
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	}
+	t.Setenv(linksMapFilePathEnvVar, filepath.Join(packageRoot, "links_table.yml"))
+	require.NoError(t, os.WriteFile(filepath.Join(packageRoot, "links_table.yml"), []byte("links: {}"), 0644))
+
+	expected := []LinkIssue{
+		{File: "_dev/build/docs/README.md", Target: "missing-key", Severity: SeverityError, Message: "key not found in the links map"},
+		{File: "docs/README.md", Target: "#missing-section", Severity: SeverityWarning, Message: "anchor doesn't match any heading"},
+		{File: "docs/README.md", Target: "OTHER.md#missing", Severity: SeverityWarning, Message: "anchor doesn't match any heading"},
+		{File: "docs/README.md", Target: "MISSING.md", Severity: SeverityError, Message: "file not found"},
+		{File: "docs/README.md", Target: "../_dev/build/docs/README.md", Severity: SeverityError, Message: "file not included in the built package"},
+		{File: "docs/README.md", Target: "../../other/manifest.yml", Severity: SeverityError, Message: "file outside of the package"},
+		{File: "docs/README.md", Target: "https://", Severity: SeverityError, Message: "URL without host"},
+		{File: "docs/README.md", Target: "../img/missing.png", Severity: SeverityError, Message: "file not found"},
+		{File: "manifest.yml", Target: "/img/other.png", Severity: SeverityWarning, Message: "screenshot size is 4x2, declared 600x600"},
+		{File: "manifest.yml", Target: "/img/missing.png", Severity: SeverityError, Message: "screenshot not found"},
+	}
+
+	t.Run("offline", func(t *testing.T) {
+		issues, err := CheckLinks(packageRoot, CheckLinksOptions{})
+		require.NoError(t, err)
+		assert.Equal(t, expected, issues)
+	})
+
+	t.Run("strict", func(t *testing.T) {
+		issues, err := CheckLinks(packageRoot, CheckLinksOptions{Strict: true})
+		require.NoError(t, err)
+		require.Len(t, issues, len(expected))
+		for _, issue := range issues {
+			assert.Equal(t, SeverityError, issue.Severity, issue.String())
+		}
+	})
+
+	t.Run("online", func(t *testing.T) {
+		issues, err := CheckLinks(packageRoot, CheckLinksOptions{Online: true})
+		require.NoError(t, err)
+		assert.Contains(t, issues, LinkIssue{File: "docs/README.md", Target: server.URL + "/broken", Severity: SeverityError, Message: "unexpected status code 404"})
+		assert.Len(t, issues, len(expected)+1)
+	})
+}
+
+func TestCheckLinksLinkedFiles(t *testing.T) {
+	repositoryRoot := t.TempDir()
+	require.NoError(t, os.Mkdir(filepath.Join(repositoryRoot, ".git"), 0755))
+	t.Chdir(repositoryRoot)
+
+	var screenshot bytes.Buffer
+	require.NoError(t, png.Encode(&screenshot, image.NewRGBA(image.Rect(0, 0, 4, 2))))
+	checksum := sha256.Sum256([]byte("# Shared\n"))
+	screenshotChecksum := sha256.Sum256(screenshot.Bytes())
+
+	packageRoot := filepath.Join(repositoryRoot, "packages", "vendor")
+	files := map[string]string{
+		"shared/SHARED.md":                      "# Shared\n",
+		"shared/overview.png":                   screenshot.String(),
+		"packages/vendor/docs/SHARED.md.link":   "../../../shared/SHARED.md " + hex.EncodeToString(checksum[:]),
+		"packages/vendor/docs/OUTDATED.md.link": "../../../shared/SHARED.md 0000",
+		"packages/vendor/docs/BROKEN.md.link":   "../../../shared/MISSING.md",
+		"packages/vendor/img/overview.png.link": "../../../shared/overview.png " + hex.EncodeToString(screenshotChecksum[:]),
+		"packages/vendor/manifest.yml": `name: vendor
+screenshots:
+  - src: /img/overview.png
+    title: Overview
+    size: 4x2
+    type: image/png
+`,
+		"packages/vendor/docs/README.md": `# Vendor
+
+- [Shared](SHARED.md)
+- [Outdated](OUTDATED.md)
+- [Broken](BROKEN.md)
+`,
+	}
+	for path, content := range files {
+		path = filepath.Join(repositoryRoot, filepath.FromSlash(path))
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	}
+	t.Setenv(linksMapFilePathEnvVar, filepath.Join(repositoryRoot, "links_table.yml"))
+	require.NoError(t, os.WriteFile(filepath.Join(repositoryRoot, "links_table.yml"), []byte("links: {}"), 0644))
+
+	issues, err := CheckLinks(packageRoot, CheckLinksOptions{})
+	require.NoError(t, err)
+	require.Len(t, issues, 2)
+	assert.Equal(t, "OUTDATED.md", issues[0].Target)
+	assert.Contains(t, issues[0].Message, "is not up to date")
+	assert.Equal(t, LinkIssue{File: "docs/README.md", Target: "BROKEN.md", Severity: SeverityError, Message: "file not found"}, issues[1])
+}
+
+func TestHeadingSlug(t *testing.T) {
+	cases := map[string]string{
+		"Set up the integration":            "set-up-the-integration",
+		"Prometheus Exporters (Collectors)": "prometheus-exporters-collectors",
+		"Logs: `access` data stream":        "logs-access-data-stream",
+		"snake_case and kebab-case":         "snake_case-and-kebab-case",
+	}
+	for heading, expected := range cases {
+		assert.Equal(t, expected, headingSlug(heading), heading)
+	}
+}
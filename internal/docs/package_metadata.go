@@ -0,0 +1,284 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package docs
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/elastic/elastic-package/internal/packages"
+	"github.com/elastic/elastic-package/internal/packages/changelog"
+)
+
+var cellEscaper = strings.NewReplacer("|", "\\|", "\r\n", " ", "\n", " ")
+
+type varsTableRecord struct {
+	scope    string
+	variable packages.Variable
+}
+
+func renderPolicyTemplates(packageRoot string) (string, error) {
+	manifest, err := packages.ReadPackageManifestFromPackageRoot(packageRoot)
+	if err != nil {
+		return "", fmt.Errorf("reading package manifest failed: %w", err)
+	}
+
+	var builder strings.Builder
+	builder.WriteString("**Policy templates**\n\n")
+	if len(manifest.PolicyTemplates) == 0 {
+		builder.WriteString("(no policy templates available)\n")
+		return builder.String(), nil
+	}
+
+	builder.WriteString("| Policy template | Title | Description | Inputs | Data streams |\n")
+	builder.WriteString("|---|---|---|---|---|\n")
+	for _, pt := range manifest.PolicyTemplates {
+		var inputs []string
+		if pt.Input != "" {
+			inputs = append(inputs, pt.Input)
+		}
+		for _, input := range pt.Inputs {
+			inputs = append(inputs, input.Type)
+		}
+
+		dataStreams := strings.Join(pt.DataStreams, ", ")
+		if dataStreams == "" && manifest.Type == "integration" {
+			dataStreams = "all"
+		}
+		fmt.Fprintf(&builder, "| %s | %s | %s | %s | %s |\n",
+			pt.Name, cellEscaper.Replace(pt.Title), cellEscaper.Replace(pt.Description), strings.Join(inputs, ", "), dataStreams)
+	}
+	return builder.String(), nil
+}
+
+// renderVars renders the configuration variables of the package, its policy templates and their
+// inputs, or the variables of the streams of a data stream if one is given.
+func renderVars(packageRoot, dataStream string) (string, error) {
+	var collected []varsTableRecord
+	if dataStream != "" {
+		manifest, err := packages.ReadDataStreamManifestFromPackageRoot(packageRoot, dataStream)
+		if err != nil {
+			return "", fmt.Errorf("reading data stream manifest failed: %w", err)
+		}
+		for _, stream := range manifest.Streams {
+			for _, v := range stream.Vars {
+				collected = append(collected, varsTableRecord{scope: stream.Input, variable: v})
+			}
+		}
+	} else {
+		manifest, err := packages.ReadPackageManifestFromPackageRoot(packageRoot)
+		if err != nil {
+			return "", fmt.Errorf("reading package manifest failed: %w", err)
+		}
+		for _, v := range manifest.Vars {
+			collected = append(collected, varsTableRecord{scope: "package", variable: v})
+		}
+		for _, pt := range manifest.PolicyTemplates {
+			for _, v := range pt.Vars {
+				collected = append(collected, varsTableRecord{scope: pt.Name, variable: v})
+			}
+			for _, input := range pt.Inputs {
+				for _, v := range input.Vars {
+					collected = append(collected, varsTableRecord{scope: pt.Name + "/" + input.Type, variable: v})
+				}
+			}
+		}
+	}
+
+	var builder strings.Builder
+	builder.WriteString("**Configuration variables**\n\n")
+	if len(collected) == 0 {
+		builder.WriteString("(no variables available)\n")
+		return builder.String(), nil
+	}
+
+	builder.WriteString("| Scope | Variable | Title | Type | Default | Required | Secret |\n")
+	builder.WriteString("|---|---|---|---|---|---|---|\n")
+	for _, c := range collected {
+		defaultValue, err := renderVarDefault(c.variable.Default)
+		if err != nil {
+			return "", fmt.Errorf("rendering default value of variable %s failed: %w", c.variable.Name, err)
+		}
+		varType := c.variable.Type
+		if c.variable.Multi {
+			varType += " (multi)"
+		}
+		fmt.Fprintf(&builder, "| %s | %s | %s | %s | %s | %s | %s |\n",
+			c.scope, c.variable.Name, cellEscaper.Replace(c.variable.Title), varType, defaultValue,
+			yesNo(c.variable.Required), yesNo(c.variable.Secret))
+	}
+	return builder.String(), nil
+}
+
+func renderVarDefault(value packages.VarValue) (string, error) {
+	d, err := json.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+	rendered := string(d)
+	if rendered == "null" {
+		return "", nil
+	}
+	var s string
+	if json.Unmarshal(d, &s) == nil {
+		rendered = s
+	}
+	if rendered == "" {
+		return "", nil
+	}
+	return "`" + cellEscaper.Replace(rendered) + "`", nil
+}
+
+func yesNo(value bool) string {
+	if value {
+		return "yes"
+	}
+	return "no"
+}
+
+func renderCompatibility(packageRoot string) (string, error) {
+	manifest, err := packages.ReadPackageManifestFromPackageRoot(packageRoot)
+	if err != nil {
+		return "", fmt.Errorf("reading package manifest failed: %w", err)
+	}
+
+	conditions := manifest.Conditions
+	kibanaVersion := conditions.Kibana.Version
+	if kibanaVersion == "" {
+		kibanaVersion = "any"
+	}
+	subscription := conditions.Elastic.Subscription
+	if subscription == "" {
+		subscription = "basic"
+	}
+	capabilities := strings.Join(conditions.Elastic.Capabilities, ", ")
+	if capabilities == "" {
+		capabilities = "all"
+	}
+
+	var builder strings.Builder
+	builder.WriteString("**Compatibility**\n\n")
+	builder.WriteString("| Requirement | Value |\n")
+	builder.WriteString("|---|---|\n")
+	fmt.Fprintf(&builder, "| Kibana version | %s |\n", cellEscaper.Replace(kibanaVersion))
+	fmt.Fprintf(&builder, "| Elastic subscription | %s |\n", subscription)
+	fmt.Fprintf(&builder, "| Serverless project capabilities | %s |\n", capabilities)
+	return builder.String(), nil
+}
+
+// renderChangelog renders the changes of the latest n versions of the package, or of all of them
+// if n is not positive.
+func renderChangelog(packageRoot string, n int) (string, error) {
+	revisions, err := changelog.ReadChangelogFromPackageRoot(packageRoot)
+	if err != nil {
+		return "", fmt.Errorf("reading changelog failed: %w", err)
+	}
+	if n > 0 && len(revisions) > n {
+		revisions = revisions[:n]
+	}
+
+	var builder strings.Builder
+	builder.WriteString("**Changelog**\n\n")
+	if len(revisions) == 0 {
+		builder.WriteString("(no changes available)\n")
+		return builder.String(), nil
+	}
+
+	builder.WriteString("| Version | Type | Description | Link |\n")
+	builder.WriteString("|---|---|---|---|\n")
+	for _, revision := range revisions {
+		for _, change := range revision.Changes {
+			link := ""
+			if change.Link != "" {
+				link = fmt.Sprintf("[link](%s)", change.Link)
+			}
+			fmt.Fprintf(&builder, "| %s | %s | %s | %s |\n",
+				revision.Version, change.Type, cellEscaper.Replace(strings.TrimSpace(change.Description)), link)
+		}
+	}
+	return builder.String(), nil
+}
+
+func renderDashboards(packageRoot string) (string, error) {
+	dashboardFiles, err := filepath.Glob(filepath.Join(packageRoot, "kibana", "dashboard", "*.json"))
+	if err != nil {
+		return "", fmt.Errorf("listing dashboards failed: %w", err)
+	}
+
+	var titles []string
+	for _, path := range dashboardFiles {
+		d, err := os.ReadFile(path)
+		if err != nil {
+			return "", fmt.Errorf("reading dashboard failed (path: %s): %w", path, err)
+		}
+		var dashboard struct {
+			Attributes struct {
+				Title string `json:"title"`
+			} `json:"attributes"`
+		}
+		err = json.Unmarshal(d, &dashboard)
+		if err != nil {
+			return "", fmt.Errorf("unmarshalling dashboard failed (path: %s): %w", path, err)
+		}
+		title := dashboard.Attributes.Title
+		if title == "" {
+			title = strings.TrimSuffix(filepath.Base(path), ".json")
+		}
+		titles = append(titles, title)
+	}
+	sort.Strings(titles)
+
+	var builder strings.Builder
+	builder.WriteString("**Dashboards**\n\n")
+	if len(titles) == 0 {
+		builder.WriteString("(no dashboards available)\n")
+		return builder.String(), nil
+	}
+	for _, title := range titles {
+		fmt.Fprintf(&builder, "- %s\n", escaper.Replace(title))
+	}
+	return builder.String(), nil
+}
+
+func renderDataStreams(packageRoot string) (string, error) {
+	manifestPaths, err := filepath.Glob(filepath.Join(packageRoot, "data_stream", "*", packages.DataStreamManifestFile))
+	if err != nil {
+		return "", fmt.Errorf("listing data streams failed: %w", err)
+	}
+
+	var builder strings.Builder
+	builder.WriteString("**Data streams**\n\n")
+	if len(manifestPaths) == 0 {
+		builder.WriteString("(no data streams available)\n")
+		return builder.String(), nil
+	}
+
+	builder.WriteString("| Data stream | Type | Title | Inputs | Release |\n")
+	builder.WriteString("|---|---|---|---|---|\n")
+	for _, path := range manifestPaths {
+		manifest, err := packages.ReadDataStreamManifest(path)
+		if err != nil {
+			return "", fmt.Errorf("reading data stream manifest failed (path: %s): %w", path, err)
+		}
+		var inputs []string
+		for _, stream := range manifest.Streams {
+			if !slices.Contains(inputs, stream.Input) {
+				inputs = append(inputs, stream.Input)
+			}
+		}
+		release := manifest.Release
+		if release == "" {
+			release = "ga"
+		}
+		fmt.Fprintf(&builder, "| %s | %s | %s | %s | %s |\n",
+			filepath.Base(filepath.Dir(path)), manifest.Type, cellEscaper.Replace(manifest.Title), strings.Join(inputs, ", "), release)
+	}
+	return builder.String(), nil
+}
@@ -0,0 +1,190 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package docs
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testPackageManifest = `format_version: 3.0.0
+name: vendor
+title: Vendor
+version: 1.1.0
+type: integration
+conditions:
+  kibana:
+    version: "^8.13.0 || ^9.0.0"
+  elastic:
+    subscription: basic
+    capabilities:
+      - observability
+      - security
+vars:
+  - name: api_key
+    type: password
+    title: API Key
+    required: true
+    secret: true
+policy_templates:
+  - name: vendor
+    title: Vendor logs
+    description: Collect logs from Vendor
+    data_streams:
+      - log
+    inputs:
+      - type: logfile
+        title: Collect logs
+        vars:
+          - name: paths
+            type: text
+            title: Paths
+            multi: true
+            default:
+              - /var/log/vendor.log
+`
+
+const testDataStreamManifest = `title: Vendor logs
+type: logs
+release: beta
+streams:
+  - input: logfile
+    title: Logs
+    vars:
+      - name: tags
+        type: text
+        title: Tags
+        multi: true
+      - name: preserve_original_event
+        type: bool
+        title: Preserve original event
+        required: true
+        default: false
+`
+
+const testChangelog = `- version: "1.1.0"
+  changes:
+    - description: Add support for secrets.
+      type: enhancement
+      link: https://github.com/elastic/integrations/pull/2
+- version: "1.0.0"
+  changes:
+    - description: Initial release.
+      type: enhancement
+      link: https://github.com/elastic/integrations/pull/1
+`
+
+const testDashboard = `{"id": "vendor-overview", "type": "dashboard", "attributes": {"title": "[Logs Vendor] Overview"}}`
+
+func createPackageWithMetadata(t *testing.T) string {
+	t.Helper()
+	packageRoot := t.TempDir()
+	files := map[string]string{
+		"manifest.yml":                          testPackageManifest,
+		"changelog.yml":                         testChangelog,
+		"data_stream/log/manifest.yml":          testDataStreamManifest,
+		"kibana/dashboard/vendor-overview.json": testDashboard,
+	}
+	for path, content := range files {
+		path = filepath.Join(packageRoot, filepath.FromSlash(path))
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	}
+	return packageRoot
+}
+
+func TestRenderReadmeWithPackageMetadata(t *testing.T) {
+	cases := []struct {
+		title    string
+		template string
+		expected string
+	}{
+		{
+			title:    "policy templates",
+			template: `{{ policyTemplates }}`,
+			expected: `**Policy templates**
+
+| Policy template | Title | Description | Inputs | Data streams |
+|---|---|---|---|---|
+| vendor | Vendor logs | Collect logs from Vendor | logfile | log |
+`,
+		},
+		{
+			title:    "package vars",
+			template: `{{ vars }}`,
+			expected: "**Configuration variables**\n\n" +
+				"| Scope | Variable | Title | Type | Default | Required | Secret |\n" +
+				"|---|---|---|---|---|---|---|\n" +
+				"| package | api_key | API Key | password |  | yes | yes |\n" +
+				"| vendor/logfile | paths | Paths | text (multi) | `[\"/var/log/vendor.log\"]` | no | no |\n",
+		},
+		{
+			title:    "data stream vars",
+			template: `{{ vars "log" }}`,
+			expected: "**Configuration variables**\n\n" +
+				"| Scope | Variable | Title | Type | Default | Required | Secret |\n" +
+				"|---|---|---|---|---|---|---|\n" +
+				"| logfile | tags | Tags | text (multi) |  | no | no |\n" +
+				"| logfile | preserve_original_event | Preserve original event | bool | `false` | yes | no |\n",
+		},
+		{
+			title:    "compatibility",
+			template: `{{ compatibility }}`,
+			expected: `**Compatibility**
+
+| Requirement | Value |
+|---|---|
+| Kibana version | ^8.13.0 \|\| ^9.0.0 |
+| Elastic subscription | basic |
+| Serverless project capabilities | observability, security |
+`,
+		},
+		{
+			title:    "latest changelog entries",
+			template: `{{ changelog 1 }}`,
+			expected: `**Changelog**
+
+| Version | Type | Description | Link |
+|---|---|---|---|
+| 1.1.0 | enhancement | Add support for secrets. | [link](https://github.com/elastic/integrations/pull/2) |
+`,
+		},
+		{
+			title:    "dashboards",
+			template: `{{ dashboards }}`,
+			expected: `**Dashboards**
+
+- [Logs Vendor] Overview
+`,
+		},
+		{
+			title:    "data streams",
+			template: `{{ dataStreams }}`,
+			expected: `**Data streams**
+
+| Data stream | Type | Title | Inputs | Release |
+|---|---|---|---|---|
+| log | logs | Vendor logs | logfile | beta |
+`,
+		},
+	}
+
+	linksMap := newLinkMap()
+	for _, c := range cases {
+		t.Run(c.title, func(t *testing.T) {
+			packageRoot := createPackageWithMetadata(t)
+			require.NoError(t, createReadmeFile(packageRoot, c.template))
+
+			templatePath := filepath.Join(packageRoot, "_dev", "build", "docs", "README.md")
+			rendered, err := renderReadme("README.md", packageRoot, templatePath, linksMap)
+			require.NoError(t, err)
+			assert.Equal(t, c.expected, string(rendered))
+		})
+	}
+}
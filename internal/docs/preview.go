@@ -0,0 +1,383 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package docs
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"html/template"
+	"io/fs"
+	"net"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/extension"
+	"github.com/yuin/goldmark/renderer/html"
+	"gopkg.in/yaml.v3"
+
+	"github.com/elastic/elastic-package/internal/logger"
+	"github.com/elastic/elastic-package/internal/packages"
+)
+
+const (
+	previewReadmeFile     = "README.md"
+	previewGenerationPath = "/_preview/generation"
+)
+
+// previewAssetPattern matches the files of the package that are served by the preview server.
+var previewAssetPattern = regexp.MustCompile(`^(img/.+|data_stream/[^/]+/sample_event\.json)$`)
+
+var markdownRenderer = goldmark.New(
+	goldmark.WithExtensions(extension.GFM),
+	// Generated documents include HTML, such as the collapsible sections of the inputs documentation.
+	goldmark.WithRendererOptions(html.WithUnsafe()),
+)
+
+var previewPageTemplate = template.Must(template.New("preview").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{ .Manifest.Title }} - {{ .FileName }}</title>
+<style>
+body { font-family: Inter, -apple-system, BlinkMacSystemFont, Helvetica, Arial, sans-serif; margin: 0; color: #343741; }
+header { display: flex; align-items: center; gap: 16px; padding: 24px 32px; background: #f7f8fc; border-bottom: 1px solid #d3dae6; }
+header img { width: 48px; height: 48px; }
+header h1 { margin: 0; font-size: 28px; }
+header p { margin: 4px 0 0; color: #69707d; }
+.layout { display: flex; gap: 32px; padding: 24px 32px; }
+nav { flex: 0 0 220px; font-size: 14px; }
+nav h4 { margin: 16px 0 8px; text-transform: uppercase; font-size: 12px; color: #69707d; }
+nav ul { list-style: none; padding: 0; margin: 0; }
+nav li { margin: 4px 0; }
+nav a { color: #0061a6; text-decoration: none; }
+nav a.current { font-weight: bold; }
+main { flex: 1; min-width: 0; max-width: 960px; line-height: 1.5; }
+main table { border-collapse: collapse; display: block; overflow-x: auto; font-size: 14px; }
+main th, main td { border: 1px solid #d3dae6; padding: 4px 8px; text-align: left; vertical-align: top; }
+main th { background: #f7f8fc; }
+main pre { background: #f7f8fc; padding: 12px; overflow-x: auto; }
+main code { font-size: 13px; }
+.screenshots { display: flex; flex-wrap: wrap; gap: 16px; margin-bottom: 24px; }
+.screenshots figure { margin: 0; width: 280px; }
+.screenshots img { width: 100%; border: 1px solid #d3dae6; }
+.screenshots figcaption { font-size: 12px; color: #69707d; }
+.error { color: #bd271e; white-space: pre-wrap; }
+</style>
+</head>
+<body>
+<header>
+{{- range .Manifest.Icons }}<img src="{{ .Src }}" alt="{{ .Title }}">{{ end }}
+<div>
+<h1>{{ .Manifest.Title }} <small>v{{ .Manifest.Version }}</small></h1>
+<p>{{ .Manifest.Description }}</p>
+</div>
+</header>
+<div class="layout">
+<nav>
+<h4>Documents</h4>
+<ul>
+{{- range .Files }}
+<li><a href="/docs/{{ . }}"{{ if eq . $.FileName }} class="current"{{ end }}>{{ . }}</a></li>
+{{- end }}
+</ul>
+{{- if .SampleEvents }}
+<h4>Sample events</h4>
+<ul>
+{{- range .SampleEvents }}
+<li><a href="/data_stream/{{ . }}/sample_event.json">{{ . }}</a></li>
+{{- end }}
+</ul>
+{{- end }}
+</nav>
+<main>
+{{- if .Manifest.Screenshots }}
+<div class="screenshots">
+{{- range .Manifest.Screenshots }}
+<figure><a href="{{ .Src }}"><img src="{{ .Src }}" alt="{{ .Title }}"></a><figcaption>{{ .Title }}</figcaption></figure>
+{{- end }}
+</div>
+{{- end }}
+{{- if .Error }}
+<pre class="error">{{ .Error }}</pre>
+{{- else }}
+{{ .Content }}
+{{- end }}
+</main>
+</div>
+<script>
+(function() {
+  let generation = {{ .Generation }};
+  setInterval(function() {
+    fetch("{{ .GenerationPath }}").then(function(response) { return response.text(); }).then(function(current) {
+      if (parseInt(current, 10) !== generation) { window.location.reload(); }
+    }).catch(function() {});
+  }, 1000);
+})();
+</script>
+</body>
+</html>
+`))
+
+type previewManifest struct {
+	Title       string         `yaml:"title"`
+	Version     string         `yaml:"version"`
+	Description string         `yaml:"description"`
+	Icons       []packageImage `yaml:"icons"`
+	Screenshots []packageImage `yaml:"screenshots"`
+}
+
+type packageImage struct {
+	Src   string `yaml:"src"`
+	Title string `yaml:"title"`
+	Size  string `yaml:"size"`
+	Type  string `yaml:"type"`
+}
+
+type previewPage struct {
+	Manifest       previewManifest
+	FileName       string
+	Files          []string
+	SampleEvents   []string
+	Content        template.HTML
+	Error          string
+	Generation     int64
+	GenerationPath string
+}
+
+// PreviewServer serves the documentation of a package rendered to HTML, together with the icons,
+// screenshots and sample events of the package. Pages are rendered on every request, and reload
+// themselves when the sources of the package change.
+type PreviewServer struct {
+	packageRoot string
+	generation  atomic.Int64
+}
+
+// NewPreviewServer creates a documentation preview server for the given package.
+func NewPreviewServer(packageRoot string) *PreviewServer {
+	return &PreviewServer{packageRoot: packageRoot}
+}
+
+// Serve serves the documentation preview in the given listener until the context is cancelled.
+// Changes in the package are watched meanwhile.
+func (s *PreviewServer) Serve(ctx context.Context, listener net.Listener) error {
+	watcher, err := s.watch()
+	if err != nil {
+		return fmt.Errorf("watching package files failed: %w", err)
+	}
+	defer watcher.Close()
+
+	server := &http.Server{
+		Handler:           s.Handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	go func() {
+		<-ctx.Done()
+		server.Close()
+	}()
+
+	err = server.Serve(listener)
+	if err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// Handler returns the HTTP handler of the preview server.
+func (s *PreviewServer) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", s.handleRoot)
+	mux.HandleFunc("/docs/", s.handleDocument)
+	mux.HandleFunc(previewGenerationPath, s.handleGeneration)
+	return mux
+}
+
+func (s *PreviewServer) handleRoot(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path == "/" {
+		http.Redirect(w, r, "/docs/"+previewReadmeFile, http.StatusFound)
+		return
+	}
+
+	name := strings.TrimPrefix(path.Clean(r.URL.Path), "/")
+	if !previewAssetPattern.MatchString(name) {
+		http.NotFound(w, r)
+		return
+	}
+	http.ServeFile(w, r, filepath.Join(s.packageRoot, filepath.FromSlash(name)))
+}
+
+func (s *PreviewServer) handleDocument(w http.ResponseWriter, r *http.Request) {
+	fileName := path.Base(r.URL.Path)
+	files, err := documentFiles(s.packageRoot)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if !slices.Contains(files, fileName) {
+		http.NotFound(w, r)
+		return
+	}
+
+	page := previewPage{
+		FileName:       fileName,
+		Files:          files,
+		Generation:     s.generation.Load(),
+		GenerationPath: previewGenerationPath,
+	}
+	status := http.StatusOK
+	err = s.renderPage(&page)
+	if err != nil {
+		page.Error = err.Error()
+		status = http.StatusInternalServerError
+	}
+
+	var buf bytes.Buffer
+	err = previewPageTemplate.Execute(&buf, page)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("rendering preview page failed: %v", err), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(status)
+	w.Write(buf.Bytes())
+}
+
+func (s *PreviewServer) handleGeneration(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Write([]byte(strconv.FormatInt(s.generation.Load(), 10)))
+}
+
+func (s *PreviewServer) renderPage(page *previewPage) error {
+	d, err := os.ReadFile(filepath.Join(s.packageRoot, packages.PackageManifestFile))
+	if err != nil {
+		return fmt.Errorf("reading package manifest failed: %w", err)
+	}
+	err = yaml.Unmarshal(d, &page.Manifest)
+	if err != nil {
+		return fmt.Errorf("unmarshalling package manifest failed: %w", err)
+	}
+
+	sampleEvents, err := filepath.Glob(filepath.Join(s.packageRoot, "data_stream", "*", sampleEventFile))
+	if err != nil {
+		return fmt.Errorf("listing sample events failed: %w", err)
+	}
+	for _, sampleEvent := range sampleEvents {
+		page.SampleEvents = append(page.SampleEvents, filepath.Base(filepath.Dir(sampleEvent)))
+	}
+
+	markdown, err := s.renderMarkdown(page.FileName)
+	if err != nil {
+		return err
+	}
+	var content bytes.Buffer
+	err = markdownRenderer.Convert(markdown, &content)
+	if err != nil {
+		return fmt.Errorf("converting %s to HTML failed: %w", page.FileName, err)
+	}
+	page.Content = template.HTML(content.String())
+	return nil
+}
+
+// renderMarkdown renders a document from its template, or reads it from the docs directory of
+// the package if it is static.
+func (s *PreviewServer) renderMarkdown(fileName string) ([]byte, error) {
+	rendered, shouldBeRendered, err := generateReadme(fileName, s.packageRoot)
+	if err != nil {
+		return nil, err
+	}
+	if shouldBeRendered {
+		return rendered, nil
+	}
+
+	existing, found, err := readReadme(fileName, s.packageRoot)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, fmt.Errorf("%s not found", fileName)
+	}
+	return existing, nil
+}
+
+// documentFiles returns the names of the documents of the package, from their templates or from
+// the docs directory.
+func documentFiles(packageRoot string) ([]string, error) {
+	var files []string
+	for _, dir := range []string{filepath.Join(packageRoot, "_dev", "build", "docs"), docsPath(packageRoot)} {
+		paths, err := filepath.Glob(filepath.Join(dir, "*.md"))
+		if err != nil {
+			return nil, fmt.Errorf("reading directory entries failed: %w", err)
+		}
+		for _, p := range paths {
+			if name := filepath.Base(p); !slices.Contains(files, name) {
+				files = append(files, name)
+			}
+		}
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// watch watches the directories of the package, so pages are reloaded when any of its files changes.
+func (s *PreviewServer) watch() (*fsnotify.Watcher, error) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+
+	err = filepath.WalkDir(s.packageRoot, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		if path != s.packageRoot && strings.HasPrefix(d.Name(), ".") {
+			return filepath.SkipDir
+		}
+		return watcher.Add(path)
+	})
+	if err != nil {
+		watcher.Close()
+		return nil, err
+	}
+
+	go func() {
+		for {
+			select {
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				logger.Debugf("Package file changed: %s", event.Name)
+				if event.Has(fsnotify.Create) {
+					if info, err := os.Stat(event.Name); err == nil && info.IsDir() {
+						watcher.Add(event.Name)
+					}
+				}
+				s.generation.Add(1)
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				logger.Warnf("Watching package files failed: %v", err)
+			}
+		}
+	}()
+	return watcher, nil
+}
@@ -0,0 +1,80 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package docs
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPreviewServer(t *testing.T) {
+	packageRoot := createPackageWithMetadata(t)
+	require.NoError(t, createReadmeFile(packageRoot, "# Vendor\n\n{{ dataStreams }}"))
+	require.NoError(t, createSampleEventFile(packageRoot, "log", `{"message": "test"}`))
+	require.NoError(t, os.MkdirAll(filepath.Join(packageRoot, "img"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(packageRoot, "img", "logo.svg"), []byte("<svg></svg>"), 0644))
+	require.NoError(t, os.MkdirAll(filepath.Join(packageRoot, "docs"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(packageRoot, "docs", "OTHER.md"), []byte("Static *document*"), 0644))
+
+	server := httptest.NewServer(NewPreviewServer(packageRoot).Handler())
+	defer server.Close()
+
+	get := func(t *testing.T, path string) (int, string) {
+		t.Helper()
+		resp, err := http.Get(server.URL + path)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		return resp.StatusCode, string(body)
+	}
+
+	t.Run("rendered document", func(t *testing.T) {
+		status, body := get(t, "/")
+		assert.Equal(t, http.StatusOK, status)
+		assert.Contains(t, body, "<h1>Vendor <small>v1.1.0</small></h1>")
+		assert.Contains(t, body, "<h1>Vendor</h1>")
+		assert.Contains(t, body, "<td>Vendor logs</td>")
+		assert.Contains(t, body, `<a href="/docs/OTHER.md">OTHER.md</a>`)
+		assert.Contains(t, body, `<a href="/data_stream/log/sample_event.json">log</a>`)
+	})
+
+	t.Run("static document", func(t *testing.T) {
+		status, body := get(t, "/docs/OTHER.md")
+		assert.Equal(t, http.StatusOK, status)
+		assert.Contains(t, body, "Static <em>document</em>")
+	})
+
+	t.Run("package assets", func(t *testing.T) {
+		status, body := get(t, "/img/logo.svg")
+		assert.Equal(t, http.StatusOK, status)
+		assert.Equal(t, "<svg></svg>", body)
+
+		status, body = get(t, "/data_stream/log/sample_event.json")
+		assert.Equal(t, http.StatusOK, status)
+		assert.Equal(t, `{"message": "test"}`, body)
+	})
+
+	t.Run("other files", func(t *testing.T) {
+		status, _ := get(t, "/manifest.yml")
+		assert.Equal(t, http.StatusNotFound, status)
+
+		status, _ = get(t, "/docs/MISSING.md")
+		assert.Equal(t, http.StatusNotFound, status)
+	})
+
+	t.Run("generation", func(t *testing.T) {
+		status, body := get(t, previewGenerationPath)
+		assert.Equal(t, http.StatusOK, status)
+		assert.Equal(t, "0", body)
+	})
+}
@@ -16,7 +16,6 @@ import (
 
 	"github.com/elastic/elastic-package/internal/builder"
 	"github.com/elastic/elastic-package/internal/logger"
-	"github.com/elastic/elastic-package/internal/packages"
 )
 
 // ReadmeFile contains file name and status of each readme file.
@@ -32,13 +31,8 @@ const (
 <!-- This file is automatically generated by Elastic Package -->`
 )
 
-// AreReadmesUpToDate function checks if all the .md readme files are up-to-date.
-func AreReadmesUpToDate() ([]ReadmeFile, error) {
-	packageRoot, err := packages.MustFindPackageRoot()
-	if err != nil {
-		return nil, fmt.Errorf("package root not found: %w", err)
-	}
-
+// AreReadmesUpToDate function checks if all the .md readme files of the package are up-to-date.
+func AreReadmesUpToDate(packageRoot string) ([]ReadmeFile, error) {
 	files, err := filepath.Glob(filepath.Join(packageRoot, "_dev", "build", "docs", "*.md"))
 	if err != nil && !errors.Is(err, os.ErrNotExist) {
 		return nil, fmt.Errorf("reading directory entries failed: %w", err)
@@ -212,6 +206,30 @@ func renderReadme(fileName, packageRoot, templatePath string, linksMap linkMap)
 		"inputDocs": func() (string, error) {
 			return renderInputDocs(packageRoot)
 		},
+		"policyTemplates": func() (string, error) {
+			return renderPolicyTemplates(packageRoot)
+		},
+		"vars": func(args ...string) (string, error) {
+			if len(args) > 0 {
+				return renderVars(packageRoot, args[0])
+			}
+			return renderVars(packageRoot, "")
+		},
+		"compatibility": func() (string, error) {
+			return renderCompatibility(packageRoot)
+		},
+		"changelog": func(args ...int) (string, error) {
+			if len(args) > 0 {
+				return renderChangelog(packageRoot, args[0])
+			}
+			return renderChangelog(packageRoot, 0)
+		},
+		"dashboards": func() (string, error) {
+			return renderDashboards(packageRoot)
+		},
+		"dataStreams": func() (string, error) {
+			return renderDataStreams(packageRoot)
+		},
 		"generatedHeader": func() string {
 			return doNotModifyStr
 		},
@@ -0,0 +1,70 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package dump
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+
+	"github.com/elastic/elastic-package/internal/packages/indextemplate"
+)
+
+// MappingsDumpDir is the directory where index template previews are dumped.
+const MappingsDumpDir = "mappings"
+
+// MappingsDumper computes and dumps the index templates that would be installed for a package,
+// without requiring a stack.
+type MappingsDumper struct {
+	packageRoot string
+}
+
+// NewMappingsDumper creates a MappingsDumper for the package in the given root.
+func NewMappingsDumper(packageRoot string) *MappingsDumper {
+	return &MappingsDumper{packageRoot: packageRoot}
+}
+
+// DumpAll dumps the index template previews of all the data streams of the package. It
+// returns the number of dumped templates.
+func (d *MappingsDumper) DumpAll(dir string) (count int, err error) {
+	previews, err := indextemplate.Generate(d.packageRoot)
+	if err != nil {
+		return 0, fmt.Errorf("failed to generate index templates: %w", err)
+	}
+
+	dir = filepath.Join(dir, MappingsDumpDir)
+	for _, preview := range previews {
+		resource, err := newIndexTemplatePreview(preview)
+		if err != nil {
+			return 0, err
+		}
+		err = dumpJSONResource(dir, resource)
+		if err != nil {
+			return 0, fmt.Errorf("failed to dump index template %s: %w", preview.Name, err)
+		}
+	}
+	return len(previews), nil
+}
+
+type indexTemplatePreview struct {
+	name string
+	raw  []byte
+}
+
+func newIndexTemplatePreview(preview indextemplate.Preview) (*indexTemplatePreview, error) {
+	raw, err := json.Marshal(preview)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode index template %s: %w", preview.Name, err)
+	}
+	return &indexTemplatePreview{name: preview.Name, raw: raw}, nil
+}
+
+func (p *indexTemplatePreview) Name() string {
+	return p.name
+}
+
+func (p *indexTemplatePreview) JSON() []byte {
+	return p.raw
+}
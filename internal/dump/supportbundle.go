@@ -0,0 +1,339 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package dump
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/elastic/go-elasticsearch/v7/esapi"
+	"gopkg.in/yaml.v3"
+
+	"github.com/elastic/elastic-package/internal/elasticsearch"
+	"github.com/elastic/elastic-package/internal/files"
+	"github.com/elastic/elastic-package/internal/kibana"
+	"github.com/elastic/elastic-package/internal/logger"
+	"github.com/elastic/elastic-package/internal/profile"
+	"github.com/elastic/elastic-package/internal/stack"
+)
+
+const (
+	// SupportBundleDumpDir is the directory where diagnostics are stored in a support bundle.
+	SupportBundleDumpDir = "diagnostics"
+
+	supportBundleErrorsFile = "collection-errors.txt"
+
+	redactedValue = "REDACTED"
+)
+
+// sensitiveKeys are the substrings that identify keys whose values must be redacted.
+var sensitiveKeys = []string{
+	"api_key",
+	"apikey",
+	"authorization",
+	"passphrase",
+	"password",
+	"passwd",
+	"private_key",
+	"secret",
+	"token",
+}
+
+// sensitiveValuePattern matches the values of sensitive keys in text files, like logs, in forms
+// like "password=changeme", `"api_key": "abc"` or "Authorization: ApiKey abc".
+var sensitiveValuePattern = regexp.MustCompile(`(?i)([\w.\-]*(?:` + sensitiveKeysAlternation() + `)[\w.\-]*"?\s*[:=]\s*)` +
+	`("[^"]*"|'[^']*'|(?:bearer|basic|apikey)\s+[^\s"',;]+|[^\s"',;&{}\[\]]+)`)
+
+func sensitiveKeysAlternation() string {
+	alternatives := make([]string, len(sensitiveKeys))
+	for i, key := range sensitiveKeys {
+		alternatives[i] = strings.ReplaceAll(regexp.QuoteMeta(key), "_", `[_\-]?`)
+	}
+	return strings.Join(alternatives, "|")
+}
+
+// SupportBundleDumper collects diagnostic information of a running stack, redacting secrets.
+type SupportBundleDumper struct {
+	api     *elasticsearch.API
+	client  *kibana.Client
+	profile *profile.Profile
+}
+
+// NewSupportBundleDumper creates a SupportBundleDumper.
+func NewSupportBundleDumper(api *elasticsearch.API, client *kibana.Client, profile *profile.Profile) *SupportBundleDumper {
+	return &SupportBundleDumper{
+		api:     api,
+		client:  client,
+		profile: profile,
+	}
+}
+
+type supportBundleItem struct {
+	path    string
+	collect func(ctx context.Context) ([]byte, error)
+}
+
+// DumpAll writes the diagnostic information to the given directory. Failures collecting
+// single items don't stop the dump, they are reported in a file in the same directory.
+func (d *SupportBundleDumper) DumpAll(ctx context.Context, dir string) error {
+	dir = filepath.Join(dir, SupportBundleDumpDir)
+	err := os.MkdirAll(dir, 0755)
+	if err != nil {
+		return fmt.Errorf("failed to create dump directory: %w", err)
+	}
+
+	var collectErrors error
+	for _, item := range d.items() {
+		logger.Debugf("Collecting %s", item.path)
+		content, err := item.collect(ctx)
+		if err != nil {
+			collectErrors = errors.Join(collectErrors, fmt.Errorf("%s: %w", item.path, err))
+			continue
+		}
+		err = writeBundleFile(filepath.Join(dir, item.path), content)
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err = NewAgentPoliciesDumper(d.client).DumpAll(ctx, filepath.Join(dir, "fleet"))
+	if err != nil {
+		collectErrors = errors.Join(collectErrors, fmt.Errorf("%s: %w", AgentPoliciesDumpDir, err))
+	}
+
+	err = d.dumpProfileConfig(filepath.Join(dir, "profile"))
+	if err != nil {
+		collectErrors = errors.Join(collectErrors, fmt.Errorf("profile: %w", err))
+	}
+
+	if collectErrors != nil {
+		logger.Warnf("Some diagnostics could not be collected, see %s", supportBundleErrorsFile)
+		err = os.WriteFile(filepath.Join(dir, supportBundleErrorsFile), []byte(collectErrors.Error()+"\n"), 0644)
+		if err != nil {
+			return fmt.Errorf("failed to write collection errors: %w", err)
+		}
+	}
+	return nil
+}
+
+func (d *SupportBundleDumper) items() []supportBundleItem {
+	api := d.api
+	return []supportBundleItem{
+		{"elasticsearch/cluster_health.json", func(ctx context.Context) ([]byte, error) {
+			return esResponseBody(api.Cluster.Health(api.Cluster.Health.WithContext(ctx)))
+		}},
+		{"elasticsearch/nodes_stats.json", func(ctx context.Context) ([]byte, error) {
+			return esResponseBody(api.Nodes.Stats(api.Nodes.Stats.WithContext(ctx)))
+		}},
+		{"elasticsearch/pending_tasks.json", func(ctx context.Context) ([]byte, error) {
+			return esResponseBody(api.Cluster.PendingTasks(api.Cluster.PendingTasks.WithContext(ctx)))
+		}},
+		{"elasticsearch/index_templates.json", func(ctx context.Context) ([]byte, error) {
+			return esResponseBody(api.Indices.GetIndexTemplate(api.Indices.GetIndexTemplate.WithContext(ctx)))
+		}},
+		{"elasticsearch/ingest_pipelines.json", func(ctx context.Context) ([]byte, error) {
+			return esResponseBody(api.Ingest.GetPipeline(api.Ingest.GetPipeline.WithContext(ctx)))
+		}},
+		{"elasticsearch/data_streams_stats.json", func(ctx context.Context) ([]byte, error) {
+			return esResponseBody(api.Indices.DataStreamsStats(api.Indices.DataStreamsStats.WithContext(ctx)))
+		}},
+		{"kibana/status.json", func(ctx context.Context) ([]byte, error) {
+			return d.kibanaGet(ctx, kibana.StatusAPI)
+		}},
+		{"fleet/agents.json", func(ctx context.Context) ([]byte, error) {
+			return d.kibanaGet(ctx, kibana.FleetAPI+"/agents?perPage=1000")
+		}},
+		{"fleet/packages.json", func(ctx context.Context) ([]byte, error) {
+			return d.kibanaGet(ctx, kibana.FleetAPI+"/epm/packages?prerelease=true")
+		}},
+	}
+}
+
+func (d *SupportBundleDumper) kibanaGet(ctx context.Context, path string) ([]byte, error) {
+	statusCode, body, err := d.client.SendRequest(ctx, http.MethodGet, path, nil)
+	if err != nil {
+		return nil, err
+	}
+	if statusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code %d: %s", statusCode, string(body))
+	}
+	return body, nil
+}
+
+// dumpProfileConfig copies the configuration files of the profile, with secrets redacted.
+func (d *SupportBundleDumper) dumpProfileConfig(dir string) error {
+	stackConfig, err := stack.LoadConfig(d.profile)
+	if err != nil {
+		return fmt.Errorf("failed to load stack config: %w", err)
+	}
+	stackContent, err := json.Marshal(stackConfig)
+	if err != nil {
+		return fmt.Errorf("failed to encode stack config: %w", err)
+	}
+	err = writeBundleFile(filepath.Join(dir, "stack-config.json"), stackContent)
+	if err != nil {
+		return err
+	}
+
+	configPath := d.profile.Path(profile.PackageProfileConfigFile)
+	content, err := os.ReadFile(configPath)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read profile config: %w", err)
+	}
+
+	var config any
+	err = yaml.Unmarshal(content, &config)
+	if err != nil {
+		return fmt.Errorf("failed to decode profile config: %w", err)
+	}
+	content, err = yaml.Marshal(redact(config))
+	if err != nil {
+		return fmt.Errorf("failed to encode profile config: %w", err)
+	}
+	return writeBundleFile(filepath.Join(dir, profile.PackageProfileConfigFile), content)
+}
+
+func esResponseBody(resp *esapi.Response, err error) ([]byte, error) {
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.IsError() {
+		return nil, fmt.Errorf("unexpected response: %s", resp.String())
+	}
+
+	d, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+	return d, nil
+}
+
+func writeBundleFile(path string, content []byte) error {
+	err := os.MkdirAll(filepath.Dir(path), 0755)
+	if err != nil {
+		return fmt.Errorf("failed to create dump directory: %w", err)
+	}
+	err = os.WriteFile(path, content, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
+
+// ArchiveSupportBundle redacts secrets in all the files of the dump in the given directory,
+// including the logs of the services, and archives them in a zip file next to it. Binary files
+// can't be redacted, so they are left out of the bundle.
+func ArchiveSupportBundle(ctx context.Context, dir string) (string, error) {
+	err := redactFiles(dir)
+	if err != nil {
+		return "", fmt.Errorf("failed to redact dumped files: %w", err)
+	}
+
+	bundlePath := filepath.Clean(dir) + ".zip"
+	err = files.Zip(ctx, dir, bundlePath)
+	if err != nil {
+		return "", fmt.Errorf("can't archive support bundle: %w", err)
+	}
+	return bundlePath, nil
+}
+
+// redactFiles redacts secrets in all the files found in the given directory, and removes the
+// binary ones.
+func redactFiles(dir string) error {
+	return filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() {
+			return nil
+		}
+
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		if bytes.IndexByte(content, 0) >= 0 {
+			logger.Debugf("Leaving binary file out of the support bundle: %s", path)
+			return os.Remove(path)
+		}
+
+		redacted, err := redactJSON(content)
+		if err != nil || filepath.Ext(path) != ".json" {
+			// Not a JSON document, like logs with one JSON object per line.
+			redacted = redactText(content)
+		}
+		return os.WriteFile(path, redacted, 0644)
+	})
+}
+
+func redactJSON(content []byte) ([]byte, error) {
+	var value any
+	err := json.Unmarshal(content, &value)
+	if err != nil {
+		return nil, err
+	}
+	return json.MarshalIndent(redact(value), "", "  ")
+}
+
+// redactText replaces the values of sensitive keys found in text.
+func redactText(content []byte) []byte {
+	return sensitiveValuePattern.ReplaceAllFunc(content, func(match []byte) []byte {
+		groups := sensitiveValuePattern.FindSubmatch(match)
+		key, value := groups[1], groups[2]
+		switch {
+		case string(value) == `""` || string(value) == `''`:
+			return match
+		case value[0] == '"' || value[0] == '\'':
+			return fmt.Appendf(nil, "%s%c%s%c", key, value[0], redactedValue, value[0])
+		}
+		return fmt.Appendf(nil, "%s%s", key, redactedValue)
+	})
+}
+
+// redact replaces the values of sensitive keys in decoded JSON or YAML documents.
+func redact(value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		for key, child := range v {
+			if isSensitiveKey(key) {
+				if child != nil && child != "" {
+					v[key] = redactedValue
+				}
+				continue
+			}
+			v[key] = redact(child)
+		}
+	case []any:
+		for i, child := range v {
+			v[i] = redact(child)
+		}
+	}
+	return value
+}
+
+func isSensitiveKey(key string) bool {
+	key = strings.ToLower(key)
+	for _, sensitive := range sensitiveKeys {
+		if strings.Contains(key, sensitive) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,111 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package dump
+
+import (
+	"archive/zip"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRedactJSON(t *testing.T) {
+	input := `{
+  "name": "agent policy",
+  "enrollment_token": "c2VjcmV0",
+  "outputs": [
+    {"hosts": ["https://elasticsearch:9200"], "password": "changeme", "api_key": ""},
+    {"ssl": {"certificate": "cert.pem", "private_key": "key.pem"}}
+  ],
+  "vars": {"Secret_Access_Key": {"value": "abc"}}
+}`
+	expected := `{
+  "enrollment_token": "REDACTED",
+  "name": "agent policy",
+  "outputs": [
+    {
+      "api_key": "",
+      "hosts": [
+        "https://elasticsearch:9200"
+      ],
+      "password": "REDACTED"
+    },
+    {
+      "ssl": {
+        "certificate": "cert.pem",
+        "private_key": "REDACTED"
+      }
+    }
+  ],
+  "vars": {
+    "Secret_Access_Key": "REDACTED"
+  }
+}`
+
+	redacted, err := redactJSON([]byte(input))
+	require.NoError(t, err)
+	assert.Equal(t, expected, string(redacted))
+}
+
+func TestRedactText(t *testing.T) {
+	input := `fleet-server-1  | {"log.level":"info","message":"enrolling","enrollment_token":"c2VjcmV0","api_key":""}
+kibana-1  | [2024-01-01T00:00:00.000+00:00][INFO][http] request headers: Authorization: ApiKey dGVzdDpzZWNyZXQ=, accept: */*
+elastic-agent-1  | FLEET_ENROLLMENT_TOKEN=c2VjcmV0 ELASTICSEARCH_PASSWORD='changeme' KIBANA_HOST=http://kibana:5601
+`
+	expected := `fleet-server-1  | {"log.level":"info","message":"enrolling","enrollment_token":"REDACTED","api_key":""}
+kibana-1  | [2024-01-01T00:00:00.000+00:00][INFO][http] request headers: Authorization: REDACTED, accept: */*
+elastic-agent-1  | FLEET_ENROLLMENT_TOKEN=REDACTED ELASTICSEARCH_PASSWORD='REDACTED' KIBANA_HOST=http://kibana:5601
+`
+	assert.Equal(t, expected, string(redactText([]byte(input))))
+}
+
+func TestArchiveSupportBundle(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "elastic-stack-dump")
+	const secret = "c2VjcmV0"
+	dumped := map[string]string{
+		"logs/fleet-server.log":                   `fleet-server-1  | {"message":"enrolling","enrollment_token":"` + secret + `"}` + "\n",
+		"logs/fleet-server-internal/state.ndjson": `{"policy":{"fleet":{"access_api_key":"` + secret + `"}}}` + "\n",
+		"diagnostics/fleet/agents.json":           `{"items":[{"id":"agent","password":"` + secret + `"}]}`,
+		"logs/elastic-agent-internal/core.dump":   "\x00\x01" + secret,
+	}
+	for path, content := range dumped {
+		path = filepath.Join(dir, filepath.FromSlash(path))
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	}
+
+	bundlePath, err := ArchiveSupportBundle(context.Background(), dir)
+	require.NoError(t, err)
+	assert.Equal(t, dir+".zip", bundlePath)
+
+	bundle, err := zip.OpenReader(bundlePath)
+	require.NoError(t, err)
+	defer bundle.Close()
+
+	var names []string
+	for _, f := range bundle.File {
+		if f.FileInfo().IsDir() {
+			continue
+		}
+		names = append(names, f.Name)
+		r, err := f.Open()
+		require.NoError(t, err)
+		content, err := io.ReadAll(r)
+		r.Close()
+		require.NoError(t, err)
+		assert.NotContains(t, string(content), secret, f.Name)
+		assert.Contains(t, string(content), redactedValue, f.Name)
+	}
+	assert.ElementsMatch(t, []string{
+		"elastic-stack-dump/diagnostics/fleet/agents.json",
+		"elastic-stack-dump/logs/fleet-server-internal/state.ndjson",
+		"elastic-stack-dump/logs/fleet-server.log",
+	}, names)
+}
@@ -317,6 +317,20 @@ func getIngestPipeline(ctx context.Context, api *elasticsearch.API, pipeline Pip
 	return nil
 }
 
+// IngestPipelineTagName returns the name of the pipeline referenced with an IngestPipeline tag in
+// the value, if it contains one.
+func IngestPipelineTagName(value string) (string, bool) {
+	found := ingestPipelineTag.FindString(value)
+	if found == "" {
+		return "", false
+	}
+	s := strings.Split(found, `"`)
+	if len(s) != 3 {
+		return "", false
+	}
+	return s[1], true
+}
+
 func getPipelineNameWithNonce(pipelineName string, nonce int64) string {
 	return fmt.Sprintf("%s-%d", pipelineName, nonce)
 }
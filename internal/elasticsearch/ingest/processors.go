@@ -52,6 +52,130 @@ func (p Pipeline) OriginalProcessors() (procs []Processor, err error) {
 	return procs, nil
 }
 
+// ProcessorNode represents an ingest processor with its configuration, and the processors nested
+// in it.
+type ProcessorNode struct {
+	Processor
+
+	// Config is the configuration of the processor.
+	Config map[string]any
+
+	// OnFailure contains the processors executed when this processor fails.
+	OnFailure []ProcessorNode
+
+	// Nested contains the processor executed by foreach processors.
+	Nested []ProcessorNode
+}
+
+// Tag returns the tag of the processor, or an empty string if it has none.
+func (n ProcessorNode) Tag() string {
+	tag, _ := n.Config["tag"].(string)
+	return tag
+}
+
+// Condition returns the condition of the processor, or an empty string if it has none.
+func (n ProcessorNode) Condition() string {
+	condition, _ := n.Config["if"].(string)
+	return condition
+}
+
+// ProcessorTree contains the processors of an ingest pipeline, and the processors executed when
+// the pipeline fails.
+type ProcessorTree struct {
+	Processors []ProcessorNode
+	OnFailure  []ProcessorNode
+}
+
+// ProcessorTree returns the tree of processors in the original definition of an ingest pipeline.
+func (p Pipeline) ProcessorTree() (*ProcessorTree, error) {
+	switch p.Format {
+	case "yaml", "yml", "json":
+	default:
+		return nil, fmt.Errorf("unsupported pipeline format: %s", p.Format)
+	}
+
+	var pipeline struct {
+		Processors []yaml.Node `yaml:"processors"`
+		OnFailure  []yaml.Node `yaml:"on_failure"`
+	}
+	err := yaml.Unmarshal(p.ContentOriginal, &pipeline)
+	if err != nil {
+		return nil, fmt.Errorf("failure processing %s pipeline '%s': %w", p.Format, p.Filename(), err)
+	}
+
+	var tree ProcessorTree
+	tree.Processors, err = processorNodes(pipeline.Processors)
+	if err != nil {
+		return nil, fmt.Errorf("failure processing %s pipeline '%s': %w", p.Format, p.Filename(), err)
+	}
+	tree.OnFailure, err = processorNodes(pipeline.OnFailure)
+	if err != nil {
+		return nil, fmt.Errorf("failure processing %s pipeline '%s' on_failure handlers: %w", p.Format, p.Filename(), err)
+	}
+	return &tree, nil
+}
+
+func processorNodes(entries []yaml.Node) ([]ProcessorNode, error) {
+	var nodes []ProcessorNode
+	for idx, entry := range entries {
+		node, err := processorNode(&entry)
+		if err != nil {
+			return nil, fmt.Errorf("processor#%d: %w", idx, err)
+		}
+		nodes = append(nodes, node)
+	}
+	return nodes, nil
+}
+
+func processorNode(entry *yaml.Node) (ProcessorNode, error) {
+	var node ProcessorNode
+	if entry.Kind != yaml.MappingNode || len(entry.Content) != 2 {
+		return node, fmt.Errorf("processor is not a single-key map (kind:%v content:%d)", entry.Kind, len(entry.Content))
+	}
+	if err := entry.Content[0].Decode(&node.Type); err != nil {
+		return node, fmt.Errorf("error decoding processor type: %w", err)
+	}
+	config := entry.Content[1]
+	if err := config.Decode(&node.Config); err != nil {
+		return node, fmt.Errorf("error decoding %s processor configuration: %w", node.Type, err)
+	}
+	node.FirstLine = entry.Content[0].Line
+	node.LastLine = lastLine(entry)
+
+	for i := 0; i+1 < len(config.Content); i += 2 {
+		var err error
+		switch value := config.Content[i+1]; config.Content[i].Value {
+		case "on_failure":
+			if value.Kind == yaml.SequenceNode {
+				entries := make([]yaml.Node, len(value.Content))
+				for j, item := range value.Content {
+					entries[j] = *item
+				}
+				node.OnFailure, err = processorNodes(entries)
+			}
+		case "processor":
+			if node.Type == "foreach" {
+				var nested ProcessorNode
+				nested, err = processorNode(value)
+				node.Nested = []ProcessorNode{nested}
+			}
+		}
+		if err != nil {
+			return node, fmt.Errorf("%s processor: %w", node.Type, err)
+		}
+	}
+	return node, nil
+}
+
+// lastLine returns the last line where a node or any of its descendants is defined.
+func lastLine(node *yaml.Node) int {
+	line := node.Line
+	for _, child := range node.Content {
+		line = max(line, lastLine(child))
+	}
+	return line
+}
+
 // processorsFromYAML extracts a list of processors from a pipeline definition in YAML format.
 func processorsFromYAML(content []byte) (procs []Processor, err error) {
 	var p struct {
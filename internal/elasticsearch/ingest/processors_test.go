@@ -344,3 +344,51 @@ processors:
 		})
 	}
 }
+
+func TestPipeline_ProcessorTree(t *testing.T) {
+	p := Pipeline{
+		Name:   "default",
+		Format: "yml",
+		ContentOriginal: []byte(`---
+processors:
+  - foreach:
+      tag: foreach_tags
+      field: tags
+      processor:
+        uppercase:
+          field: _ingest._value
+  - rename:
+      tag: rename_level
+      field: level
+      target_field: log.level
+      if: ctx.level != null
+      on_failure:
+        - remove:
+            field: level
+on_failure:
+  - set:
+      field: error.message
+      value: '{{{ _ingest.on_failure_message }}}'
+`),
+	}
+	tree, err := p.ProcessorTree()
+	assert.NoError(t, err)
+
+	assert.Len(t, tree.Processors, 2)
+	foreach := tree.Processors[0]
+	assert.Equal(t, Processor{Type: "foreach", FirstLine: 3, LastLine: 8}, foreach.Processor)
+	assert.Equal(t, "foreach_tags", foreach.Tag())
+	assert.Equal(t, []ProcessorNode{{
+		Processor: Processor{Type: "uppercase", FirstLine: 7, LastLine: 8},
+		Config:    map[string]any{"field": "_ingest._value"},
+	}}, foreach.Nested)
+
+	rename := tree.Processors[1]
+	assert.Equal(t, "ctx.level != null", rename.Condition())
+	assert.Len(t, rename.OnFailure, 1)
+	assert.Equal(t, Processor{Type: "remove", FirstLine: 15, LastLine: 16}, rename.OnFailure[0].Processor)
+
+	assert.Len(t, tree.OnFailure, 1)
+	assert.Equal(t, "set", tree.OnFailure[0].Type)
+	assert.Empty(t, tree.OnFailure[0].Tag())
+}
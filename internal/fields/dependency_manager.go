@@ -32,7 +32,8 @@ const (
 
 // DependencyManager is responsible for resolving external field dependencies.
 type DependencyManager struct {
-	schema map[string][]FieldDefinition
+	schema       map[string][]FieldDefinition
+	ecsReference string
 }
 
 // CreateFieldDependencyManager function creates a new instance of the DependencyManager.
@@ -42,10 +43,28 @@ func CreateFieldDependencyManager(deps buildmanifest.Dependencies) (*DependencyM
 		return nil, fmt.Errorf("can't build fields schema: %w", err)
 	}
 	return &DependencyManager{
-		schema: schema,
+		schema:       schema,
+		ecsReference: deps.ECS.Reference,
 	}, nil
 }
 
+// ECSSchema returns the version of the ECS schema used to resolve fields, and the location it is
+// read from. The version is empty if there is no ECS dependency, and for local schemas it is the
+// reference defined in the build manifest.
+func (dm *DependencyManager) ECSSchema() (version string, source string) {
+	switch {
+	case dm.ecsReference == "":
+		return "", ""
+	case strings.HasPrefix(dm.ecsReference, localFilePrefix):
+		return dm.ecsReference, strings.TrimPrefix(dm.ecsReference, localFilePrefix)
+	}
+	gitReference, err := asGitReference(dm.ecsReference)
+	if err != nil {
+		return dm.ecsReference, ""
+	}
+	return gitReference, fmt.Sprintf(ecsSchemaURL, gitReference, ecsSchemaFile)
+}
+
 func buildFieldsSchema(deps buildmanifest.Dependencies) (map[string][]FieldDefinition, error) {
 	schema := map[string][]FieldDefinition{}
 	ecsSchema, err := loadECSFieldsSchema(deps.ECS)
@@ -53,6 +72,14 @@ func buildFieldsSchema(deps buildmanifest.Dependencies) (map[string][]FieldDefin
 		return nil, fmt.Errorf("can't load fields: %w", err)
 	}
 	schema[ecsSchemaName] = ecsSchema
+
+	semconvSchema, err := loadSemconvFieldsSchema(deps.Semconv)
+	if err != nil {
+		return nil, fmt.Errorf("can't load semantic conventions: %w", err)
+	}
+	if semconvSchema != nil {
+		schema[semconvSchemaName] = semconvSchema
+	}
 	return schema, nil
 }
 
@@ -317,6 +344,9 @@ func (dm *DependencyManager) importField(schemaName, fieldPath string) (FieldDef
 		return FieldDefinition{}, fmt.Errorf(`schema "%s" is not defined as package depedency`, schemaName)
 	}
 
+	if schemaName == semconvSchemaName {
+		fieldPath = trimSemconvAttributesPrefix(fieldPath)
+	}
 	imported := FindElementDefinition(fieldPath, schema)
 	if imported == nil {
 		return FieldDefinition{}, fmt.Errorf("field definition not found in schema (name: %s)", fieldPath)
@@ -324,6 +354,12 @@ func (dm *DependencyManager) importField(schemaName, fieldPath string) (FieldDef
 	return *imported, nil
 }
 
+// hasLeafField method checks if the schema defines the field, and it is not a group of fields.
+func (dm *DependencyManager) hasLeafField(schemaName, fieldPath string) bool {
+	imported, err := dm.importField(schemaName, fieldPath)
+	return err == nil && imported.Type != "group"
+}
+
 // ImportAllFields method resolves all fields available in the default ECS schema.
 func (dm *DependencyManager) ImportAllFields(schemaName string) ([]FieldDefinition, error) {
 	if dm == nil {
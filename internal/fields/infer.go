@@ -0,0 +1,360 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package fields
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"math"
+	"net"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/elastic/elastic-package/internal/common"
+	"github.com/elastic/elastic-package/internal/logger"
+)
+
+const (
+	// Strings with more words than this are inferred as text.
+	maxKeywordWords = 5
+
+	pipelineTestExpectedSuffix = "-expected.json"
+)
+
+// Layouts of the strings inferred as dates.
+var dateLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05.999999999Z0700",
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02 15:04:05.999999999",
+}
+
+// InferredField is a field definition inferred from documents.
+type InferredField struct {
+	Name     string          `yaml:"name"`
+	Type     string          `yaml:"type,omitempty"`
+	External string          `yaml:"external,omitempty"`
+	Fields   []InferredField `yaml:"fields,omitempty"`
+}
+
+// FieldsInferrer infers field definitions from the values found in documents.
+type FieldsInferrer struct {
+	types map[string][]string
+}
+
+// NewFieldsInferrer creates a new fields inferrer.
+func NewFieldsInferrer() *FieldsInferrer {
+	return &FieldsInferrer{types: make(map[string][]string)}
+}
+
+// Add collects the types of the values of the fields in the document.
+func (fi *FieldsInferrer) Add(doc common.MapStr) {
+	for key, value := range doc {
+		fi.addValue(key, value)
+	}
+}
+
+func (fi *FieldsInferrer) addValue(path string, value any) {
+	switch v := value.(type) {
+	case nil:
+		fi.observe(path, "")
+	case []any:
+		for _, element := range v {
+			fi.addValue(path, element)
+		}
+	case common.MapStr:
+		fi.addValue(path, map[string]any(v))
+	case map[string]any:
+		if isGeoPoint(v) {
+			fi.observe(path, "geo_point")
+			return
+		}
+		for key, child := range v {
+			fi.addValue(path+"."+key, child)
+		}
+	default:
+		valueType := ValueType(value)
+		if valueType == "" {
+			logger.Debugf("Ignoring value of unexpected type %T in field %s", value, path)
+			return
+		}
+		fi.observe(path, valueType)
+	}
+}
+
+// ValueType returns the field type inferred from a single value found in a document. It
+// returns an empty string for null values, arrays, and objects other than geo points.
+func ValueType(value any) string {
+	switch v := value.(type) {
+	case map[string]any:
+		if isGeoPoint(v) {
+			return "geo_point"
+		}
+	case bool:
+		return "boolean"
+	case float64:
+		if v == math.Trunc(v) {
+			return "long"
+		}
+		return "double"
+	case json.Number:
+		if _, err := v.Int64(); err == nil {
+			return "long"
+		}
+		return "double"
+	case string:
+		return stringType(v)
+	}
+	return ""
+}
+
+func (fi *FieldsInferrer) observe(path, fieldType string) {
+	if !slices.Contains(fi.types[path], fieldType) {
+		fi.types[path] = append(fi.types[path], fieldType)
+	}
+}
+
+// isGeoPoint returns true for objects with only numeric lat and lon values.
+func isGeoPoint(v map[string]any) bool {
+	if len(v) != 2 {
+		return false
+	}
+	for _, key := range []string{"lat", "lon"} {
+		switch v[key].(type) {
+		case float64, json.Number:
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+func stringType(v string) string {
+	if net.ParseIP(v) != nil {
+		return "ip"
+	}
+	for _, layout := range dateLayouts {
+		if _, err := time.Parse(layout, v); err == nil {
+			return "date"
+		}
+	}
+	if strings.Contains(v, "\n") || len(strings.Fields(v)) > maxKeywordWords {
+		return "text"
+	}
+	return "keyword"
+}
+
+// resolveType selects the type of a field from the types of its values.
+func resolveType(types []string) string {
+	types = slices.DeleteFunc(slices.Clone(types), func(t string) bool { return t == "" })
+	slices.Sort(types)
+	switch {
+	case len(types) == 0:
+		return "keyword"
+	case len(types) == 1:
+		return types[0]
+	case slices.Equal(types, []string{"double", "long"}):
+		return "double"
+	case slices.Equal(types, []string{"keyword", "text"}):
+		return "text"
+	}
+	return "keyword"
+}
+
+// InferOptions are the options used to build the inferred field definitions.
+type InferOptions struct {
+	// DependencyManager, if set, is used to map fields to the external fields with the same
+	// name in the ECS schema.
+	DependencyManager *DependencyManager
+
+	// Skip contains the names of fields that are already defined. These fields, and the fields
+	// under them, are not included in the inferred definitions.
+	Skip []string
+}
+
+// Fields returns the inferred field definitions, grouped by their parent objects and sorted
+// by name.
+func (fi *FieldsInferrer) Fields(options InferOptions) []InferredField {
+	paths := make([]string, 0, len(fi.types))
+	for path := range fi.types {
+		if isSkippedField(path, options.Skip) {
+			continue
+		}
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	root := &inferredNode{}
+	for _, path := range paths {
+		field := InferredField{Type: resolveType(fi.types[path])}
+		if options.DependencyManager != nil && options.DependencyManager.hasLeafField(ecsSchemaName, path) {
+			field = InferredField{External: ecsSchemaName}
+		}
+		root.insert(strings.Split(path, "."), field)
+	}
+	return root.fields()
+}
+
+func isSkippedField(path string, skip []string) bool {
+	for _, name := range skip {
+		if path == name || strings.HasPrefix(path, name+".") {
+			return true
+		}
+	}
+	return false
+}
+
+type inferredNode struct {
+	field    *InferredField
+	children map[string]*inferredNode
+}
+
+func (n *inferredNode) insert(path []string, field InferredField) {
+	if n.children == nil {
+		n.children = make(map[string]*inferredNode)
+	}
+	child, found := n.children[path[0]]
+	if !found {
+		child = &inferredNode{}
+		n.children[path[0]] = child
+	}
+	if len(path) == 1 {
+		child.field = &field
+		return
+	}
+	child.insert(path[1:], field)
+}
+
+func (n *inferredNode) fields() []InferredField {
+	names := make([]string, 0, len(n.children))
+	for name := range n.children {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var fields []InferredField
+	for _, name := range names {
+		child := n.children[name]
+		if len(child.children) > 0 {
+			if child.field != nil {
+				logger.Debugf("Field %s found both as a value and as an object, keeping the object", name)
+			}
+			fields = append(fields, InferredField{
+				Name:   name,
+				Type:   "group",
+				Fields: child.fields(),
+			})
+			continue
+		}
+		field := *child.field
+		field.Name = name
+		fields = append(fields, field)
+	}
+	return fields
+}
+
+// LoadSampleDocuments reads documents from a file or from the files in a directory. Files can
+// contain newline-delimited JSON documents (.ndjson or .jsonl), a JSON document or a list of
+// them (.json), or the expected results of pipeline tests (*-expected.json). Only pipeline test
+// results and newline-delimited JSON files are read from directories.
+func LoadSampleDocuments(path string) ([]common.MapStr, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return loadSampleDocumentsFile(path)
+	}
+
+	var docs []common.MapStr
+	err = filepath.WalkDir(path, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		name := d.Name()
+		if !strings.HasSuffix(name, pipelineTestExpectedSuffix) && !isNDJSONFile(name) {
+			return nil
+		}
+		fileDocs, err := loadSampleDocumentsFile(path)
+		if err != nil {
+			return err
+		}
+		docs = append(docs, fileDocs...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return docs, nil
+}
+
+func isNDJSONFile(name string) bool {
+	return strings.HasSuffix(name, ".ndjson") || strings.HasSuffix(name, ".jsonl")
+}
+
+func loadSampleDocumentsFile(path string) ([]common.MapStr, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var docs []common.MapStr
+	switch {
+	case isNDJSONFile(path):
+		scanner := bufio.NewScanner(bytes.NewReader(content))
+		scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+		for line := 1; scanner.Scan(); line++ {
+			if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+				continue
+			}
+			var doc common.MapStr
+			err := json.Unmarshal(scanner.Bytes(), &doc)
+			if err != nil {
+				return nil, fmt.Errorf("invalid document in line %d of %s: %w", line, path, err)
+			}
+			docs = append(docs, doc)
+		}
+		if err := scanner.Err(); err != nil {
+			return nil, fmt.Errorf("reading %s failed: %w", path, err)
+		}
+	case strings.HasSuffix(path, pipelineTestExpectedSuffix):
+		var results struct {
+			Expected []common.MapStr `json:"expected"`
+		}
+		err := json.Unmarshal(content, &results)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pipeline test results in %s: %w", path, err)
+		}
+		for _, doc := range results.Expected {
+			// Dropped documents are null.
+			if doc != nil {
+				docs = append(docs, doc)
+			}
+		}
+	default:
+		trimmed := bytes.TrimSpace(content)
+		if bytes.HasPrefix(trimmed, []byte("[")) {
+			err = json.Unmarshal(trimmed, &docs)
+		} else {
+			var doc common.MapStr
+			err = json.Unmarshal(trimmed, &doc)
+			docs = append(docs, doc)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid documents in %s: %w", path, err)
+		}
+	}
+	return docs, nil
+}
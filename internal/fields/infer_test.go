@@ -0,0 +1,123 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package fields
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/elastic-package/internal/common"
+)
+
+func inferFromJSON(t *testing.T, options InferOptions, docs ...string) []InferredField {
+	t.Helper()
+	inferrer := NewFieldsInferrer()
+	for _, doc := range docs {
+		var d common.MapStr
+		require.NoError(t, json.Unmarshal([]byte(doc), &d))
+		inferrer.Add(d)
+	}
+	return inferrer.Fields(options)
+}
+
+func TestFieldsInferrer(t *testing.T) {
+	inferred := inferFromJSON(t, InferOptions{},
+		`{
+			"@timestamp": "2024-01-02T03:04:05.678Z",
+			"message": "GET /index.html HTTP/1.1 200 1024 bytes",
+			"source": {"ip": "10.0.0.1", "port": 8080, "location": {"lat": 40.1, "lon": -3.7}},
+			"http.response.time": 12,
+			"tags": ["foo", "bar"],
+			"enabled": true,
+			"empty": null
+		}`,
+		`{
+			"http": {"response": {"time": 12.5}},
+			"source.port": 9090,
+			"mixed": "foo"
+		}`,
+		`{"mixed": 42}`,
+	)
+
+	assert.Equal(t, []InferredField{
+		{Name: "@timestamp", Type: "date"},
+		{Name: "empty", Type: "keyword"},
+		{Name: "enabled", Type: "boolean"},
+		{Name: "http", Type: "group", Fields: []InferredField{
+			{Name: "response", Type: "group", Fields: []InferredField{
+				{Name: "time", Type: "double"},
+			}},
+		}},
+		{Name: "message", Type: "text"},
+		{Name: "mixed", Type: "keyword"},
+		{Name: "source", Type: "group", Fields: []InferredField{
+			{Name: "ip", Type: "ip"},
+			{Name: "location", Type: "geo_point"},
+			{Name: "port", Type: "long"},
+		}},
+		{Name: "tags", Type: "keyword"},
+	}, inferred)
+}
+
+func TestFieldsInferrerSkip(t *testing.T) {
+	inferred := inferFromJSON(t, InferOptions{Skip: []string{"data_stream.type", "labels"}},
+		`{"data_stream": {"type": "logs", "dataset": "foo"}, "labels": {"env": "prod"}}`,
+	)
+
+	assert.Equal(t, []InferredField{
+		{Name: "data_stream", Type: "group", Fields: []InferredField{
+			{Name: "dataset", Type: "keyword"},
+		}},
+	}, inferred)
+}
+
+func TestFieldsInferrerExternal(t *testing.T) {
+	dm := &DependencyManager{schema: map[string][]FieldDefinition{
+		ecsSchemaName: {
+			{Name: "source", Type: "group", Fields: []FieldDefinition{
+				{Name: "ip", Type: "ip"},
+			}},
+		},
+	}}
+	inferred := inferFromJSON(t, InferOptions{DependencyManager: dm},
+		`{"source": {"ip": "10.0.0.1", "custom": "foo"}}`,
+	)
+
+	assert.Equal(t, []InferredField{
+		{Name: "source", Type: "group", Fields: []InferredField{
+			{Name: "custom", Type: "keyword"},
+			{Name: "ip", External: ecsSchemaName},
+		}},
+	}, inferred)
+}
+
+func TestLoadSampleDocuments(t *testing.T) {
+	dir := t.TempDir()
+	testDir := filepath.Join(dir, "_dev", "test", "pipeline")
+	require.NoError(t, os.MkdirAll(testDir, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(testDir, "test-access.log-expected.json"),
+		[]byte(`{"expected": [{"message": "foo"}, null]}`), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(testDir, "test-access.log"), []byte("foo\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "samples.ndjson"),
+		[]byte("{\"message\": \"bar\"}\n\n{\"message\": \"baz\"}\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "sample_event.json"), []byte(`{"message": "qux"}`), 0644))
+
+	docs, err := LoadSampleDocuments(dir)
+	require.NoError(t, err)
+	assert.Equal(t, []common.MapStr{
+		{"message": "foo"},
+		{"message": "bar"},
+		{"message": "baz"},
+	}, docs)
+
+	docs, err = LoadSampleDocuments(filepath.Join(dir, "sample_event.json"))
+	require.NoError(t, err)
+	assert.Equal(t, []common.MapStr{{"message": "qux"}}, docs)
+}
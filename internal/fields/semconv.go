@@ -0,0 +1,311 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package fields
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/elastic/elastic-package/internal/common"
+	"github.com/elastic/elastic-package/internal/configuration/locations"
+	"github.com/elastic/elastic-package/internal/logger"
+	"github.com/elastic/elastic-package/internal/multierror"
+	"github.com/elastic/elastic-package/internal/packages/buildmanifest"
+)
+
+const (
+	semconvSchemaName = "semconv"
+
+	semconvArchiveFile = "semantic-conventions.tar.gz"
+	semconvArchiveURL  = "https://github.com/open-telemetry/semantic-conventions/archive/refs/tags/%s.tar.gz"
+)
+
+// semconvAttributesPrefixes are the objects where OpenTelemetry documents store attributes.
+var semconvAttributesPrefixes = []string{"resource.attributes", "scope.attributes", "attributes"}
+
+// semconvModel is the format of the YAML files of the semantic conventions model.
+type semconvModel struct {
+	Groups []struct {
+		ID         string             `yaml:"id"`
+		Attributes []semconvAttribute `yaml:"attributes"`
+	} `yaml:"groups"`
+}
+
+type semconvAttribute struct {
+	ID    string    `yaml:"id"`
+	Type  yaml.Node `yaml:"type"`
+	Brief string    `yaml:"brief"`
+}
+
+func loadSemconvFieldsSchema(dep buildmanifest.SemconvDependency) ([]FieldDefinition, error) {
+	if dep.Reference == "" {
+		logger.Debugf("Semantic conventions dependency isn't defined")
+		return nil, nil
+	}
+
+	if strings.HasPrefix(dep.Reference, localFilePrefix) {
+		path := strings.TrimPrefix(dep.Reference, localFilePrefix)
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("error reading semantic conventions file: %w", err)
+		}
+		return parseSemconvModels([][]byte{content})
+	}
+
+	archive, err := readSemconvArchive(dep)
+	if err != nil {
+		return nil, fmt.Errorf("error reading semantic conventions archive: %w", err)
+	}
+	models, err := extractSemconvModels(archive)
+	if err != nil {
+		return nil, err
+	}
+	return parseSemconvModels(models)
+}
+
+func readSemconvArchive(dep buildmanifest.SemconvDependency) ([]byte, error) {
+	gitReference, err := asGitReference(dep.Reference)
+	if err != nil {
+		return nil, fmt.Errorf("can't process the value as Git reference: %w", err)
+	}
+
+	loc, err := locations.NewLocationManager()
+	if err != nil {
+		return nil, fmt.Errorf("error fetching profile path: %w", err)
+	}
+	cachedArchivePath := filepath.Join(loc.CacheDir(locations.FieldsCacheName), semconvSchemaName, gitReference, semconvArchiveFile)
+	content, err := os.ReadFile(cachedArchivePath)
+	if err == nil {
+		return content, nil
+	}
+	if !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("can't read cached archive (path: %s): %w", cachedArchivePath, err)
+	}
+
+	logger.Debugf("Pulling semantic conventions dependency using reference: %s", dep.Reference)
+	url := fmt.Sprintf(semconvArchiveURL, gitReference)
+	logger.Debugf("Archive URL: %s", url)
+	resp, err := http.Get(url)
+	if err != nil {
+		return nil, fmt.Errorf("can't download the semantic conventions (URL: %s): %w", url, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("unsatisfied semantic conventions dependency, reference defined in build manifest doesn't exist (HTTP StatusNotFound, URL: %s)", url)
+	} else if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected HTTP status code: %d", resp.StatusCode)
+	}
+
+	content, err = io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("can't read archive content (URL: %s): %w", url, err)
+	}
+	logger.Debugf("Downloaded %d bytes", len(content))
+
+	cachedArchiveDir := filepath.Dir(cachedArchivePath)
+	err = os.MkdirAll(cachedArchiveDir, 0755)
+	if err != nil {
+		return nil, fmt.Errorf("can't create cache directories for archive (path: %s): %w", cachedArchiveDir, err)
+	}
+
+	logger.Debugf("Cache downloaded archive: %s", cachedArchivePath)
+	err = os.WriteFile(cachedArchivePath, content, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("can't write cached archive (path: %s): %w", cachedArchivePath, err)
+	}
+	return content, nil
+}
+
+// extractSemconvModels returns the content of the YAML files in the model directory of a
+// semantic conventions archive.
+func extractSemconvModels(archive []byte) ([][]byte, error) {
+	gz, err := gzip.NewReader(bytes.NewReader(archive))
+	if err != nil {
+		return nil, fmt.Errorf("can't decompress archive: %w", err)
+	}
+	defer gz.Close()
+
+	var models [][]byte
+	reader := tar.NewReader(gz)
+	for {
+		header, err := reader.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("can't read archive: %w", err)
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+		// Paths in the archive are prefixed by the directory of the repository.
+		_, name, _ := strings.Cut(header.Name, "/")
+		if !strings.HasPrefix(name, "model/") || (path.Ext(name) != ".yaml" && path.Ext(name) != ".yml") {
+			continue
+		}
+		content, err := io.ReadAll(reader)
+		if err != nil {
+			return nil, fmt.Errorf("can't read %s from archive: %w", header.Name, err)
+		}
+		models = append(models, content)
+	}
+	return models, nil
+}
+
+// parseSemconvModels converts the attributes defined in semantic conventions models into
+// field definitions.
+func parseSemconvModels(models [][]byte) ([]FieldDefinition, error) {
+	found := make(map[string]bool)
+	var fields []FieldDefinition
+	for _, content := range models {
+		var model semconvModel
+		err := yaml.Unmarshal(content, &model)
+		if err != nil {
+			return nil, fmt.Errorf("unmarshalling semantic conventions model failed: %w", err)
+		}
+		for _, group := range model.Groups {
+			for _, attribute := range group.Attributes {
+				// Attributes without id are references to attributes defined in other groups.
+				if attribute.ID == "" || found[attribute.ID] {
+					continue
+				}
+				field, ok := semconvField(attribute)
+				if !ok {
+					logger.Debugf("Ignoring semantic conventions attribute %s with unsupported type", attribute.ID)
+					continue
+				}
+				found[attribute.ID] = true
+				fields = append(fields, field)
+			}
+		}
+	}
+	sort.Slice(fields, func(i, j int) bool { return fields[i].Name < fields[j].Name })
+	return fields, nil
+}
+
+func semconvField(attribute semconvAttribute) (FieldDefinition, bool) {
+	field := FieldDefinition{
+		Name:        attribute.ID,
+		Description: strings.TrimSpace(attribute.Brief),
+		External:    semconvSchemaName,
+	}
+
+	switch attribute.Type.Kind {
+	case yaml.ScalarNode:
+		typ := attribute.Type.Value
+		if template, ok := strings.CutPrefix(typ, "template["); ok {
+			objectType, ok := semconvFieldType(strings.TrimSuffix(template, "]"))
+			if !ok {
+				return field, false
+			}
+			field.Type = "object"
+			field.ObjectType = objectType
+			return field, true
+		}
+		fieldType, ok := semconvFieldType(typ)
+		field.Type = fieldType
+		return field, ok
+	case yaml.MappingNode:
+		// Enums define their members, whose values can be strings or numbers.
+		var enum struct {
+			Members []struct {
+				Value yaml.Node `yaml:"value"`
+			} `yaml:"members"`
+		}
+		if err := attribute.Type.Decode(&enum); err != nil {
+			return field, false
+		}
+		field.Type = "long"
+		for _, member := range enum.Members {
+			switch {
+			case member.Value.Tag == "!!float" && field.Type == "long":
+				field.Type = "double"
+			case member.Value.Tag != "!!int" && member.Value.Tag != "!!float":
+				field.Type = "keyword"
+			}
+		}
+		return field, true
+	}
+	return field, false
+}
+
+// semconvFieldType returns the field type used for a type of attribute. Arrays are mapped to
+// the type of their elements.
+func semconvFieldType(typ string) (string, bool) {
+	switch strings.TrimSuffix(typ, "[]") {
+	case "string":
+		return "keyword", true
+	case "int":
+		return "long", true
+	case "double":
+		return "double", true
+	case "boolean":
+		return "boolean", true
+	}
+	return "", false
+}
+
+// trimSemconvAttributesPrefix removes the object where attributes are stored in OpenTelemetry
+// documents from the name of a field, so it can be found in the semantic conventions.
+func trimSemconvAttributesPrefix(name string) string {
+	for _, prefix := range semconvAttributesPrefixes {
+		if trimmed, ok := strings.CutPrefix(name, prefix+"."); ok {
+			return trimmed
+		}
+	}
+	return name
+}
+
+// validateSemconvAttributes validates the values of the attributes of an OpenTelemetry document
+// that are defined in the semantic conventions. Other attributes are not validated.
+func (v *Validator) validateSemconvAttributes(doc common.MapStr) multierror.Error {
+	var errs multierror.Error
+	for _, prefix := range semconvAttributesPrefixes {
+		attributes, err := doc.GetValue(prefix)
+		if err != nil {
+			continue
+		}
+		errs = append(errs, v.validateSemconvAttributesObject(prefix, "", attributes, doc)...)
+	}
+	return errs
+}
+
+func (v *Validator) validateSemconvAttributesObject(prefix, name string, value any, doc common.MapStr) multierror.Error {
+	var object map[string]any
+	switch value := value.(type) {
+	case common.MapStr:
+		object = value
+	case map[string]any:
+		object = value
+	default:
+		definition := FindElementDefinition(name, v.semconvSchema)
+		if definition == nil {
+			return nil
+		}
+		return v.parseElementValue(prefix+"."+name, *definition, value, doc)
+	}
+
+	var errs multierror.Error
+	for key, child := range object {
+		childName := key
+		if name != "" {
+			childName = name + "." + key
+		}
+		errs = append(errs, v.validateSemconvAttributesObject(prefix, childName, child, doc)...)
+	}
+	return errs
+}
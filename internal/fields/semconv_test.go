@@ -0,0 +1,108 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package fields
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/elastic-package/internal/common"
+	"github.com/elastic/elastic-package/internal/packages/buildmanifest"
+)
+
+func TestParseSemconvModels(t *testing.T) {
+	content, err := os.ReadFile(filepath.Join("testdata", "semconv_model.yml"))
+	require.NoError(t, err)
+
+	fields, err := parseSemconvModels([][]byte{content})
+	require.NoError(t, err)
+
+	var types []string
+	for _, field := range fields {
+		assert.Equal(t, semconvSchemaName, field.External)
+		types = append(types, field.Name+":"+field.Type+":"+field.ObjectType)
+	}
+	assert.Equal(t, []string{
+		"http.request.header:object:keyword",
+		"http.request.method:keyword:",
+		"http.response.status_code:long:",
+		"server.address:keyword:",
+		"server.port:long:",
+	}, types)
+}
+
+func TestDependencyManagerWithSemconv(t *testing.T) {
+	model, err := filepath.Abs(filepath.Join("testdata", "semconv_model.yml"))
+	require.NoError(t, err)
+
+	dm, err := CreateFieldDependencyManager(buildmanifest.Dependencies{
+		Semconv: buildmanifest.SemconvDependency{Reference: "file://" + model},
+	})
+	require.NoError(t, err)
+
+	defs := []common.MapStr{
+		{
+			"name": "attributes",
+			"type": "group",
+			"fields": []any{
+				map[string]any{"name": "http.response.status_code", "external": "semconv"},
+			},
+		},
+		{"name": "resource.attributes.server.address", "external": "semconv"},
+	}
+	result, changed, err := dm.InjectFields(defs)
+	require.NoError(t, err)
+	assert.True(t, changed)
+
+	fields, ok := result[0]["fields"].([]common.MapStr)
+	require.True(t, ok)
+	assert.Equal(t, "long", fields[0]["type"])
+	assert.Equal(t, "keyword", result[1]["type"])
+
+	_, _, err = dm.InjectFields([]common.MapStr{{"name": "attributes.unknown", "external": "semconv"}})
+	assert.Error(t, err)
+}
+
+func TestValidateSemconvAttributes(t *testing.T) {
+	content, err := os.ReadFile(filepath.Join("testdata", "semconv_model.yml"))
+	require.NoError(t, err)
+	schema, err := parseSemconvModels([][]byte{content})
+	require.NoError(t, err)
+
+	validator := &Validator{
+		enabledOTELValidation: true,
+		semconvSchema:         schema,
+	}
+
+	valid := common.MapStr{
+		"attributes": map[string]any{
+			"http.request.method":       "GET",
+			"http.response.status_code": float64(200),
+			"http.request.header": map[string]any{
+				"content-type": []any{"application/json"},
+			},
+			"vendor.custom": "not validated",
+		},
+		"resource": map[string]any{
+			"attributes": map[string]any{
+				"server": map[string]any{"port": float64(8080)},
+			},
+		},
+	}
+	assert.Empty(t, validator.ValidateDocumentMap(valid))
+
+	invalid := common.MapStr{
+		"attributes": map[string]any{
+			"http.response.status_code": "OK",
+		},
+	}
+	errs := validator.ValidateDocumentMap(invalid)
+	require.Len(t, errs, 1)
+	assert.Contains(t, errs[0].Error(), "attributes.http.response.status_code")
+}
@@ -156,6 +156,10 @@ type Validator struct {
 
 	enabledOTELValidation bool
 
+	// semconvSchema contains the semantic conventions attributes the package depends on,
+	// used to validate the attributes of OpenTelemetry documents.
+	semconvSchema []FieldDefinition
+
 	injectFieldsOptions InjectFieldsOptions
 }
 
@@ -260,15 +264,27 @@ type packageRootFinder interface {
 	FindPackageRoot() (string, bool, error)
 }
 
-type packageRoot struct{}
+// packageRoot finds the root of the package containing a directory, or of the package in the
+// working directory if the directory is not in a package.
+type packageRoot struct {
+	fromDir string
+}
 
 func (p packageRoot) FindPackageRoot() (string, bool, error) {
+	fromDir, err := filepath.Abs(p.fromDir)
+	if err != nil {
+		return "", false, err
+	}
+	root, found, err := packages.FindPackageRootFrom(fromDir)
+	if err != nil || found {
+		return root, found, err
+	}
 	return packages.FindPackageRoot()
 }
 
 // CreateValidatorForDirectory function creates a validator for the directory.
 func CreateValidatorForDirectory(fieldsParentDir string, opts ...ValidatorOption) (v *Validator, err error) {
-	p := packageRoot{}
+	p := packageRoot{fromDir: fieldsParentDir}
 	return createValidatorForDirectoryAndPackageRoot(fieldsParentDir, p, opts...)
 }
 
@@ -299,6 +315,9 @@ func createValidatorForDirectoryAndPackageRoot(fieldsParentDir string, finder pa
 		if err != nil {
 			return nil, fmt.Errorf("failed to initialize dependency management: %w", err)
 		}
+		if fdm != nil {
+			v.semconvSchema = fdm.schema[semconvSchemaName]
+		}
 	}
 
 	fields, err := loadFieldsFromDir(fieldsDir, fdm, v.injectFieldsOptions)
@@ -510,6 +529,12 @@ func initializeAllowedCIDRsList() (cidrs []*net.IPNet) {
 	return cidrs
 }
 
+// ReadFieldsFromDir reads the field definitions of the files in a fields directory, without
+// resolving external fields.
+func ReadFieldsFromDir(fieldsDir string) ([]FieldDefinition, error) {
+	return loadFieldsFromDir(fieldsDir, nil, InjectFieldsOptions{})
+}
+
 func loadFieldsFromDir(fieldsDir string, fdm *DependencyManager, injectOptions InjectFieldsOptions) ([]FieldDefinition, error) {
 	files, err := filepath.Glob(filepath.Join(fieldsDir, "*.yml"))
 	if err != nil {
@@ -571,9 +596,12 @@ func (v *Validator) ValidateDocumentMap(body common.MapStr) multierror.Error {
 	errs := v.validateDocumentValues(body)
 
 	// If package uses OpenTelemetry Collector, skip field validation and just
-	// validate document values (datasets).
+	// validate document values (datasets), and the attributes defined in the
+	// semantic conventions the package depends on.
 	if !v.enabledOTELValidation {
 		errs = append(errs, v.validateMapElement("", body, body)...)
+	} else if len(v.semconvSchema) > 0 {
+		errs = append(errs, v.validateSemconvAttributes(body)...)
 	}
 
 	if len(errs) == 0 {
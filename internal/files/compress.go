@@ -5,8 +5,10 @@
 package files
 
 import (
+	"archive/zip"
 	"context"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"strings"
@@ -68,6 +70,63 @@ func Zip(ctx context.Context, sourcePath, destinationFile string) error {
 	return nil
 }
 
+// Unzip extracts the content of the .zip archive into the destination directory.
+func Unzip(zipFile, destinationDir string) error {
+	logger.Debugf("Extract %s (destination: %s)", zipFile, destinationDir)
+
+	r, err := zip.OpenReader(zipFile)
+	if err != nil {
+		return fmt.Errorf("can't open archive %s: %w", zipFile, err)
+	}
+	defer r.Close()
+
+	for _, f := range r.File {
+		path := filepath.Join(destinationDir, filepath.FromSlash(f.Name))
+		if !strings.HasPrefix(path, filepath.Clean(destinationDir)+string(os.PathSeparator)) {
+			return fmt.Errorf("illegal file path in archive: %s", f.Name)
+		}
+
+		if f.FileInfo().IsDir() {
+			err := os.MkdirAll(path, 0o755)
+			if err != nil {
+				return fmt.Errorf("can't create directory %s: %w", path, err)
+			}
+			continue
+		}
+
+		err := extractZipFile(f, path)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func extractZipFile(f *zip.File, path string) error {
+	err := os.MkdirAll(filepath.Dir(path), 0o755)
+	if err != nil {
+		return fmt.Errorf("can't create directory for %s: %w", path, err)
+	}
+
+	in, err := f.Open()
+	if err != nil {
+		return fmt.Errorf("can't open %s in archive: %w", f.Name, err)
+	}
+	defer in.Close()
+
+	out, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("can't create file %s: %w", path, err)
+	}
+	defer out.Close()
+
+	_, err = io.Copy(out, in)
+	if err != nil {
+		return fmt.Errorf("can't extract %s: %w", f.Name, err)
+	}
+	return nil
+}
+
 // folderNameFromFileName returns the folder name from the destination file.
 // Based on mholt/archiver: https://github.com/mholt/archiver/blob/d35d4ce7c5b2411973fb7bd96ca1741eb011011b/archiver.go#L397
 func folderNameFromFileName(filename string) string {
@@ -0,0 +1,64 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package files
+
+import (
+	"archive/zip"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeTestZip(t *testing.T, path string, entries map[string]string) {
+	t.Helper()
+	f, err := os.Create(path)
+	require.NoError(t, err)
+	defer f.Close()
+
+	w := zip.NewWriter(f)
+	for name, content := range entries {
+		entry, err := w.Create(name)
+		require.NoError(t, err)
+		_, err = entry.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, w.Close())
+}
+
+func TestUnzip(t *testing.T) {
+	dir := t.TempDir()
+	zipPath := filepath.Join(dir, "foo-1.0.0.zip")
+	writeTestZip(t, zipPath, map[string]string{
+		"foo-1.0.0/manifest.yml":              "name: foo\n",
+		"foo-1.0.0/data_stream/log/fields/":   "",
+		"foo-1.0.0/docs/README.md":            "# Foo\n",
+		"foo-1.0.0/data_stream/log/agent.hbs": "paths: {{paths}}\n",
+	})
+
+	destination := filepath.Join(dir, "extracted")
+	require.NoError(t, Unzip(zipPath, destination))
+
+	d, err := os.ReadFile(filepath.Join(destination, "foo-1.0.0", "manifest.yml"))
+	require.NoError(t, err)
+	assert.Equal(t, "name: foo\n", string(d))
+	assert.DirExists(t, filepath.Join(destination, "foo-1.0.0", "data_stream", "log", "fields"))
+	assert.FileExists(t, filepath.Join(destination, "foo-1.0.0", "docs", "README.md"))
+	assert.FileExists(t, filepath.Join(destination, "foo-1.0.0", "data_stream", "log", "agent.hbs"))
+}
+
+func TestUnzipIllegalPath(t *testing.T) {
+	dir := t.TempDir()
+	zipPath := filepath.Join(dir, "evil.zip")
+	writeTestZip(t, zipPath, map[string]string{
+		"../outside.txt": "content",
+	})
+
+	err := Unzip(zipPath, filepath.Join(dir, "extracted"))
+	assert.ErrorContains(t, err, "illegal file path")
+	assert.NoFileExists(t, filepath.Join(dir, "outside.txt"))
+}
@@ -28,6 +28,29 @@ func CopyWithoutDev(sourcePath, destinationPath string) error {
 
 // CopyWithSkipped method copies files from the source to the destination, but skips selected directories, empty folders and selected hidden files.
 func CopyWithSkipped(sourcePath, destinationPath string, skippedDirs, skippedFileGlobs []string) error {
+	return walkWithSkipped(sourcePath, skippedDirs, skippedFileGlobs, func(relativePath string) error {
+		return CopyFile(
+			filepath.Join(sourcePath, relativePath),
+			filepath.Join(destinationPath, relativePath))
+	})
+}
+
+// WalkWithoutDev method calls the given function for each file that would be copied by CopyWithoutDev,
+// with its path relative to the source.
+func WalkWithoutDev(sourcePath string, fn func(relativePath string) error) error {
+	return walkWithSkipped(sourcePath, defaultFoldersToSkip, defaultFileGlobsToSkip, fn)
+}
+
+// CopyFile method copies a single file, creating the destination directory if needed.
+func CopyFile(sourcePath, destinationPath string) error {
+	err := os.MkdirAll(filepath.Dir(destinationPath), 0755)
+	if err != nil {
+		return err
+	}
+	return sh.Copy(destinationPath, sourcePath)
+}
+
+func walkWithSkipped(sourcePath string, skippedDirs, skippedFileGlobs []string, fn func(relativePath string) error) error {
 	return filepath.Walk(sourcePath, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
 			return err
@@ -60,14 +83,7 @@ func CopyWithSkipped(sourcePath, destinationPath string, skippedDirs, skippedFil
 			}
 		}
 
-		err = os.MkdirAll(filepath.Join(destinationPath, filepath.Dir(relativePath)), 0755)
-		if err != nil {
-			return err
-		}
-
-		return sh.Copy(
-			filepath.Join(destinationPath, relativePath),
-			filepath.Join(sourcePath, relativePath))
+		return fn(relativePath)
 	})
 }
 
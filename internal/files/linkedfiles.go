@@ -160,6 +160,11 @@ func (lfs *LinksFS) IncludeLinkedFiles(toDir string) ([]Link, error) {
 	return includeLinkedFiles(lfs.repoRoot, lfs.workDir, toDir)
 }
 
+// ListLinkedFiles returns the linked files in the directory.
+func (lfs *LinksFS) ListLinkedFiles() ([]Link, error) {
+	return listLinkedFiles(lfs.repoRoot, lfs.workDir)
+}
+
 // ListLinkedFilesByPackage returns a mapping of packages to their linked files that reference
 // files from the given directory.
 func (lfs *LinksFS) ListLinkedFilesByPackage() ([]PackageLinks, error) {
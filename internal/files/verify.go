@@ -0,0 +1,121 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package files
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/ProtonMail/go-crypto/openpgp/armor"
+	"github.com/ProtonMail/gopenpgp/v2/constants"
+	"github.com/ProtonMail/gopenpgp/v2/crypto"
+
+	"github.com/elastic/elastic-package/internal/logger"
+)
+
+// ErrSignatureNotFound is returned when the file to verify has no signature file.
+var ErrSignatureNotFound = errors.New("signature file not found")
+
+type VerifyOptions struct {
+	// PublicKeyPath is the path to the public key, or keyring, used to verify signatures.
+	// Armored and binary formats are supported.
+	PublicKeyPath string
+}
+
+// Verify function verifies the detached signature in the {targetFile}.sig file against the configured public key
+// or keyring. It returns the version header of the signature, that for signatures created by elastic-package
+// contains the name and the version of the signed package.
+func Verify(targetFile string, options VerifyOptions) (string, error) {
+	if options.PublicKeyPath == "" {
+		return "", errors.New("public key is required to verify signatures")
+	}
+
+	logger.Debugf("Read verifier public keyfile: %s", options.PublicKeyPath)
+	publicKey, err := os.ReadFile(options.PublicKeyPath)
+	if err != nil {
+		return "", fmt.Errorf("can't read the public keyfile (path: %s): %w", options.PublicKeyPath, err)
+	}
+	keyRing, err := readKeyRing(publicKey)
+	if err != nil {
+		return "", fmt.Errorf("can't read the public keyfile (path: %s): %w", options.PublicKeyPath, err)
+	}
+	if !keyRing.CanVerify() {
+		return "", fmt.Errorf("no keys that can verify signatures found (path: %s)", options.PublicKeyPath)
+	}
+
+	targetSigFile := targetFile + ".sig"
+	armoredSignature, err := os.ReadFile(targetSigFile)
+	if errors.Is(err, os.ErrNotExist) {
+		return "", fmt.Errorf("%w: %s", ErrSignatureNotFound, targetSigFile)
+	}
+	if err != nil {
+		return "", fmt.Errorf("can't read the signature file: %w", err)
+	}
+	signature, version, err := readSignature(armoredSignature)
+	if err != nil {
+		return "", fmt.Errorf("can't read the signature file (path: %s): %w", targetSigFile, err)
+	}
+
+	messageReader, err := os.Open(targetFile)
+	if err != nil {
+		return "", fmt.Errorf("os.Open failed (targetFile: %s): %w", targetFile, err)
+	}
+	defer messageReader.Close()
+
+	err = keyRing.VerifyDetachedStream(messageReader, signature, crypto.GetUnixTime())
+	if err != nil {
+		return "", fmt.Errorf("invalid signature (path: %s): %w", targetSigFile, err)
+	}
+
+	logger.Debugf("Signature verified for the target file: %s", targetFile)
+	return version, nil
+}
+
+// readKeyRing reads the keys from armored or binary public keys or keyrings.
+func readKeyRing(data []byte) (*crypto.KeyRing, error) {
+	if !isArmored(data) {
+		return crypto.NewKeyRingFromBinary(data)
+	}
+
+	block, err := armor.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("armor.Decode failed: %w", err)
+	}
+	if block.Type != constants.PublicKeyHeader {
+		return nil, fmt.Errorf("unexpected armor type %q, public key expected", block.Type)
+	}
+	binKeys, err := io.ReadAll(block.Body)
+	if err != nil {
+		return nil, fmt.Errorf("can't read armored keys: %w", err)
+	}
+	return crypto.NewKeyRingFromBinary(binKeys)
+}
+
+// readSignature reads an armored signature, and its version header.
+func readSignature(data []byte) (*crypto.PGPSignature, string, error) {
+	if !isArmored(data) {
+		return crypto.NewPGPSignature(data), "", nil
+	}
+
+	block, err := armor.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", fmt.Errorf("armor.Decode failed: %w", err)
+	}
+	if block.Type != constants.PGPSignatureHeader {
+		return nil, "", fmt.Errorf("unexpected armor type %q, signature expected", block.Type)
+	}
+	binSignature, err := io.ReadAll(block.Body)
+	if err != nil {
+		return nil, "", fmt.Errorf("can't read armored signature: %w", err)
+	}
+	return crypto.NewPGPSignature(binSignature), block.Header["Version"], nil
+}
+
+func isArmored(data []byte) bool {
+	return bytes.HasPrefix(bytes.TrimSpace(data), []byte("-----BEGIN"))
+}
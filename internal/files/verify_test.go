@@ -0,0 +1,107 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package files
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ProtonMail/gopenpgp/v2/crypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeTestKeys generates a key pair, configures it for signing, and returns the path to the
+// armored public key.
+func writeTestKeys(t *testing.T, dir, name string) string {
+	t.Helper()
+	passphrase := []byte("secret")
+
+	key, err := crypto.GenerateKey(name, name+"@example.com", "x25519", 0)
+	require.NoError(t, err)
+	locked, err := key.Lock(passphrase)
+	require.NoError(t, err)
+	armoredPrivateKey, err := locked.Armor()
+	require.NoError(t, err)
+	armoredPublicKey, err := key.GetArmoredPublicKey()
+	require.NoError(t, err)
+
+	privateKeyPath := filepath.Join(dir, name+".private.asc")
+	require.NoError(t, os.WriteFile(privateKeyPath, []byte(armoredPrivateKey), 0600))
+	publicKeyPath := filepath.Join(dir, name+".public.asc")
+	require.NoError(t, os.WriteFile(publicKeyPath, []byte(armoredPublicKey), 0644))
+
+	t.Setenv(signerPrivateKeyfileEnv, privateKeyPath)
+	t.Setenv(signerPassphraseEnv, string(passphrase))
+	return publicKeyPath
+}
+
+func TestVerify(t *testing.T) {
+	dir := t.TempDir()
+	otherPublicKey := writeTestKeys(t, dir, "other")
+	publicKey := writeTestKeys(t, dir, "signer")
+
+	target := filepath.Join(dir, "foo-1.0.0.zip")
+	require.NoError(t, os.WriteFile(target, []byte("package contents"), 0644))
+	require.NoError(t, Sign(target, SignOptions{PackageName: "foo", PackageVersion: "1.0.0"}))
+
+	t.Run("valid signature", func(t *testing.T) {
+		signedFor, err := Verify(target, VerifyOptions{PublicKeyPath: publicKey})
+		require.NoError(t, err)
+		assert.Equal(t, "foo-1.0.0", signedFor)
+	})
+
+	t.Run("keyring", func(t *testing.T) {
+		otherKey, err := os.ReadFile(otherPublicKey)
+		require.NoError(t, err)
+		signerKey, err := os.ReadFile(publicKey)
+		require.NoError(t, err)
+
+		// Binary keyrings are concatenations of binary keys.
+		var binKeys []byte
+		for _, armored := range [][]byte{otherKey, signerKey} {
+			key, err := crypto.NewKeyFromArmored(string(armored))
+			require.NoError(t, err)
+			binKey, err := key.GetPublicKey()
+			require.NoError(t, err)
+			binKeys = append(binKeys, binKey...)
+		}
+		keyRingPath := filepath.Join(dir, "keyring.gpg")
+		require.NoError(t, os.WriteFile(keyRingPath, binKeys, 0644))
+
+		_, err = Verify(target, VerifyOptions{PublicKeyPath: keyRingPath})
+		assert.NoError(t, err)
+	})
+
+	t.Run("other key", func(t *testing.T) {
+		_, err := Verify(target, VerifyOptions{PublicKeyPath: otherPublicKey})
+		assert.ErrorContains(t, err, "invalid signature")
+	})
+
+	t.Run("modified file", func(t *testing.T) {
+		modified := filepath.Join(dir, "modified.zip")
+		require.NoError(t, os.WriteFile(modified, []byte("other contents"), 0644))
+		signature, err := os.ReadFile(target + ".sig")
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(modified+".sig", signature, 0644))
+
+		_, err = Verify(modified, VerifyOptions{PublicKeyPath: publicKey})
+		assert.ErrorContains(t, err, "invalid signature")
+	})
+
+	t.Run("not signed", func(t *testing.T) {
+		unsigned := filepath.Join(dir, "unsigned.zip")
+		require.NoError(t, os.WriteFile(unsigned, []byte("package contents"), 0644))
+
+		_, err := Verify(unsigned, VerifyOptions{PublicKeyPath: publicKey})
+		assert.ErrorIs(t, err, ErrSignatureNotFound)
+	})
+
+	t.Run("no public key", func(t *testing.T) {
+		_, err := Verify(target, VerifyOptions{})
+		assert.Error(t, err)
+	})
+}
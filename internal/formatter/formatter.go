@@ -5,12 +5,19 @@
 package formatter
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
 	"os"
+	"path"
 	"path/filepath"
+	"slices"
+	"strings"
 
 	"github.com/Masterminds/semver/v3"
+	"github.com/pmezard/go-difflib/difflib"
 
+	"github.com/elastic/elastic-package/internal/logger"
 	"github.com/elastic/elastic-package/internal/packages"
 )
 
@@ -19,29 +26,129 @@ const (
 	KeysWithDotActionNested
 )
 
+// Options contains the options to format the files of a package.
+type Options struct {
+	// FailFast makes formatting fail if any file requires updates, instead of overwriting it.
+	FailFast bool
+
+	// Extended enables formatting of ingest pipelines in YAML format, Handlebars templates and
+	// Markdown documents.
+	Extended bool
+}
+
 type formatterOptions struct {
 	extension                 string
 	specVersion               semver.Version
 	preferedKeysWithDotAction int
 
+	// path is the path of the file relative to the package root, with forward slashes.
+	path string
+
 	failFast bool
+	extended bool
 }
 
 type formatter func(content []byte) ([]byte, bool, error)
 
+// FileChange contains the changes needed to format a file.
+type FileChange struct {
+	// Path is the path of the file relative to the package root.
+	Path string
+
+	// Diff is the unified diff between the current and the formatted content of the file.
+	Diff string
+}
+
 func newFormatter(options formatterOptions) formatter {
+	// Files in subdirectories of ingest_pipeline directories are also considered part of the pipelines.
+	inIngestPipelineDir := slices.Contains(strings.Split(path.Dir(options.path), "/"), "ingest_pipeline")
 	switch options.extension {
 	case ".json":
+		if inIngestPipelineDir {
+			// Ingest pipelines in JSON format are not formatted, as they can contain Handlebars templates.
+			return nil
+		}
 		return JSONFormatterBuilder(options.specVersion).Format
 	case ".yaml", ".yml":
+		if inIngestPipelineDir {
+			if !options.extended {
+				return nil
+			}
+			return NewIngestPipelineFormatter().Format
+		}
 		return NewYAMLFormatter(options.preferedKeysWithDotAction).Format
+	case ".hbs":
+		if !options.extended {
+			return nil
+		}
+		return NewHandlebarsFormatter().Format
+	case ".md":
+		if !options.extended {
+			return nil
+		}
+		return NewMarkdownFormatter().Format
 	default:
 		return nil
 	}
 }
 
 // Format method formats files inside of the integration directory.
-func Format(packageRoot string, failFast bool) error {
+func Format(packageRoot string, options Options) error {
+	return walkFormattedFiles(packageRoot, options, func(options formatterOptions, content, formatted []byte) error {
+		filePath := filepath.Join(packageRoot, filepath.FromSlash(options.path))
+		if options.failFast {
+			return fmt.Errorf("file is not formatted (path: %s)", filePath)
+		}
+
+		err := os.WriteFile(filePath, formatted, 0755)
+		if err != nil {
+			return fmt.Errorf("rewriting file failed (path: %s): %w", filePath, err)
+		}
+		return nil
+	})
+}
+
+// Check method returns the files inside of the integration directory that are not formatted, without
+// rewriting them.
+func Check(packageRoot string, options Options) ([]FileChange, error) {
+	options.FailFast = false
+
+	var changes []FileChange
+	err := walkFormattedFiles(packageRoot, options, func(options formatterOptions, content, formatted []byte) error {
+		var diff bytes.Buffer
+		err := difflib.WriteUnifiedDiff(&diff, difflib.UnifiedDiff{
+			A:        diffLines(content),
+			B:        diffLines(formatted),
+			FromFile: "a/" + options.path,
+			ToFile:   "b/" + options.path,
+			Context:  3,
+		})
+		if err != nil {
+			return fmt.Errorf("building diff failed (path: %s): %w", options.path, err)
+		}
+		changes = append(changes, FileChange{Path: options.path, Diff: diff.String()})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return changes, nil
+}
+
+// diffLines splits the content in lines to build unified diffs, marking the last line if it
+// doesn't end with a newline, as git does.
+func diffLines(content []byte) []string {
+	lines := strings.SplitAfter(string(content), "\n")
+	if lines[len(lines)-1] == "" {
+		return lines[:len(lines)-1]
+	}
+	lines[len(lines)-1] += "\n\\ No newline at end of file\n"
+	return lines
+}
+
+// walkFormattedFiles formats the files inside of the integration directory, and calls the given
+// function for every file whose content changes.
+func walkFormattedFiles(packageRoot string, formatOptions Options, onChange func(options formatterOptions, content, formatted []byte) error) error {
 	manifest, err := packages.ReadPackageManifestFromPackageRoot(packageRoot)
 	if err != nil {
 		return fmt.Errorf("failed to read package manifest: %w", err)
@@ -51,22 +158,24 @@ func Format(packageRoot string, failFast bool) error {
 		return fmt.Errorf("failed to parse package format version %q: %w", manifest.SpecVersion, err)
 	}
 
-	err = filepath.Walk(packageRoot, func(path string, info os.FileInfo, err error) error {
+	err = filepath.Walk(packageRoot, func(filePath string, info os.FileInfo, err error) error {
 		if err != nil {
 			return err
 		}
+		if info.IsDir() {
+			return nil
+		}
 
+		relPath, err := filepath.Rel(packageRoot, filePath)
+		if err != nil {
+			return err
+		}
 		options := formatterOptions{
 			specVersion: *specVersion,
 			extension:   filepath.Ext(info.Name()),
-			failFast:    failFast,
-		}
-
-		if info.IsDir() && info.Name() == "ingest_pipeline" {
-			return filepath.SkipDir
-		}
-		if info.IsDir() {
-			return nil
+			path:        filepath.ToSlash(relPath),
+			failFast:    formatOptions.FailFast,
+			extended:    formatOptions.Extended,
 		}
 
 		// Configure handling of keys with dots.
@@ -76,12 +185,27 @@ func Format(packageRoot string, failFast bool) error {
 			}
 		}
 
-		err = formatFile(path, options)
+		generated, err := isGeneratedDocument(packageRoot, options.path)
 		if err != nil {
-			return fmt.Errorf("formatting file failed (path: %s): %w", path, err)
+			return err
+		}
+		if generated {
+			// Documents generated from templates are formatted by formatting their templates.
+			return nil
 		}
 
-		return nil
+		content, formatted, err := formatFile(filePath, options)
+		if errors.Is(err, errFoldedScriptSource) {
+			logger.Warnf("Ingest pipeline not formatted, rewrite its scripts as literal blocks (path: %s): %v", filePath, err)
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("formatting file failed (path: %s): %w", filePath, err)
+		}
+		if formatted == nil {
+			return nil
+		}
+		return onChange(options, content, formatted)
 	})
 	if err != nil {
 		return fmt.Errorf("walking through the integration files failed: %w", err)
@@ -89,33 +213,41 @@ func Format(packageRoot string, failFast bool) error {
 	return nil
 }
 
-func formatFile(path string, options formatterOptions) error {
-	content, err := os.ReadFile(path)
+// isGeneratedDocument checks if the file is a document rendered from a template in _dev/build/docs.
+func isGeneratedDocument(packageRoot, relPath string) (bool, error) {
+	if path.Dir(relPath) != "docs" || path.Ext(relPath) != ".md" {
+		return false, nil
+	}
+	_, err := os.Stat(filepath.Join(packageRoot, "_dev", "build", "docs", path.Base(relPath)))
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
 	if err != nil {
-		return fmt.Errorf("reading file content failed: %w", err)
+		return false, err
 	}
+	return true, nil
+}
 
+// formatFile returns the current and the formatted content of the file, or nil as formatted content
+// if the file is already formatted or can't be formatted.
+func formatFile(filePath string, options formatterOptions) ([]byte, []byte, error) {
 	format := newFormatter(options)
 	if format == nil {
-		return nil // no errors returned as we have few files that will be never formatted (png, svg, log, etc.)
+		return nil, nil, nil // no errors returned as we have few files that will be never formatted (png, svg, log, etc.)
 	}
 
-	newContent, alreadyFormatted, err := format(content)
+	content, err := os.ReadFile(filePath)
 	if err != nil {
-		return fmt.Errorf("formatting file content failed: %w", err)
+		return nil, nil, fmt.Errorf("reading file content failed: %w", err)
 	}
 
-	if alreadyFormatted {
-		return nil
-	}
-
-	if options.failFast {
-		return fmt.Errorf("file is not formatted (path: %s)", path)
+	newContent, alreadyFormatted, err := format(content)
+	if err != nil {
+		return nil, nil, fmt.Errorf("formatting file content failed: %w", err)
 	}
 
-	err = os.WriteFile(path, newContent, 0755)
-	if err != nil {
-		return fmt.Errorf("rewriting file failed (path: %s): %w", path, err)
+	if alreadyFormatted {
+		return nil, nil, nil
 	}
-	return nil
+	return content, newContent, nil
 }
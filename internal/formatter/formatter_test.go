@@ -0,0 +1,85 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package formatter
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheck(t *testing.T) {
+	packageRoot := t.TempDir()
+	files := map[string]string{
+		"manifest.yml":                                "format_version: 3.0.0\nname: vendor\n",
+		"_dev/build/docs/README.md":                   "# Vendor\n{{fields \"log\"}}\n",
+		"docs/README.md":                              "# Vendor\n  \n\n",
+		"data_stream/log/agent/stream/stream.yml.hbs": "paths:\n{{#each paths}}\n  - {{this}}\n{{/each}}\n",
+		"data_stream/log/elasticsearch/ingest_pipeline/default.json":      `{"processors": []}`,
+		"data_stream/log/elasticsearch/ingest_pipeline/nested/other.json": `{"processors": []}`,
+		"data_stream/log/elasticsearch/ingest_pipeline/default.yml":       "processors: []\ndescription: Pipeline\n",
+		"data_stream/log/elasticsearch/ingest_pipeline/formatted.yml":     "description: Pipeline\nprocessors: []\n",
+		"docs/OTHER.md": "Notes",
+	}
+	for path, content := range files {
+		path = filepath.Join(packageRoot, filepath.FromSlash(path))
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	}
+
+	// Only JSON and YAML files are formatted by default.
+	changes, err := Check(packageRoot, Options{})
+	require.NoError(t, err)
+	assert.Empty(t, changes)
+
+	options := Options{Extended: true}
+	changes, err = Check(packageRoot, options)
+	require.NoError(t, err)
+	assert.Equal(t, []FileChange{
+		{
+			Path: "_dev/build/docs/README.md",
+			Diff: `--- a/_dev/build/docs/README.md
++++ b/_dev/build/docs/README.md
+@@ -1,2 +1,3 @@
+ # Vendor
++
+ {{fields "log"}}
+`,
+		},
+		{
+			Path: "data_stream/log/elasticsearch/ingest_pipeline/default.yml",
+			Diff: `--- a/data_stream/log/elasticsearch/ingest_pipeline/default.yml
++++ b/data_stream/log/elasticsearch/ingest_pipeline/default.yml
+@@ -1,2 +1,2 @@
++description: Pipeline
+ processors: []
+-description: Pipeline
+`,
+		},
+		{
+			Path: "docs/OTHER.md",
+			Diff: `--- a/docs/OTHER.md
++++ b/docs/OTHER.md
+@@ -1 +1 @@
+-Notes
+\ No newline at end of file
++Notes
+`,
+		},
+	}, changes)
+
+	// Files are not rewritten.
+	content, err := os.ReadFile(filepath.Join(packageRoot, "data_stream", "log", "elasticsearch", "ingest_pipeline", "default.yml"))
+	require.NoError(t, err)
+	assert.Equal(t, files["data_stream/log/elasticsearch/ingest_pipeline/default.yml"], string(content))
+
+	require.NoError(t, Format(packageRoot, options))
+	changes, err = Check(packageRoot, options)
+	require.NoError(t, err)
+	assert.Empty(t, changes)
+}
@@ -0,0 +1,68 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package formatter
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	handlebarsExpressionPattern = regexp.MustCompile(`\{\{(~?)([^{}]*?)(~?)\}\}`)
+	handlebarsStandalonePattern = regexp.MustCompile(`^\{\{[^{}]*\}\}$`)
+)
+
+// HandlebarsFormatter is responsible for formatting Handlebars templates, as the ones used for agent streams.
+type HandlebarsFormatter struct{}
+
+func NewHandlebarsFormatter() *HandlebarsFormatter {
+	return &HandlebarsFormatter{}
+}
+
+// Format formats the Handlebars template. Spaces around expressions and whitespace control characters are
+// removed, block helpers standing alone in their lines are aligned with their opening tags, and trailing
+// whitespaces are removed.
+func (f *HandlebarsFormatter) Format(content []byte) ([]byte, bool, error) {
+	lines := strings.Split(strings.TrimRight(string(content), " \t\r\n"), "\n")
+
+	var openers []string
+	for i, line := range lines {
+		line = strings.TrimRight(line, " \t\r")
+		line = handlebarsExpressionPattern.ReplaceAllStringFunc(line, formatHandlebarsExpression)
+
+		trimmed := strings.TrimLeft(line, " \t")
+		indentation := line[:len(line)-len(trimmed)]
+		if handlebarsStandalonePattern.MatchString(trimmed) {
+			switch tag := strings.TrimPrefix(trimmed[2:], "~"); {
+			case strings.HasPrefix(tag, "#"), strings.HasPrefix(tag, "^") && tag != "^}}" && tag != "^~}}":
+				openers = append(openers, indentation)
+			case strings.HasPrefix(tag, "/"):
+				if len(openers) > 0 {
+					indentation = openers[len(openers)-1]
+					openers = openers[:len(openers)-1]
+				}
+			case strings.HasPrefix(tag, "else"), strings.HasPrefix(tag, "^"):
+				if len(openers) > 0 {
+					indentation = openers[len(openers)-1]
+				}
+			}
+			line = indentation + trimmed
+		}
+		lines[i] = line
+	}
+
+	formatted := strings.Join(lines, "\n") + "\n"
+	return []byte(formatted), string(content) == formatted, nil
+}
+
+// formatHandlebarsExpression removes the spaces around a Handlebars expression. Comments are kept as they are.
+func formatHandlebarsExpression(expression string) string {
+	match := handlebarsExpressionPattern.FindStringSubmatch(expression)
+	body := strings.Trim(match[2], " \t")
+	if strings.HasPrefix(body, "!") {
+		return expression
+	}
+	return "{{" + match[1] + body + match[3] + "}}"
+}
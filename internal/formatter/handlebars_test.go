@@ -0,0 +1,65 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package formatter
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandlebarsFormatter(t *testing.T) {
+	cases := []struct {
+		title    string
+		doc      string
+		expected string
+	}{
+		{
+			title:    "already formatted",
+			doc:      "hosts:\n{{#each hosts}}\n  - {{this}}\n{{/each}}\n",
+			expected: "hosts:\n{{#each hosts}}\n  - {{this}}\n{{/each}}\n",
+		},
+		{
+			title:    "spaces in expressions",
+			doc:      "period: {{ period }}\n{{~ tags ~}}\n{{{ raw }}}\n{{! keep this comment }}\n",
+			expected: "period: {{period}}\n{{~tags~}}\n{{{raw}}}\n{{! keep this comment }}\n",
+		},
+		{
+			title: "blocks aligned with their opening tags",
+			doc: `{{#if leaderelection }}
+  {{#if condition}}
+condition: {{ condition }}
+{{ else }}
+condition: true
+    {{/if}}
+{{/if}}
+`,
+			expected: `{{#if leaderelection}}
+  {{#if condition}}
+condition: {{condition}}
+  {{else}}
+condition: true
+  {{/if}}
+{{/if}}
+`,
+		},
+		{
+			title:    "trailing whitespaces",
+			doc:      "request.body: \n  {{request_body}}\n\n\n",
+			expected: "request.body:\n  {{request_body}}\n",
+		},
+	}
+
+	formatter := NewHandlebarsFormatter().Format
+	for _, c := range cases {
+		t.Run(c.title, func(t *testing.T) {
+			result, alreadyFormatted, err := formatter([]byte(c.doc))
+			require.NoError(t, err)
+			assert.Equal(t, c.expected, string(result))
+			assert.Equal(t, c.doc == c.expected, alreadyFormatted)
+		})
+	}
+}
@@ -0,0 +1,184 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package formatter
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ingestPipelineKeysOrder is the canonical order of the top-level keys of ingest pipelines. Other
+// keys are kept after these ones, in their original order.
+var ingestPipelineKeysOrder = []string{"description", "version", "processors", "on_failure", "_meta"}
+
+// errFoldedScriptSource is returned when a script source is written in a folded block that can't be
+// written as a literal block without changing its value.
+var errFoldedScriptSource = errors.New("script source in folded block can't be written as literal block without changing its value")
+
+// IngestPipelineFormatter is responsible for formatting ingest pipelines in YAML format.
+type IngestPipelineFormatter struct{}
+
+func NewIngestPipelineFormatter() *IngestPipelineFormatter {
+	return &IngestPipelineFormatter{}
+}
+
+// Format formats the ingest pipeline. Top-level keys are sorted in a canonical order, the order of the keys of
+// processors is kept, and the multiline sources of script processors are written as literal blocks. Pipelines with
+// scripts in folded blocks that would change their values are not formatted.
+func (f *IngestPipelineFormatter) Format(content []byte) ([]byte, bool, error) {
+	var node yaml.Node
+	err := yaml.Unmarshal(content, &node)
+	if err != nil {
+		return nil, false, fmt.Errorf("unmarshalling YAML file failed: %w", err)
+	}
+
+	if len(node.Content) > 0 && node.Content[0].Kind == yaml.MappingNode {
+		pipeline := node.Content[0]
+		sortPipelineKeys(pipeline)
+		lines := strings.Split(string(content), "\n")
+		folded := formatProcessors(lines, mappingValue(pipeline, "processors"))
+		folded = append(folded, formatProcessors(lines, mappingValue(pipeline, "on_failure"))...)
+		if len(folded) > 0 {
+			// Encoding would also reflow the folded blocks, so the pipeline is not rewritten.
+			return nil, false, fmt.Errorf("%w (lines: %v)", errFoldedScriptSource, folded)
+		}
+	}
+
+	return encodeYAML(content, &node)
+}
+
+func sortPipelineKeys(node *yaml.Node) {
+	type pair struct {
+		key, value *yaml.Node
+	}
+	var pairs []pair
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		pairs = append(pairs, pair{node.Content[i], node.Content[i+1]})
+	}
+
+	rank := func(key string) int {
+		if i := slices.Index(ingestPipelineKeysOrder, key); i >= 0 {
+			return i
+		}
+		return len(ingestPipelineKeysOrder)
+	}
+	slices.SortStableFunc(pairs, func(a, b pair) int {
+		return rank(a.key.Value) - rank(b.key.Value)
+	})
+
+	node.Content = node.Content[:0]
+	for _, p := range pairs {
+		node.Content = append(node.Content, p.key, p.value)
+	}
+}
+
+// formatProcessors formats a list of processors, including the processors nested in "on_failure"
+// handlers and in "foreach" processors. It returns the lines of the script sources in folded blocks
+// that can't be written as literal blocks.
+func formatProcessors(lines []string, node *yaml.Node) []int {
+	if node == nil || node.Kind != yaml.SequenceNode {
+		return nil
+	}
+	var folded []int
+	for _, processor := range node.Content {
+		if processor.Kind != yaml.MappingNode {
+			continue
+		}
+		for i := 0; i+1 < len(processor.Content); i += 2 {
+			folded = append(folded, formatProcessor(lines, processor.Content[i].Value, processor.Content[i+1])...)
+		}
+	}
+	return folded
+}
+
+func formatProcessor(lines []string, processorType string, config *yaml.Node) []int {
+	if config.Kind != yaml.MappingNode {
+		return nil
+	}
+	var folded []int
+	if processorType == "script" {
+		for i := 0; i+1 < len(config.Content); i += 2 {
+			if config.Content[i].Value != "source" {
+				continue
+			}
+			if !formatScriptSource(lines, config.Content[i], config.Content[i+1]) {
+				folded = append(folded, config.Content[i].Line)
+			}
+		}
+	}
+	if processorType == "foreach" {
+		if processor := mappingValue(config, "processor"); processor != nil && processor.Kind == yaml.MappingNode {
+			for i := 0; i+1 < len(processor.Content); i += 2 {
+				folded = append(folded, formatProcessor(lines, processor.Content[i].Value, processor.Content[i+1])...)
+			}
+		}
+	}
+	return append(folded, formatProcessors(lines, mappingValue(config, "on_failure"))...)
+}
+
+// formatScriptSource writes multiline painless scripts as literal blocks. Scripts in folded blocks are
+// only rewritten if their values don't change when written as literal blocks, as folding joins lines,
+// otherwise it returns false.
+func formatScriptSource(contentLines []string, key, node *yaml.Node) bool {
+	if node.Kind != yaml.ScalarNode || node.Tag != "!!str" {
+		return true
+	}
+	if node.Style == yaml.FoldedStyle {
+		value, found := blockScalarSource(contentLines, key, node)
+		if !found || value != node.Value {
+			return false
+		}
+	}
+	if strings.Contains(strings.TrimRight(node.Value, "\n"), "\n") {
+		node.Style = yaml.LiteralStyle
+	}
+	return true
+}
+
+// blockScalarSource returns the value of a block scalar as it is written in the content, without folding
+// its lines. The lines of the block are the ones indented deeper than its key.
+func blockScalarSource(contentLines []string, key, node *yaml.Node) (string, bool) {
+	var block []string
+	indentation := -1
+	for _, line := range contentLines[min(node.Line, len(contentLines)):] {
+		trimmed := strings.TrimLeft(line, " ")
+		if strings.TrimSpace(line) == "" {
+			block = append(block, "")
+			continue
+		}
+		if indentation < 0 {
+			indentation = len(line) - len(trimmed)
+		}
+		if len(line)-len(trimmed) < indentation {
+			break
+		}
+		block = append(block, line[indentation:])
+	}
+	if indentation <= key.Column-1 {
+		return "", false
+	}
+
+	value := strings.TrimRight(strings.Join(block, "\n"), "\n")
+	if strings.HasSuffix(node.Value, "\n") {
+		value += "\n"
+	}
+	return value, true
+}
+
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
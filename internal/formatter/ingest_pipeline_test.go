@@ -0,0 +1,141 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package formatter
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIngestPipelineFormatter(t *testing.T) {
+	cases := []struct {
+		title    string
+		doc      string
+		expected string
+	}{
+		{
+			title: "canonical order of top-level keys",
+			doc: `---
+on_failure:
+  - set:
+      value: failed
+      field: error.message
+_meta:
+  managed: true
+processors:
+  - set:
+      value: bar
+      field: foo
+      tag: set_foo
+description: Pipeline
+`,
+			expected: `---
+description: Pipeline
+processors:
+  - set:
+      value: bar
+      field: foo
+      tag: set_foo
+on_failure:
+  - set:
+      value: failed
+      field: error.message
+_meta:
+  managed: true
+`,
+		},
+		{
+			title: "script sources as literal blocks",
+			doc: `processors:
+  - script:
+      lang: painless
+      source: "def a = 1;\nctx.a = a;\n"
+  - script:
+      source: >-
+        if (ctx.a == null) {
+          return;
+        }
+  - script:
+      source: ctx.c = 1;
+`,
+			expected: `processors:
+  - script:
+      lang: painless
+      source: |
+        def a = 1;
+        ctx.a = a;
+  - script:
+      source: |-
+        if (ctx.a == null) {
+          return;
+        }
+  - script:
+      source: ctx.c = 1;
+`,
+		},
+		{
+			title: "nested processors",
+			doc: `processors:
+  - foreach:
+      field: values
+      processor:
+        script:
+          source: "ctx.a = 1;\nctx.b = 2;"
+  - set:
+      field: foo
+      value: bar
+      on_failure:
+        - script:
+            source: "ctx.a = 1;\nctx.b = 2;"
+`,
+			expected: `processors:
+  - foreach:
+      field: values
+      processor:
+        script:
+          source: |-
+            ctx.a = 1;
+            ctx.b = 2;
+  - set:
+      field: foo
+      value: bar
+      on_failure:
+        - script:
+            source: |-
+              ctx.a = 1;
+              ctx.b = 2;
+`,
+		},
+	}
+
+	formatter := NewIngestPipelineFormatter().Format
+	for _, c := range cases {
+		t.Run(c.title, func(t *testing.T) {
+			result, alreadyFormatted, err := formatter([]byte(c.doc))
+			require.NoError(t, err)
+			assert.Equal(t, c.expected, string(result))
+			assert.False(t, alreadyFormatted)
+
+			_, alreadyFormatted, err = formatter(result)
+			require.NoError(t, err)
+			assert.True(t, alreadyFormatted)
+		})
+	}
+}
+
+func TestIngestPipelineFormatterFoldedScript(t *testing.T) {
+	doc := `processors:
+  - script:
+      source: >-
+        if (ctx.a == null) {
+          return;
+        }
+        ctx.b = ctx.a;
+`
+	_, _, err := NewIngestPipelineFormatter().Format([]byte(doc))
+	assert.ErrorIs(t, err, errFoldedScriptSource)
+}
@@ -0,0 +1,81 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package formatter
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	markdownFencePattern   = regexp.MustCompile("^ {0,3}(```+|~~~+)")
+	markdownHeadingPattern = regexp.MustCompile(`^ {0,3}#{1,6}([ \t]|$)`)
+)
+
+// MarkdownFormatter is responsible for formatting Markdown documents.
+type MarkdownFormatter struct{}
+
+func NewMarkdownFormatter() *MarkdownFormatter {
+	return &MarkdownFormatter{}
+}
+
+// Format formats the Markdown document. Out of code blocks, trailing whitespaces are removed except for
+// hard line breaks, consecutive blank lines are collapsed, and headings are surrounded by blank lines.
+func (f *MarkdownFormatter) Format(content []byte) ([]byte, bool, error) {
+	var lines []string
+	blank := func() bool {
+		return len(lines) == 0 || lines[len(lines)-1] == ""
+	}
+
+	fence := ""
+	afterHeading := false
+	for _, line := range strings.Split(string(content), "\n") {
+		line = strings.TrimSuffix(line, "\r")
+		if fence != "" {
+			lines = append(lines, line)
+			if strings.HasPrefix(strings.TrimLeft(line, " "), fence) && strings.Trim(line, " \t"+fence[:1]) == "" {
+				fence = ""
+			}
+			continue
+		}
+
+		line = trimMarkdownLine(line)
+		if line == "" {
+			if !blank() {
+				lines = append(lines, line)
+			}
+			afterHeading = false
+			continue
+		}
+		if afterHeading {
+			lines = append(lines, "")
+			afterHeading = false
+		}
+
+		if match := markdownFencePattern.FindStringSubmatch(line); match != nil {
+			fence = match[1]
+		}
+		if markdownHeadingPattern.MatchString(line) {
+			if !blank() {
+				lines = append(lines, "")
+			}
+			afterHeading = true
+		}
+		lines = append(lines, line)
+	}
+
+	formatted := strings.TrimRight(strings.Join(lines, "\n"), "\n") + "\n"
+	return []byte(formatted), string(content) == formatted, nil
+}
+
+// trimMarkdownLine removes trailing whitespaces of the line, keeping two spaces if they are
+// used as hard line break.
+func trimMarkdownLine(line string) string {
+	trimmed := strings.TrimRight(line, " \t")
+	if trimmed != "" && strings.HasSuffix(line, "  ") {
+		return trimmed + "  "
+	}
+	return trimmed
+}
@@ -0,0 +1,51 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package formatter
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMarkdownFormatter(t *testing.T) {
+	cases := []struct {
+		title    string
+		doc      string
+		expected string
+	}{
+		{
+			title:    "already formatted",
+			doc:      "# Title\n\nSome text.\n",
+			expected: "# Title\n\nSome text.\n",
+		},
+		{
+			title:    "blank lines around headings",
+			doc:      "{{- generatedHeader }}\n# Title\nSome text.\n## Section\n{{fields \"access\"}}",
+			expected: "{{- generatedHeader }}\n\n# Title\n\nSome text.\n\n## Section\n\n{{fields \"access\"}}\n",
+		},
+		{
+			title:    "trailing whitespaces and hard line breaks",
+			doc:      "\n\nSome text. \nWith a hard break   \nand more text.\t\n\n\n\nEnd.\n\n",
+			expected: "Some text.\nWith a hard break  \nand more text.\n\nEnd.\n",
+		},
+		{
+			title:    "code blocks are kept",
+			doc:      "Example:\n\n```yaml\n# Not a heading\n\n\nkey: value  \n```\n",
+			expected: "Example:\n\n```yaml\n# Not a heading\n\n\nkey: value  \n```\n",
+		},
+	}
+
+	formatter := NewMarkdownFormatter().Format
+	for _, c := range cases {
+		t.Run(c.title, func(t *testing.T) {
+			result, alreadyFormatted, err := formatter([]byte(c.doc))
+			require.NoError(t, err)
+			assert.Equal(t, c.expected, string(result))
+			assert.Equal(t, c.doc == c.expected, alreadyFormatted)
+		})
+	}
+}
@@ -34,10 +34,16 @@ func (f *YAMLFormatter) Format(content []byte) ([]byte, bool, error) {
 
 	applyActionOnKeysWithDots(&node, f.keysWithDotsAction)
 
+	return encodeYAML(content, &node)
+}
+
+// encodeYAML encodes the node with the formatting options used for YAML files, and checks if the
+// result is equal to the original content.
+func encodeYAML(content []byte, node *yaml.Node) ([]byte, bool, error) {
 	var b bytes.Buffer
 	encoder := yaml.NewEncoder(&b)
 	encoder.SetIndent(2)
-	err = encoder.Encode(&node)
+	err := encoder.Encode(node)
 	if err != nil {
 		return nil, false, fmt.Errorf("marshalling YAML node failed: %w", err)
 	}
@@ -0,0 +1,30 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package git
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+
+	"github.com/elastic/elastic-package/internal/logger"
+)
+
+// Run function runs the git command with the given arguments in the directory, and returns
+// its output.
+func Run(ctx context.Context, dir string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	errOutput := new(bytes.Buffer)
+	cmd.Stderr = errOutput
+
+	logger.Debugf("run command: %s", cmd)
+	output, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("git command failed (stderr=%q): %w", errOutput.String(), err)
+	}
+	return string(output), nil
+}
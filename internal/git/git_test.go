@@ -0,0 +1,28 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package git
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRun(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	_, err := Run(ctx, dir, "init", "-q")
+	require.NoError(t, err)
+
+	output, err := Run(ctx, dir, "rev-parse", "--is-inside-work-tree")
+	require.NoError(t, err)
+	assert.Equal(t, "true\n", output)
+
+	_, err = Run(ctx, dir, "rev-parse", "--verify", "missing-ref")
+	assert.ErrorContains(t, err, "git command failed")
+}
@@ -19,6 +19,13 @@ type FleetOutput struct {
 	Hosts []string  `json:"hosts,omitempty"`
 	Type  string    `json:"type,omitempty"`
 	SSL   *AgentSSL `json:"ssl,omitempty"`
+
+	// Settings of Kafka outputs.
+	Topic    string `json:"topic,omitempty"`
+	AuthType string `json:"auth_type,omitempty"`
+
+	// Settings of remote Elasticsearch outputs.
+	ServiceToken string `json:"service_token,omitempty"`
 }
 
 type FleetServerHost struct {
@@ -328,3 +328,114 @@ func (c *Client) DeletePackagePolicy(ctx context.Context, p PackagePolicy) error
 
 	return nil
 }
+
+// PackagePolicyInfo contains the identification of a package policy and the version
+// of the package it uses.
+type PackagePolicyInfo struct {
+	ID      string `json:"id"`
+	Name    string `json:"name"`
+	Package struct {
+		Name    string `json:"name"`
+		Version string `json:"version"`
+	} `json:"package"`
+}
+
+// ListPolicyPackagePolicies lists the package policies included in the given Policy.
+func (c *Client) ListPolicyPackagePolicies(ctx context.Context, policyID string) ([]PackagePolicyInfo, error) {
+	statusCode, respBody, err := c.get(ctx, fmt.Sprintf("%s/agent_policies/%s", FleetAPI, policyID))
+	if err != nil {
+		return nil, fmt.Errorf("could not get policy: %w", err)
+	}
+	if statusCode == http.StatusNotFound {
+		return nil, &ErrPolicyNotFound{id: policyID}
+	}
+	if statusCode != http.StatusOK {
+		return nil, fmt.Errorf("could not get policy; API status code = %d; response body = %s", statusCode, respBody)
+	}
+
+	var resp struct {
+		Item struct {
+			PackagePolicies []PackagePolicyInfo `json:"package_policies"`
+		} `json:"item"`
+	}
+
+	if err := json.Unmarshal(respBody, &resp); err != nil {
+		return nil, fmt.Errorf("could not convert policy (response) to JSON: %w", err)
+	}
+
+	return resp.Item.PackagePolicies, nil
+}
+
+// PackagePolicyUpgradeResult is the result of upgrading a package policy, or of checking
+// if it can be upgraded.
+type PackagePolicyUpgradeResult struct {
+	ID         string `json:"id"`
+	Name       string `json:"name"`
+	Success    bool   `json:"success"`
+	HasErrors  bool   `json:"hasErrors"`
+	StatusCode int    `json:"statusCode"`
+	Body       struct {
+		Message string `json:"message"`
+	} `json:"body"`
+
+	// Diff contains the current and the proposed package policies in dry runs.
+	Diff []struct {
+		Errors []struct {
+			Key     string `json:"key"`
+			Message string `json:"message"`
+		} `json:"errors"`
+	} `json:"diff"`
+}
+
+// Failed returns true if the upgrade of the package policy failed or would fail.
+func (r PackagePolicyUpgradeResult) Failed(dryRun bool) bool {
+	if dryRun {
+		return r.HasErrors
+	}
+	return !r.Success
+}
+
+// Errors returns the errors reported for the upgrade of the package policy.
+func (r PackagePolicyUpgradeResult) Errors() []string {
+	var errs []string
+	if r.Body.Message != "" {
+		errs = append(errs, r.Body.Message)
+	}
+	for _, diff := range r.Diff {
+		for _, e := range diff.Errors {
+			errs = append(errs, fmt.Sprintf("%s: %s", e.Key, e.Message))
+		}
+	}
+	return errs
+}
+
+// UpgradePackagePolicies upgrades the given package policies to the installed version of their
+// packages. If dryRun is set, Fleet only checks if the policies can be upgraded without conflicts.
+func (c *Client) UpgradePackagePolicies(ctx context.Context, packagePolicyIDs []string, dryRun bool) ([]PackagePolicyUpgradeResult, error) {
+	reqBody, err := json.Marshal(struct {
+		PackagePolicyIDs []string `json:"packagePolicyIds"`
+	}{
+		PackagePolicyIDs: packagePolicyIDs,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("could not convert package policies upgrade (request) to JSON: %w", err)
+	}
+
+	path := fmt.Sprintf("%s/package_policies/upgrade", FleetAPI)
+	if dryRun {
+		path += "/dryrun"
+	}
+	statusCode, respBody, err := c.post(ctx, path, reqBody)
+	if err != nil {
+		return nil, fmt.Errorf("could not upgrade package policies: %w", err)
+	}
+	if statusCode != http.StatusOK {
+		return nil, fmt.Errorf("could not upgrade package policies; API status code = %d; response body = %s", statusCode, respBody)
+	}
+
+	var results []PackagePolicyUpgradeResult
+	if err := json.Unmarshal(respBody, &results); err != nil {
+		return nil, fmt.Errorf("could not convert package policies upgrade (response) from JSON: %w", err)
+	}
+	return results, nil
+}
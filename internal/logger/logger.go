@@ -6,6 +6,7 @@ package logger
 
 import (
 	"fmt"
+	"io"
 	"log"
 )
 
@@ -99,6 +100,13 @@ func Errorf(format string, a ...interface{}) {
 	logMessagef("ERROR", format, a...)
 }
 
+// SetOutput sets the destination of the log messages, and returns the previous one.
+func SetOutput(w io.Writer) io.Writer {
+	previous := log.Writer()
+	log.SetOutput(w)
+	return previous
+}
+
 func logMessage(level string, a ...interface{}) {
 	var all []interface{}
 	all = append(all, fmt.Sprintf("%5s ", level))
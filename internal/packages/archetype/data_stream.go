@@ -86,7 +86,7 @@ func CreateDataStream(dataStreamDescriptor DataStreamDescriptor) error {
 	}
 
 	logger.Debugf("Format the entire package")
-	err = formatter.Format(dataStreamDescriptor.PackageRoot, false)
+	err = formatter.Format(dataStreamDescriptor.PackageRoot, formatter.Options{})
 	if err != nil {
 		return fmt.Errorf("can't format the new data stream: %w", err)
 	}
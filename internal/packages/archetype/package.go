@@ -118,7 +118,7 @@ func createPackageInDir(packageDescriptor PackageDescriptor, cwd string) error {
 	}
 
 	logger.Debugf("Format the entire package")
-	err = formatter.Format(baseDir, false)
+	err = formatter.Format(baseDir, formatter.Options{})
 	if err != nil {
 		return fmt.Errorf("can't format the new package: %w", err)
 	}
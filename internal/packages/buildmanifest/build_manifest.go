@@ -12,6 +12,8 @@ import (
 
 	"github.com/elastic/go-ucfg"
 	"github.com/elastic/go-ucfg/yaml"
+
+	"github.com/elastic/elastic-package/internal/packages"
 )
 
 // BuildManifest defines the manifest defining the building procedure.
@@ -21,7 +23,8 @@ type BuildManifest struct {
 
 // Dependencies define external package dependencies.
 type Dependencies struct {
-	ECS ECSDependency `config:"ecs"`
+	ECS     ECSDependency     `config:"ecs"`
+	Semconv SemconvDependency `config:"semconv"`
 }
 
 // ECSDependency defines a dependency on ECS fields.
@@ -30,9 +33,14 @@ type ECSDependency struct {
 	ImportMappings bool   `config:"import_mappings"`
 }
 
+// SemconvDependency defines a dependency on OpenTelemetry semantic conventions attributes.
+type SemconvDependency struct {
+	Reference string `config:"reference"`
+}
+
 // HasDependencies function checks if there are any dependencies defined.
 func (bm *BuildManifest) HasDependencies() bool {
-	return bm.Dependencies.ECS.Reference != ""
+	return bm.Dependencies.ECS.Reference != "" || bm.Dependencies.Semconv.Reference != ""
 }
 
 // ImportMappings function checks if there are any dependencies defined.
@@ -56,6 +64,17 @@ func ReadBuildManifest(packageRoot string) (*BuildManifest, bool, error) {
 	if err != nil {
 		return nil, true, fmt.Errorf("unpacking build manifest failed (path: %s): %w", path, err)
 	}
+
+	if bm.Dependencies.Semconv.Reference != "" {
+		manifest, err := packages.ReadPackageManifestFromPackageRoot(packageRoot)
+		if err != nil {
+			return nil, true, fmt.Errorf("reading package manifest failed: %w", err)
+		}
+		err = checkSemconvSupport(manifest.SpecVersion)
+		if err != nil {
+			return nil, true, fmt.Errorf("invalid build manifest: %w", err)
+		}
+	}
 	return &bm, true, nil
 }
 
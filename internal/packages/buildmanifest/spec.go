@@ -0,0 +1,111 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package buildmanifest
+
+import (
+	"fmt"
+	"io/fs"
+	"slices"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+	spec "github.com/elastic/package-spec/v3"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	buildManifestSpecFile = "integration/_dev/build/build.spec.yml"
+	fieldsSpecFile        = "integration/data_stream/fields/fields.spec.yml"
+)
+
+// specFile is the format of the files of the package spec. The schema of the latest version is
+// defined in spec, and previous versions are obtained by applying the patches in versions.
+type specFile struct {
+	Spec     any `yaml:"spec"`
+	Versions []struct {
+		Before string `yaml:"before"`
+		Patch  []struct {
+			Path string `yaml:"path"`
+		} `yaml:"patch"`
+	} `yaml:"versions"`
+}
+
+// checkSemconvSupport checks that the package spec accepts the semantic conventions dependency
+// in the build manifest, and fields imported from it, for the given format version.
+func checkSemconvSupport(formatVersion string) error {
+	version, err := semver.NewVersion(formatVersion)
+	if err != nil {
+		return fmt.Errorf("invalid format version %q: %w", formatVersion, err)
+	}
+
+	checks := []struct {
+		file  string
+		path  []string
+		value string
+	}{
+		{file: buildManifestSpecFile, path: []string{"properties", "dependencies", "properties", "semconv"}},
+		{file: fieldsSpecFile, path: []string{"items", "properties", "external", "enum"}, value: "semconv"},
+	}
+	for _, check := range checks {
+		supported, err := specSupports(spec.FS(), check.file, *version, check.path, check.value)
+		if err != nil {
+			return fmt.Errorf("can't read package spec: %w", err)
+		}
+		if !supported {
+			return fmt.Errorf("semconv dependency is not supported by the package spec for format version %s", formatVersion)
+		}
+	}
+	return nil
+}
+
+// specSupports checks if the schema of a spec file defines the given path, and if the path is
+// not removed or replaced by the patches for the given version. If a value is given, the path
+// must be a list containing it.
+func specSupports(specFS fs.FS, file string, version semver.Version, path []string, value string) (bool, error) {
+	content, err := fs.ReadFile(specFS, file)
+	if err != nil {
+		return false, err
+	}
+	var sf specFile
+	err = yaml.Unmarshal(content, &sf)
+	if err != nil {
+		return false, fmt.Errorf("unmarshalling %s failed: %w", file, err)
+	}
+
+	node := sf.Spec
+	for _, key := range path {
+		m, ok := node.(map[string]any)
+		if !ok {
+			return false, nil
+		}
+		node, ok = m[key]
+		if !ok {
+			return false, nil
+		}
+	}
+	if value != "" {
+		values, ok := node.([]any)
+		if !ok || !slices.Contains(values, any(value)) {
+			return false, nil
+		}
+	}
+
+	pointer := "/" + strings.Join(path, "/")
+	for _, v := range sf.Versions {
+		before, err := semver.NewVersion(v.Before)
+		if err != nil {
+			return false, fmt.Errorf("invalid version %q in %s: %w", v.Before, file, err)
+		}
+		if !version.LessThan(before) {
+			continue
+		}
+		for _, patch := range v.Patch {
+			if patch.Path == pointer || strings.HasPrefix(patch.Path, pointer+"/") || strings.HasPrefix(pointer, patch.Path+"/") {
+				return false, nil
+			}
+		}
+	}
+	return true, nil
+}
@@ -0,0 +1,71 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package buildmanifest
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSpecSupports(t *testing.T) {
+	specFS := fstest.MapFS{
+		"build.spec.yml": {Data: []byte(`spec:
+  properties:
+    dependencies:
+      properties:
+        ecs:
+          type: object
+        semconv:
+          type: object
+versions:
+  - before: 3.6.0
+    patch:
+      - op: remove
+        path: "/properties/dependencies/properties/semconv"
+`)},
+		"fields.spec.yml": {Data: []byte(`spec:
+  items:
+    properties:
+      external:
+        enum:
+          - ecs
+`)},
+	}
+	semconvPath := []string{"properties", "dependencies", "properties", "semconv"}
+	externalPath := []string{"items", "properties", "external", "enum"}
+
+	cases := []struct {
+		title     string
+		file      string
+		version   string
+		path      []string
+		value     string
+		supported bool
+	}{
+		{title: "defined", file: "build.spec.yml", version: "3.6.0", path: semconvPath, supported: true},
+		{title: "removed for previous versions", file: "build.spec.yml", version: "3.5.0", path: semconvPath, supported: false},
+		{title: "not defined", file: "build.spec.yml", version: "3.6.0", path: []string{"properties", "dependencies", "properties", "other"}, supported: false},
+		{title: "value in enum", file: "fields.spec.yml", version: "3.6.0", path: externalPath, value: "ecs", supported: true},
+		{title: "value not in enum", file: "fields.spec.yml", version: "3.6.0", path: externalPath, value: "semconv", supported: false},
+	}
+
+	for _, c := range cases {
+		t.Run(c.title, func(t *testing.T) {
+			supported, err := specSupports(specFS, c.file, *semver.MustParse(c.version), c.path, c.value)
+			require.NoError(t, err)
+			assert.Equal(t, c.supported, supported)
+		})
+	}
+}
+
+func TestCheckSemconvSupport(t *testing.T) {
+	// The package spec used in this version doesn't support the semconv dependency.
+	err := checkSemconvSupport("3.5.0")
+	assert.ErrorContains(t, err, "semconv dependency is not supported by the package spec for format version 3.5.0")
+}
@@ -0,0 +1,538 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package diff
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+	"gopkg.in/yaml.v3"
+
+	"github.com/elastic/elastic-package/internal/fields"
+	"github.com/elastic/elastic-package/internal/packages"
+)
+
+// Types of changes, they match the types of entries in changelogs.
+const (
+	Breaking    = "breaking-change"
+	Enhancement = "enhancement"
+	Bugfix      = "bugfix"
+)
+
+// Areas of the package affected by changes.
+const (
+	AreaConditions     = "conditions"
+	AreaDataStream     = "data_stream"
+	AreaField          = "field"
+	AreaIngestPipeline = "ingest_pipeline"
+	AreaInput          = "input"
+	AreaPolicyTemplate = "policy_template"
+	AreaVariable       = "variable"
+)
+
+// Types contains the types of changes, in order of relevance.
+var Types = []string{Breaking, Enhancement, Bugfix}
+
+// Change is a semantic change between two versions of a package.
+type Change struct {
+	Type        string `json:"type"`
+	Area        string `json:"area"`
+	DataStream  string `json:"data_stream,omitempty"`
+	Description string `json:"description"`
+}
+
+// Report contains the changes found between two versions of a package.
+type Report struct {
+	Name       string   `json:"name"`
+	OldVersion string   `json:"old_version"`
+	NewVersion string   `json:"new_version"`
+	Changes    []Change `json:"changes"`
+}
+
+// ChangesOfType returns the changes of the given type.
+func (r *Report) ChangesOfType(changeType string) []Change {
+	var changes []Change
+	for _, change := range r.Changes {
+		if change.Type == changeType {
+			changes = append(changes, change)
+		}
+	}
+	return changes
+}
+
+// HasBreakingChanges returns true if any of the changes is breaking.
+func (r *Report) HasBreakingChanges() bool {
+	return len(r.ChangesOfType(Breaking)) > 0
+}
+
+func (r *Report) add(changeType, area, dataStream, format string, a ...any) {
+	r.Changes = append(r.Changes, Change{
+		Type:        changeType,
+		Area:        area,
+		DataStream:  dataStream,
+		Description: fmt.Sprintf(format, a...),
+	})
+}
+
+// Packages compares the sources of two versions of a package.
+func Packages(oldRoot, newRoot string) (*Report, error) {
+	oldManifest, err := packages.ReadPackageManifestFromPackageRoot(oldRoot)
+	if err != nil {
+		return nil, fmt.Errorf("reading manifest of old package failed: %w", err)
+	}
+	newManifest, err := packages.ReadPackageManifestFromPackageRoot(newRoot)
+	if err != nil {
+		return nil, fmt.Errorf("reading manifest of new package failed: %w", err)
+	}
+	if oldManifest.Name != newManifest.Name {
+		return nil, fmt.Errorf("can't compare different packages (%s and %s)", oldManifest.Name, newManifest.Name)
+	}
+
+	report := Report{
+		Name:       newManifest.Name,
+		OldVersion: oldManifest.Version,
+		NewVersion: newManifest.Version,
+	}
+
+	compareConditions(&report, oldManifest.Conditions, newManifest.Conditions)
+	compareVars(&report, "", "package", oldManifest.Vars, newManifest.Vars)
+	comparePolicyTemplates(&report, oldManifest.PolicyTemplates, newManifest.PolicyTemplates)
+
+	err = compareFields(&report, "", filepath.Join(oldRoot, "fields"), filepath.Join(newRoot, "fields"))
+	if err != nil {
+		return nil, err
+	}
+
+	err = compareDataStreams(&report, oldRoot, newRoot)
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(report.Changes, func(i, j int) bool {
+		return slices.Index(Types, report.Changes[i].Type) < slices.Index(Types, report.Changes[j].Type)
+	})
+	return &report, nil
+}
+
+var versionRegexp = regexp.MustCompile(`\d+(?:\.\d+){0,2}`)
+
+// constraintNarrowed checks if a version constraint accepts fewer versions than a previous one.
+// Constraints are compared checking the versions mentioned in any of them, and the versions
+// around these ones. Empty constraints accept any version, and constraints that can't be parsed
+// are considered narrowed.
+func constraintNarrowed(oldConstraint, newConstraint string) bool {
+	if newConstraint == "" {
+		return false
+	}
+	newConstraints, err := semver.NewConstraint(newConstraint)
+	if err != nil {
+		return true
+	}
+	var oldConstraints *semver.Constraints
+	if oldConstraint != "" {
+		oldConstraints, err = semver.NewConstraint(oldConstraint)
+		if err != nil {
+			return true
+		}
+	}
+
+	for _, v := range constraintCandidates(oldConstraint + " " + newConstraint) {
+		if (oldConstraints == nil || oldConstraints.Check(v)) && !newConstraints.Check(v) {
+			return true
+		}
+	}
+	return false
+}
+
+// constraintCandidates returns the versions mentioned in the constraints, the versions right
+// before and after them, and versions lower and higher than all of them.
+func constraintCandidates(constraints string) []*semver.Version {
+	const maxComponent = 9999
+	candidates := []*semver.Version{semver.New(0, 0, 0, "", ""), semver.New(maxComponent, 0, 0, "", "")}
+	for _, match := range versionRegexp.FindAllString(constraints, -1) {
+		v, err := semver.NewVersion(match)
+		if err != nil {
+			continue
+		}
+		next := []semver.Version{*v, v.IncPatch(), v.IncMinor(), v.IncMajor()}
+		for i := range next {
+			candidates = append(candidates, &next[i])
+		}
+		major, minor, patch := v.Major(), v.Minor(), v.Patch()
+		if patch > 0 {
+			candidates = append(candidates, semver.New(major, minor, patch-1, "", ""))
+		}
+		if minor > 0 {
+			candidates = append(candidates, semver.New(major, minor-1, maxComponent, "", ""))
+		}
+		if major > 0 {
+			candidates = append(candidates, semver.New(major-1, maxComponent, maxComponent, "", ""))
+		}
+	}
+	return candidates
+}
+
+var subscriptions = []string{"basic", "gold", "platinum", "enterprise"}
+
+func compareConditions(report *Report, oldConditions, newConditions packages.Conditions) {
+	oldKibana, newKibana := oldConditions.Kibana.Version, newConditions.Kibana.Version
+	if oldKibana != newKibana {
+		changeType := Enhancement
+		if constraintNarrowed(oldKibana, newKibana) {
+			changeType = Breaking
+		}
+		report.add(changeType, AreaConditions, "", "Kibana version constraint changed from %q to %q", oldKibana, newKibana)
+	}
+
+	oldSubscription, newSubscription := oldConditions.Elastic.Subscription, newConditions.Elastic.Subscription
+	if oldSubscription == "" {
+		oldSubscription = "basic"
+	}
+	if newSubscription == "" {
+		newSubscription = "basic"
+	}
+	if oldSubscription != newSubscription {
+		changeType := Enhancement
+		if slices.Index(subscriptions, newSubscription) > slices.Index(subscriptions, oldSubscription) {
+			changeType = Breaking
+		}
+		report.add(changeType, AreaConditions, "", "required subscription changed from %q to %q", oldSubscription, newSubscription)
+	}
+}
+
+func comparePolicyTemplates(report *Report, oldTemplates, newTemplates []packages.PolicyTemplate) {
+	for _, oldTemplate := range oldTemplates {
+		idx := slices.IndexFunc(newTemplates, func(t packages.PolicyTemplate) bool { return t.Name == oldTemplate.Name })
+		if idx < 0 {
+			report.add(Breaking, AreaPolicyTemplate, "", "policy template %q removed", oldTemplate.Name)
+			continue
+		}
+		newTemplate := newTemplates[idx]
+		scope := fmt.Sprintf("policy template %q", oldTemplate.Name)
+
+		compareInputs(report, "", scope, policyTemplateInputs(oldTemplate), policyTemplateInputs(newTemplate))
+		compareVars(report, "", scope, oldTemplate.Vars, newTemplate.Vars)
+	}
+	for _, newTemplate := range newTemplates {
+		if !slices.ContainsFunc(oldTemplates, func(t packages.PolicyTemplate) bool { return t.Name == newTemplate.Name }) {
+			report.add(Enhancement, AreaPolicyTemplate, "", "policy template %q added", newTemplate.Name)
+		}
+	}
+}
+
+func policyTemplateInputs(template packages.PolicyTemplate) map[string][]packages.Variable {
+	inputs := make(map[string][]packages.Variable)
+	for _, input := range template.Inputs {
+		inputs[input.Type] = input.Vars
+	}
+	if template.Input != "" {
+		// Input packages define a single input, with the variables at the policy template level.
+		inputs[template.Input] = nil
+	}
+	return inputs
+}
+
+// compareInputs compares inputs, identified by their type, and their variables.
+func compareInputs(report *Report, dataStream, scope string, oldInputs, newInputs map[string][]packages.Variable) {
+	for _, name := range sortedKeys(oldInputs) {
+		newVars, found := newInputs[name]
+		if !found {
+			report.add(Breaking, AreaInput, dataStream, "input %q removed from %s", name, scope)
+			continue
+		}
+		compareVars(report, dataStream, fmt.Sprintf("input %q in %s", name, scope), oldInputs[name], newVars)
+	}
+	for _, name := range sortedKeys(newInputs) {
+		if _, found := oldInputs[name]; !found {
+			report.add(Enhancement, AreaInput, dataStream, "input %q added to %s", name, scope)
+		}
+	}
+}
+
+func compareVars(report *Report, dataStream, scope string, oldVars, newVars []packages.Variable) {
+	for _, oldVar := range oldVars {
+		idx := slices.IndexFunc(newVars, func(v packages.Variable) bool { return v.Name == oldVar.Name })
+		if idx < 0 {
+			report.add(Breaking, AreaVariable, dataStream, "variable %q removed from %s", oldVar.Name, scope)
+			continue
+		}
+		newVar := newVars[idx]
+
+		if oldVar.Type != newVar.Type {
+			report.add(Breaking, AreaVariable, dataStream, "type of variable %q in %s changed from %q to %q", oldVar.Name, scope, oldVar.Type, newVar.Type)
+		}
+		if oldVar.Multi != newVar.Multi {
+			report.add(Breaking, AreaVariable, dataStream, "variable %q in %s changed multi from %t to %t", oldVar.Name, scope, oldVar.Multi, newVar.Multi)
+		}
+		newDefault := varValueString(newVar.Default)
+		if !oldVar.Required && newVar.Required && newDefault == "" {
+			report.add(Breaking, AreaVariable, dataStream, "variable %q in %s is now required", oldVar.Name, scope)
+		}
+		if oldDefault := varValueString(oldVar.Default); oldDefault != newDefault {
+			report.add(Enhancement, AreaVariable, dataStream, "default value of variable %q in %s changed from %s to %s", oldVar.Name, scope, oldDefault, newDefault)
+		}
+	}
+	for _, newVar := range newVars {
+		if slices.ContainsFunc(oldVars, func(v packages.Variable) bool { return v.Name == newVar.Name }) {
+			continue
+		}
+		if newVar.Required && varValueString(newVar.Default) == "" {
+			report.add(Breaking, AreaVariable, dataStream, "required variable %q without default value added to %s", newVar.Name, scope)
+			continue
+		}
+		report.add(Enhancement, AreaVariable, dataStream, "variable %q added to %s", newVar.Name, scope)
+	}
+}
+
+func varValueString(value packages.VarValue) string {
+	d, err := value.MarshalJSON()
+	if err != nil || string(d) == "null" {
+		return ""
+	}
+	return string(d)
+}
+
+func compareDataStreams(report *Report, oldRoot, newRoot string) error {
+	oldDataStreams, err := listDataStreams(oldRoot)
+	if err != nil {
+		return err
+	}
+	newDataStreams, err := listDataStreams(newRoot)
+	if err != nil {
+		return err
+	}
+
+	for _, name := range oldDataStreams {
+		if !slices.Contains(newDataStreams, name) {
+			report.add(Breaking, AreaDataStream, name, "data stream %q removed", name)
+			continue
+		}
+		err := compareDataStream(report, name,
+			filepath.Join(oldRoot, "data_stream", name),
+			filepath.Join(newRoot, "data_stream", name),
+		)
+		if err != nil {
+			return fmt.Errorf("comparing data stream %q failed: %w", name, err)
+		}
+	}
+	for _, name := range newDataStreams {
+		if !slices.Contains(oldDataStreams, name) {
+			report.add(Enhancement, AreaDataStream, name, "data stream %q added", name)
+		}
+	}
+	return nil
+}
+
+func listDataStreams(root string) ([]string, error) {
+	paths, err := filepath.Glob(filepath.Join(root, "data_stream", "*", packages.DataStreamManifestFile))
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, path := range paths {
+		names = append(names, filepath.Base(filepath.Dir(path)))
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+func compareDataStream(report *Report, name, oldPath, newPath string) error {
+	oldManifest, err := packages.ReadDataStreamManifest(filepath.Join(oldPath, packages.DataStreamManifestFile))
+	if err != nil {
+		return err
+	}
+	newManifest, err := packages.ReadDataStreamManifest(filepath.Join(newPath, packages.DataStreamManifestFile))
+	if err != nil {
+		return err
+	}
+
+	if oldManifest.Type != newManifest.Type {
+		report.add(Breaking, AreaDataStream, name, "type of data stream changed from %q to %q", oldManifest.Type, newManifest.Type)
+	}
+	if oldManifest.Dataset != newManifest.Dataset {
+		report.add(Breaking, AreaDataStream, name, "dataset changed from %q to %q", oldManifest.Dataset, newManifest.Dataset)
+	}
+
+	compareInputs(report, name, fmt.Sprintf("data stream %q", name), streamInputs(oldManifest), streamInputs(newManifest))
+
+	err = compareFields(report, name, filepath.Join(oldPath, "fields"), filepath.Join(newPath, "fields"))
+	if err != nil {
+		return err
+	}
+
+	return comparePipelines(report, name,
+		filepath.Join(oldPath, "elasticsearch", "ingest_pipeline"),
+		filepath.Join(newPath, "elasticsearch", "ingest_pipeline"),
+	)
+}
+
+func streamInputs(manifest *packages.DataStreamManifest) map[string][]packages.Variable {
+	inputs := make(map[string][]packages.Variable)
+	for _, stream := range manifest.Streams {
+		inputs[stream.Input] = stream.Vars
+	}
+	return inputs
+}
+
+// fieldInfo contains the properties of a field relevant for the comparison.
+type fieldInfo struct {
+	Type     string
+	External string
+}
+
+func compareFields(report *Report, dataStream, oldDir, newDir string) error {
+	oldFields, err := readFlatFields(oldDir)
+	if err != nil {
+		return fmt.Errorf("reading fields of old package failed: %w", err)
+	}
+	newFields, err := readFlatFields(newDir)
+	if err != nil {
+		return fmt.Errorf("reading fields of new package failed: %w", err)
+	}
+
+	for _, name := range sortedKeys(oldFields) {
+		oldField := oldFields[name]
+		newField, found := newFields[name]
+		if !found {
+			report.add(Breaking, AreaField, dataStream, "field %q removed", name)
+			continue
+		}
+		// Built packages include the definitions of external fields, types can be only
+		// compared if they are defined in both packages.
+		if oldField.External != "" || newField.External != "" {
+			continue
+		}
+		if oldField.Type != newField.Type {
+			report.add(Breaking, AreaField, dataStream, "type of field %q changed from %q to %q", name, oldField.Type, newField.Type)
+		}
+	}
+	for _, name := range sortedKeys(newFields) {
+		if _, found := oldFields[name]; !found {
+			report.add(Enhancement, AreaField, dataStream, "field %q added", name)
+		}
+	}
+	return nil
+}
+
+func readFlatFields(dir string) (map[string]fieldInfo, error) {
+	result := make(map[string]fieldInfo)
+	if _, err := os.Stat(dir); errors.Is(err, os.ErrNotExist) {
+		return result, nil
+	}
+	definitions, err := fields.ReadFieldsFromDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	flattenFields("", definitions, result)
+	return result, nil
+}
+
+func flattenFields(prefix string, definitions []fields.FieldDefinition, result map[string]fieldInfo) {
+	for _, definition := range definitions {
+		name := definition.Name
+		if prefix != "" {
+			name = prefix + "." + name
+		}
+		if definition.Type == "group" || (definition.Type == "" && len(definition.Fields) > 0) {
+			flattenFields(name, definition.Fields, result)
+			continue
+		}
+
+		fieldType := definition.Type
+		if fieldType == "object" && definition.ObjectType != "" {
+			fieldType = fmt.Sprintf("object (object_type: %s)", definition.ObjectType)
+		}
+		result[name] = fieldInfo{
+			Type:     fieldType,
+			External: definition.External,
+		}
+	}
+}
+
+func comparePipelines(report *Report, dataStream, oldDir, newDir string) error {
+	oldPipelines, err := readPipelines(oldDir)
+	if err != nil {
+		return fmt.Errorf("reading ingest pipelines of old package failed: %w", err)
+	}
+	newPipelines, err := readPipelines(newDir)
+	if err != nil {
+		return fmt.Errorf("reading ingest pipelines of new package failed: %w", err)
+	}
+
+	for _, name := range sortedKeys(oldPipelines) {
+		oldPipeline := oldPipelines[name]
+		newPipeline, found := newPipelines[name]
+		if !found {
+			changeType := Bugfix
+			if name == "default" {
+				changeType = Breaking
+			}
+			report.add(changeType, AreaIngestPipeline, dataStream, "ingest pipeline %q removed", name)
+			continue
+		}
+		if !reflect.DeepEqual(oldPipeline, newPipeline) {
+			report.add(Bugfix, AreaIngestPipeline, dataStream, "ingest pipeline %q changed (processors: %d -> %d)",
+				name, countProcessors(oldPipeline), countProcessors(newPipeline))
+		}
+	}
+	for _, name := range sortedKeys(newPipelines) {
+		if _, found := oldPipelines[name]; !found {
+			report.add(Enhancement, AreaIngestPipeline, dataStream, "ingest pipeline %q added", name)
+		}
+	}
+	return nil
+}
+
+// readPipelines reads the pipelines in a directory, indexed by their name without extension,
+// so pipelines converted between YAML and JSON are considered the same.
+func readPipelines(dir string) (map[string]map[string]any, error) {
+	pipelines := make(map[string]map[string]any)
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return pipelines, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range entries {
+		ext := filepath.Ext(entry.Name())
+		if entry.IsDir() || (ext != ".yml" && ext != ".yaml" && ext != ".json") {
+			continue
+		}
+		d, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		var pipeline map[string]any
+		err = yaml.Unmarshal(d, &pipeline)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode pipeline %s: %w", entry.Name(), err)
+		}
+		pipelines[strings.TrimSuffix(entry.Name(), ext)] = pipeline
+	}
+	return pipelines, nil
+}
+
+func countProcessors(pipeline map[string]any) int {
+	processors, _ := pipeline["processors"].([]any)
+	return len(processors)
+}
+
+func sortedKeys[T any](m map[string]T) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,196 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package diff
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writePackage(t *testing.T, files map[string]string) string {
+	t.Helper()
+	root := t.TempDir()
+	for path, content := range files {
+		path = filepath.Join(root, filepath.FromSlash(path))
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	}
+	return root
+}
+
+const oldManifest = `name: foo
+version: 1.0.0
+conditions:
+  kibana:
+    version: "^8.13.0"
+vars:
+  - name: api_key
+    type: password
+policy_templates:
+  - name: foo
+    inputs:
+      - type: logfile
+      - type: httpjson
+`
+
+const newManifest = `name: foo
+version: 2.0.0
+conditions:
+  kibana:
+    version: "^8.15.0 || ^9.0.0"
+vars:
+  - name: api_key
+    type: password
+  - name: proxy_url
+    type: text
+policy_templates:
+  - name: foo
+    inputs:
+      - type: logfile
+      - type: cel
+`
+
+const oldDataStreamManifest = `title: Logs
+type: logs
+streams:
+  - input: logfile
+    vars:
+      - name: paths
+        type: text
+        multi: true
+        default:
+          - /var/log/foo.log
+      - name: tags
+        type: text
+        multi: true
+`
+
+const newDataStreamManifest = `title: Logs
+type: logs
+streams:
+  - input: logfile
+    vars:
+      - name: paths
+        type: text
+        multi: true
+        default:
+          - /var/log/foo/*.log
+      - name: preserve_original_event
+        type: bool
+        required: true
+`
+
+const oldFields = `- name: foo
+  type: group
+  fields:
+    - name: id
+      type: keyword
+    - name: size
+      type: long
+    - name: old
+      type: keyword
+- name: host.name
+  external: ecs
+`
+
+const newFields = `- name: foo.id
+  type: keyword
+- name: foo.size
+  type: double
+- name: foo.new
+  type: keyword
+- name: host.name
+  type: keyword
+`
+
+func TestPackages(t *testing.T) {
+	oldRoot := writePackage(t, map[string]string{
+		"manifest.yml":                                               oldManifest,
+		"data_stream/logs/manifest.yml":                              oldDataStreamManifest,
+		"data_stream/logs/fields/fields.yml":                         oldFields,
+		"data_stream/metrics/manifest.yml":                           "title: Metrics\ntype: metrics\n",
+		"data_stream/logs/elasticsearch/ingest_pipeline/default.yml": "processors:\n  - set:\n      field: a\n      value: b\n",
+		"data_stream/logs/elasticsearch/ingest_pipeline/other.json":  `{"processors": [{"remove": {"field": "a"}}]}`,
+	})
+	newRoot := writePackage(t, map[string]string{
+		"manifest.yml":                                               newManifest,
+		"data_stream/logs/manifest.yml":                              newDataStreamManifest,
+		"data_stream/logs/fields/fields.yml":                         newFields,
+		"data_stream/traces/manifest.yml":                            "title: Traces\ntype: traces\n",
+		"data_stream/logs/elasticsearch/ingest_pipeline/default.yml": "processors:\n  - set:\n      field: a\n      value: c\n  - remove:\n      field: b\n",
+		"data_stream/logs/elasticsearch/ingest_pipeline/other.yml":   "processors:\n  - remove:\n      field: a\n",
+	})
+
+	report, err := Packages(oldRoot, newRoot)
+	require.NoError(t, err)
+
+	assert.Equal(t, "foo", report.Name)
+	assert.Equal(t, "1.0.0", report.OldVersion)
+	assert.Equal(t, "2.0.0", report.NewVersion)
+	assert.True(t, report.HasBreakingChanges())
+
+	expected := []Change{
+		{Type: Breaking, Area: AreaConditions, Description: `Kibana version constraint changed from "^8.13.0" to "^8.15.0 || ^9.0.0"`},
+		{Type: Breaking, Area: AreaInput, Description: `input "httpjson" removed from policy template "foo"`},
+		{Type: Breaking, Area: AreaVariable, DataStream: "logs", Description: `variable "tags" removed from input "logfile" in data stream "logs"`},
+		{Type: Breaking, Area: AreaVariable, DataStream: "logs", Description: `required variable "preserve_original_event" without default value added to input "logfile" in data stream "logs"`},
+		{Type: Breaking, Area: AreaField, DataStream: "logs", Description: `field "foo.old" removed`},
+		{Type: Breaking, Area: AreaField, DataStream: "logs", Description: `type of field "foo.size" changed from "long" to "double"`},
+		{Type: Breaking, Area: AreaDataStream, DataStream: "metrics", Description: `data stream "metrics" removed`},
+		{Type: Enhancement, Area: AreaVariable, Description: `variable "proxy_url" added to package`},
+		{Type: Enhancement, Area: AreaInput, Description: `input "cel" added to policy template "foo"`},
+		{Type: Enhancement, Area: AreaVariable, DataStream: "logs", Description: `default value of variable "paths" in input "logfile" in data stream "logs" changed from ["/var/log/foo.log"] to ["/var/log/foo/*.log"]`},
+		{Type: Enhancement, Area: AreaField, DataStream: "logs", Description: `field "foo.new" added`},
+		{Type: Enhancement, Area: AreaDataStream, DataStream: "traces", Description: `data stream "traces" added`},
+		{Type: Bugfix, Area: AreaIngestPipeline, DataStream: "logs", Description: `ingest pipeline "default" changed (processors: 1 -> 2)`},
+	}
+	assert.Equal(t, expected, report.Changes)
+}
+
+func TestPackagesWithoutChanges(t *testing.T) {
+	files := map[string]string{
+		"manifest.yml":                       oldManifest,
+		"data_stream/logs/manifest.yml":      oldDataStreamManifest,
+		"data_stream/logs/fields/fields.yml": oldFields,
+	}
+	report, err := Packages(writePackage(t, files), writePackage(t, files))
+	require.NoError(t, err)
+	assert.Empty(t, report.Changes)
+	assert.False(t, report.HasBreakingChanges())
+}
+
+func TestPackagesDifferentNames(t *testing.T) {
+	oldRoot := writePackage(t, map[string]string{"manifest.yml": oldManifest})
+	newRoot := writePackage(t, map[string]string{"manifest.yml": "name: bar\nversion: 1.0.0\n"})
+	_, err := Packages(oldRoot, newRoot)
+	assert.Error(t, err)
+}
+
+func TestConstraintNarrowed(t *testing.T) {
+	cases := []struct {
+		old, new string
+		narrowed bool
+	}{
+		{old: "^8.13.0", new: "^8.15.0", narrowed: true},
+		{old: "^8.15.0", new: "^8.13.0", narrowed: false},
+		{old: "^8.15.0 || ^9.0.0", new: "^8.15.0", narrowed: true},
+		{old: "^8.15.0", new: "^8.15.0 || ^9.0.0", narrowed: false},
+		{old: "^8.13.0", new: "^8.15.0 || ^9.0.0", narrowed: true},
+		{old: ">= 8.0.0 || ~7.17.0", new: ">= 7.17.0", narrowed: false},
+		{old: ">= 8.0.0", new: ">= 8.0.0, < 9.0.0", narrowed: true},
+		{old: "", new: "^8.15.0", narrowed: true},
+		{old: "^8.15.0", new: "", narrowed: false},
+		{old: "^8.15.0", new: "invalid", narrowed: true},
+	}
+	for _, c := range cases {
+		t.Run(c.old+" to "+c.new, func(t *testing.T) {
+			assert.Equal(t, c.narrowed, constraintNarrowed(c.old, c.new))
+		})
+	}
+}
@@ -0,0 +1,137 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package diff
+
+import (
+	"archive/tar"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/elastic/elastic-package/internal/git"
+)
+
+// ResolveGitSource extracts the package in packageRoot, as it is in the given git reference, into
+// the working directory, and returns its root directory.
+func ResolveGitSource(ctx context.Context, ref, packageRoot, workDir string) (string, error) {
+	repositoryRoot, err := git.Run(ctx, packageRoot, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return "", fmt.Errorf("%s is not in a git repository: %w", packageRoot, err)
+	}
+	repositoryRoot = strings.TrimSpace(repositoryRoot)
+
+	absPackageRoot, err := filepath.Abs(packageRoot)
+	if err != nil {
+		return "", err
+	}
+	absPackageRoot, err = filepath.EvalSymlinks(absPackageRoot)
+	if err != nil {
+		return "", err
+	}
+	relPath, err := filepath.Rel(repositoryRoot, absPackageRoot)
+	if err != nil {
+		return "", fmt.Errorf("failed to find package path in the repository: %w", err)
+	}
+	relPath = filepath.ToSlash(relPath)
+
+	archive, err := git.Run(ctx, repositoryRoot, "archive", "--format=tar", ref, "--", relPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to read package from git reference %q: %w", ref, err)
+	}
+
+	destination := filepath.Join(workDir, "git", strings.NewReplacer("/", "_", "\\", "_").Replace(ref))
+	err = untar(strings.NewReader(archive), destination)
+	if err != nil {
+		return "", fmt.Errorf("failed to extract package from git reference %q: %w", ref, err)
+	}
+	return filepath.Join(destination, filepath.FromSlash(relPath)), nil
+}
+
+// IsGitReference returns true if the given reference exists in the git repository containing
+// the given directory.
+func IsGitReference(ctx context.Context, dir, ref string) bool {
+	_, err := git.Run(ctx, dir, "rev-parse", "--verify", "--quiet", ref+"^{commit}")
+	return err == nil
+}
+
+// LatestGitTag returns the most recent tag of the package reachable from the current commit of
+// the git repository containing the package. Only tags for the package, named <name>-<version>
+// or <name>/v<version>, are considered, as repositories can contain multiple packages. Other tags
+// are only considered if the package is in the root of the repository.
+func LatestGitTag(ctx context.Context, packageRoot, name string) (string, error) {
+	patterns := []string{name + "-*", name + "/v*"}
+	for _, pattern := range patterns {
+		tag, err := git.Run(ctx, packageRoot, "describe", "--tags", "--abbrev=0", "--match", pattern)
+		if err == nil {
+			return strings.TrimSpace(tag), nil
+		}
+	}
+
+	inRoot, err := isRepositoryRoot(ctx, packageRoot)
+	if err != nil {
+		return "", err
+	}
+	if !inRoot {
+		return "", fmt.Errorf("no git tag found for package %s (patterns: %s)", name, strings.Join(patterns, ", "))
+	}
+	tag, err := git.Run(ctx, packageRoot, "describe", "--tags", "--abbrev=0")
+	if err != nil {
+		return "", fmt.Errorf("no git tag found: %w", err)
+	}
+	return strings.TrimSpace(tag), nil
+}
+
+// isRepositoryRoot checks if the directory is the root of the git repository containing it.
+func isRepositoryRoot(ctx context.Context, dir string) (bool, error) {
+	prefix, err := git.Run(ctx, dir, "rev-parse", "--show-prefix")
+	if err != nil {
+		return false, fmt.Errorf("%s is not in a git repository: %w", dir, err)
+	}
+	return strings.TrimSpace(prefix) == "", nil
+}
+
+func untar(r io.Reader, destinationDir string) error {
+	tr := tar.NewReader(r)
+	for {
+		header, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		path := filepath.Join(destinationDir, filepath.FromSlash(header.Name))
+		if !strings.HasPrefix(path, filepath.Clean(destinationDir)+string(os.PathSeparator)) {
+			return fmt.Errorf("invalid file path in archive: %s", header.Name)
+		}
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			err = os.MkdirAll(path, 0755)
+			if err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			err = os.MkdirAll(filepath.Dir(path), 0755)
+			if err != nil {
+				return err
+			}
+			f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, header.FileInfo().Mode().Perm())
+			if err != nil {
+				return err
+			}
+			_, err = io.Copy(f, tr)
+			f.Close()
+			if err != nil {
+				return err
+			}
+		}
+	}
+}
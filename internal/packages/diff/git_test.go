@@ -0,0 +1,82 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package diff
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/elastic-package/internal/git"
+)
+
+func TestLatestGitTag(t *testing.T) {
+	ctx := context.Background()
+	repository := t.TempDir()
+	packageRoot := filepath.Join(repository, "packages", "foo")
+	require.NoError(t, os.MkdirAll(packageRoot, 0o755))
+
+	commit := func(version string) {
+		t.Helper()
+		manifest := "name: foo\nversion: " + version + "\n"
+		require.NoError(t, os.WriteFile(filepath.Join(packageRoot, "manifest.yml"), []byte(manifest), 0o644))
+		_, err := git.Run(ctx, repository, "add", "-A")
+		require.NoError(t, err)
+		_, err = git.Run(ctx, repository, "-c", "user.name=test", "-c", "user.email=test@example.com", "commit", "-q", "-m", version)
+		require.NoError(t, err)
+	}
+
+	_, err := git.Run(ctx, repository, "init", "-q")
+	require.NoError(t, err)
+	commit("1.0.0")
+
+	_, err = LatestGitTag(ctx, packageRoot, "foo")
+	assert.Error(t, err)
+
+	// Tags not scoped to the package are ignored, as the package is not in the repository root.
+	_, err = git.Run(ctx, repository, "tag", "v1.0.0")
+	require.NoError(t, err)
+	_, err = LatestGitTag(ctx, packageRoot, "foo")
+	assert.Error(t, err)
+
+	_, err = git.Run(ctx, repository, "tag", "foo-1.0.0")
+	require.NoError(t, err)
+	commit("1.1.0")
+	_, err = git.Run(ctx, repository, "tag", "bar-2.0.0")
+	require.NoError(t, err)
+
+	tag, err := LatestGitTag(ctx, packageRoot, "foo")
+	require.NoError(t, err)
+	assert.Equal(t, "foo-1.0.0", tag)
+
+	releasedRoot, err := ResolveGitSource(ctx, tag, packageRoot, t.TempDir())
+	require.NoError(t, err)
+	d, err := os.ReadFile(filepath.Join(releasedRoot, "manifest.yml"))
+	require.NoError(t, err)
+	assert.Contains(t, string(d), "version: 1.0.0")
+}
+
+func TestLatestGitTagRepositoryRoot(t *testing.T) {
+	ctx := context.Background()
+	packageRoot := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(packageRoot, "manifest.yml"), []byte("name: foo\nversion: 1.0.0\n"), 0o644))
+
+	_, err := git.Run(ctx, packageRoot, "init", "-q")
+	require.NoError(t, err)
+	_, err = git.Run(ctx, packageRoot, "add", "-A")
+	require.NoError(t, err)
+	_, err = git.Run(ctx, packageRoot, "-c", "user.name=test", "-c", "user.email=test@example.com", "commit", "-q", "-m", "1.0.0")
+	require.NoError(t, err)
+	_, err = git.Run(ctx, packageRoot, "tag", "v1.0.0")
+	require.NoError(t, err)
+
+	tag, err := LatestGitTag(ctx, packageRoot, "foo")
+	require.NoError(t, err)
+	assert.Equal(t, "v1.0.0", tag)
+}
@@ -0,0 +1,90 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package diff
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/elastic/elastic-package/internal/files"
+	"github.com/elastic/elastic-package/internal/logger"
+	"github.com/elastic/elastic-package/internal/packages"
+	"github.com/elastic/elastic-package/internal/registry"
+)
+
+// ErrNotPublished is returned when resolving a package that is not published in the registry.
+var ErrNotPublished = errors.New("package not published in the registry")
+
+// ResolveSource returns the root directory of the package referenced by the given source, that
+// can be a package directory, a package archive, or a package published in the registry, as
+// <name>@<version>. If the version is omitted, the latest published version is used.
+// Archives and published packages are extracted into the working directory.
+func ResolveSource(source, workDir string, client *registry.Client) (string, error) {
+	info, err := os.Stat(source)
+	switch {
+	case err == nil && info.IsDir():
+		if _, err := os.Stat(filepath.Join(source, packages.PackageManifestFile)); err != nil {
+			return "", fmt.Errorf("%s is not a package directory: %w", source, err)
+		}
+		return source, nil
+	case err == nil && strings.HasSuffix(source, ".zip"):
+		return extractPackage(source, workDir)
+	case err == nil:
+		return "", fmt.Errorf("%s is not a package directory or archive", source)
+	}
+
+	name, version, _ := strings.Cut(source, "@")
+	revisions, err := client.Revisions(name, registry.SearchOptions{
+		All:        true,
+		Prerelease: true,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to get published versions of package %s: %w", name, err)
+	}
+	if len(revisions) == 0 {
+		return "", fmt.Errorf("%s is not a local package: %w", source, ErrNotPublished)
+	}
+	if version == "" {
+		// Revisions are sorted by version.
+		version = revisions[len(revisions)-1].Version
+	} else if !containsVersion(revisions, version) {
+		return "", fmt.Errorf("version %s of package %s not found in the registry", version, name)
+	}
+
+	logger.Debugf("Downloading %s-%s from the registry", name, version)
+	zipPath, err := client.DownloadPackage(name, version, workDir)
+	if err != nil {
+		return "", err
+	}
+	return extractPackage(zipPath, workDir)
+}
+
+func containsVersion(revisions []packages.PackageManifest, version string) bool {
+	for _, revision := range revisions {
+		if revision.Version == version {
+			return true
+		}
+	}
+	return false
+}
+
+// extractPackage extracts the package archive into the working directory, and returns the
+// root directory of the extracted package.
+func extractPackage(zipPath, workDir string) (string, error) {
+	name := strings.TrimSuffix(filepath.Base(zipPath), ".zip")
+	dir := filepath.Join(workDir, "extracted", name)
+	err := files.Unzip(zipPath, dir)
+	if err != nil {
+		return "", err
+	}
+	root, err := packages.FindExtractedPackageRoot(dir)
+	if err != nil {
+		return "", fmt.Errorf("can't find package in %s: %w", zipPath, err)
+	}
+	return root, nil
+}
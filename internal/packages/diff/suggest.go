@@ -0,0 +1,107 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package diff
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/Masterminds/semver/v3"
+
+	"github.com/elastic/elastic-package/internal/packages/changelog"
+)
+
+// Semantic version bumps.
+const (
+	BumpMajor = "major"
+	BumpMinor = "minor"
+	BumpPatch = "patch"
+)
+
+// Suggestion is a changelog revision proposed for the changes in a report.
+type Suggestion struct {
+	// Bump is the part of the released version that needs to be increased.
+	Bump string
+
+	Revision changelog.Revision
+}
+
+// Suggest proposes a changelog revision for the changes found in the report, with one entry
+// per type of change. The version of the revision is the released version (the old version in
+// the report) increased according to the most relevant change, or the version in the working
+// tree if this is already greater. It returns nil if there are no changes.
+func Suggest(report *Report, link string) (*Suggestion, error) {
+	if len(report.Changes) == 0 {
+		return nil, nil
+	}
+
+	released, err := semver.NewVersion(report.OldVersion)
+	if err != nil {
+		return nil, fmt.Errorf("invalid released version %q: %w", report.OldVersion, err)
+	}
+	current, err := semver.NewVersion(report.NewVersion)
+	if err != nil {
+		return nil, fmt.Errorf("invalid current version %q: %w", report.NewVersion, err)
+	}
+
+	suggestion := Suggestion{Bump: suggestBump(report, released)}
+	var next semver.Version
+	switch suggestion.Bump {
+	case BumpMajor:
+		next = released.IncMajor()
+	case BumpMinor:
+		next = released.IncMinor()
+	default:
+		next = released.IncPatch()
+	}
+	if current.GreaterThan(&next) {
+		next = *current
+	}
+	suggestion.Revision.Version = next.String()
+
+	for _, changeType := range Types {
+		changes := report.ChangesOfType(changeType)
+		if len(changes) == 0 {
+			continue
+		}
+		suggestion.Revision.Changes = append(suggestion.Revision.Changes, changelog.Entry{
+			Description: entryDescription(changes),
+			Type:        changeType,
+			Link:        link,
+		})
+	}
+	return &suggestion, nil
+}
+
+// suggestBump returns the version bump required by the changes in the report. Breaking changes
+// only require a minor bump in packages that are not GA yet (0.x versions).
+func suggestBump(report *Report, released *semver.Version) string {
+	switch {
+	case report.HasBreakingChanges() && released.Major() > 0:
+		return BumpMajor
+	case report.HasBreakingChanges(), len(report.ChangesOfType(Enhancement)) > 0:
+		return BumpMinor
+	default:
+		return BumpPatch
+	}
+}
+
+func entryDescription(changes []Change) string {
+	descriptions := make([]string, len(changes))
+	for i, change := range changes {
+		description := change.Description
+		// Changes of whole data streams already mention them in their descriptions.
+		if change.DataStream != "" && !strings.Contains(description, fmt.Sprintf("%q", change.DataStream)) {
+			description = fmt.Sprintf("%s in data stream %q", description, change.DataStream)
+		}
+		descriptions[i] = description
+	}
+	description := strings.Join(descriptions, ", ")
+
+	runes := []rune(description)
+	runes[0] = unicode.ToUpper(runes[0])
+	return string(runes) + "."
+}
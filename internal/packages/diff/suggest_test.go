@@ -0,0 +1,134 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package diff
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/elastic-package/internal/packages/changelog"
+)
+
+func TestSuggest(t *testing.T) {
+	const link = "https://github.com/elastic/integrations/pull/1"
+
+	cases := []struct {
+		title      string
+		oldVersion string
+		newVersion string
+		changes    []Change
+		expected   *Suggestion
+	}{
+		{
+			title:      "no changes",
+			oldVersion: "1.0.0",
+			newVersion: "1.0.0",
+		},
+		{
+			title:      "bugfix",
+			oldVersion: "1.2.3",
+			newVersion: "1.2.3",
+			changes: []Change{
+				{Type: Bugfix, Area: AreaIngestPipeline, DataStream: "logs", Description: `ingest pipeline "default" changed`},
+			},
+			expected: &Suggestion{
+				Bump: BumpPatch,
+				Revision: changelog.Revision{
+					Version: "1.2.4",
+					Changes: []changelog.Entry{
+						{Type: Bugfix, Link: link, Description: `Ingest pipeline "default" changed in data stream "logs".`},
+					},
+				},
+			},
+		},
+		{
+			title:      "enhancements and bugfixes",
+			oldVersion: "1.2.3",
+			newVersion: "1.2.3",
+			changes: []Change{
+				{Type: Enhancement, Area: AreaDataStream, DataStream: "metrics", Description: `data stream "metrics" added`},
+				{Type: Enhancement, Area: AreaField, Description: `field "foo" added`},
+				{Type: Bugfix, Area: AreaVariable, Description: `default value of variable "period" in package changed from 10s to 30s`},
+			},
+			expected: &Suggestion{
+				Bump: BumpMinor,
+				Revision: changelog.Revision{
+					Version: "1.3.0",
+					Changes: []changelog.Entry{
+						{Type: Enhancement, Link: link, Description: `Data stream "metrics" added, field "foo" added.`},
+						{Type: Bugfix, Link: link, Description: `Default value of variable "period" in package changed from 10s to 30s.`},
+					},
+				},
+			},
+		},
+		{
+			title:      "breaking change",
+			oldVersion: "1.2.3",
+			newVersion: "1.2.3",
+			changes: []Change{
+				{Type: Breaking, Area: AreaField, DataStream: "logs", Description: `field "foo" removed`},
+			},
+			expected: &Suggestion{
+				Bump: BumpMajor,
+				Revision: changelog.Revision{
+					Version: "2.0.0",
+					Changes: []changelog.Entry{
+						{Type: Breaking, Link: link, Description: `Field "foo" removed in data stream "logs".`},
+					},
+				},
+			},
+		},
+		{
+			title:      "breaking change before GA",
+			oldVersion: "0.4.1",
+			newVersion: "0.4.1",
+			changes: []Change{
+				{Type: Breaking, Area: AreaField, Description: `field "foo" removed`},
+			},
+			expected: &Suggestion{
+				Bump: BumpMinor,
+				Revision: changelog.Revision{
+					Version: "0.5.0",
+					Changes: []changelog.Entry{
+						{Type: Breaking, Link: link, Description: `Field "foo" removed.`},
+					},
+				},
+			},
+		},
+		{
+			title:      "version already increased",
+			oldVersion: "1.2.3",
+			newVersion: "2.0.0",
+			changes: []Change{
+				{Type: Enhancement, Area: AreaField, Description: `field "foo" added`},
+			},
+			expected: &Suggestion{
+				Bump: BumpMinor,
+				Revision: changelog.Revision{
+					Version: "2.0.0",
+					Changes: []changelog.Entry{
+						{Type: Enhancement, Link: link, Description: `Field "foo" added.`},
+					},
+				},
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.title, func(t *testing.T) {
+			report := Report{
+				Name:       "foo",
+				OldVersion: c.oldVersion,
+				NewVersion: c.newVersion,
+				Changes:    c.changes,
+			}
+			suggestion, err := Suggest(&report, link)
+			require.NoError(t, err)
+			assert.Equal(t, c.expected, suggestion)
+		})
+	}
+}
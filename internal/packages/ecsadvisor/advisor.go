@@ -0,0 +1,540 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package ecsadvisor
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/elastic/elastic-package/internal/common"
+	"github.com/elastic/elastic-package/internal/fields"
+	"github.com/elastic/elastic-package/internal/packages"
+	"github.com/elastic/elastic-package/internal/packages/buildmanifest"
+)
+
+// Kinds of findings.
+const (
+	// KindEquivalent is used for custom fields that have an equivalent field in ECS.
+	KindEquivalent = "ecs_equivalent"
+	// KindAlias is used for custom fields that duplicate the values of their ECS equivalent.
+	KindAlias = "alias"
+	// KindCopyTo is used for custom fields whose values could be copied to an ECS field
+	// that is not set.
+	KindCopyTo = "copy_to"
+	// KindExternal is used for fields defined locally with the same name and type as in ECS.
+	KindExternal = "external"
+	// KindType is used for ECS fields defined with a different type than in ECS.
+	KindType = "type"
+	// KindValue is used for ECS fields with values that don't match their ECS type.
+	KindValue = "value"
+	// KindCategorization is used for missing categorization fields.
+	KindCategorization = "categorization"
+)
+
+// Kinds contains the kinds of findings, in the order they are reported.
+var Kinds = []string{KindEquivalent, KindAlias, KindCopyTo, KindExternal, KindType, KindValue, KindCategorization}
+
+const ecsSchemaName = "ecs"
+
+// Finding is a suggestion to align a field with ECS.
+type Finding struct {
+	Kind        string `json:"kind"`
+	DataStream  string `json:"data_stream,omitempty"`
+	Field       string `json:"field"`
+	ECSField    string `json:"ecs_field,omitempty"`
+	Description string `json:"description"`
+}
+
+// Report contains the findings of the ECS alignment check of a package.
+type Report struct {
+	Name       string         `json:"name"`
+	Version    string         `json:"version"`
+	ECSVersion string         `json:"ecs_version"`
+	Summary    map[string]int `json:"summary"`
+	Findings   []Finding      `json:"findings"`
+}
+
+// FindingsOfKind returns the findings of the given kind.
+func (r *Report) FindingsOfKind(kind string) []Finding {
+	var findings []Finding
+	for _, finding := range r.Findings {
+		if finding.Kind == kind {
+			findings = append(findings, finding)
+		}
+	}
+	return findings
+}
+
+func (r *Report) add(kind, dataStream, field, ecsField, format string, a ...any) {
+	r.Findings = append(r.Findings, Finding{
+		Kind:        kind,
+		DataStream:  dataStream,
+		Field:       field,
+		ECSField:    ecsField,
+		Description: fmt.Sprintf(format, a...),
+	})
+	r.Summary[kind]++
+}
+
+// Check compares the field definitions of the package, and the values of the fields in the
+// results of pipeline tests, with the ECS schema the package depends on.
+func Check(packageRoot string) (*Report, error) {
+	manifest, err := packages.ReadPackageManifestFromPackageRoot(packageRoot)
+	if err != nil {
+		return nil, fmt.Errorf("reading package manifest failed: %w", err)
+	}
+
+	bm, ok, err := buildmanifest.ReadBuildManifest(packageRoot)
+	if err != nil {
+		return nil, fmt.Errorf("can't read build manifest: %w", err)
+	}
+	if !ok || bm.Dependencies.ECS.Reference == "" {
+		return nil, errors.New("package doesn't depend on ECS, it must be defined in the build manifest (_dev/build/build.yml)")
+	}
+	fdm, err := fields.CreateFieldDependencyManager(bm.Dependencies)
+	if err != nil {
+		return nil, fmt.Errorf("can't create field dependency manager: %w", err)
+	}
+	ecsFields, err := fdm.ImportAllFields(ecsSchemaName)
+	if err != nil {
+		return nil, fmt.Errorf("can't read ECS fields: %w", err)
+	}
+	ecsVersion, _ := fdm.ECSSchema()
+
+	report := Report{
+		Name:       manifest.Name,
+		Version:    manifest.Version,
+		ECSVersion: ecsVersion,
+		Summary:    make(map[string]int),
+	}
+	for _, kind := range Kinds {
+		report.Summary[kind] = 0
+	}
+
+	advisor := newAdvisor(ecsFields)
+	if manifest.Type == "input" {
+		err := advisor.check(&report, "", packageRoot, "logs")
+		if err != nil {
+			return nil, err
+		}
+		return &report, nil
+	}
+
+	dataStreams, err := filepath.Glob(filepath.Join(packageRoot, "data_stream", "*", packages.DataStreamManifestFile))
+	if err != nil {
+		return nil, err
+	}
+	for _, path := range dataStreams {
+		dsManifest, err := packages.ReadDataStreamManifest(path)
+		if err != nil {
+			return nil, fmt.Errorf("reading data stream manifest failed: %w", err)
+		}
+		dataStreamRoot := filepath.Dir(path)
+		err = advisor.check(&report, filepath.Base(dataStreamRoot), dataStreamRoot, dsManifest.Type)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return &report, nil
+}
+
+// field is a leaf field definition with its full name.
+type field struct {
+	name     string
+	typ      string
+	external string
+}
+
+type advisor struct {
+	ecs map[string]field
+
+	// ecsSuffixes indexes ECS fields by the names of the last two levels of their paths.
+	ecsSuffixes map[string][]string
+}
+
+func newAdvisor(ecsDefinitions []fields.FieldDefinition) *advisor {
+	a := advisor{
+		ecs:         make(map[string]field),
+		ecsSuffixes: make(map[string][]string),
+	}
+	for _, f := range flattenDefinitions("", ecsDefinitions) {
+		a.ecs[f.name] = f
+		parts := strings.Split(f.name, ".")
+		if len(parts) >= 2 {
+			suffix := strings.Join(parts[len(parts)-2:], ".")
+			a.ecsSuffixes[suffix] = append(a.ecsSuffixes[suffix], f.name)
+		}
+	}
+	return &a
+}
+
+func (a *advisor) check(report *Report, dataStream, root, dataStreamType string) error {
+	definitions, err := fields.ReadFieldsFromDir(filepath.Join(root, "fields"))
+	if err != nil {
+		return fmt.Errorf("reading field definitions failed: %w", err)
+	}
+	local := flattenDefinitions("", definitions)
+
+	docs, err := readPipelineTestResults(root)
+	if err != nil {
+		return err
+	}
+	values := make(map[string][]documentValue)
+	for i, doc := range docs {
+		flattenDocument(i, "", doc, values)
+	}
+
+	for _, f := range local {
+		ecsField, isECS := a.ecs[f.name]
+		if !isECS {
+			if f.external == "" {
+				a.checkCustomField(report, dataStream, f, values)
+			}
+			continue
+		}
+
+		switch {
+		case f.external == "" && f.typ == ecsField.typ:
+			report.add(KindExternal, dataStream, f.name, f.name,
+				"field %s is defined in ECS, import it with \"external: ecs\"", f.name)
+		case f.typ != "" && !compatibleDefinitionType(ecsField.typ, f.typ):
+			report.add(KindType, dataStream, f.name, f.name,
+				"field %s is defined with type %s, but it is %s in ECS", f.name, f.typ, ecsField.typ)
+		}
+	}
+
+	// Values of ECS fields are checked also for fields not defined in the data stream,
+	// such as the ones defined in ecs@mappings.
+	for _, name := range sortedKeys(values) {
+		ecsField, found := a.ecs[name]
+		if !found {
+			continue
+		}
+		valueType, ok := valuesType(values[name], ecsField.typ)
+		if !ok {
+			report.add(KindValue, dataStream, name, name,
+				"field %s has %s values in pipeline test results, but it is %s in ECS", name, valueType, ecsField.typ)
+		}
+	}
+
+	a.checkCategorization(report, dataStream, dataStreamType, local, values, len(docs) > 0)
+	return nil
+}
+
+func (a *advisor) checkCustomField(report *Report, dataStream string, f field, values map[string][]documentValue) {
+	fieldValues := values[f.name]
+	for _, candidate := range a.equivalents(f.name, fieldValues) {
+		if _, ok := valuesType(fieldValues, a.ecs[candidate].typ); !ok {
+			continue
+		}
+		if duplicatedValues(fieldValues, values[candidate]) {
+			report.add(KindAlias, dataStream, f.name, candidate,
+				"field %s duplicates the values of ECS field %s, define it as an alias (type: alias, path: %s) or remove it", f.name, candidate, candidate)
+			return
+		}
+		if len(fieldValues) > 0 && len(values[candidate]) == 0 {
+			report.add(KindCopyTo, dataStream, f.name, candidate,
+				"ECS field %s is not set, copy the values of field %s to it (copy_to: %s) or replace it", candidate, f.name, candidate)
+			return
+		}
+		report.add(KindEquivalent, dataStream, f.name, candidate,
+			"custom field %s could be replaced by ECS field %s", f.name, candidate)
+		return
+	}
+}
+
+// equivalents returns the names of the ECS fields that could be used instead of a custom field.
+func (a *advisor) equivalents(name string, values []documentValue) []string {
+	var candidates []string
+	addCandidate := func(candidate string) {
+		if _, found := a.ecs[candidate]; found && candidate != name && !slices.Contains(candidates, candidate) {
+			candidates = append(candidates, candidate)
+		}
+	}
+
+	parts := strings.Split(name, ".")
+	if len(parts) > 2 {
+		for _, candidate := range a.ecsSuffixes[strings.Join(parts[len(parts)-2:], ".")] {
+			if strings.HasSuffix(name, "."+candidate) {
+				addCandidate(candidate)
+			}
+		}
+	}
+
+	last := strings.ToLower(strings.ReplaceAll(parts[len(parts)-1], "-", "_"))
+	if candidate, found := ecsSynonyms[last]; found {
+		addCandidate(candidate)
+	}
+
+	if direction, attribute, found := splitDirection(last); found {
+		if attribute == "addr" || attribute == "address" {
+			if valueType, _ := valuesType(values, "ip"); valueType == "ip" {
+				attribute = "ip"
+			} else {
+				attribute = "address"
+			}
+		}
+		addCandidate(direction + "." + attribute)
+	}
+	return candidates
+}
+
+// Names used for custom fields, and the ECS fields that could be used instead.
+var ecsSynonyms = map[string]string{
+	"action":           "event.action",
+	"dport":            "destination.port",
+	"dpt":              "destination.port",
+	"dstip":            "destination.ip",
+	"dstport":          "destination.port",
+	"duration":         "event.duration",
+	"file_name":        "file.name",
+	"file_path":        "file.path",
+	"filename":         "file.name",
+	"filepath":         "file.path",
+	"host_name":        "host.name",
+	"hostname":         "host.name",
+	"http_method":      "http.request.method",
+	"http_status":      "http.response.status_code",
+	"http_status_code": "http.response.status_code",
+	"level":            "log.level",
+	"log_level":        "log.level",
+	"loglevel":         "log.level",
+	"md5":              "file.hash.md5",
+	"method":           "http.request.method",
+	"msg":              "message",
+	"pid":              "process.pid",
+	"process_id":       "process.pid",
+	"process_name":     "process.name",
+	"proto":            "network.transport",
+	"protocol":         "network.protocol",
+	"referer":          "http.request.referrer",
+	"referrer":         "http.request.referrer",
+	"request_method":   "http.request.method",
+	"response_code":    "http.response.status_code",
+	"rule_id":          "rule.id",
+	"rule_name":        "rule.name",
+	"sha1":             "file.hash.sha1",
+	"sha256":           "file.hash.sha256",
+	"sport":            "source.port",
+	"spt":              "source.port",
+	"srcip":            "source.ip",
+	"srcport":          "source.port",
+	"status_code":      "http.response.status_code",
+	"timezone":         "event.timezone",
+	"uri":              "url.original",
+	"url":              "url.original",
+	"user":             "user.name",
+	"user_agent":       "user_agent.original",
+	"user_id":          "user.id",
+	"user_name":        "user.name",
+	"useragent":        "user_agent.original",
+	"username":         "user.name",
+}
+
+var (
+	directionPrefixes = map[string]string{
+		"src":         "source",
+		"source":      "source",
+		"dst":         "destination",
+		"dest":        "destination",
+		"destination": "destination",
+		"client":      "client",
+		"server":      "server",
+	}
+
+	directionAttributes = []string{"ip", "port", "mac", "bytes", "packets", "domain", "addr", "address"}
+)
+
+// splitDirection splits names like src_ip or destination_port in the ECS field set of the
+// direction and the attribute.
+func splitDirection(name string) (string, string, bool) {
+	prefix, attribute, found := strings.Cut(name, "_")
+	if !found {
+		return "", "", false
+	}
+	direction, found := directionPrefixes[prefix]
+	if !found || !slices.Contains(directionAttributes, attribute) {
+		return "", "", false
+	}
+	return direction, attribute, true
+}
+
+var categorizationFields = map[string][]string{
+	"logs":    {"event.kind", "event.category", "event.type"},
+	"metrics": {"event.kind"},
+}
+
+func (a *advisor) checkCategorization(report *Report, dataStream, dataStreamType string, local []field, values map[string][]documentValue, hasResults bool) {
+	for _, name := range categorizationFields[dataStreamType] {
+		if _, found := a.ecs[name]; !found {
+			continue
+		}
+		if slices.ContainsFunc(local, func(f field) bool { return f.name == name }) && !hasResults {
+			continue
+		}
+		if len(values[name]) > 0 {
+			continue
+		}
+		if hasResults {
+			report.add(KindCategorization, dataStream, name, name,
+				"categorization field %s is not set in pipeline test results", name)
+		} else {
+			report.add(KindCategorization, dataStream, name, name,
+				"categorization field %s is not defined", name)
+		}
+	}
+}
+
+// compatibleDefinitionType checks if a local definition can override the type of an ECS field.
+func compatibleDefinitionType(ecsType, localType string) bool {
+	switch {
+	case ecsType == localType:
+		return true
+	case ecsType == "keyword" && localType == "constant_keyword":
+		return true
+	case ecsType == "object" && (localType == "group" || localType == "nested"):
+		return true
+	}
+	return false
+}
+
+// documentValue is a value of a field in a document.
+type documentValue struct {
+	doc   int
+	value any
+}
+
+// valuesType returns the type inferred for the values, and if they are compatible with the
+// ECS type.
+func valuesType(values []documentValue, ecsType string) (string, bool) {
+	for _, v := range values {
+		valueType := fields.ValueType(v.value)
+		if valueType == "" {
+			continue
+		}
+		if !compatibleValueType(ecsType, valueType) {
+			return valueType, false
+		}
+	}
+	if len(values) == 0 {
+		return "", true
+	}
+	return fields.ValueType(values[0].value), true
+}
+
+func compatibleValueType(ecsType, valueType string) bool {
+	switch ecsType {
+	case "ip":
+		return valueType == "ip"
+	case "date":
+		return valueType == "date" || valueType == "long"
+	case "long", "integer", "short", "byte", "unsigned_long":
+		return valueType == "long"
+	case "float", "half_float", "double", "scaled_float":
+		return valueType == "long" || valueType == "double"
+	case "boolean":
+		return valueType == "boolean"
+	case "geo_point":
+		return valueType == "geo_point"
+	case "keyword", "constant_keyword", "wildcard", "text", "match_only_text":
+		return valueType != "geo_point"
+	}
+	// Other types, like objects or flattened fields, are not checked.
+	return true
+}
+
+// duplicatedValues checks if the values of a field are also in the other field, in the same documents.
+func duplicatedValues(values, other []documentValue) bool {
+	if len(values) == 0 || len(other) == 0 {
+		return false
+	}
+	for _, v := range values {
+		if !slices.ContainsFunc(other, func(o documentValue) bool {
+			return o.doc == v.doc && fmt.Sprint(o.value) == fmt.Sprint(v.value)
+		}) {
+			return false
+		}
+	}
+	return true
+}
+
+func flattenDefinitions(prefix string, definitions []fields.FieldDefinition) []field {
+	var result []field
+	for _, definition := range definitions {
+		name := definition.Name
+		if prefix != "" {
+			name = prefix + "." + name
+		}
+		if len(definition.Fields) > 0 {
+			result = append(result, flattenDefinitions(name, definition.Fields)...)
+			continue
+		}
+		if definition.Type == "group" {
+			continue
+		}
+		result = append(result, field{
+			name:     name,
+			typ:      definition.Type,
+			external: definition.External,
+		})
+	}
+	return result
+}
+
+func flattenDocument(doc int, prefix string, value any, result map[string][]documentValue) {
+	switch v := value.(type) {
+	case nil:
+	case []any:
+		for _, element := range v {
+			flattenDocument(doc, prefix, element, result)
+		}
+	case common.MapStr:
+		flattenDocument(doc, prefix, map[string]any(v), result)
+	case map[string]any:
+		if fields.ValueType(v) == "geo_point" {
+			result[prefix] = append(result[prefix], documentValue{doc: doc, value: v})
+			return
+		}
+		for key, child := range v {
+			name := key
+			if prefix != "" {
+				name = prefix + "." + key
+			}
+			flattenDocument(doc, name, child, result)
+		}
+	default:
+		result[prefix] = append(result[prefix], documentValue{doc: doc, value: v})
+	}
+}
+
+// readPipelineTestResults reads the documents in the expected results of pipeline tests.
+func readPipelineTestResults(root string) ([]common.MapStr, error) {
+	results, err := filepath.Glob(filepath.Join(root, "_dev", "test", "pipeline", "*-expected.json"))
+	if err != nil {
+		return nil, err
+	}
+	var docs []common.MapStr
+	for _, path := range results {
+		resultDocs, err := fields.LoadSampleDocuments(path)
+		if err != nil {
+			return nil, err
+		}
+		docs = append(docs, resultDocs...)
+	}
+	return docs, nil
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
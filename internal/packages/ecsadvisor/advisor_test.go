@@ -0,0 +1,118 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package ecsadvisor
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeTestFile(t *testing.T, path, content string) {
+	t.Helper()
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+}
+
+func TestCheck(t *testing.T) {
+	ecsSchema, err := filepath.Abs(filepath.Join("..", "..", "fields", "testdata", "ecs_nested_v8.10.0.yml"))
+	require.NoError(t, err)
+
+	packageRoot := t.TempDir()
+	writeTestFile(t, filepath.Join(packageRoot, "manifest.yml"), `format_version: 3.0.0
+name: vendor
+title: Vendor
+version: 1.0.0
+type: integration
+`)
+	writeTestFile(t, filepath.Join(packageRoot, "_dev", "build", "build.yml"), `dependencies:
+  ecs:
+    reference: file://`+ecsSchema+`
+`)
+
+	dataStreamRoot := filepath.Join(packageRoot, "data_stream", "log")
+	writeTestFile(t, filepath.Join(dataStreamRoot, "manifest.yml"), `title: Logs
+type: logs
+`)
+	writeTestFile(t, filepath.Join(dataStreamRoot, "fields", "ecs.yml"), `- name: event.kind
+  external: ecs
+- name: source.port
+  external: ecs
+  type: keyword
+- name: host.name
+  type: keyword
+`)
+	writeTestFile(t, filepath.Join(dataStreamRoot, "fields", "fields.yml"), `- name: vendor
+  type: group
+  fields:
+    - name: src_ip
+      type: keyword
+    - name: user
+      type: keyword
+    - name: dst_addr
+      type: keyword
+    - name: custom
+      type: keyword
+    - name: srcport
+      type: long
+`)
+	writeTestFile(t, filepath.Join(dataStreamRoot, "_dev", "test", "pipeline", "test-log.log-expected.json"), `{
+  "expected": [
+    {
+      "event": {"kind": "event"},
+      "destination": {"port": "http"},
+      "source": {"port": 80},
+      "user": {"name": "alice"},
+      "vendor": {"src_ip": "10.0.0.1", "user": "alice", "dst_addr": "example.com", "custom": "foo", "srcport": 1234}
+    }
+  ]
+}`)
+
+	report, err := Check(packageRoot)
+	require.NoError(t, err)
+
+	assert.Equal(t, "vendor", report.Name)
+	assert.Equal(t, []Finding{
+		{Kind: KindType, DataStream: "log", Field: "source.port", ECSField: "source.port", Description: "field source.port is defined with type keyword, but it is long in ECS"},
+		{Kind: KindExternal, DataStream: "log", Field: "host.name", ECSField: "host.name", Description: `field host.name is defined in ECS, import it with "external: ecs"`},
+		{Kind: KindCopyTo, DataStream: "log", Field: "vendor.src_ip", ECSField: "source.ip", Description: "ECS field source.ip is not set, copy the values of field vendor.src_ip to it (copy_to: source.ip) or replace it"},
+		{Kind: KindAlias, DataStream: "log", Field: "vendor.user", ECSField: "user.name", Description: "field vendor.user duplicates the values of ECS field user.name, define it as an alias (type: alias, path: user.name) or remove it"},
+		{Kind: KindCopyTo, DataStream: "log", Field: "vendor.dst_addr", ECSField: "destination.address", Description: "ECS field destination.address is not set, copy the values of field vendor.dst_addr to it (copy_to: destination.address) or replace it"},
+		{Kind: KindEquivalent, DataStream: "log", Field: "vendor.srcport", ECSField: "source.port", Description: "custom field vendor.srcport could be replaced by ECS field source.port"},
+		{Kind: KindValue, DataStream: "log", Field: "destination.port", ECSField: "destination.port", Description: "field destination.port has keyword values in pipeline test results, but it is long in ECS"},
+		{Kind: KindCategorization, DataStream: "log", Field: "event.category", ECSField: "event.category", Description: "categorization field event.category is not set in pipeline test results"},
+		{Kind: KindCategorization, DataStream: "log", Field: "event.type", ECSField: "event.type", Description: "categorization field event.type is not set in pipeline test results"},
+	}, report.Findings)
+	assert.Equal(t, 1, report.Summary[KindEquivalent])
+	assert.Equal(t, 2, report.Summary[KindCopyTo])
+	assert.Equal(t, 2, report.Summary[KindCategorization])
+}
+
+func TestSplitDirection(t *testing.T) {
+	cases := []struct {
+		name      string
+		direction string
+		attribute string
+		found     bool
+	}{
+		{name: "src_ip", direction: "source", attribute: "ip", found: true},
+		{name: "destination_port", direction: "destination", attribute: "port", found: true},
+		{name: "client_mac", direction: "client", attribute: "mac", found: true},
+		{name: "src_country", found: false},
+		{name: "ip", found: false},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			direction, attribute, found := splitDirection(c.name)
+			assert.Equal(t, c.found, found)
+			assert.Equal(t, c.direction, direction)
+			assert.Equal(t, c.attribute, attribute)
+		})
+	}
+}
@@ -0,0 +1,74 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package fieldusage
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/elastic/elastic-package/internal/packages"
+)
+
+// KnownFields contains the fields that documents can have when they are processed by the ingest
+// pipelines of a data stream: the fields declared in the data stream, and the ones produced by
+// its ingest pipelines.
+type KnownFields struct {
+	declared []declaredField
+	pipeline *pipelineFields
+}
+
+// ReadKnownFields reads the fields known in a data stream, or in the package root for input packages.
+func ReadKnownFields(packageRoot, root string) (*KnownFields, error) {
+	manifest, err := packages.ReadPackageManifestFromPackageRoot(packageRoot)
+	if err != nil {
+		return nil, fmt.Errorf("reading package manifest failed: %w", err)
+	}
+	declared, err := readDeclaredFields(root, manifest.SpecVersion)
+	if err != nil {
+		return nil, err
+	}
+	pipeline := newPipelineFields()
+	err = collectIngestPipelinesFields(root, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	return &KnownFields{declared: declared, pipeline: pipeline}, nil
+}
+
+// Unknown returns the fields referenced in a painless condition that are not known. Metadata fields
+// are always known, and no fields are reported if the pipelines produce fields that can't be known
+// statically.
+func (k *KnownFields) Unknown(condition string) []string {
+	if k.pipeline.dynamic {
+		return nil
+	}
+	known := newNameSet()
+	known.merge(k.pipeline.produced)
+	known.merge(k.pipeline.scripted)
+	for name := range known {
+		if strings.Contains(name, "{{") {
+			return nil
+		}
+	}
+
+	unknown := newNameSet()
+	for _, name := range scriptFields(condition) {
+		if strings.HasPrefix(name, "_") || isDeclared(name, k.declared) || isProduced(name, known) {
+			continue
+		}
+		unknown.add(name)
+	}
+	return unknown.sorted()
+}
+
+// isProduced checks if a field, an object containing it, or a field inside it is produced.
+func isProduced(name string, produced nameSet) bool {
+	for field := range produced {
+		if field == name || strings.HasPrefix(field, name+".") || strings.HasPrefix(name, field+".") {
+			return true
+		}
+	}
+	return false
+}
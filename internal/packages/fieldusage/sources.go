@@ -0,0 +1,406 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package fieldusage
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	// ctxFieldPattern matches fields referenced in painless scripts and conditions, like ctx.a.b or ctx?.a?.b.
+	ctxFieldPattern = regexp.MustCompile(`ctx\??\.([A-Za-z_@][\w@]*(?:\??\.[A-Za-z_@][\w@]*)*)(\()?`)
+
+	// templateFieldPattern matches fields referenced in mustache templates.
+	templateFieldPattern = regexp.MustCompile(`\{\{\{?\s*([A-Za-z_@][\w.@]*)\s*\}?\}\}`)
+
+	// grokFieldPatterns match the fields captured in grok patterns.
+	grokFieldPatterns = []*regexp.Regexp{
+		regexp.MustCompile(`%\{[A-Za-z0-9_]+:([^:}]+)(?::[a-z]+)?\}`),
+		regexp.MustCompile(`\(\?<([^>]+)>`),
+	}
+
+	// dissectFieldPattern matches the keys in dissect patterns.
+	dissectFieldPattern = regexp.MustCompile(`%\{([^}]*)\}`)
+
+	// templateConfigPattern matches settings in agent templates that reference fields.
+	templateConfigPattern = regexp.MustCompile(`(?m)^\s*-?\s*(?:field|from|to|target|target_field)\s*:\s*["']?([A-Za-z_@][\w.@-]*)["']?\s*$`)
+
+	// templateFieldsListPattern matches inline lists of fields in agent templates.
+	templateFieldsListPattern = regexp.MustCompile(`(?m)^\s*-?\s*fields\s*:\s*\[([^\]]*)\]`)
+
+	// idPattern matches the identifiers used in Kibana assets to reference other elements.
+	idPattern = regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}$`)
+
+	// kqlFieldPattern matches the fields in KQL and Lucene queries.
+	kqlFieldPattern = regexp.MustCompile(`(?:^|[\s(!])([A-Za-z_@][\w.@-]*)\s*:`)
+)
+
+// Default target fields of processors that have one.
+var defaultTargetFields = map[string]string{
+	"date":       "@timestamp",
+	"geoip":      "geoip",
+	"user_agent": "user_agent",
+}
+
+// pipelineFields contains the fields used by ingest pipelines.
+type pipelineFields struct {
+	produced   nameSet
+	referenced nameSet
+	removed    nameSet
+
+	// scripted contains the fields referenced in scripts, that can be set by them.
+	scripted nameSet
+
+	// dynamic is true if some processor produces fields that can't be known statically.
+	dynamic bool
+}
+
+func newPipelineFields() *pipelineFields {
+	return &pipelineFields{
+		produced:   newNameSet(),
+		referenced: newNameSet(),
+		removed:    newNameSet(),
+		scripted:   newNameSet(),
+	}
+}
+
+func collectIngestPipelinesFields(root string, pf *pipelineFields) error {
+	paths, err := filepath.Glob(filepath.Join(root, "elasticsearch", "ingest_pipeline", "*"))
+	if err != nil {
+		return err
+	}
+	for _, path := range paths {
+		switch filepath.Ext(path) {
+		case ".yml", ".yaml", ".json":
+		default:
+			continue
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("reading ingest pipeline failed: %w", err)
+		}
+		var pipeline struct {
+			Processors []any `yaml:"processors"`
+			OnFailure  []any `yaml:"on_failure"`
+		}
+		// JSON pipelines can be read as YAML.
+		err = yaml.Unmarshal(content, &pipeline)
+		if err != nil {
+			return fmt.Errorf("parsing ingest pipeline failed (path: %s): %w", path, err)
+		}
+		pf.collect(pipeline.Processors)
+		pf.collect(pipeline.OnFailure)
+	}
+	return nil
+}
+
+func (pf *pipelineFields) collect(processors []any) {
+	for _, processor := range processors {
+		processor, ok := processor.(map[string]any)
+		if !ok {
+			continue
+		}
+		for processorType, config := range processor {
+			config, ok := config.(map[string]any)
+			if !ok {
+				continue
+			}
+			pf.collectProcessor(processorType, config)
+		}
+	}
+}
+
+func (pf *pipelineFields) collectProcessor(processorType string, config map[string]any) {
+	if condition, ok := config["if"].(string); ok {
+		pf.referenced.add(scriptFields(condition)...)
+	}
+	if onFailure, ok := config["on_failure"].([]any); ok {
+		pf.collect(onFailure)
+	}
+
+	switch processorType {
+	case "set", "append":
+		pf.produced.add(stringValue(config["field"]))
+		pf.referenced.add(stringValue(config["copy_from"]))
+		pf.referenced.add(templateFields(config["value"])...)
+		return
+	case "remove":
+		names := stringValues(config["field"])
+		pf.removed.add(names...)
+		pf.referenced.add(names...)
+		return
+	case "script":
+		names := scriptFields(stringValue(config["source"]))
+		pf.referenced.add(names...)
+		pf.scripted.add(names...)
+		return
+	case "foreach":
+		pf.referenced.add(stringValue(config["field"]))
+		if processor, ok := config["processor"].(map[string]any); ok {
+			pf.collect([]any{processor})
+		}
+		return
+	case "grok":
+		for _, pattern := range stringValues(config["patterns"]) {
+			for _, re := range grokFieldPatterns {
+				for _, match := range re.FindAllStringSubmatch(pattern, -1) {
+					pf.produced.add(match[1])
+				}
+			}
+		}
+	case "dissect":
+		for _, match := range dissectFieldPattern.FindAllStringSubmatch(stringValue(config["pattern"]), -1) {
+			pf.produced.add(dissectKey(match[1]))
+		}
+	case "kv":
+		pf.dynamic = pf.dynamic || config["target_field"] == nil
+	case "json":
+		pf.dynamic = pf.dynamic || config["add_to_root"] == true
+	}
+
+	pf.referenced.add(stringValues(config["field"])...)
+	pf.referenced.add(stringValues(config["fields"])...)
+	target := stringValue(config["target_field"])
+	if target == "" {
+		target = defaultTargetFields[processorType]
+	}
+	pf.produced.add(target)
+}
+
+// dissectKey returns the field of a key in a dissect pattern, or an empty string if it
+// doesn't produce a field.
+func dissectKey(key string) string {
+	key, _, _ = strings.Cut(key, "->")
+	key, _, _ = strings.Cut(key, "/")
+	switch {
+	case strings.HasPrefix(key, "?"), strings.HasPrefix(key, "*"), strings.HasPrefix(key, "&"):
+		// Skipped and reference keys.
+		return ""
+	}
+	return strings.TrimPrefix(key, "+")
+}
+
+func scriptFields(source string) []string {
+	var names []string
+	for _, match := range ctxFieldPattern.FindAllStringSubmatch(source, -1) {
+		name := strings.ReplaceAll(match[1], "?", "")
+		if match[2] != "" {
+			// Last element is a method call.
+			i := strings.LastIndex(name, ".")
+			if i < 0 {
+				continue
+			}
+			name = name[:i]
+		}
+		names = append(names, name)
+	}
+	return names
+}
+
+func templateFields(value any) []string {
+	var names []string
+	for _, s := range stringValues(value) {
+		for _, match := range templateFieldPattern.FindAllStringSubmatch(s, -1) {
+			if !strings.HasPrefix(match[1], "_ingest.") {
+				names = append(names, match[1])
+			}
+		}
+	}
+	return names
+}
+
+func stringValue(value any) string {
+	s, _ := value.(string)
+	return s
+}
+
+func stringValues(value any) []string {
+	switch v := value.(type) {
+	case string:
+		return []string{v}
+	case []any:
+		var values []string
+		for _, element := range v {
+			if s, ok := element.(string); ok {
+				values = append(values, s)
+			}
+		}
+		return values
+	}
+	return nil
+}
+
+// collectAgentTemplatesFields collects the fields referenced in agent stream and input templates.
+func collectAgentTemplatesFields(root string, used nameSet) error {
+	var templates []string
+	for _, dir := range []string{"stream", "input"} {
+		paths, err := filepath.Glob(filepath.Join(root, "agent", dir, "*.hbs"))
+		if err != nil {
+			return err
+		}
+		templates = append(templates, paths...)
+	}
+	for _, path := range templates {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("reading agent template failed: %w", err)
+		}
+		for _, match := range templateConfigPattern.FindAllSubmatch(content, -1) {
+			used.add(string(match[1]))
+		}
+		for _, match := range templateFieldsListPattern.FindAllSubmatch(content, -1) {
+			for _, name := range strings.Split(string(match[1]), ",") {
+				used.add(strings.Trim(strings.TrimSpace(name), `"'`))
+			}
+		}
+	}
+	return nil
+}
+
+// Kibana assets that reference fields.
+var kibanaAssetTypes = []string{"dashboard", "visualization", "lens", "search", "map"}
+
+// Keys of the settings of Kibana assets that reference fields.
+var kibanaFieldKeys = []string{"field", "fieldName", "sourceField", "geoField", "terms_field", "time_field", "timeField"}
+
+// collectKibanaFields collects the fields referenced in Kibana assets.
+func collectKibanaFields(packageRoot string, used nameSet) error {
+	for _, assetType := range kibanaAssetTypes {
+		paths, err := filepath.Glob(filepath.Join(packageRoot, "kibana", assetType, "*.json"))
+		if err != nil {
+			return err
+		}
+		for _, path := range paths {
+			content, err := os.ReadFile(path)
+			if err != nil {
+				return fmt.Errorf("reading Kibana asset failed: %w", err)
+			}
+			var asset any
+			err = json.Unmarshal(content, &asset)
+			if err != nil {
+				return fmt.Errorf("parsing Kibana asset failed (path: %s): %w", path, err)
+			}
+			collectKibanaAssetFields("", asset, used)
+		}
+	}
+	return nil
+}
+
+func collectKibanaAssetFields(key string, value any, used nameSet) {
+	switch v := value.(type) {
+	case map[string]any:
+		for k, child := range v {
+			collectKibanaAssetFields(k, child, used)
+		}
+	case []any:
+		for _, element := range v {
+			if key == "columns" {
+				if name, ok := element.(string); ok {
+					addKibanaField(name, used)
+					continue
+				}
+			}
+			collectKibanaAssetFields(key, element, used)
+		}
+	case string:
+		trimmed := strings.TrimSpace(v)
+		if strings.HasPrefix(trimmed, "{") || strings.HasPrefix(trimmed, "[") {
+			// Some settings are serialized JSON objects.
+			var embedded any
+			if err := json.Unmarshal([]byte(trimmed), &embedded); err == nil {
+				collectKibanaAssetFields(key, embedded, used)
+				return
+			}
+		}
+		switch {
+		case key == "query":
+			for _, name := range queryFields(v) {
+				addKibanaField(name, used)
+			}
+		case slices.Contains(kibanaFieldKeys, key):
+			addKibanaField(v, used)
+		}
+	}
+}
+
+func addKibanaField(name string, used nameSet) {
+	// Metadata fields, patterns and references to other elements are not checked.
+	if strings.HasPrefix(name, "_") || strings.Contains(name, "*") || idPattern.MatchString(name) {
+		return
+	}
+	used.add(name)
+}
+
+func queryFields(query string) []string {
+	var names []string
+	for _, match := range kqlFieldPattern.FindAllStringSubmatchIndex(query, -1) {
+		end := match[1]
+		if strings.HasPrefix(query[end:], "//") {
+			// Part of an URL.
+			continue
+		}
+		names = append(names, query[match[2]:match[3]])
+	}
+	return names
+}
+
+// Keys of the settings of transforms that reference fields.
+var transformFieldKeys = []string{"field", "fields", "unique_key", "sort"}
+
+// collectTransformsFields collects the source fields referenced in transforms.
+func collectTransformsFields(packageRoot string, used nameSet) error {
+	transformsDir := filepath.Join(packageRoot, "elasticsearch", "transform")
+	return filepath.WalkDir(transformsDir, func(path string, d fs.DirEntry, err error) error {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || d.Name() != "transform.yml" {
+			return nil
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("reading transform failed: %w", err)
+		}
+		var transform any
+		err = yaml.Unmarshal(content, &transform)
+		if err != nil {
+			return fmt.Errorf("parsing transform failed (path: %s): %w", path, err)
+		}
+		collectTransformFields("", transform, used)
+		return nil
+	})
+}
+
+func collectTransformFields(key string, value any, used nameSet) {
+	switch v := value.(type) {
+	case map[string]any:
+		for k, child := range v {
+			collectTransformFields(k, child, used)
+		}
+	case []any:
+		for _, element := range v {
+			collectTransformFields(key, element, used)
+		}
+	case string:
+		if slices.Contains(transformFieldKeys, key) {
+			used.add(v)
+		}
+		if key == "source" {
+			used.add(scriptFields(v)...)
+		}
+	}
+}
@@ -0,0 +1,377 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package fieldusage
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/elastic/elastic-package/internal/common"
+	"github.com/elastic/elastic-package/internal/fields"
+	"github.com/elastic/elastic-package/internal/packages"
+)
+
+// ErrReadingDeclaredFields is returned when the fields declared in a data stream can't be read,
+// for example because the ECS schema the package depends on is not available.
+var ErrReadingDeclaredFields = errors.New("reading declared fields failed")
+
+// DataStreamReport contains the fields with usage issues in a data stream.
+type DataStreamReport struct {
+	DataStream string
+
+	// Unused contains fields declared in the data stream, but never produced or referenced.
+	Unused []string
+
+	// Undeclared contains fields produced by the ingest pipelines of the data stream, but
+	// not declared.
+	Undeclared []string
+}
+
+// Report contains the fields with usage issues in a package.
+type Report struct {
+	DataStreams []DataStreamReport
+
+	// UndeclaredInKibana contains fields used by Kibana assets, but not declared in any
+	// data stream.
+	UndeclaredInKibana []string
+}
+
+// Empty returns true if no issues were found.
+func (r *Report) Empty() bool {
+	return len(r.DataStreams) == 0 && len(r.UndeclaredInKibana) == 0
+}
+
+// Check cross-references the fields declared in the package with the fields produced and
+// referenced by its ingest pipelines, agent templates, Kibana assets and transforms.
+func Check(packageRoot string) (*Report, error) {
+	manifest, err := packages.ReadPackageManifestFromPackageRoot(packageRoot)
+	if err != nil {
+		return nil, fmt.Errorf("reading package manifest failed: %w", err)
+	}
+
+	packageRefs := newNameSet()
+	err = collectKibanaFields(packageRoot, packageRefs)
+	if err != nil {
+		return nil, err
+	}
+	kibanaRefs := packageRefs.clone()
+
+	var allDeclared []declaredField
+	transforms, err := filepath.Glob(filepath.Join(packageRoot, "elasticsearch", "transform", "*", "fields"))
+	if err != nil {
+		return nil, err
+	}
+	for _, dir := range transforms {
+		declared, err := readDeclaredFields(filepath.Dir(dir), manifest.SpecVersion)
+		if err != nil {
+			return nil, err
+		}
+		allDeclared = append(allDeclared, declared...)
+	}
+	err = collectTransformsFields(packageRoot, packageRefs)
+	if err != nil {
+		return nil, err
+	}
+
+	var roots []string
+	if manifest.Type == "input" {
+		roots = []string{packageRoot}
+	} else {
+		dataStreams, err := filepath.Glob(filepath.Join(packageRoot, "data_stream", "*", packages.DataStreamManifestFile))
+		if err != nil {
+			return nil, err
+		}
+		for _, path := range dataStreams {
+			roots = append(roots, filepath.Dir(path))
+		}
+	}
+
+	var report Report
+	for _, root := range roots {
+		dataStream := ""
+		if root != packageRoot {
+			dataStream = filepath.Base(root)
+		}
+		dsReport, declared, err := checkDataStream(dataStream, root, manifest.SpecVersion, packageRefs)
+		if err != nil {
+			return nil, err
+		}
+		allDeclared = append(allDeclared, declared...)
+		if len(dsReport.Unused) > 0 || len(dsReport.Undeclared) > 0 {
+			report.DataStreams = append(report.DataStreams, dsReport)
+		}
+	}
+
+	for _, name := range kibanaRefs.sorted() {
+		if !isDeclared(name, allDeclared) {
+			report.UndeclaredInKibana = append(report.UndeclaredInKibana, name)
+		}
+	}
+	return &report, nil
+}
+
+func checkDataStream(dataStream, root, specVersion string, packageRefs nameSet) (DataStreamReport, []declaredField, error) {
+	report := DataStreamReport{DataStream: dataStream}
+	local, err := readLocalFields(filepath.Join(root, "fields"))
+	if err != nil {
+		return report, nil, err
+	}
+	declared, err := readDeclaredFields(root, specVersion)
+	if err != nil {
+		return report, nil, err
+	}
+
+	pipeline := newPipelineFields()
+	err = collectIngestPipelinesFields(root, pipeline)
+	if err != nil {
+		return report, nil, err
+	}
+
+	used := packageRefs.clone()
+	used.merge(pipeline.produced)
+	used.merge(pipeline.referenced)
+	err = collectAgentTemplatesFields(root, used)
+	if err != nil {
+		return report, nil, err
+	}
+	err = collectSampleDocumentsFields(root, used)
+	if err != nil {
+		return report, nil, err
+	}
+
+	for _, field := range local {
+		if !isUsed(field, used) {
+			report.Unused = append(report.Unused, field.name)
+		}
+	}
+	for _, name := range pipeline.produced.sorted() {
+		if pipeline.removed[name] || strings.HasPrefix(name, "_") || strings.Contains(name, "{{") {
+			continue
+		}
+		if !isDeclared(name, declared) {
+			report.Undeclared = append(report.Undeclared, name)
+		}
+	}
+	return report, declared, nil
+}
+
+type nameSet map[string]bool
+
+func newNameSet() nameSet {
+	return make(nameSet)
+}
+
+func (s nameSet) add(names ...string) {
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		if name != "" {
+			s[name] = true
+		}
+	}
+}
+
+func (s nameSet) merge(other nameSet) {
+	for name := range other {
+		s[name] = true
+	}
+}
+
+func (s nameSet) clone() nameSet {
+	clone := newNameSet()
+	clone.merge(s)
+	return clone
+}
+
+func (s nameSet) sorted() []string {
+	names := make([]string, 0, len(s))
+	for name := range s {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// declaredField is a leaf field declared in a fields directory.
+type declaredField struct {
+	name string
+	typ  string
+}
+
+// hasSubfields returns true for fields whose children are not declared individually.
+func (f declaredField) hasSubfields() bool {
+	return f.typ == "object" || f.typ == "flattened" || f.typ == "nested"
+}
+
+// producedFieldsFiles are the conventional files for the fields added by Elastic Agent processors
+// and by Fleet, that are not expected to be referenced in the package.
+var producedFieldsFiles = []string{"agent.yml", "base-fields.yml"}
+
+// isProducedOutsidePackage checks if a field is set in all documents outside of the package.
+func isProducedOutsidePackage(name string) bool {
+	return name == "@timestamp" || strings.HasPrefix(name, "data_stream.")
+}
+
+// readLocalFields reads the fields declared in the files of a fields directory. Fields produced
+// outside of the package are not included.
+func readLocalFields(fieldsDir string) ([]declaredField, error) {
+	paths, err := filepath.Glob(filepath.Join(fieldsDir, "*.yml"))
+	if err != nil {
+		return nil, err
+	}
+	var result []declaredField
+	for _, path := range paths {
+		if slices.Contains(producedFieldsFiles, filepath.Base(path)) {
+			continue
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("%w: reading fields file failed: %w", ErrReadingDeclaredFields, err)
+		}
+		var definitions []fields.FieldDefinition
+		err = yaml.Unmarshal(content, &definitions)
+		if err != nil {
+			return nil, fmt.Errorf("%w: unmarshalling fields file failed (path: %s): %w", ErrReadingDeclaredFields, path, err)
+		}
+		for _, field := range flattenDefinitions("", definitions) {
+			if !isProducedOutsidePackage(field.name) {
+				result = append(result, field)
+			}
+		}
+	}
+	return result, nil
+}
+
+// readDeclaredFields reads the fields declared for the documents of a data stream, as they are
+// used in the validation of documents in tests. They include the external fields, and the ECS
+// fields if the package or the stack define mappings for them.
+func readDeclaredFields(root, specVersion string) ([]declaredField, error) {
+	validator, err := fields.CreateValidatorForDirectory(root,
+		fields.WithSpecVersion(specVersion),
+		fields.WithEnabledImportAllECSSChema(true),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%w: can't create fields validator instance (path: %s): %w", ErrReadingDeclaredFields, root, err)
+	}
+	return flattenDefinitions("", validator.Schema), nil
+}
+
+func flattenDefinitions(prefix string, definitions []fields.FieldDefinition) []declaredField {
+	var result []declaredField
+	for _, definition := range definitions {
+		name := definition.Name
+		if prefix != "" {
+			name = prefix + "." + name
+		}
+		if len(definition.Fields) > 0 {
+			result = append(result, flattenDefinitions(name, definition.Fields)...)
+			continue
+		}
+		if definition.Type == "group" && definition.External == "" {
+			continue
+		}
+		result = append(result, declaredField{name: name, typ: definition.Type})
+	}
+	return result
+}
+
+// matchName checks if a name matches a field name that can contain wildcards.
+func matchName(pattern, name string) bool {
+	if !strings.Contains(pattern, "*") {
+		return pattern == name
+	}
+	patternParts := strings.Split(pattern, ".")
+	nameParts := strings.Split(name, ".")
+	if len(patternParts) != len(nameParts) {
+		return false
+	}
+	for i := range patternParts {
+		if ok, _ := path.Match(patternParts[i], nameParts[i]); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// isDeclared checks if a field, or an object containing declared fields, is declared.
+func isDeclared(name string, declared []declaredField) bool {
+	return slices.ContainsFunc(declared, func(field declaredField) bool {
+		switch {
+		case matchName(field.name, name):
+			return true
+		case strings.HasPrefix(field.name, name+"."):
+			return true
+		case field.hasSubfields() && strings.HasPrefix(name, field.name+"."):
+			return true
+		}
+		return false
+	})
+}
+
+// isUsed checks if a declared field, or an object containing it, is used.
+func isUsed(field declaredField, used nameSet) bool {
+	for name := range used {
+		switch {
+		case matchName(field.name, name):
+			return true
+		case strings.HasPrefix(field.name, name+"."):
+			return true
+		case field.hasSubfields() && strings.HasPrefix(name, field.name+"."):
+			return true
+		}
+	}
+	return false
+}
+
+// collectSampleDocumentsFields collects the fields found in the sample event and in the
+// results of pipeline tests.
+func collectSampleDocumentsFields(root string, used nameSet) error {
+	paths, err := filepath.Glob(filepath.Join(root, "_dev", "test", "pipeline", "*-expected.json"))
+	if err != nil {
+		return err
+	}
+	sampleEvent := filepath.Join(root, "sample_event.json")
+	if _, err := os.Stat(sampleEvent); err == nil {
+		paths = append(paths, sampleEvent)
+	}
+	for _, path := range paths {
+		docs, err := fields.LoadSampleDocuments(path)
+		if err != nil {
+			return err
+		}
+		for _, doc := range docs {
+			collectDocumentFields("", doc, used)
+		}
+	}
+	return nil
+}
+
+func collectDocumentFields(prefix string, value any, used nameSet) {
+	switch v := value.(type) {
+	case []any:
+		for _, element := range v {
+			collectDocumentFields(prefix, element, used)
+		}
+	case common.MapStr:
+		collectDocumentFields(prefix, map[string]any(v), used)
+	case map[string]any:
+		for key, child := range v {
+			name := key
+			if prefix != "" {
+				name = prefix + "." + key
+			}
+			collectDocumentFields(name, child, used)
+		}
+	default:
+		used.add(prefix)
+	}
+}
@@ -0,0 +1,167 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package fieldusage
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeTestFile(t *testing.T, path, content string) {
+	t.Helper()
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+}
+
+func TestCheck(t *testing.T) {
+	packageRoot := t.TempDir()
+	writeTestFile(t, filepath.Join(packageRoot, "manifest.yml"), `format_version: 3.0.0
+name: vendor
+title: Vendor
+version: 1.0.0
+type: integration
+`)
+	writeTestFile(t, filepath.Join(packageRoot, "kibana", "search", "vendor-search.json"), `{
+  "attributes": {
+    "columns": ["vendor.log.user", "vendor.log.unknown"],
+    "kibanaSavedObjectMeta": {
+      "searchSourceJSON": "{\"query\":{\"language\":\"kuery\",\"query\":\"data_stream.dataset:vendor.log and vendor.log.level:error\"}}"
+    }
+  }
+}`)
+
+	dataStreamRoot := filepath.Join(packageRoot, "data_stream", "log")
+	writeTestFile(t, filepath.Join(dataStreamRoot, "manifest.yml"), `title: Logs
+type: logs
+`)
+	writeTestFile(t, filepath.Join(dataStreamRoot, "fields", "base-fields.yml"), `- name: data_stream.dataset
+  type: constant_keyword
+- name: data_stream.type
+  type: constant_keyword
+- name: event.module
+  type: constant_keyword
+`)
+	writeTestFile(t, filepath.Join(dataStreamRoot, "fields", "ecs.yml"), `- name: '@timestamp'
+  external: ecs
+- name: data_stream.namespace
+  type: constant_keyword
+`)
+	writeTestFile(t, filepath.Join(dataStreamRoot, "fields", "agent.yml"), `- name: cloud.provider
+  type: keyword
+`)
+	writeTestFile(t, filepath.Join(dataStreamRoot, "fields", "fields.yml"), `- name: vendor.log
+  type: group
+  fields:
+    - name: user
+      type: keyword
+    - name: level
+      type: keyword
+    - name: code
+      type: long
+    - name: path
+      type: keyword
+    - name: labels
+      type: object
+    - name: forgotten
+      type: keyword
+`)
+	writeTestFile(t, filepath.Join(dataStreamRoot, "elasticsearch", "ingest_pipeline", "default.yml"), `processors:
+  - grok:
+      field: message
+      patterns:
+        - '%{WORD:vendor.log.user} %{NUMBER:vendor.log.code:long} %{GREEDYDATA:_tmp.rest}'
+  - dissect:
+      field: _tmp.rest
+      pattern: '%{vendor.log.labels.env} %{?ignored} %{vendor.log.extra}'
+  - set:
+      field: event.kind
+      value: event
+  - rename:
+      field: vendor.log.path
+      target_field: file.path
+      if: ctx.vendor?.log?.level != null
+  - date:
+      field: vendor.log.time
+  - remove:
+      field: _tmp
+`)
+	writeTestFile(t, filepath.Join(dataStreamRoot, "_dev", "test", "pipeline", "test-log.log-expected.json"), `{
+  "expected": [
+    {"message": "alice 200 prod x y"}
+  ]
+}`)
+
+	report, err := Check(packageRoot)
+	require.NoError(t, err)
+
+	assert.Equal(t, &Report{
+		DataStreams: []DataStreamReport{
+			{
+				DataStream: "log",
+				Unused:     []string{"vendor.log.forgotten"},
+				Undeclared: []string{"event.kind", "file.path", "vendor.log.extra"},
+			},
+		},
+		UndeclaredInKibana: []string{"vendor.log.unknown"},
+	}, report)
+}
+
+func TestKnownFields(t *testing.T) {
+	packageRoot := t.TempDir()
+	writeTestFile(t, filepath.Join(packageRoot, "manifest.yml"), `format_version: 3.0.0
+name: vendor
+title: Vendor
+version: 1.0.0
+type: input
+`)
+	writeTestFile(t, filepath.Join(packageRoot, "fields", "fields.yml"), `- name: vendor.level
+  type: keyword
+`)
+	pipeline := filepath.Join(packageRoot, "elasticsearch", "ingest_pipeline", "default.yml")
+	writeTestFile(t, pipeline, `processors:
+  - set:
+      field: event.kind
+      value: event
+  - script:
+      source: ctx.vendor.score = 1
+`)
+
+	known, err := ReadKnownFields(packageRoot, packageRoot)
+	require.NoError(t, err)
+	condition := `ctx.vendor?.level == "x" && ctx.vendor.score > 0 && ctx.event?.kind != null && ctx._tmp == null && ctx.vendor.other != null`
+	assert.Equal(t, []string{"vendor.other"}, known.Unknown(condition))
+
+	// Nothing is reported when pipelines produce fields that can't be known.
+	writeTestFile(t, pipeline, `processors:
+  - json:
+      field: message
+      add_to_root: true
+`)
+	known, err = ReadKnownFields(packageRoot, packageRoot)
+	require.NoError(t, err)
+	assert.Empty(t, known.Unknown(condition))
+}
+
+func TestScriptFields(t *testing.T) {
+	source := `if (ctx?.vendor?.log?.level != null && ctx.message.contains("x")) { ctx.event.kind = "alert" }`
+	assert.Equal(t, []string{"vendor.log.level", "message", "event.kind"}, scriptFields(source))
+}
+
+func TestDissectKey(t *testing.T) {
+	assert.Equal(t, "a.b", dissectKey("a.b"))
+	assert.Equal(t, "a", dissectKey("+a/2"))
+	assert.Equal(t, "a", dissectKey("a->"))
+	assert.Equal(t, "", dissectKey("?skipped"))
+	assert.Equal(t, "", dissectKey("*key"))
+}
+
+func TestQueryFields(t *testing.T) {
+	query := `(data_stream.dataset:nginx.access OR url.original:*) and not http.response.status_code : 200 and url.full:"http://example.com"`
+	assert.Equal(t, []string{"data_stream.dataset", "url.original", "http.response.status_code", "url.full"}, queryFields(query))
+}
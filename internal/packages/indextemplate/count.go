@@ -0,0 +1,152 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package indextemplate
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// DefaultTotalFieldsLimit is the default value of index.mapping.total_fields.limit in Elasticsearch.
+const DefaultTotalFieldsLimit = 1000
+
+// FieldsCount contains the number of fields in some mappings, counted as Elasticsearch counts them
+// for the total fields limit: objects, fields, multi-fields, aliases and runtime fields.
+type FieldsCount struct {
+	Total int
+
+	// Groups contains the number of fields under each top-level field.
+	Groups map[string]int
+
+	// DynamicTemplates is the number of dynamic templates, that can map additional fields.
+	DynamicTemplates int
+}
+
+// GroupCount is the number of fields under a top-level field.
+type GroupCount struct {
+	Name  string
+	Count int
+}
+
+// LargestGroups returns the top-level fields with more fields under them, up to n.
+func (c FieldsCount) LargestGroups(n int) []GroupCount {
+	groups := make([]GroupCount, 0, len(c.Groups))
+	for name, count := range c.Groups {
+		groups = append(groups, GroupCount{Name: name, Count: count})
+	}
+	sort.Slice(groups, func(i, j int) bool {
+		if groups[i].Count != groups[j].Count {
+			return groups[i].Count > groups[j].Count
+		}
+		return groups[i].Name < groups[j].Name
+	})
+	if len(groups) > n {
+		groups = groups[:n]
+	}
+	return groups
+}
+
+// CountFields counts the fields mapped in the preview.
+func (p *Preview) CountFields() FieldsCount {
+	return countMappings(p.Template.Mappings)
+}
+
+// TotalFieldsLimit returns the value of index.mapping.total_fields.limit in the settings of the
+// preview, or the default limit if it is not set.
+func (p *Preview) TotalFieldsLimit() int {
+	value, found := lookupSetting(p.Template.Settings, "index", "mapping", "total_fields", "limit")
+	if !found {
+		return DefaultTotalFieldsLimit
+	}
+	switch limit := value.(type) {
+	case int:
+		return limit
+	case float64:
+		return int(limit)
+	case string:
+		if n, err := strconv.Atoi(limit); err == nil {
+			return n
+		}
+	}
+	return DefaultTotalFieldsLimit
+}
+
+// CountMappedFields counts the fields in mappings in JSON format, as they are returned by Elasticsearch.
+func CountMappedFields(properties, dynamicTemplates json.RawMessage) (FieldsCount, error) {
+	mappings := make(map[string]any)
+	if len(properties) > 0 {
+		var rawProperties map[string]any
+		err := json.Unmarshal(properties, &rawProperties)
+		if err != nil {
+			return FieldsCount{}, fmt.Errorf("failed to decode properties: %w", err)
+		}
+		mappings["properties"] = rawProperties
+	}
+	if len(dynamicTemplates) > 0 {
+		var rawDynamicTemplates []any
+		err := json.Unmarshal(dynamicTemplates, &rawDynamicTemplates)
+		if err != nil {
+			return FieldsCount{}, fmt.Errorf("failed to decode dynamic templates: %w", err)
+		}
+		mappings["dynamic_templates"] = rawDynamicTemplates
+	}
+	return countMappings(mappings), nil
+}
+
+func countMappings(mappings map[string]any) FieldsCount {
+	count := FieldsCount{Groups: make(map[string]int)}
+	properties, _ := mappings["properties"].(map[string]any)
+	for name, definition := range properties {
+		n := countDefinition(definition)
+		count.Groups[name] += n
+		count.Total += n
+	}
+	runtime, _ := mappings["runtime"].(map[string]any)
+	for name := range runtime {
+		count.Groups[topLevelName(name)]++
+		count.Total++
+	}
+	templates, _ := mappings["dynamic_templates"].([]any)
+	count.DynamicTemplates = len(templates)
+	return count
+}
+
+func countDefinition(definition any) int {
+	mapping, ok := definition.(map[string]any)
+	if !ok {
+		return 1
+	}
+	n := 1
+	properties, _ := mapping["properties"].(map[string]any)
+	for _, child := range properties {
+		n += countDefinition(child)
+	}
+	fields, _ := mapping["fields"].(map[string]any)
+	n += len(fields)
+	return n
+}
+
+func topLevelName(name string) string {
+	topLevel, _, _ := strings.Cut(name, ".")
+	return topLevel
+}
+
+func lookupSetting(settings map[string]any, path ...string) (any, bool) {
+	var current any = settings
+	for _, key := range path {
+		m, ok := current.(map[string]any)
+		if !ok {
+			return nil, false
+		}
+		current, ok = m[key]
+		if !ok {
+			return nil, false
+		}
+	}
+	return current, true
+}
@@ -0,0 +1,41 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package indextemplate
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCountMappedFields(t *testing.T) {
+	properties := []byte(`{
+  "@timestamp": {"type": "date"},
+  "host": {
+    "properties": {
+      "name": {"type": "keyword", "fields": {"text": {"type": "match_only_text"}}},
+      "ip": {"type": "ip"}
+    }
+  }
+}`)
+	dynamicTemplates := []byte(`[{"labels": {"path_match": "labels.*", "mapping": {"type": "keyword"}}}]`)
+
+	count, err := CountMappedFields(properties, dynamicTemplates)
+	require.NoError(t, err)
+
+	assert.Equal(t, 5, count.Total)
+	assert.Equal(t, map[string]int{"@timestamp": 1, "host": 4}, count.Groups)
+	assert.Equal(t, 1, count.DynamicTemplates)
+	assert.Equal(t, []GroupCount{{Name: "host", Count: 4}}, count.LargestGroups(1))
+}
+
+func TestTotalFieldsLimit(t *testing.T) {
+	preview := Preview{Template: Template{Settings: map[string]any{}}}
+	assert.Equal(t, DefaultTotalFieldsLimit, preview.TotalFieldsLimit())
+
+	preview.Template.Settings = expandDottedKeys(map[string]any{"index.mapping.total_fields.limit": "5000"})
+	assert.Equal(t, 5000, preview.TotalFieldsLimit())
+}
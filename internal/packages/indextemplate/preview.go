@@ -0,0 +1,593 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package indextemplate
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/elastic/elastic-package/internal/builder"
+	"github.com/elastic/elastic-package/internal/common"
+	"github.com/elastic/elastic-package/internal/elasticsearch"
+	"github.com/elastic/elastic-package/internal/packages"
+)
+
+const (
+	defaultIgnoreAbove   = 1024
+	defaultScalingFactor = 1000
+
+	finalPipeline = ".fleet_final_pipeline-1"
+)
+
+// Preview is the index template that Fleet would install for a data stream. It contains the
+// settings and mappings that are computed from the package, but not the ones included in
+// the component templates provided by the stack.
+type Preview struct {
+	Name          string   `json:"-"`
+	DataStream    string   `json:"-"`
+	IndexPatterns []string `json:"index_patterns"`
+	Template      Template `json:"template"`
+}
+
+// Template contains the settings and mappings of an index template.
+type Template struct {
+	Settings map[string]any `json:"settings"`
+	Mappings map[string]any `json:"mappings"`
+}
+
+// Mappings returns the properties and dynamic templates of the preview, in the same format
+// they are returned by Elasticsearch.
+func (p *Preview) Mappings() (*elasticsearch.Mappings, error) {
+	properties, err := json.Marshal(p.Template.Mappings["properties"])
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode properties: %w", err)
+	}
+	dynamicTemplates, err := json.Marshal(p.Template.Mappings["dynamic_templates"])
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode dynamic templates: %w", err)
+	}
+	return &elasticsearch.Mappings{
+		Properties:       properties,
+		DynamicTemplates: dynamicTemplates,
+	}, nil
+}
+
+// Generate computes, without a stack, the index templates that Fleet would install for the
+// data streams of the package. For input packages, one template is computed for each policy
+// template, with its default dataset.
+func Generate(packageRoot string) ([]Preview, error) {
+	manifest, err := packages.ReadPackageManifestFromPackageRoot(packageRoot)
+	if err != nil {
+		return nil, fmt.Errorf("reading package manifest failed: %w", err)
+	}
+
+	if manifest.Type == "input" {
+		var previews []Preview
+		for _, policyTemplate := range manifest.PolicyTemplates {
+			preview, err := generateForInput(packageRoot, manifest, policyTemplate)
+			if err != nil {
+				return nil, err
+			}
+			previews = append(previews, *preview)
+		}
+		return previews, nil
+	}
+
+	dataStreams, err := filepath.Glob(filepath.Join(packageRoot, "data_stream", "*", packages.DataStreamManifestFile))
+	if err != nil {
+		return nil, err
+	}
+	var previews []Preview
+	for _, path := range dataStreams {
+		preview, err := GenerateForDataStream(packageRoot, filepath.Base(filepath.Dir(path)))
+		if err != nil {
+			return nil, err
+		}
+		previews = append(previews, *preview)
+	}
+	return previews, nil
+}
+
+// GenerateForDataStream computes, without a stack, the index template that Fleet would install
+// for the given data stream of an integration package.
+func GenerateForDataStream(packageRoot, dataStream string) (*Preview, error) {
+	manifest, err := packages.ReadPackageManifestFromPackageRoot(packageRoot)
+	if err != nil {
+		return nil, fmt.Errorf("reading package manifest failed: %w", err)
+	}
+
+	dataStreamRoot := filepath.Join(packageRoot, "data_stream", dataStream)
+	manifestPath := filepath.Join(dataStreamRoot, packages.DataStreamManifestFile)
+	dsManifest, err := packages.ReadDataStreamManifest(manifestPath)
+	if err != nil {
+		return nil, fmt.Errorf("reading data stream manifest failed: %w", err)
+	}
+
+	dataset := dsManifest.Dataset
+	if dataset == "" {
+		dataset = manifest.Name + "." + dsManifest.Name
+	}
+
+	g := generator{
+		packageRoot: packageRoot,
+		manifest:    manifest,
+		name:        dsManifest.IndexTemplateName(manifest.Name),
+		dataStream:  dsManifest.Name,
+		typ:         dsManifest.Type,
+		dataset:     dataset,
+	}
+	_, err = os.Stat(filepath.Join(dataStreamRoot, "elasticsearch", "ingest_pipeline"))
+	g.hasPipeline = err == nil
+	return g.generate(dataStreamRoot, manifestPath)
+}
+
+func generateForInput(packageRoot string, manifest *packages.PackageManifest, policyTemplate packages.PolicyTemplate) (*Preview, error) {
+	dataset := manifest.Name + "." + policyTemplate.Name
+	g := generator{
+		packageRoot: packageRoot,
+		manifest:    manifest,
+		name:        policyTemplate.Type + "-" + dataset,
+		dataStream:  policyTemplate.Name,
+		typ:         policyTemplate.Type,
+		dataset:     dataset,
+	}
+	_, err := os.Stat(filepath.Join(packageRoot, "elasticsearch", "ingest_pipeline"))
+	g.hasPipeline = err == nil
+	return g.generate(packageRoot, filepath.Join(packageRoot, packages.PackageManifestFile))
+}
+
+type generator struct {
+	packageRoot string
+	manifest    *packages.PackageManifest
+
+	name        string
+	dataStream  string
+	typ         string
+	dataset     string
+	hasPipeline bool
+
+	timeSeries       bool
+	subobjects       bool
+	dynamicTemplates []any
+	runtime          map[string]any
+}
+
+// manifestElasticsearch contains the settings of the manifest that are relevant for the
+// index template.
+type manifestElasticsearch struct {
+	Elasticsearch struct {
+		IndexMode     string `yaml:"index_mode"`
+		SourceMode    string `yaml:"source_mode"`
+		IndexTemplate struct {
+			Settings map[string]any `yaml:"settings"`
+			Mappings map[string]any `yaml:"mappings"`
+		} `yaml:"index_template"`
+	} `yaml:"elasticsearch"`
+}
+
+func (g *generator) generate(root, manifestPath string) (*Preview, error) {
+	content, err := os.ReadFile(manifestPath)
+	if err != nil {
+		return nil, fmt.Errorf("reading manifest failed: %w", err)
+	}
+	var es manifestElasticsearch
+	err = yaml.Unmarshal(content, &es)
+	if err != nil {
+		return nil, fmt.Errorf("unmarshalling manifest failed (path: %s): %w", manifestPath, err)
+	}
+	manifestMappings := es.Elasticsearch.IndexTemplate.Mappings
+
+	g.timeSeries = es.Elasticsearch.IndexMode == "time_series"
+	g.subobjects = true
+	if subobjects, ok := manifestMappings["subobjects"].(bool); ok {
+		g.subobjects = subobjects
+	}
+	g.runtime = make(map[string]any)
+
+	definitions, err := g.readFields(filepath.Join(root, "fields"))
+	if err != nil {
+		return nil, err
+	}
+	properties := make(map[string]any)
+	err = g.addFields(properties, "", definitions)
+	if err != nil {
+		return nil, fmt.Errorf("generating mappings for %s failed: %w", g.name, err)
+	}
+
+	mappings := map[string]any{
+		"properties": properties,
+		"_meta": map[string]any{
+			"package":    map[string]any{"name": g.manifest.Name},
+			"managed_by": "fleet",
+			"managed":    true,
+		},
+	}
+	for key, value := range manifestMappings {
+		switch key {
+		case "dynamic_templates":
+			templates, _ := value.([]any)
+			g.dynamicTemplates = append(g.dynamicTemplates, templates...)
+		case "properties":
+			manifestProperties, _ := value.(map[string]any)
+			mergeMaps(properties, manifestProperties)
+		default:
+			mappings[key] = value
+		}
+	}
+
+	ecsTemplates, err := builder.EcsDynamicTemplates(g.packageRoot)
+	if err != nil {
+		return nil, fmt.Errorf("loading ECS dynamic templates failed: %w", err)
+	}
+	for _, template := range ecsTemplates {
+		g.dynamicTemplates = append(g.dynamicTemplates, template)
+	}
+	if len(g.dynamicTemplates) > 0 {
+		mappings["dynamic_templates"] = g.dynamicTemplates
+	}
+	if len(g.runtime) > 0 {
+		mappings["runtime"] = g.runtime
+	}
+	if es.Elasticsearch.SourceMode != "" {
+		mappings["_source"] = map[string]any{"mode": es.Elasticsearch.SourceMode}
+	}
+
+	index := make(map[string]any)
+	if g.hasPipeline {
+		index["default_pipeline"] = fmt.Sprintf("%s-%s-%s", g.typ, g.dataset, g.manifest.Version)
+	}
+	index["final_pipeline"] = finalPipeline
+	if es.Elasticsearch.IndexMode != "" {
+		index["mode"] = es.Elasticsearch.IndexMode
+	}
+	settings := map[string]any{"index": index}
+	mergeMaps(settings, expandDottedKeys(es.Elasticsearch.IndexTemplate.Settings))
+
+	return &Preview{
+		Name:          g.name,
+		DataStream:    g.dataStream,
+		IndexPatterns: []string{g.name + "-*"},
+		Template: Template{
+			Settings: settings,
+			Mappings: mappings,
+		},
+	}, nil
+}
+
+// readFields reads the field definitions of a fields directory, with their external fields resolved.
+func (g *generator) readFields(fieldsDir string) ([]common.MapStr, error) {
+	paths, err := filepath.Glob(filepath.Join(fieldsDir, "*.yml"))
+	if err != nil {
+		return nil, err
+	}
+	var definitions []common.MapStr
+	for _, path := range paths {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("reading fields file failed: %w", err)
+		}
+		var defs []common.MapStr
+		err = yaml.Unmarshal(content, &defs)
+		if err != nil {
+			return nil, fmt.Errorf("unmarshalling fields file failed (path: %s): %w", path, err)
+		}
+		definitions = append(definitions, defs...)
+	}
+	definitions, err = builder.ResolveExternalFields(g.packageRoot, definitions)
+	if err != nil {
+		return nil, fmt.Errorf("resolving external fields failed: %w", err)
+	}
+	return definitions, nil
+}
+
+func (g *generator) addFields(properties map[string]any, prefix string, definitions []common.MapStr) error {
+	for _, definition := range definitions {
+		name, _ := definition["name"].(string)
+		if name == "" {
+			return errors.New("found field without name")
+		}
+		path := name
+		if prefix != "" {
+			path = prefix + "." + name
+		}
+
+		typ, _ := definition["type"].(string)
+		switch typ {
+		case "group", "":
+			if typ == "" && definition["fields"] == nil {
+				typ = "keyword"
+				break
+			}
+			children, err := childDefinitions(definition)
+			if err != nil {
+				return fmt.Errorf("invalid fields in %s: %w", path, err)
+			}
+			objectProperties := g.objectProperties(properties, name)
+			err = g.addFields(objectProperties, path, children)
+			if err != nil {
+				return err
+			}
+			continue
+		case "object":
+			if objectType, ok := definition["object_type"].(string); ok && objectType != "" {
+				template, err := objectDynamicTemplate(path, objectType, definition)
+				if err != nil {
+					return err
+				}
+				g.dynamicTemplates = append(g.dynamicTemplates, template)
+				continue
+			}
+		}
+
+		if runtime, ok := definition["runtime"]; ok && runtime != false {
+			runtimeField := map[string]any{"type": typ}
+			if script, ok := runtime.(string); ok {
+				runtimeField["script"] = map[string]any{"source": script}
+			}
+			g.runtime[path] = runtimeField
+			continue
+		}
+
+		mapping, err := g.fieldMapping(path, typ, definition)
+		if err != nil {
+			return err
+		}
+		parent, leaf := g.parentProperties(properties, name)
+		if existing, ok := parent[leaf].(map[string]any); ok {
+			mergeMaps(existing, mapping)
+			continue
+		}
+		parent[leaf] = mapping
+	}
+	return nil
+}
+
+// fieldMapping returns the mapping of a field that is not a group.
+func (g *generator) fieldMapping(path, typ string, definition common.MapStr) (map[string]any, error) {
+	mapping := map[string]any{"type": typ}
+	switch typ {
+	case "keyword":
+		mapping["ignore_above"] = defaultIgnoreAbove
+		copyParameters(mapping, definition, "ignore_above", "normalizer", "null_value")
+	case "text", "match_only_text":
+		copyParameters(mapping, definition, "analyzer", "search_analyzer", "norms")
+	case "wildcard", "version":
+		copyParameters(mapping, definition, "ignore_above", "null_value")
+	case "constant_keyword":
+		copyParameters(mapping, definition, "value")
+	case "scaled_float":
+		mapping["scaling_factor"] = defaultScalingFactor
+		copyParameters(mapping, definition, "scaling_factor", "null_value")
+	case "alias":
+		copyParameters(mapping, definition, "path")
+	case "date", "date_nanos":
+		copyParameters(mapping, definition, "format", "null_value")
+	case "aggregate_metric_double":
+		copyParameters(mapping, definition, "metrics", "default_metric")
+	case "object":
+		copyParameters(mapping, definition, "enabled", "dynamic")
+	case "nested", "group-nested":
+		mapping["type"] = "nested"
+		copyParameters(mapping, definition, "include_in_parent", "include_in_root", "enabled", "dynamic")
+		children, err := childDefinitions(definition)
+		if err != nil {
+			return nil, fmt.Errorf("invalid fields in %s: %w", path, err)
+		}
+		if len(children) > 0 {
+			properties := make(map[string]any)
+			err = g.addFields(properties, path, children)
+			if err != nil {
+				return nil, err
+			}
+			mapping["properties"] = properties
+		}
+	case "long", "integer", "short", "byte", "double", "float", "half_float", "boolean", "ip":
+		copyParameters(mapping, definition, "null_value")
+	}
+	copyParameters(mapping, definition, "index", "doc_values", "store", "copy_to", "ignore_malformed")
+
+	if g.timeSeries {
+		if metricType, ok := definition["metric_type"].(string); ok {
+			mapping["time_series_metric"] = metricType
+		}
+		if dimension, ok := definition["dimension"].(bool); ok && dimension {
+			mapping["time_series_dimension"] = true
+		}
+	}
+
+	multiFields, err := multiFieldsDefinitions(definition)
+	if err != nil {
+		return nil, fmt.Errorf("invalid multi fields in %s: %w", path, err)
+	}
+	if len(multiFields) > 0 {
+		fields := make(map[string]any)
+		for _, multiField := range multiFields {
+			name, _ := multiField["name"].(string)
+			typ, _ := multiField["type"].(string)
+			if typ == "" {
+				typ = "keyword"
+			}
+			fieldMapping, err := g.fieldMapping(path+"."+name, typ, multiField)
+			if err != nil {
+				return nil, err
+			}
+			fields[name] = fieldMapping
+		}
+		mapping["fields"] = fields
+	}
+	return mapping, nil
+}
+
+// objectProperties returns the properties of the object with the given name, creating it and
+// its parents if needed.
+func (g *generator) objectProperties(properties map[string]any, name string) map[string]any {
+	parent, leaf := g.parentProperties(properties, name)
+	object, ok := parent[leaf].(map[string]any)
+	if !ok {
+		object = make(map[string]any)
+		parent[leaf] = object
+	}
+	objectProperties, ok := object["properties"].(map[string]any)
+	if !ok {
+		objectProperties = make(map[string]any)
+		object["properties"] = objectProperties
+	}
+	return objectProperties
+}
+
+// parentProperties returns the properties where a field with a dotted name should be added,
+// and the name to use there.
+func (g *generator) parentProperties(properties map[string]any, name string) (map[string]any, string) {
+	if !g.subobjects {
+		return properties, name
+	}
+	parts := strings.Split(name, ".")
+	for _, part := range parts[:len(parts)-1] {
+		properties = g.objectProperties(properties, part)
+	}
+	return properties, parts[len(parts)-1]
+}
+
+// objectDynamicTemplate returns the dynamic template used for fields of type object with
+// an object type.
+func objectDynamicTemplate(path, objectType string, definition common.MapStr) (map[string]any, error) {
+	matchMappingType, _ := definition["object_type_mapping_type"].(string)
+	if matchMappingType == "" {
+		switch objectType {
+		case "keyword", "text":
+			matchMappingType = "string"
+		case "double", "float", "half_float", "scaled_float":
+			matchMappingType = "double"
+		case "long", "integer", "short", "byte", "unsigned_long":
+			matchMappingType = "long"
+		case "boolean":
+			matchMappingType = "boolean"
+		case "object":
+			matchMappingType = "object"
+		case "histogram":
+			matchMappingType = "*"
+		default:
+			return nil, fmt.Errorf("unsupported object type %q in %s", objectType, path)
+		}
+	}
+
+	pathMatch := path
+	if !strings.Contains(path, "*") {
+		pathMatch = path + ".*"
+	}
+	mapping := map[string]any{"type": objectType}
+	switch objectType {
+	case "keyword":
+		mapping["ignore_above"] = defaultIgnoreAbove
+	case "scaled_float":
+		mapping["scaling_factor"] = defaultScalingFactor
+		copyParameters(mapping, definition, "scaling_factor")
+	}
+
+	return map[string]any{
+		path: map[string]any{
+			"path_match":         pathMatch,
+			"match_mapping_type": matchMappingType,
+			"mapping":            mapping,
+		},
+	}, nil
+}
+
+func childDefinitions(definition common.MapStr) ([]common.MapStr, error) {
+	return definitionsList(definition["fields"])
+}
+
+func multiFieldsDefinitions(definition common.MapStr) ([]common.MapStr, error) {
+	return definitionsList(definition["multi_fields"])
+}
+
+func definitionsList(value any) ([]common.MapStr, error) {
+	if value == nil {
+		return nil, nil
+	}
+	list, ok := value.([]any)
+	if !ok {
+		if defs, ok := value.([]common.MapStr); ok {
+			return defs, nil
+		}
+		return nil, fmt.Errorf("expected list of fields, found %T", value)
+	}
+	defs := make([]common.MapStr, 0, len(list))
+	for _, element := range list {
+		switch def := element.(type) {
+		case common.MapStr:
+			defs = append(defs, def)
+		case map[string]any:
+			defs = append(defs, common.MapStr(def))
+		default:
+			return nil, fmt.Errorf("expected field definition, found %T", element)
+		}
+	}
+	return defs, nil
+}
+
+func copyParameters(mapping map[string]any, definition common.MapStr, names ...string) {
+	for _, name := range names {
+		if value, ok := definition[name]; ok {
+			mapping[name] = value
+		}
+	}
+}
+
+// mergeMaps merges recursively the values of src into dst.
+func mergeMaps(dst, src map[string]any) {
+	for key, value := range src {
+		srcMap, srcIsMap := value.(map[string]any)
+		dstMap, dstIsMap := dst[key].(map[string]any)
+		if srcIsMap && dstIsMap {
+			mergeMaps(dstMap, srcMap)
+			continue
+		}
+		dst[key] = value
+	}
+}
+
+// expandDottedKeys converts settings with dotted keys, as "index.mapping.total_fields.limit",
+// into nested objects.
+func expandDottedKeys(settings map[string]any) map[string]any {
+	result := make(map[string]any)
+	keys := make([]string, 0, len(settings))
+	for key := range settings {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		value := settings[key]
+		if nested, ok := value.(map[string]any); ok {
+			value = expandDottedKeys(nested)
+		}
+		parts := strings.Split(key, ".")
+		current := result
+		for _, part := range parts[:len(parts)-1] {
+			next, ok := current[part].(map[string]any)
+			if !ok {
+				next = make(map[string]any)
+				current[part] = next
+			}
+			current = next
+		}
+		last := parts[len(parts)-1]
+		existing, existingIsMap := current[last].(map[string]any)
+		valueMap, valueIsMap := value.(map[string]any)
+		if existingIsMap && valueIsMap {
+			mergeMaps(existing, valueMap)
+			continue
+		}
+		current[last] = value
+	}
+	return result
+}
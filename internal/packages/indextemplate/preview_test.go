@@ -0,0 +1,157 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package indextemplate
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeTestFile(t *testing.T, path, content string) {
+	t.Helper()
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+}
+
+func TestGenerate(t *testing.T) {
+	packageRoot := t.TempDir()
+	writeTestFile(t, filepath.Join(packageRoot, "manifest.yml"), `format_version: 3.0.0
+name: vendor
+title: Vendor
+version: 1.2.0
+type: integration
+`)
+
+	dataStreamRoot := filepath.Join(packageRoot, "data_stream", "metrics")
+	writeTestFile(t, filepath.Join(dataStreamRoot, "manifest.yml"), `title: Metrics
+type: metrics
+elasticsearch:
+  index_mode: time_series
+  index_template:
+    settings:
+      index.mapping.total_fields.limit: 5000
+    mappings:
+      dynamic: false
+`)
+	writeTestFile(t, filepath.Join(dataStreamRoot, "elasticsearch", "ingest_pipeline", "default.yml"), `processors: []`)
+	writeTestFile(t, filepath.Join(dataStreamRoot, "fields", "base-fields.yml"), `- name: data_stream.type
+  type: constant_keyword
+  value: metrics
+- name: '@timestamp'
+  type: date
+`)
+	writeTestFile(t, filepath.Join(dataStreamRoot, "fields", "fields.yml"), `- name: vendor.metrics
+  type: group
+  fields:
+    - name: host
+      type: keyword
+      dimension: true
+      multi_fields:
+        - name: text
+          type: match_only_text
+    - name: usage
+      type: scaled_float
+      metric_type: gauge
+    - name: labels
+      type: object
+      object_type: keyword
+    - name: hostname
+      type: alias
+      path: vendor.metrics.host
+    - name: raw
+      type: object
+      enabled: false
+`)
+
+	previews, err := Generate(packageRoot)
+	require.NoError(t, err)
+	require.Len(t, previews, 1)
+
+	preview := previews[0]
+	assert.Equal(t, "metrics-vendor.metrics", preview.Name)
+	assert.Equal(t, "metrics", preview.DataStream)
+
+	expected := `{
+  "index_patterns": ["metrics-vendor.metrics-*"],
+  "template": {
+    "settings": {
+      "index": {
+        "default_pipeline": "metrics-vendor.metrics-1.2.0",
+        "final_pipeline": ".fleet_final_pipeline-1",
+        "mode": "time_series",
+        "mapping": {"total_fields": {"limit": 5000}}
+      }
+    },
+    "mappings": {
+      "_meta": {"managed": true, "managed_by": "fleet", "package": {"name": "vendor"}},
+      "dynamic": false,
+      "dynamic_templates": [
+        {
+          "vendor.metrics.labels": {
+            "mapping": {"ignore_above": 1024, "type": "keyword"},
+            "match_mapping_type": "string",
+            "path_match": "vendor.metrics.labels.*"
+          }
+        }
+      ],
+      "properties": {
+        "@timestamp": {"type": "date"},
+        "data_stream": {
+          "properties": {
+            "type": {"type": "constant_keyword", "value": "metrics"}
+          }
+        },
+        "vendor": {
+          "properties": {
+            "metrics": {
+              "properties": {
+                "host": {
+                  "type": "keyword",
+                  "ignore_above": 1024,
+                  "time_series_dimension": true,
+                  "fields": {"text": {"type": "match_only_text"}}
+                },
+                "hostname": {"type": "alias", "path": "vendor.metrics.host"},
+                "raw": {"type": "object", "enabled": false},
+                "usage": {"type": "scaled_float", "scaling_factor": 1000, "time_series_metric": "gauge"}
+              }
+            }
+          }
+        }
+      }
+    }
+  }
+}`
+	actual, err := json.Marshal(preview)
+	require.NoError(t, err)
+	assert.JSONEq(t, expected, string(actual))
+
+	mappings, err := preview.Mappings()
+	require.NoError(t, err)
+	assert.Contains(t, string(mappings.DynamicTemplates), "vendor.metrics.labels")
+	assert.Contains(t, string(mappings.Properties), `"vendor"`)
+}
+
+func TestExpandDottedKeys(t *testing.T) {
+	settings := map[string]any{
+		"index.codec": "best_compression",
+		"index": map[string]any{
+			"mapping.total_fields.limit": 2000,
+		},
+	}
+	assert.Equal(t, map[string]any{
+		"index": map[string]any{
+			"codec": "best_compression",
+			"mapping": map[string]any{
+				"total_fields": map[string]any{"limit": 2000},
+			},
+		},
+	}, expandDottedKeys(settings))
+}
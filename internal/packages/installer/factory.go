@@ -12,6 +12,7 @@ import (
 	"github.com/Masterminds/semver/v3"
 
 	"github.com/elastic/elastic-package/internal/builder"
+	"github.com/elastic/elastic-package/internal/files"
 	"github.com/elastic/elastic-package/internal/kibana"
 	"github.com/elastic/elastic-package/internal/logger"
 	"github.com/elastic/elastic-package/internal/packages"
@@ -37,6 +38,11 @@ type Options struct {
 	RootPath       string
 	ZipPath        string
 	SkipValidation bool
+
+	// RequireSignature makes the installation of zip packages fail if they are not signed, or
+	// if their signature cannot be verified with the public key in VerifyOptions.
+	RequireSignature bool
+	VerifyOptions    files.VerifyOptions
 }
 
 // NewForPackage creates a new installer for a package, given its root path, or its prebuilt zip.
@@ -66,6 +72,14 @@ func NewForPackage(ctx context.Context, options Options) (Installer, error) {
 			return nil, errors.New(reason)
 		}
 
+		if options.RequireSignature {
+			logger.Debugf("Verifying signature of the .zip package (path: %s)", options.ZipPath)
+			_, err := VerifyZipPackage(options.ZipPath, options.VerifyOptions)
+			if err != nil {
+				return nil, fmt.Errorf("signed package required: %w", err)
+			}
+		}
+
 		if !options.SkipValidation {
 			logger.Debugf("Validating built .zip package (path: %s)", options.ZipPath)
 			errs, skipped := validation.ValidateAndFilterFromZip(options.ZipPath)
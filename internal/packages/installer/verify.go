@@ -0,0 +1,63 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package installer
+
+import (
+	"archive/zip"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/elastic/elastic-package/internal/files"
+	"github.com/elastic/elastic-package/internal/packages"
+)
+
+// VerifyZipPackage verifies the signature of a zip package, and that its contents match the
+// package they claim to be: the package files are in a directory named after the name and the
+// version in the manifest, and the signature was created for the same package.
+func VerifyZipPackage(zipPath string, options files.VerifyOptions) (*packages.PackageManifest, error) {
+	signedFor, err := files.Verify(zipPath, options)
+	if err != nil {
+		return nil, err
+	}
+
+	manifest, err := packages.ReadPackageManifestFromZipPackage(zipPath)
+	if err != nil {
+		return nil, err
+	}
+	expected := fmt.Sprintf("%s-%s", manifest.Name, manifest.Version)
+
+	dirs, err := zipRootDirectories(zipPath)
+	if err != nil {
+		return nil, err
+	}
+	if len(dirs) != 1 || dirs[0] != expected {
+		return nil, fmt.Errorf("package files are expected in directory %q according to its manifest, found: %s", expected, strings.Join(dirs, ", "))
+	}
+
+	if signedFor != "" && signedFor != expected {
+		return nil, fmt.Errorf("signature was created for package %q, but the zip contains package %q", signedFor, expected)
+	}
+	return manifest, nil
+}
+
+// zipRootDirectories returns the sorted names of the directories in the root of the zip file.
+func zipRootDirectories(zipPath string) ([]string, error) {
+	zipReader, err := zip.OpenReader(zipPath)
+	if err != nil {
+		return nil, fmt.Errorf("can't open zip file (path: %s): %w", zipPath, err)
+	}
+	defer zipReader.Close()
+
+	var dirs []string
+	for _, f := range zipReader.File {
+		dir, _, _ := strings.Cut(f.Name, "/")
+		if !slices.Contains(dirs, dir) {
+			dirs = append(dirs, dir)
+		}
+	}
+	slices.Sort(dirs)
+	return dirs, nil
+}
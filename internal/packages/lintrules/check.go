@@ -0,0 +1,329 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package lintrules
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/elastic/elastic-package/internal/packages"
+)
+
+// suppressionPattern matches comments suppressing violations of rules, in the same or in the
+// previous line, or in the whole file.
+var suppressionPattern = regexp.MustCompile(`#\s*elastic-package:(ignore|ignore-file)\s+([\w\-]+(?:\s*,\s*[\w\-]+)*)`)
+
+// Violation is a node of a file of the package that doesn't pass a rule.
+type Violation struct {
+	RuleID   string
+	Severity Severity
+
+	// File is the path of the file, relative to the package root.
+	File string
+	Line int
+
+	Message string
+}
+
+func (v Violation) String() string {
+	return fmt.Sprintf("%s:%d: %s: %s (%s)", v.File, v.Line, v.Severity, v.Message, v.RuleID)
+}
+
+type document struct {
+	root         *yaml.Node
+	suppressions map[int][]string
+	fileWide     []string
+}
+
+type selectedNode struct {
+	node *yaml.Node
+	line int
+}
+
+type checker struct {
+	packageRoot string
+	manifest    map[string]any
+	documents   map[string]*document
+}
+
+// Check checks the files of the package against the rules. Only YAML and JSON files are checked.
+func Check(packageRoot string, rules *Rules) ([]Violation, error) {
+	if len(rules.Rules) == 0 {
+		return nil, nil
+	}
+
+	c := checker{
+		packageRoot: packageRoot,
+		documents:   make(map[string]*document),
+	}
+	manifest, err := c.document(packages.PackageManifestFile)
+	if err != nil {
+		return nil, err
+	}
+	err = manifest.root.Decode(&c.manifest)
+	if err != nil {
+		return nil, fmt.Errorf("decoding package manifest failed: %w", err)
+	}
+
+	var violations []Violation
+	for _, rule := range rules.Rules {
+		paths, err := c.files(rule)
+		if err != nil {
+			return nil, err
+		}
+		for _, path := range paths {
+			document, err := c.document(path)
+			if err != nil {
+				return nil, err
+			}
+			for _, selected := range selectNodes(document.root, rule.path, document.root.Line) {
+				messages, err := c.check(rule, path, selected.node)
+				if err != nil {
+					return nil, fmt.Errorf("checking rule %q failed (path: %s): %w", rule.ID, path, err)
+				}
+				for _, message := range messages {
+					if document.suppressed(rule.ID, selected.line) {
+						continue
+					}
+					if rule.Description != "" {
+						message = rule.Description + ": " + message
+					}
+					violations = append(violations, Violation{
+						RuleID:   rule.ID,
+						Severity: rule.Severity,
+						File:     path,
+						Line:     selected.line,
+						Message:  message,
+					})
+				}
+			}
+		}
+	}
+
+	slices.SortStableFunc(violations, func(a, b Violation) int {
+		if a.File != b.File {
+			return strings.Compare(a.File, b.File)
+		}
+		return a.Line - b.Line
+	})
+	return violations, nil
+}
+
+// files returns the paths of the YAML and JSON files selected by the rule, relative to the package root.
+func (c *checker) files(rule Rule) ([]string, error) {
+	var paths []string
+	err := filepath.WalkDir(c.packageRoot, func(filePath string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		switch filepath.Ext(filePath) {
+		case ".yml", ".yaml", ".json":
+		default:
+			return nil
+		}
+		rel, err := filepath.Rel(c.packageRoot, filePath)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		for _, pattern := range rule.Files {
+			matched, err := matchFiles(strings.Split(pattern, "/"), strings.Split(rel, "/"))
+			if err != nil {
+				return fmt.Errorf("invalid files pattern in rule %q: %w", rule.ID, err)
+			}
+			if matched {
+				paths = append(paths, rel)
+				break
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("looking for files of rule %q failed: %w", rule.ID, err)
+	}
+	return paths, nil
+}
+
+// matchFiles matches a path with a glob pattern, both split in segments. "**" segments match any
+// number of directories.
+func matchFiles(pattern, segments []string) (bool, error) {
+	if len(pattern) == 0 {
+		return len(segments) == 0, nil
+	}
+	if pattern[0] == "**" {
+		for i := 0; i <= len(segments); i++ {
+			matched, err := matchFiles(pattern[1:], segments[i:])
+			if matched || err != nil {
+				return matched, err
+			}
+		}
+		return false, nil
+	}
+	if len(segments) == 0 {
+		return false, nil
+	}
+	matched, err := path.Match(pattern[0], segments[0])
+	if !matched || err != nil {
+		return false, err
+	}
+	return matchFiles(pattern[1:], segments[1:])
+}
+
+func (c *checker) document(path string) (*document, error) {
+	if d, found := c.documents[path]; found {
+		return d, nil
+	}
+
+	content, err := os.ReadFile(filepath.Join(c.packageRoot, filepath.FromSlash(path)))
+	if err != nil {
+		return nil, fmt.Errorf("reading file failed (path: %s): %w", path, err)
+	}
+	var root yaml.Node
+	err = yaml.Unmarshal(content, &root)
+	if err != nil {
+		return nil, fmt.Errorf("parsing file failed (path: %s): %w", path, err)
+	}
+
+	d := document{root: &root, suppressions: make(map[int][]string)}
+	if len(root.Content) > 0 {
+		d.root = root.Content[0]
+	}
+	for i, line := range strings.Split(string(content), "\n") {
+		for _, match := range suppressionPattern.FindAllStringSubmatch(line, -1) {
+			var ids []string
+			for _, id := range strings.Split(match[2], ",") {
+				ids = append(ids, strings.TrimSpace(id))
+			}
+			if match[1] == "ignore-file" {
+				d.fileWide = append(d.fileWide, ids...)
+			} else {
+				d.suppressions[i+1] = append(d.suppressions[i+1], ids...)
+			}
+		}
+	}
+	c.documents[path] = &d
+	return &d, nil
+}
+
+// suppressed checks if the violations of the rule in the given line are suppressed with comments.
+func (d *document) suppressed(ruleID string, line int) bool {
+	return slices.Contains(d.fileWide, ruleID) ||
+		slices.Contains(d.suppressions[line], ruleID) ||
+		slices.Contains(d.suppressions[line-1], ruleID)
+}
+
+// check checks a node selected by the rule, and returns messages describing the problems found.
+func (c *checker) check(rule Rule, path string, node *yaml.Node) ([]string, error) {
+	if rule.program != nil {
+		var value any
+		err := node.Decode(&value)
+		if err != nil {
+			return nil, fmt.Errorf("decoding node failed: %w", err)
+		}
+		result, _, err := rule.program.Eval(map[string]any{
+			"value":    value,
+			"file":     path,
+			"manifest": c.manifest,
+		})
+		if err != nil {
+			// Errors evaluating expressions, like accessing missing keys, are considered failures.
+			return []string{fmt.Sprintf("expression failed: %v", err)}, nil
+		}
+		passed, ok := result.Value().(bool)
+		if !ok {
+			return nil, fmt.Errorf("expression evaluated to %v, expected a boolean", result.Value())
+		}
+		if !passed {
+			return []string{"expression evaluated to false"}, nil
+		}
+		return nil, nil
+	}
+
+	var messages []string
+	if node.Kind == yaml.MappingNode {
+		for _, key := range rule.Required {
+			if mappingValue(node, key) == nil {
+				messages = append(messages, fmt.Sprintf("missing required key %q", key))
+			}
+		}
+	}
+	if node.Kind == yaml.ScalarNode {
+		if rule.pattern != nil && !rule.pattern.MatchString(node.Value) {
+			messages = append(messages, fmt.Sprintf("value %q doesn't match pattern %q", node.Value, rule.Pattern))
+		}
+		if rule.forbiddenPattern != nil && rule.forbiddenPattern.MatchString(node.Value) {
+			messages = append(messages, fmt.Sprintf("value %q matches forbidden pattern %q", node.Value, rule.ForbiddenPattern))
+		}
+	}
+	return messages, nil
+}
+
+// selectNodes returns the nodes matching the path, with the lines where they are defined. Values
+// of objects are considered to be defined in the lines of their keys.
+func selectNodes(node *yaml.Node, path []string, line int) []selectedNode {
+	if len(path) == 0 {
+		return []selectedNode{{node: node, line: line}}
+	}
+
+	switch segment := path[0]; segment {
+	case "**":
+		selected := selectNodes(node, path[1:], line)
+		for _, child := range children(node) {
+			selected = append(selected, selectNodes(child.node, path, child.line)...)
+		}
+		return selected
+	case "*":
+		var selected []selectedNode
+		for _, child := range children(node) {
+			selected = append(selected, selectNodes(child.node, path[1:], child.line)...)
+		}
+		return selected
+	default:
+		if node.Kind != yaml.MappingNode {
+			return nil
+		}
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if node.Content[i].Value == segment {
+				return selectNodes(node.Content[i+1], path[1:], node.Content[i].Line)
+			}
+		}
+		return nil
+	}
+}
+
+func children(node *yaml.Node) []selectedNode {
+	var children []selectedNode
+	switch node.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			children = append(children, selectedNode{node: node.Content[i+1], line: node.Content[i].Line})
+		}
+	case yaml.SequenceNode:
+		for _, item := range node.Content {
+			children = append(children, selectedNode{node: item, line: item.Line})
+		}
+	}
+	return children
+}
+
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
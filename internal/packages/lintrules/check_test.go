@@ -0,0 +1,136 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package lintrules
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const houseRules = `rules:
+  - id: processor-tag
+    description: Processors must have a tag
+    files:
+      - elasticsearch/ingest_pipeline/*.yml
+      - data_stream/*/elasticsearch/ingest_pipeline/*.yml
+    path: processors.*.*
+    required: [tag]
+  - id: ignore-failure-handled
+    description: Processors ignoring failures must handle them
+    files:
+      - data_stream/*/elasticsearch/ingest_pipeline/*.yml
+    path: processors.*.*
+    expression: '!has(value.ignore_failure) || value.ignore_failure != true || has(value.on_failure)'
+  - id: owner
+    files: [manifest.yml]
+    path: owner.github
+    pattern: ^elastic/obs-team$
+  - id: hardcoded-index-patterns
+    severity: warning
+    files: ["**/*.yml", "kibana/**/*.json"]
+    path: "**"
+    forbidden_pattern: '^logs-\*'
+`
+
+func TestCheck(t *testing.T) {
+	packageRoot := t.TempDir()
+	files := map[string]string{
+		"manifest.yml": `name: vendor
+owner:
+  github: elastic/other-team
+`,
+		"data_stream/log/manifest.yml": `title: Logs
+# elastic-package:ignore hardcoded-index-patterns
+index_pattern: logs-*
+`,
+		"data_stream/log/elasticsearch/ingest_pipeline/default.yml": `processors:
+  - set:
+      tag: set_foo
+      field: foo
+      value: bar
+  - rename:
+      field: a
+      target_field: b
+      ignore_failure: true
+  # elastic-package:ignore processor-tag
+  - remove:
+      field: c
+  - remove: # elastic-package:ignore processor-tag, ignore-failure-handled
+      field: d
+      ignore_failure: true
+  - remove:
+      tag: remove_e
+      field: e
+      ignore_failure: true
+      on_failure:
+        - set:
+            field: error.message
+            value: failed
+`,
+		"kibana/dashboard/vendor-overview.json": `{"attributes": {"index": "logs-*"}}`,
+	}
+	for path, content := range files {
+		path = filepath.Join(packageRoot, filepath.FromSlash(path))
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	}
+	rulesPath := filepath.Join(t.TempDir(), "lint_rules.yml")
+	require.NoError(t, os.WriteFile(rulesPath, []byte(houseRules), 0644))
+
+	rules, err := LoadRules(rulesPath)
+	require.NoError(t, err)
+
+	violations, err := Check(packageRoot, rules)
+	require.NoError(t, err)
+	assert.Equal(t, []Violation{
+		{
+			RuleID:   "processor-tag",
+			Severity: SeverityError,
+			File:     "data_stream/log/elasticsearch/ingest_pipeline/default.yml",
+			Line:     6,
+			Message:  `Processors must have a tag: missing required key "tag"`,
+		},
+		{
+			RuleID:   "ignore-failure-handled",
+			Severity: SeverityError,
+			File:     "data_stream/log/elasticsearch/ingest_pipeline/default.yml",
+			Line:     6,
+			Message:  "Processors ignoring failures must handle them: expression evaluated to false",
+		},
+		{
+			RuleID:   "hardcoded-index-patterns",
+			Severity: SeverityWarning,
+			File:     "kibana/dashboard/vendor-overview.json",
+			Line:     1,
+			Message:  `value "logs-*" matches forbidden pattern "^logs-\\*"`,
+		},
+		{
+			RuleID:   "owner",
+			Severity: SeverityError,
+			File:     "manifest.yml",
+			Line:     3,
+			Message:  `value "elastic/other-team" doesn't match pattern "^elastic/obs-team$"`,
+		},
+	}, violations)
+}
+
+func TestCheckFileSuppression(t *testing.T) {
+	packageRoot := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(packageRoot, "manifest.yml"), []byte(`# elastic-package:ignore-file owner
+name: vendor
+owner:
+  github: elastic/other-team
+`), 0644))
+	rules := Rules{Rules: []Rule{{ID: "owner", Files: []string{"manifest.yml"}, Path: "owner.github", Pattern: "^elastic/obs-team$"}}}
+	require.NoError(t, rules.compile())
+
+	violations, err := Check(packageRoot, &rules)
+	require.NoError(t, err)
+	assert.Empty(t, violations)
+}
@@ -0,0 +1,200 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package lintrules
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/google/cel-go/cel"
+	"gopkg.in/yaml.v3"
+
+	"github.com/elastic/elastic-package/internal/environment"
+	"github.com/elastic/elastic-package/internal/files"
+	"github.com/elastic/elastic-package/internal/logger"
+)
+
+const rulesFileNameDefault = "lint_rules.yml"
+
+var rulesFilePathEnvVar = environment.WithElasticPackagePrefix("LINT_RULES_FILE_PATH")
+
+// Severity is the severity of the violations of a rule.
+type Severity string
+
+const (
+	// SeverityError is the severity of rules whose violations make the lint fail.
+	SeverityError Severity = "error"
+
+	// SeverityWarning is the severity of rules whose violations are only reported.
+	SeverityWarning Severity = "warning"
+)
+
+// Rules is the set of custom lint rules defined in a repository.
+type Rules struct {
+	Rules []Rule `yaml:"rules"`
+}
+
+// Rule is a custom lint rule. It selects nodes in the files of a package, and checks them with
+// declarative assertions or with a CEL expression.
+type Rule struct {
+	ID          string   `yaml:"id"`
+	Description string   `yaml:"description"`
+	Severity    Severity `yaml:"severity"`
+
+	// Files are glob patterns of the files checked by the rule, relative to the package root. "**"
+	// matches any number of directories.
+	Files []string `yaml:"files"`
+
+	// Path selects the nodes checked in each file, as keys separated by dots. "*" matches any key
+	// or list item, and "**" matches any number of levels. The whole document is selected if empty.
+	Path string `yaml:"path"`
+
+	// Required are the keys that the selected objects must contain.
+	Required []string `yaml:"required"`
+
+	// Pattern is a regular expression that the selected values must match.
+	Pattern string `yaml:"pattern"`
+
+	// ForbiddenPattern is a regular expression that the selected values must not match.
+	ForbiddenPattern string `yaml:"forbidden_pattern"`
+
+	// Expression is a CEL expression that must evaluate to true for the selected nodes.
+	Expression string `yaml:"expression"`
+
+	path             []string
+	pattern          *regexp.Regexp
+	forbiddenPattern *regexp.Regexp
+	program          cel.Program
+}
+
+// LoadRepositoryRules reads the rules defined in the repository of the working directory. If the
+// ELASTIC_PACKAGE_LINT_RULES_FILE_PATH environment variable is set, rules are read from the file
+// it points to. It returns no rules if the repository doesn't define any, or if the working
+// directory is not in a repository.
+func LoadRepositoryRules() (*Rules, error) {
+	rulesFilePath, found := os.LookupEnv(rulesFilePathEnvVar)
+	if !found {
+		dir, err := files.FindRepositoryRootDirectory()
+		if errors.Is(err, os.ErrNotExist) {
+			logger.Debugf("working directory is not in a repository, no lint rules used")
+			return &Rules{}, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("locating repository root failed: %w", err)
+		}
+		rulesFilePath = filepath.Join(dir, rulesFileNameDefault)
+		if _, err := os.Stat(rulesFilePath); errors.Is(err, os.ErrNotExist) {
+			logger.Debugf("lint rules default file doesn't exist: %s", rulesFilePath)
+			return &Rules{}, nil
+		}
+	}
+
+	logger.Debugf("Using lint rules file: %s", rulesFilePath)
+	return LoadRules(rulesFilePath)
+}
+
+// LoadRules reads and compiles the rules defined in the given file.
+func LoadRules(path string) (*Rules, error) {
+	d, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading lint rules file failed (path: %s): %w", path, err)
+	}
+	var rules Rules
+	err = yaml.Unmarshal(d, &rules)
+	if err != nil {
+		return nil, fmt.Errorf("unmarshalling lint rules file failed (path: %s): %w", path, err)
+	}
+	err = rules.compile()
+	if err != nil {
+		return nil, fmt.Errorf("invalid lint rules (path: %s): %w", path, err)
+	}
+	return &rules, nil
+}
+
+func (r *Rules) compile() error {
+	env, err := cel.NewEnv(
+		cel.Variable("value", cel.DynType),
+		cel.Variable("file", cel.StringType),
+		cel.Variable("manifest", cel.MapType(cel.StringType, cel.DynType)),
+	)
+	if err != nil {
+		return fmt.Errorf("creating CEL environment failed: %w", err)
+	}
+
+	ids := make(map[string]bool)
+	for i := range r.Rules {
+		rule := &r.Rules[i]
+		if rule.ID == "" {
+			return fmt.Errorf("rule #%d has no id", i+1)
+		}
+		if ids[rule.ID] {
+			return fmt.Errorf("rule %q defined more than once", rule.ID)
+		}
+		ids[rule.ID] = true
+
+		err := rule.compile(env)
+		if err != nil {
+			return fmt.Errorf("rule %q: %w", rule.ID, err)
+		}
+	}
+	return nil
+}
+
+func (r *Rule) compile(env *cel.Env) error {
+	switch r.Severity {
+	case "":
+		r.Severity = SeverityError
+	case SeverityError, SeverityWarning:
+	default:
+		return fmt.Errorf("unknown severity %q, expected %q or %q", r.Severity, SeverityError, SeverityWarning)
+	}
+	if len(r.Files) == 0 {
+		return errors.New("no files selected")
+	}
+
+	declarative := len(r.Required) > 0 || r.Pattern != "" || r.ForbiddenPattern != ""
+	switch {
+	case declarative && r.Expression != "":
+		return errors.New("assertions and expression can't be used in the same rule")
+	case !declarative && r.Expression == "":
+		return errors.New("no assertions or expression defined")
+	}
+
+	if r.Path != "" {
+		r.path = strings.Split(r.Path, ".")
+	}
+
+	var err error
+	if r.Pattern != "" {
+		r.pattern, err = regexp.Compile(r.Pattern)
+		if err != nil {
+			return fmt.Errorf("invalid pattern: %w", err)
+		}
+	}
+	if r.ForbiddenPattern != "" {
+		r.forbiddenPattern, err = regexp.Compile(r.ForbiddenPattern)
+		if err != nil {
+			return fmt.Errorf("invalid forbidden pattern: %w", err)
+		}
+	}
+	if r.Expression != "" {
+		ast, issues := env.Compile(r.Expression)
+		if issues.Err() != nil {
+			return fmt.Errorf("invalid expression: %w", issues.Err())
+		}
+		if ast.OutputType() != cel.BoolType && ast.OutputType() != cel.DynType {
+			return fmt.Errorf("expression must evaluate to a boolean, found %s", ast.OutputType())
+		}
+		r.program, err = env.Program(ast)
+		if err != nil {
+			return fmt.Errorf("building program for expression failed: %w", err)
+		}
+	}
+	return nil
+}
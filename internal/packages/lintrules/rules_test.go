@@ -0,0 +1,96 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package lintrules
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRulesCompile(t *testing.T) {
+	cases := []struct {
+		title string
+		rules []Rule
+		err   string
+	}{
+		{
+			title: "valid rules",
+			rules: []Rule{
+				{ID: "tag", Files: []string{"manifest.yml"}, Required: []string{"tag"}},
+				{ID: "expression", Files: []string{"manifest.yml"}, Expression: `manifest.type == "integration"`},
+			},
+		},
+		{
+			title: "missing id",
+			rules: []Rule{{Files: []string{"manifest.yml"}, Required: []string{"tag"}}},
+			err:   "rule #1 has no id",
+		},
+		{
+			title: "duplicated id",
+			rules: []Rule{
+				{ID: "tag", Files: []string{"manifest.yml"}, Required: []string{"tag"}},
+				{ID: "tag", Files: []string{"manifest.yml"}, Required: []string{"tag"}},
+			},
+			err: `rule "tag" defined more than once`,
+		},
+		{
+			title: "unknown severity",
+			rules: []Rule{{ID: "tag", Severity: "fatal", Files: []string{"manifest.yml"}, Required: []string{"tag"}}},
+			err:   `rule "tag": unknown severity "fatal", expected "error" or "warning"`,
+		},
+		{
+			title: "no files",
+			rules: []Rule{{ID: "tag", Required: []string{"tag"}}},
+			err:   `rule "tag": no files selected`,
+		},
+		{
+			title: "no assertions",
+			rules: []Rule{{ID: "tag", Files: []string{"manifest.yml"}}},
+			err:   `rule "tag": no assertions or expression defined`,
+		},
+		{
+			title: "assertions and expression",
+			rules: []Rule{{ID: "tag", Files: []string{"manifest.yml"}, Required: []string{"tag"}, Expression: "true"}},
+			err:   `rule "tag": assertions and expression can't be used in the same rule`,
+		},
+		{
+			title: "invalid pattern",
+			rules: []Rule{{ID: "tag", Files: []string{"manifest.yml"}, Pattern: "("}},
+			err:   `rule "tag": invalid pattern`,
+		},
+		{
+			title: "expression not returning a boolean",
+			rules: []Rule{{ID: "tag", Files: []string{"manifest.yml"}, Expression: `"tag"`}},
+			err:   `rule "tag": expression must evaluate to a boolean, found string`,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.title, func(t *testing.T) {
+			rules := Rules{Rules: c.rules}
+			err := rules.compile()
+			if c.err == "" {
+				require.NoError(t, err)
+				assert.Equal(t, SeverityError, rules.Rules[0].Severity)
+				return
+			}
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), c.err)
+		})
+	}
+}
+
+func TestLoadRepositoryRulesOutsideRepository(t *testing.T) {
+	t.Setenv(rulesFilePathEnvVar, "")
+	os.Unsetenv(rulesFilePathEnvVar)
+	t.Chdir(t.TempDir())
+
+	rules, err := LoadRepositoryRules()
+	require.NoError(t, err)
+	assert.Empty(t, rules.Rules)
+}
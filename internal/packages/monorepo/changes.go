@@ -0,0 +1,304 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package monorepo
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/elastic/elastic-package/internal/files"
+	"github.com/elastic/elastic-package/internal/git"
+	"github.com/elastic/elastic-package/internal/logger"
+)
+
+const dataStreamDir = "data_stream"
+
+// ChangedFiles returns the files changed in the repository since the common ancestor of the given
+// git reference and HEAD, including uncommitted and untracked files. Paths are relative to the
+// repository root, with forward slashes.
+func ChangedFiles(ctx context.Context, repositoryRoot, ref string) ([]string, error) {
+	base, err := git.Run(ctx, repositoryRoot, "merge-base", ref, "HEAD")
+	if err != nil {
+		return nil, fmt.Errorf("failed to find common ancestor with %q: %w", ref, err)
+	}
+	diff, err := git.Run(ctx, repositoryRoot, "diff", "--name-only", "--no-renames", strings.TrimSpace(base))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list changed files: %w", err)
+	}
+	untracked, err := git.Run(ctx, repositoryRoot, "ls-files", "--others", "--exclude-standard")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list untracked files: %w", err)
+	}
+
+	var changed []string
+	for _, line := range strings.Split(diff+"\n"+untracked, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || slices.Contains(changed, line) {
+			continue
+		}
+		changed = append(changed, line)
+	}
+	slices.Sort(changed)
+	return changed, nil
+}
+
+// selection keeps the parts of a package affected by changes.
+type selection struct {
+	all         bool
+	dataStreams []string
+}
+
+// add selects the part of the package a path belongs to, the path is relative to the package root.
+func (s *selection) add(rel string) {
+	if s.all {
+		return
+	}
+	parts := strings.Split(rel, "/")
+	if len(parts) < 3 || parts[0] != dataStreamDir {
+		s.all = true
+		s.dataStreams = nil
+		return
+	}
+	if !slices.Contains(s.dataStreams, parts[1]) {
+		s.dataStreams = append(s.dataStreams, parts[1])
+	}
+}
+
+func (s *selection) empty() bool {
+	return !s.all && len(s.dataStreams) == 0
+}
+
+// Affected returns the packages affected by the changed files, with the affected data streams, or
+// no data streams if the whole package is affected. A package is affected when files in the
+// package change, when files included in the package through links change, or when files out of
+// the package referenced by its docker compose service deployers change.
+// Changed files are relative to the repository root, with forward slashes.
+func Affected(repositoryRoot string, pkgs []Package, changedFiles []string) ([]Package, error) {
+	root, err := os.OpenRoot(repositoryRoot)
+	if err != nil {
+		return nil, fmt.Errorf("opening repository root: %w", err)
+	}
+	defer root.Close()
+
+	var affected []Package
+	for _, pkg := range pkgs {
+		var s selection
+		for _, changed := range changedFiles {
+			if rel, ok := within(changed, pkg.Path); ok {
+				s.add(rel)
+			}
+		}
+
+		dependencies, err := externalDependencies(root, repositoryRoot, pkg)
+		if err != nil {
+			return nil, err
+		}
+		for _, dependency := range dependencies {
+			for _, changed := range changedFiles {
+				if _, ok := within(changed, dependency.path); ok || changed == dependency.path {
+					s.add(dependency.usedIn)
+					break
+				}
+			}
+		}
+
+		// Removed data streams cannot be tested.
+		s.dataStreams = slices.DeleteFunc(s.dataStreams, func(dataStream string) bool {
+			_, err := os.Stat(filepath.Join(pkg.Root, dataStreamDir, dataStream))
+			return err != nil
+		})
+		if s.empty() {
+			continue
+		}
+		slices.Sort(s.dataStreams)
+		pkg.DataStreams = s.dataStreams
+		affected = append(affected, pkg)
+	}
+	return affected, nil
+}
+
+// within returns the path relative to the directory if the path is in the directory.
+func within(p, dir string) (string, bool) {
+	if dir == "." || dir == "" {
+		return p, true
+	}
+	rel, found := strings.CutPrefix(p, dir+"/")
+	return rel, found
+}
+
+// dependency is a file or directory out of a package that is used by the package.
+type dependency struct {
+	// path is the path of the dependency, relative to the repository root.
+	path string
+
+	// usedIn is the path of the file using the dependency, relative to the package root.
+	usedIn string
+}
+
+// externalDependencies returns the files and directories out of the package that are used by it,
+// through linked files and docker compose definitions.
+func externalDependencies(root *os.Root, repositoryRoot string, pkg Package) ([]dependency, error) {
+	linksFS, err := files.NewLinksFS(root, pkg.Root)
+	if err != nil {
+		return nil, fmt.Errorf("creating links filesystem for package %s failed: %w", pkg.Name, err)
+	}
+	links, err := linksFS.ListLinkedFiles()
+	if err != nil {
+		return nil, fmt.Errorf("listing linked files of package %s failed: %w", pkg.Name, err)
+	}
+
+	var dependencies []dependency
+	for _, l := range links {
+		linkPath := filepath.Join(l.WorkDir, l.LinkFilePath)
+		included := filepath.Join(filepath.Dir(linkPath), l.IncludedFilePath)
+		d, ok := newDependency(repositoryRoot, pkg, included, linkPath)
+		if ok {
+			dependencies = append(dependencies, d)
+		}
+	}
+
+	composeFiles, err := composeFiles(pkg.Root)
+	if err != nil {
+		return nil, fmt.Errorf("looking for docker compose files of package %s failed: %w", pkg.Name, err)
+	}
+	for _, composeFile := range composeFiles {
+		paths, err := composePaths(composeFile)
+		if err != nil {
+			logger.Debugf("Ignoring invalid docker compose file (path: %s): %v", composeFile, err)
+			continue
+		}
+		for _, p := range paths {
+			d, ok := newDependency(repositoryRoot, pkg, p, composeFile)
+			if ok {
+				dependencies = append(dependencies, d)
+			}
+		}
+	}
+	return dependencies, nil
+}
+
+// newDependency creates a dependency if the path is out of the package and in the repository.
+func newDependency(repositoryRoot string, pkg Package, p, usedIn string) (dependency, bool) {
+	rel, err := filepath.Rel(repositoryRoot, p)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return dependency{}, false
+	}
+	rel = filepath.ToSlash(rel)
+	if _, ok := within(rel, pkg.Path); ok {
+		return dependency{}, false
+	}
+	usedInRel, err := filepath.Rel(pkg.Root, usedIn)
+	if err != nil {
+		return dependency{}, false
+	}
+	return dependency{path: rel, usedIn: filepath.ToSlash(usedInRel)}, true
+}
+
+// composeFiles returns the docker compose files of the service deployers of the package and of
+// its data streams.
+func composeFiles(packageRoot string) ([]string, error) {
+	var found []string
+	for _, pattern := range []string{
+		filepath.Join(packageRoot, "_dev", "deploy", "docker", "*.yml"),
+		filepath.Join(packageRoot, dataStreamDir, "*", "_dev", "deploy", "docker", "*.yml"),
+	} {
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, err
+		}
+		found = append(found, matches...)
+	}
+	return found, nil
+}
+
+type composeService struct {
+	Build   yaml.Node   `yaml:"build"`
+	Volumes []yaml.Node `yaml:"volumes"`
+	EnvFile yaml.Node   `yaml:"env_file"`
+	Extends struct {
+		File string `yaml:"file"`
+	} `yaml:"extends"`
+}
+
+// composePaths returns the absolute paths of the local files and directories referenced by the
+// services of a docker compose file. Paths with variables are ignored.
+func composePaths(composeFile string) ([]string, error) {
+	d, err := os.ReadFile(composeFile)
+	if err != nil {
+		return nil, err
+	}
+	var compose struct {
+		Services map[string]composeService `yaml:"services"`
+	}
+	err = yaml.Unmarshal(d, &compose)
+	if err != nil {
+		return nil, err
+	}
+
+	var paths []string
+	add := func(p string) {
+		if p == "" || strings.Contains(p, "$") || strings.Contains(p, "://") {
+			return
+		}
+		if !filepath.IsAbs(p) {
+			p = filepath.Join(filepath.Dir(composeFile), p)
+		}
+		paths = append(paths, filepath.Clean(p))
+	}
+	for _, service := range compose.Services {
+		switch service.Build.Kind {
+		case yaml.ScalarNode:
+			add(service.Build.Value)
+		case yaml.MappingNode:
+			var build struct {
+				Context string `yaml:"context"`
+			}
+			if err := service.Build.Decode(&build); err == nil {
+				add(build.Context)
+			}
+		}
+		for _, volume := range service.Volumes {
+			switch volume.Kind {
+			case yaml.ScalarNode:
+				source, _, _ := strings.Cut(volume.Value, ":")
+				if isVolumePath(source) {
+					add(source)
+				}
+			case yaml.MappingNode:
+				var v struct {
+					Source string `yaml:"source"`
+				}
+				if err := volume.Decode(&v); err == nil && isVolumePath(v.Source) {
+					add(v.Source)
+				}
+			}
+		}
+		switch service.EnvFile.Kind {
+		case yaml.ScalarNode:
+			add(service.EnvFile.Value)
+		case yaml.SequenceNode:
+			var envFiles []string
+			if err := service.EnvFile.Decode(&envFiles); err == nil {
+				for _, f := range envFiles {
+					add(f)
+				}
+			}
+		}
+		add(service.Extends.File)
+	}
+	return paths, nil
+}
+
+// isVolumePath returns true if the source of a volume is a path, and not a named volume.
+func isVolumePath(source string) bool {
+	return strings.HasPrefix(source, ".") || path.IsAbs(filepath.ToSlash(source)) || filepath.IsAbs(source)
+}
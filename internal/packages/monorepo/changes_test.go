@@ -0,0 +1,140 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package monorepo
+
+import (
+	"context"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAffected(t *testing.T) {
+	root := t.TempDir()
+	writePackage(t, root, "packages/base", "base")
+	writePackage(t, root, "packages/consumer", "consumer")
+	writePackage(t, root, "packages/service", "service")
+	writeFile(t, filepath.Join(root, "packages", "base", "fields", "shared.yml"), "- name: shared\n  type: keyword\n")
+	writeFile(t, filepath.Join(root, "packages", "consumer", "data_stream", "logs", "fields", "shared.yml.link"), "../../../../base/fields/shared.yml\n")
+	writeFile(t, filepath.Join(root, "packages", "service", "data_stream", "logs", "_dev", "deploy", "docker", "docker-compose.yml"), `version: '2.3'
+services:
+  service:
+    build: ../../../../../../../testing/service
+    env_file:
+      - ../../../../../../../testing/env/common.env
+    volumes:
+      - ${SERVICE_LOGS_DIR}:/var/log/service
+      - ./config:/etc/service
+      - data:/var/lib/service
+`)
+
+	pkgs, err := Discover(root, nil)
+	require.NoError(t, err)
+
+	cases := []struct {
+		title    string
+		changed  []string
+		expected []Package
+	}{
+		{
+			title: "no changes",
+		},
+		{
+			title:   "changes out of packages",
+			changed: []string{"README.md", "packages/README.md"},
+		},
+		{
+			title:   "data stream changes",
+			changed: []string{"packages/consumer/data_stream/logs/manifest.yml"},
+			expected: []Package{
+				{Name: "consumer", Path: "packages/consumer", DataStreams: []string{"logs"}},
+			},
+		},
+		{
+			title:   "package changes",
+			changed: []string{"packages/consumer/data_stream/logs/manifest.yml", "packages/consumer/manifest.yml"},
+			expected: []Package{
+				{Name: "consumer", Path: "packages/consumer"},
+			},
+		},
+		{
+			title:   "removed data stream",
+			changed: []string{"packages/consumer/data_stream/metrics/manifest.yml"},
+		},
+		{
+			title:   "linked file changes",
+			changed: []string{"packages/base/fields/shared.yml"},
+			expected: []Package{
+				{Name: "base", Path: "packages/base"},
+				{Name: "consumer", Path: "packages/consumer", DataStreams: []string{"logs"}},
+			},
+		},
+		{
+			title:   "service build context changes",
+			changed: []string{"testing/service/Dockerfile"},
+			expected: []Package{
+				{Name: "service", Path: "packages/service", DataStreams: []string{"logs"}},
+			},
+		},
+		{
+			title:   "service env file changes",
+			changed: []string{"testing/env/common.env"},
+			expected: []Package{
+				{Name: "service", Path: "packages/service", DataStreams: []string{"logs"}},
+			},
+		},
+		{
+			title:   "other testing files changes",
+			changed: []string{"testing/env/other.env"},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.title, func(t *testing.T) {
+			affected, err := Affected(root, pkgs, c.changed)
+			require.NoError(t, err)
+			for i := range affected {
+				affected[i].Root = ""
+			}
+			assert.Equal(t, c.expected, affected)
+		})
+	}
+}
+
+func TestChangedFiles(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+
+	root := t.TempDir()
+	runGit := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+		cmd.Dir = root
+		output, err := cmd.CombinedOutput()
+		require.NoError(t, err, string(output))
+	}
+
+	runGit("init", "--quiet", "--initial-branch=main")
+	writeFile(t, filepath.Join(root, "unchanged.txt"), "foo\n")
+	writeFile(t, filepath.Join(root, "packages", "foo", "manifest.yml"), "name: foo\n")
+	runGit("add", "-A")
+	runGit("commit", "--quiet", "-m", "initial")
+
+	runGit("checkout", "--quiet", "-b", "feature")
+	writeFile(t, filepath.Join(root, "packages", "foo", "manifest.yml"), "name: foo\nversion: 1.0.0\n")
+	runGit("commit", "--quiet", "-am", "change")
+	writeFile(t, filepath.Join(root, "packages", "bar", "manifest.yml"), "name: bar\n")
+
+	changed, err := ChangedFiles(context.Background(), root, "main")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"packages/bar/manifest.yml", "packages/foo/manifest.yml"}, changed)
+
+	_, err = ChangedFiles(context.Background(), root, "unknown")
+	assert.ErrorContains(t, err, "unknown")
+}
@@ -0,0 +1,271 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package monorepo
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/elastic/elastic-package/internal/files"
+	"github.com/elastic/elastic-package/internal/logger"
+	"github.com/elastic/elastic-package/internal/packages"
+)
+
+// Directories that are not looked for packages.
+var skippedDirs = []string{".git", "build", "node_modules"}
+
+// Package is a package found in the repository.
+type Package struct {
+	Name string
+
+	// Root is the path to the root directory of the package.
+	Root string
+
+	// Path is the path of the package relative to the repository root, with forward slashes.
+	Path string
+
+	// DataStreams are the data streams selected in the package, all of them if empty.
+	DataStreams []string
+}
+
+// Discover looks for the packages under the repository root. If patterns are given, only the
+// packages whose name or path (relative to the repository root) match any of them are returned.
+// Packages are sorted by path.
+func Discover(repositoryRoot string, patterns []string) ([]Package, error) {
+	for _, pattern := range patterns {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid pattern %q: %w", pattern, err)
+		}
+	}
+
+	var found []Package
+	err := filepath.WalkDir(repositoryRoot, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		if p != repositoryRoot && slices.Contains(skippedDirs, d.Name()) {
+			return filepath.SkipDir
+		}
+
+		manifestPath := filepath.Join(p, packages.PackageManifestFile)
+		if _, err := os.Stat(manifestPath); err != nil {
+			return nil
+		}
+		root, ok, err := packages.FindPackageRootFrom(p)
+		if err != nil {
+			logger.Debugf("Ignoring invalid package manifest (path: %s): %v", manifestPath, err)
+			return nil
+		}
+		if !ok || root != p {
+			return nil
+		}
+
+		manifest, err := packages.ReadPackageManifestFromPackageRoot(p)
+		if err != nil {
+			return fmt.Errorf("reading package manifest failed (path: %s): %w", p, err)
+		}
+		rel, err := filepath.Rel(repositoryRoot, p)
+		if err != nil {
+			return err
+		}
+		pkg := Package{
+			Name: manifest.Name,
+			Root: p,
+			Path: filepath.ToSlash(rel),
+		}
+		if matchesAny(pkg, patterns) {
+			found = append(found, pkg)
+		}
+
+		// Packages are not nested.
+		return filepath.SkipDir
+	})
+	if err != nil {
+		return nil, fmt.Errorf("looking for packages failed: %w", err)
+	}
+	return found, nil
+}
+
+func matchesAny(pkg Package, patterns []string) bool {
+	if len(patterns) == 0 {
+		return true
+	}
+	for _, pattern := range patterns {
+		pattern = strings.TrimSuffix(filepath.ToSlash(pattern), "/")
+		if ok, _ := path.Match(pattern, pkg.Name); ok {
+			return true
+		}
+		if ok, _ := path.Match(pattern, pkg.Path); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// Order sorts the packages in levels, so packages that include files from other packages through
+// links are in later levels than them. Packages in the same level don't depend on each other.
+func Order(repositoryRoot string, pkgs []Package) ([][]Package, error) {
+	root, err := os.OpenRoot(repositoryRoot)
+	if err != nil {
+		return nil, fmt.Errorf("opening repository root: %w", err)
+	}
+	defer root.Close()
+
+	byDirName := make(map[string]string, len(pkgs))
+	for _, pkg := range pkgs {
+		byDirName[filepath.Base(pkg.Root)] = pkg.Path
+	}
+
+	dependencies := make(map[string][]string, len(pkgs))
+	for _, pkg := range pkgs {
+		linksFS, err := files.NewLinksFS(root, pkg.Root)
+		if err != nil {
+			return nil, fmt.Errorf("creating links filesystem for package %s failed: %w", pkg.Name, err)
+		}
+		links, err := linksFS.ListLinkedFiles()
+		if err != nil {
+			return nil, fmt.Errorf("listing linked files of package %s failed: %w", pkg.Name, err)
+		}
+		for _, l := range links {
+			source, found := byDirName[l.IncludedPackageName]
+			if !found || source == pkg.Path || slices.Contains(dependencies[pkg.Path], source) {
+				continue
+			}
+			dependencies[pkg.Path] = append(dependencies[pkg.Path], source)
+		}
+	}
+	return orderByDependencies(pkgs, dependencies)
+}
+
+// orderByDependencies sorts the packages in levels, dependencies are indexed by package path.
+func orderByDependencies(pkgs []Package, dependencies map[string][]string) ([][]Package, error) {
+	pending := slices.Clone(pkgs)
+	done := make(map[string]bool, len(pkgs))
+
+	var levels [][]Package
+	for len(pending) > 0 {
+		var level, rest []Package
+		for _, pkg := range pending {
+			ready := true
+			for _, dependency := range dependencies[pkg.Path] {
+				if !done[dependency] {
+					ready = false
+					break
+				}
+			}
+			if ready {
+				level = append(level, pkg)
+			} else {
+				rest = append(rest, pkg)
+			}
+		}
+		if len(level) == 0 {
+			var names []string
+			for _, pkg := range rest {
+				names = append(names, pkg.Name)
+			}
+			return nil, fmt.Errorf("circular linked files found between packages: %s", strings.Join(names, ", "))
+		}
+		sort.Slice(level, func(i, j int) bool { return level[i].Path < level[j].Path })
+		for _, pkg := range level {
+			done[pkg.Path] = true
+		}
+		levels = append(levels, level)
+		pending = rest
+	}
+	return levels, nil
+}
+
+// Action is executed on each package, it writes its output in the given writer.
+type Action func(ctx context.Context, pkg Package, w io.Writer) error
+
+// Result is the result of the execution of an action on a package.
+type Result struct {
+	Package  Package
+	Duration time.Duration
+	Output   string
+	Err      error
+}
+
+// Report contains the results of the execution of an action on multiple packages.
+type Report struct {
+	Results []Result
+}
+
+// Failed returns the results of the packages where the action failed.
+func (r *Report) Failed() []Result {
+	var failed []Result
+	for _, result := range r.Results {
+		if result.Err != nil {
+			failed = append(failed, result)
+		}
+	}
+	return failed
+}
+
+// Run executes the action on the packages, level by level, running at most parallelism actions
+// at the same time. The done function, if not nil, is called after each package is processed,
+// never concurrently. Results in the report follow the order of the packages.
+func Run(ctx context.Context, levels [][]Package, parallelism int, action Action, done func(Result)) *Report {
+	if parallelism < 1 {
+		parallelism = 1
+	}
+
+	var report Report
+	var mutex sync.Mutex
+	for _, level := range levels {
+		results := make([]Result, len(level))
+		semaphore := make(chan struct{}, parallelism)
+		var wg sync.WaitGroup
+		for i, pkg := range level {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				semaphore <- struct{}{}
+				defer func() { <-semaphore }()
+
+				results[i] = runAction(ctx, pkg, action)
+				if done != nil {
+					mutex.Lock()
+					defer mutex.Unlock()
+					done(results[i])
+				}
+			}()
+		}
+		wg.Wait()
+		report.Results = append(report.Results, results...)
+	}
+	return &report
+}
+
+func runAction(ctx context.Context, pkg Package, action Action) Result {
+	result := Result{Package: pkg}
+	if err := ctx.Err(); err != nil {
+		result.Err = err
+		return result
+	}
+
+	var output bytes.Buffer
+	start := time.Now()
+	err := action(ctx, pkg, &output)
+	result.Duration = time.Since(start)
+	result.Output = output.String()
+	result.Err = err
+	return result
+}
@@ -0,0 +1,181 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package monorepo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+}
+
+func writePackage(t *testing.T, root, path, name string) {
+	t.Helper()
+	manifest := fmt.Sprintf("format_version: 3.0.0\nname: %s\nversion: 1.0.0\ntype: integration\n", name)
+	writeFile(t, filepath.Join(root, filepath.FromSlash(path), "manifest.yml"), manifest)
+	writeFile(t, filepath.Join(root, filepath.FromSlash(path), "data_stream", "logs", "manifest.yml"), "title: Logs\n")
+}
+
+func packagePaths(pkgs []Package) []string {
+	var paths []string
+	for _, pkg := range pkgs {
+		paths = append(paths, pkg.Path)
+	}
+	return paths
+}
+
+func TestDiscover(t *testing.T) {
+	root := t.TempDir()
+	writePackage(t, root, "packages/apache", "apache")
+	writePackage(t, root, "packages/nginx", "nginx")
+	writePackage(t, root, "packages/nginx_ingress_controller", "nginx_ingress_controller")
+	writePackage(t, root, "test/packages/other/sql_input", "sql_input")
+	writePackage(t, root, "build/packages/apache/1.0.0", "apache")
+	writeFile(t, filepath.Join(root, "not_a_package", "manifest.yml"), "name: foo\n")
+
+	cases := []struct {
+		patterns []string
+		expected []string
+	}{
+		{
+			expected: []string{"packages/apache", "packages/nginx", "packages/nginx_ingress_controller", "test/packages/other/sql_input"},
+		},
+		{
+			patterns: []string{"nginx*"},
+			expected: []string{"packages/nginx", "packages/nginx_ingress_controller"},
+		},
+		{
+			patterns: []string{"test/packages/*/*", "apache"},
+			expected: []string{"packages/apache", "test/packages/other/sql_input"},
+		},
+		{
+			patterns: []string{"packages/nginx/"},
+			expected: []string{"packages/nginx"},
+		},
+		{
+			patterns: []string{"foo"},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(fmt.Sprint(c.patterns), func(t *testing.T) {
+			pkgs, err := Discover(root, c.patterns)
+			require.NoError(t, err)
+			assert.Equal(t, c.expected, packagePaths(pkgs))
+		})
+	}
+}
+
+func TestDiscoverInvalidPattern(t *testing.T) {
+	_, err := Discover(t.TempDir(), []string{"[a-"})
+	assert.Error(t, err)
+}
+
+func TestOrder(t *testing.T) {
+	root := t.TempDir()
+	writePackage(t, root, "packages/base", "base")
+	writePackage(t, root, "packages/consumer", "consumer")
+	writePackage(t, root, "packages/other", "other")
+	writeFile(t, filepath.Join(root, "packages", "base", "fields", "shared.yml"), "- name: shared\n  type: keyword\n")
+	writeFile(t, filepath.Join(root, "packages", "consumer", "fields", "shared.yml.link"), "../../base/fields/shared.yml\n")
+
+	pkgs, err := Discover(root, nil)
+	require.NoError(t, err)
+
+	levels, err := Order(root, pkgs)
+	require.NoError(t, err)
+	require.Len(t, levels, 2)
+	assert.Equal(t, []string{"packages/base", "packages/other"}, packagePaths(levels[0]))
+	assert.Equal(t, []string{"packages/consumer"}, packagePaths(levels[1]))
+}
+
+func TestOrderByDependenciesCircular(t *testing.T) {
+	pkgs := []Package{
+		{Name: "a", Path: "packages/a"},
+		{Name: "b", Path: "packages/b"},
+		{Name: "c", Path: "packages/c"},
+	}
+	dependencies := map[string][]string{
+		"packages/a": {"packages/b"},
+		"packages/b": {"packages/a"},
+	}
+	_, err := orderByDependencies(pkgs, dependencies)
+	assert.ErrorContains(t, err, "circular linked files found between packages: a, b")
+}
+
+func TestRun(t *testing.T) {
+	levels := [][]Package{
+		{{Name: "a"}, {Name: "b"}, {Name: "c"}},
+		{{Name: "d"}},
+	}
+
+	var running, maxRunning atomic.Int32
+	var finishedBeforeD atomic.Int32
+	var done []string
+	report := Run(context.Background(), levels, 2,
+		func(ctx context.Context, pkg Package, w io.Writer) error {
+			current := running.Add(1)
+			defer running.Add(-1)
+			for {
+				max := maxRunning.Load()
+				if current <= max || maxRunning.CompareAndSwap(max, current) {
+					break
+				}
+			}
+
+			fmt.Fprintf(w, "processing %s", pkg.Name)
+			switch pkg.Name {
+			case "b":
+				return errors.New("failed")
+			case "d":
+				finishedBeforeD.Store(int32(len(done)))
+			}
+			return nil
+		},
+		func(result Result) {
+			done = append(done, result.Package.Name)
+		},
+	)
+
+	assert.LessOrEqual(t, maxRunning.Load(), int32(2))
+	assert.Equal(t, int32(3), finishedBeforeD.Load())
+	assert.Len(t, done, 4)
+
+	require.Len(t, report.Results, 4)
+	for i, name := range []string{"a", "b", "c", "d"} {
+		assert.Equal(t, name, report.Results[i].Package.Name)
+		assert.Equal(t, "processing "+name, report.Results[i].Output)
+	}
+
+	failed := report.Failed()
+	require.Len(t, failed, 1)
+	assert.Equal(t, "b", failed[0].Package.Name)
+}
+
+func TestRunCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	report := Run(ctx, [][]Package{{{Name: "a"}}}, 1,
+		func(ctx context.Context, pkg Package, w io.Writer) error {
+			t.Fatal("action should not be executed")
+			return nil
+		}, nil)
+	require.Len(t, report.Failed(), 1)
+	assert.ErrorIs(t, report.Results[0].Err, context.Canceled)
+}
@@ -119,8 +119,10 @@ type Variable struct {
 
 // Input is a single input configuration.
 type Input struct {
-	Type string     `config:"type" json:"type" yaml:"type"`
-	Vars []Variable `config:"vars" json:"vars" yaml:"vars"`
+	Type        string     `config:"type" json:"type" yaml:"type"`
+	Title       string     `config:"title" json:"title" yaml:"title"`
+	Description string     `config:"description" json:"description" yaml:"description"`
+	Vars        []Variable `config:"vars" json:"vars" yaml:"vars"`
 }
 
 // Source contains metadata about the source code of the package.
@@ -135,7 +137,8 @@ type KibanaConditions struct {
 
 // ElasticConditions defines conditions related to Elastic subscriptions or partnerships.
 type ElasticConditions struct {
-	Subscription string `config:"subscription" json:"subscription" yaml:"subscription"`
+	Subscription string   `config:"subscription" json:"subscription" yaml:"subscription"`
+	Capabilities []string `config:"capabilities" json:"capabilities" yaml:"capabilities"`
 }
 
 // Conditions define requirements for different parts of the Elastic stack.
@@ -157,6 +160,8 @@ type DiscoveryField struct {
 // PolicyTemplate is a configuration of inputs responsible for collecting log or metric data.
 type PolicyTemplate struct {
 	Name        string   `config:"name" json:"name" yaml:"name"`                                                       // Name of policy template.
+	Title       string   `config:"title" json:"title" yaml:"title"`                                                    // Title of policy template.
+	Description string   `config:"description" json:"description" yaml:"description"`                                  // Description of policy template.
 	DataStreams []string `config:"data_streams,omitempty" json:"data_streams,omitempty" yaml:"data_streams,omitempty"` // List of data streams compatible with the policy template.
 	Inputs      []Input  `config:"inputs,omitempty" json:"inputs,omitempty" yaml:"inputs,omitempty"`
 
@@ -373,6 +378,28 @@ func ReadPackageManifestFromZipPackage(zipPackage string) (*PackageManifest, err
 	return ReadPackageManifestBytes(contents)
 }
 
+// FindExtractedPackageRoot looks for the root of a package extracted from an archive, that
+// is usually contained in a directory named after the package and its version.
+func FindExtractedPackageRoot(dir string) (string, error) {
+	if _, err := os.Stat(filepath.Join(dir, PackageManifestFile)); err == nil {
+		return dir, nil
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return "", fmt.Errorf("can't read extracted package: %w", err)
+	}
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		root := filepath.Join(dir, entry.Name())
+		if _, err := os.Stat(filepath.Join(root, PackageManifestFile)); err == nil {
+			return root, nil
+		}
+	}
+	return "", errors.New("package manifest not found")
+}
+
 func extractPackageManifestZipPackage(zipPath, sourcePath string) ([]byte, error) {
 	zipReader, err := zip.OpenReader(zipPath)
 	if err != nil {
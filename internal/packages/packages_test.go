@@ -6,6 +6,8 @@ package packages
 
 import (
 	"encoding/json"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -94,3 +96,21 @@ func TestDataStreamManifest_IndexTemplateName(t *testing.T) {
 		})
 	}
 }
+
+func TestFindExtractedPackageRoot(t *testing.T) {
+	dir := t.TempDir()
+	root := filepath.Join(dir, "foo-1.0.0")
+	require.NoError(t, os.MkdirAll(root, 0o755))
+
+	_, err := FindExtractedPackageRoot(dir)
+	assert.Error(t, err)
+
+	require.NoError(t, os.WriteFile(filepath.Join(root, PackageManifestFile), []byte("name: foo\n"), 0o644))
+	found, err := FindExtractedPackageRoot(dir)
+	require.NoError(t, err)
+	assert.Equal(t, root, found)
+
+	found, err = FindExtractedPackageRoot(root)
+	require.NoError(t, err)
+	assert.Equal(t, root, found)
+}
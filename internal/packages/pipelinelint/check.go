@@ -0,0 +1,330 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package pipelinelint
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/elastic/elastic-package/internal/elasticsearch/ingest"
+	"github.com/elastic/elastic-package/internal/files"
+	"github.com/elastic/elastic-package/internal/packages"
+	"github.com/elastic/elastic-package/internal/packages/fieldusage"
+)
+
+// Severity is the severity of an issue found in a pipeline.
+type Severity string
+
+const (
+	// SeverityError is the severity of issues that make the lint fail.
+	SeverityError Severity = "error"
+
+	// SeverityWarning is the severity of issues that are only reported.
+	SeverityWarning Severity = "warning"
+)
+
+// Issue is a problem found in an ingest pipeline of the package.
+type Issue struct {
+	Severity Severity
+
+	// File is the path of the pipeline, relative to the package root.
+	File string
+	Line int
+
+	Message string
+}
+
+func (i Issue) String() string {
+	return fmt.Sprintf("%s:%d: %s: %s", i.File, i.Line, i.Severity, i.Message)
+}
+
+// CheckOptions contains the options to analyse the ingest pipelines.
+type CheckOptions struct {
+	// SkipConditionFields disables the check of the fields referenced in conditions, for
+	// example when the declared fields are known to be unreadable.
+	SkipConditionFields bool
+}
+
+// Check analyses the ingest pipelines of the package, without installing them.
+func Check(packageRoot string, options CheckOptions) ([]Issue, error) {
+	manifest, err := packages.ReadPackageManifestFromPackageRoot(packageRoot)
+	if err != nil {
+		return nil, fmt.Errorf("reading package manifest failed: %w", err)
+	}
+
+	roots := []string{packageRoot}
+	dataStreams, err := filepath.Glob(filepath.Join(packageRoot, "data_stream", "*", packages.DataStreamManifestFile))
+	if err != nil {
+		return nil, err
+	}
+	for _, path := range dataStreams {
+		roots = append(roots, filepath.Dir(path))
+	}
+
+	var issues []Issue
+	for _, root := range roots {
+		pipelines, err := readPipelines(root)
+		if err != nil {
+			return nil, err
+		}
+		if len(pipelines) == 0 {
+			continue
+		}
+
+		// Fields are only known for data streams, and for the pipelines of input packages. Fields used
+		// in conditions are not checked if they can't be read.
+		var known *fieldusage.KnownFields
+		var knownErr error
+		if !options.SkipConditionFields && (root != packageRoot || manifest.Type == "input") {
+			known, knownErr = fieldusage.ReadKnownFields(packageRoot, root)
+		}
+
+		names := make(map[string]bool)
+		for _, pipeline := range pipelines {
+			names[pipeline.Name] = true
+		}
+		for _, pipeline := range pipelines {
+			file, err := filepath.Rel(packageRoot, pipeline.Path)
+			if err != nil {
+				return nil, err
+			}
+			tree, err := pipeline.ProcessorTree()
+			if err != nil {
+				return nil, err
+			}
+			c := pipelineChecker{
+				file:      filepath.ToSlash(file),
+				pipelines: names,
+				known:     known,
+				tags:      make(map[string]int),
+			}
+			if knownErr != nil {
+				c.report(SeverityWarning, 1, "usage of fields in conditions not checked: reading known fields failed: %v", knownErr)
+			}
+			c.check(tree)
+			issues = append(issues, c.issues...)
+		}
+	}
+
+	slices.SortStableFunc(issues, func(a, b Issue) int {
+		if a.File != b.File {
+			return strings.Compare(a.File, b.File)
+		}
+		return a.Line - b.Line
+	})
+	return issues, nil
+}
+
+// readPipelines reads the ingest pipelines defined in a data stream, or in the package root.
+func readPipelines(root string) ([]ingest.Pipeline, error) {
+	dir := filepath.Join(root, "elasticsearch", "ingest_pipeline")
+	paths, err := filepath.Glob(filepath.Join(dir, "*"))
+	if err != nil {
+		return nil, err
+	}
+
+	var linksFS *files.LinksFS
+	var pipelines []ingest.Pipeline
+	for _, path := range paths {
+		format := filepath.Ext(strings.TrimSuffix(path, ".link"))
+		switch format {
+		case ".yml", ".yaml", ".json":
+		default:
+			continue
+		}
+		var content []byte
+		if filepath.Ext(path) == ".link" {
+			if linksFS == nil {
+				linksFS, err = files.CreateLinksFSFromPath(dir)
+				if err != nil {
+					return nil, fmt.Errorf("creating links filesystem failed: %w", err)
+				}
+			}
+			content, err = linksFS.ReadFile(path)
+		} else {
+			content, err = os.ReadFile(path)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("reading ingest pipeline failed (path: %s): %w", path, err)
+		}
+		name := filepath.Base(path)
+		pipelines = append(pipelines, ingest.Pipeline{
+			Path:            path,
+			Name:            name[:strings.Index(name, ".")],
+			Format:          format[1:],
+			Content:         content,
+			ContentOriginal: content,
+		})
+	}
+	return pipelines, nil
+}
+
+type pipelineChecker struct {
+	file string
+
+	// pipelines are the names of the pipelines defined in the same directory.
+	pipelines map[string]bool
+
+	// known are the fields known in the documents processed by the pipeline, nil if unknown.
+	known *fieldusage.KnownFields
+
+	// tags are the tags used in the pipeline, with the lines where they are first used.
+	tags map[string]int
+
+	// untagged are the lines of the processors without tags, they are reported together.
+	untagged []int
+
+	issues []Issue
+}
+
+func (c *pipelineChecker) report(severity Severity, line int, format string, args ...any) {
+	c.issues = append(c.issues, Issue{
+		Severity: severity,
+		File:     c.file,
+		Line:     line,
+		Message:  fmt.Sprintf(format, args...),
+	})
+}
+
+func (c *pipelineChecker) check(tree *ingest.ProcessorTree) {
+	if len(tree.Processors) > 0 && len(tree.OnFailure) == 0 {
+		c.report(SeverityWarning, 1, "pipeline has no on_failure handlers")
+	}
+	c.checkProcessors(tree.Processors, true)
+	c.checkProcessors(tree.OnFailure, false)
+	c.reportUntagged()
+}
+
+// reportUntagged reports the processors without tags in a single issue, in the line of the
+// first one of them.
+func (c *pipelineChecker) reportUntagged() {
+	if len(c.untagged) == 0 {
+		return
+	}
+	lines := make([]string, len(c.untagged))
+	for i, line := range c.untagged {
+		lines[i] = strconv.Itoa(line)
+	}
+	if len(lines) == 1 {
+		c.report(SeverityWarning, c.untagged[0], "1 processor has no tag (line %s)", lines[0])
+		return
+	}
+	c.report(SeverityWarning, c.untagged[0], "%d processors have no tag (lines %s)", len(lines), strings.Join(lines, ", "))
+}
+
+// checkProcessors checks a list of processors. Tags are only required out of failure handlers.
+func (c *pipelineChecker) checkProcessors(nodes []ingest.ProcessorNode, requireTags bool) {
+	var terminal *ingest.ProcessorNode
+	for i, node := range nodes {
+		if terminal != nil {
+			c.report(SeverityError, node.FirstLine, "%s processor is unreachable after the %s processor in line %d", node.Type, terminal.Type, terminal.FirstLine)
+		}
+		c.checkProcessor(node, requireTags)
+		if terminal == nil && terminates(node) {
+			terminal = &nodes[i]
+		}
+	}
+}
+
+// terminates checks if the processor always stops the execution of the processors after it.
+func terminates(node ingest.ProcessorNode) bool {
+	if node.Condition() != "" {
+		return false
+	}
+	switch node.Type {
+	case "drop", "reroute":
+		return true
+	case "fail":
+		ignoreFailure, _ := node.Config["ignore_failure"].(bool)
+		return !ignoreFailure && len(node.OnFailure) == 0
+	}
+	return false
+}
+
+func (c *pipelineChecker) checkProcessor(node ingest.ProcessorNode, requireTags bool) {
+	switch tag := node.Tag(); {
+	case tag == "":
+		if requireTags {
+			c.untagged = append(c.untagged, node.FirstLine)
+		}
+	case c.tags[tag] > 0:
+		c.report(SeverityWarning, node.FirstLine, "duplicate tag %q, already used in line %d", tag, c.tags[tag])
+	default:
+		c.tags[tag] = node.FirstLine
+	}
+
+	if condition := node.Condition(); condition != "" && !strings.Contains(condition, "{{") {
+		if err := checkPainless(condition); err != nil {
+			c.report(SeverityError, node.FirstLine, "invalid painless condition: %v", err)
+		} else if c.known != nil {
+			for _, name := range c.known.Unknown(condition) {
+				c.report(SeverityWarning, node.FirstLine, "condition references field %q, that is not declared or set by the pipelines", name)
+			}
+		}
+	}
+
+	switch node.Type {
+	case "script":
+		c.checkScript(node)
+	case "pipeline":
+		c.checkPipelineReference(node)
+	case "grok":
+		c.checkGrok(node)
+	}
+
+	c.checkProcessors(node.Nested, requireTags)
+	c.checkProcessors(node.OnFailure, false)
+}
+
+func (c *pipelineChecker) checkScript(node ingest.ProcessorNode) {
+	lang, _ := node.Config["lang"].(string)
+	source, _ := node.Config["source"].(string)
+	if (lang != "" && lang != "painless") || source == "" || strings.Contains(source, "{{") {
+		return
+	}
+	if err := checkPainless(source); err != nil {
+		c.report(SeverityError, node.FirstLine, "invalid painless source: %v", err)
+	}
+}
+
+// checkPipelineReference checks that the pipelines referenced with IngestPipeline tags exist.
+func (c *pipelineChecker) checkPipelineReference(node ingest.ProcessorNode) {
+	name, _ := node.Config["name"].(string)
+	referenced, found := ingest.IngestPipelineTagName(name)
+	if found && !c.pipelines[referenced] {
+		c.report(SeverityError, node.FirstLine, "referenced pipeline %q doesn't exist", referenced)
+	}
+}
+
+func (c *pipelineChecker) checkGrok(node ingest.ProcessorNode) {
+	var patterns []string
+	if list, ok := node.Config["patterns"].([]any); ok {
+		for _, pattern := range list {
+			if pattern, ok := pattern.(string); ok {
+				patterns = append(patterns, pattern)
+			}
+		}
+	}
+	definitions := make(map[string]string)
+	if defined, ok := node.Config["pattern_definitions"].(map[string]any); ok {
+		for name, definition := range defined {
+			if definition, ok := definition.(string); ok {
+				definitions[name] = definition
+			}
+		}
+	}
+
+	check := checkGrok(patterns, definitions)
+	for _, message := range check.errors {
+		c.report(SeverityError, node.FirstLine, "%s", message)
+	}
+	for _, message := range check.warnings {
+		c.report(SeverityWarning, node.FirstLine, "%s", message)
+	}
+}
@@ -0,0 +1,152 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package pipelinelint
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheck(t *testing.T) {
+	packageRoot := t.TempDir()
+	files := map[string]string{
+		"manifest.yml": `format_version: 3.0.0
+name: vendor
+title: Vendor
+version: 1.0.0
+type: integration
+`,
+		"data_stream/log/manifest.yml": `title: Logs
+type: logs
+`,
+		"data_stream/log/fields/fields.yml": `- name: vendor.log
+  type: group
+  fields:
+    - name: level
+      type: keyword
+    - name: user
+      type: keyword
+`,
+		"data_stream/log/elasticsearch/ingest_pipeline/default.yml": `---
+description: Pipeline for vendor logs
+processors:
+  - grok:
+      tag: grok_message
+      field: message
+      patterns:
+        - '%{WORD:vendor.log.level} %{USERNAME:vendor.log.user} %{GREEDYDATA:_tmp.rest}'
+  - set:
+      tag: set_kind
+      field: event.kind
+      value: alert
+      if: ctx.vendor?.log?.level == "ERROR" && ctx.vendor.log.severity != null
+  - pipeline:
+      tag: pipeline_audit
+      name: '{{ IngestPipeline "audit" }}'
+      if: ctx.event?.kind == 'alert'
+  - pipeline:
+      tag: pipeline_missing
+      name: '{{ IngestPipeline "missing" }}'
+  - script:
+      tag: script_labels
+      source: |
+        if (ctx._tmp?.rest != null) {
+          ctx.vendor.log.labels = ctx._tmp.rest.splitOnToken(' ');
+  - drop:
+      tag: drop_debug
+  - remove:
+      tag: set_kind
+      field: _tmp
+      ignore_missing: true
+on_failure:
+  - set:
+      field: error.message
+      value: '{{{ _ingest.on_failure_message }}}'
+`,
+		"data_stream/log/elasticsearch/ingest_pipeline/audit.json": `{
+  "processors": [
+    {
+      "grok": {
+        "field": "message",
+        "patterns": ["(%{VENDOR_ACTION:event.action}"]
+      }
+    },
+    {
+      "set": {"field": "event.kind", "value": "event"}
+    }
+  ]
+}`,
+	}
+	for path, content := range files {
+		path = filepath.Join(packageRoot, filepath.FromSlash(path))
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	}
+
+	issues, err := Check(packageRoot, CheckOptions{})
+	require.NoError(t, err)
+
+	const auditFile = "data_stream/log/elasticsearch/ingest_pipeline/audit.json"
+	const defaultFile = "data_stream/log/elasticsearch/ingest_pipeline/default.yml"
+	assert.Equal(t, []Issue{
+		{Severity: SeverityWarning, File: auditFile, Line: 1, Message: "pipeline has no on_failure handlers"},
+		{Severity: SeverityError, File: auditFile, Line: 4, Message: "grok pattern \"(%{VENDOR_ACTION:event.action}\" doesn't compile: error parsing regexp: missing closing ): `((?:x)`"},
+		{Severity: SeverityWarning, File: auditFile, Line: 4, Message: `unknown grok pattern "VENDOR_ACTION"`},
+		{Severity: SeverityWarning, File: auditFile, Line: 4, Message: "2 processors have no tag (lines 4, 10)"},
+		{Severity: SeverityWarning, File: defaultFile, Line: 9, Message: `condition references field "vendor.log.severity", that is not declared or set by the pipelines`},
+		{Severity: SeverityError, File: defaultFile, Line: 18, Message: `referenced pipeline "missing" doesn't exist`},
+		{Severity: SeverityError, File: defaultFile, Line: 21, Message: `invalid painless source: unclosed '{' opened in line 1 of the script`},
+		{Severity: SeverityError, File: defaultFile, Line: 28, Message: "remove processor is unreachable after the drop processor in line 26"},
+		{Severity: SeverityWarning, File: defaultFile, Line: 28, Message: `duplicate tag "set_kind", already used in line 9`},
+	}, issues)
+}
+
+func TestCheckUnreadableFields(t *testing.T) {
+	packageRoot := t.TempDir()
+	files := map[string]string{
+		"manifest.yml": `format_version: 3.0.0
+name: vendor
+title: Vendor
+version: 1.0.0
+type: integration
+`,
+		"data_stream/log/manifest.yml": `title: Logs
+type: logs
+`,
+		"data_stream/log/fields/fields.yml": `- name: [vendor`,
+		"data_stream/log/elasticsearch/ingest_pipeline/default.yml": `---
+processors:
+  - set:
+      tag: set_kind
+      if: ctx.vendor?.log?.level == "ERROR"
+      field: event.kind
+      value: alert
+on_failure:
+  - set:
+      field: error.message
+      value: failed
+`,
+	}
+	for path, content := range files {
+		path = filepath.Join(packageRoot, filepath.FromSlash(path))
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	}
+
+	issues, err := Check(packageRoot, CheckOptions{})
+	require.NoError(t, err)
+	require.Len(t, issues, 1)
+	assert.Equal(t, SeverityWarning, issues[0].Severity)
+	assert.Equal(t, "data_stream/log/elasticsearch/ingest_pipeline/default.yml", issues[0].File)
+	assert.Contains(t, issues[0].Message, "usage of fields in conditions not checked")
+
+	issues, err = Check(packageRoot, CheckOptions{SkipConditionFields: true})
+	require.NoError(t, err)
+	assert.Empty(t, issues)
+}
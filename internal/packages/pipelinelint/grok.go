@@ -0,0 +1,148 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package pipelinelint
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"regexp/syntax"
+	"slices"
+	"strings"
+)
+
+// grokReference matches references to patterns in grok expressions, like %{IP:source.ip:type}.
+var grokReference = regexp.MustCompile(`%{([^:}]*)(?::([^:}]*))?(?::([^:}]*))?}`)
+
+// grokTypes are the types that grok can convert captured values to.
+var grokTypes = []string{"int", "long", "float", "double", "boolean"}
+
+// grokStructuralErrors are the errors of Go regular expressions that are also errors in the
+// Oniguruma syntax used by grok. Other errors can be caused by features unsupported in Go.
+var grokStructuralErrors = []syntax.ErrorCode{
+	syntax.ErrMissingParen,
+	syntax.ErrUnexpectedParen,
+	syntax.ErrMissingBracket,
+	syntax.ErrInvalidCharRange,
+	syntax.ErrMissingRepeatArgument,
+	syntax.ErrTrailingBackslash,
+}
+
+// grokBuiltinPatterns are the names of the patterns included in Elasticsearch.
+var grokBuiltinPatterns = []string{
+	// grok-patterns
+	"USERNAME", "USER", "EMAILLOCALPART", "EMAILADDRESS", "INT", "BASE10NUM", "NUMBER", "BASE16NUM",
+	"BASE16FLOAT", "POSINT", "NONNEGINT", "WORD", "NOTSPACE", "SPACE", "DATA", "GREEDYDATA",
+	"QUOTEDSTRING", "UUID", "URN", "MAC", "CISCOMAC", "WINDOWSMAC", "COMMONMAC", "IPV6", "IPV4", "IP",
+	"HOSTNAME", "IPORHOST", "HOSTPORT", "PATH", "UNIXPATH", "TTY", "WINPATH", "URIPROTO", "URIHOST",
+	"URIPATH", "URIQUERY", "URIPARAM", "URIPATHPARAM", "URI", "MONTH", "MONTHNUM", "MONTHNUM2",
+	"MONTHDAY", "DAY", "YEAR", "HOUR", "MINUTE", "SECOND", "TIME", "DATE_US", "DATE_EU",
+	"ISO8601_TIMEZONE", "ISO8601_SECOND", "TIMESTAMP_ISO8601", "DATE", "DATESTAMP", "TZ",
+	"DATESTAMP_RFC822", "DATESTAMP_RFC2822", "DATESTAMP_OTHER", "DATESTAMP_EVENTLOG",
+	"SYSLOGTIMESTAMP", "PROG", "SYSLOGPROG", "SYSLOGHOST", "SYSLOGFACILITY", "HTTPDATE", "QS",
+	"SYSLOGBASE", "LOGLEVEL",
+	// httpd
+	"HTTPDUSER", "HTTPDERROR_DATE", "HTTPD_COMMONLOG", "HTTPD_COMBINEDLOG", "HTTPD20_ERRORLOG",
+	"HTTPD24_ERRORLOG", "HTTPD_ERRORLOG", "COMMONAPACHELOG", "COMBINEDAPACHELOG",
+	// java
+	"JAVACLASS", "JAVAFILE", "JAVAMETHOD", "JAVASTACKTRACEPART", "JAVATHREAD", "JAVALOGMESSAGE",
+	"CATALINA_DATESTAMP", "TOMCAT_DATESTAMP", "CATALINALOG", "TOMCATLOG",
+	// linux-syslog
+	"SYSLOG5424PRINTASCII", "SYSLOGBASE2", "SYSLOGPAMSESSION", "CRON_ACTION", "CRONLOG", "SYSLOGLINE",
+	"SYSLOG5424PRI", "SYSLOG5424SD", "SYSLOG5424BASE", "SYSLOG5424LINE",
+	// others
+	"NETSCREENSESSIONLOG", "SFW2", "SHOREWALL", "MAVEN_VERSION", "MCOLLECTIVE", "MCOLLECTIVEAUDIT",
+	"POSTGRESQL", "RUUID", "RCONTROLLER", "RAILS3HEAD", "RPROCESSING", "RAILS3FOOT", "RAILS3PROFILE",
+	"RAILS3", "REDISTIMESTAMP", "REDISLOG", "REDISMONLOG", "RUBY_LOGLEVEL", "RUBY_LOGGER", "SQUID3",
+}
+
+// grokBuiltinPrefixes are the prefixes of families of patterns included in Elasticsearch.
+var grokBuiltinPrefixes = []string{
+	"AWS", "BACULA_", "BIND9", "BRO_", "CISCO", "CLOUDFRONT_", "ELB_", "EXIM_", "HAPROXY", "JUNOS_",
+	"MONGO", "NAGIOS", "RT_FLOW", "S3_", "SYSLOG", "ZEEK_",
+}
+
+// grokCheck contains the problems found in the patterns of a grok processor.
+type grokCheck struct {
+	errors   []string
+	warnings []string
+}
+
+// checkGrok checks that the patterns of a grok processor compile, and that the patterns they
+// reference exist.
+func checkGrok(patterns []string, definitions map[string]string) grokCheck {
+	var check grokCheck
+	unknown := make(map[string]bool)
+	for _, pattern := range patterns {
+		expanded, err := expandGrok(pattern, definitions, unknown, nil)
+		if err != nil {
+			check.errors = append(check.errors, fmt.Sprintf("grok pattern %q is invalid: %v", pattern, err))
+			continue
+		}
+		if err := compileGrok(expanded); err != nil {
+			check.errors = append(check.errors, fmt.Sprintf("grok pattern %q doesn't compile: %v", pattern, err))
+		}
+	}
+	for name := range unknown {
+		check.warnings = append(check.warnings, fmt.Sprintf("unknown grok pattern %q", name))
+	}
+	slices.Sort(check.warnings)
+	return check
+}
+
+// expandGrok replaces the references in a grok expression with the custom patterns they reference,
+// or with a placeholder for builtin and unknown patterns.
+func expandGrok(pattern string, definitions map[string]string, unknown map[string]bool, expanding []string) (string, error) {
+	var err error
+	expanded := grokReference.ReplaceAllStringFunc(pattern, func(reference string) string {
+		if err != nil {
+			return ""
+		}
+		match := grokReference.FindStringSubmatch(reference)
+		name, conversion := match[1], match[3]
+		if conversion != "" && !slices.Contains(grokTypes, conversion) {
+			err = fmt.Errorf("unsupported type %q in %s", conversion, reference)
+			return ""
+		}
+
+		definition, found := definitions[name]
+		if !found {
+			if !isGrokBuiltin(name) {
+				unknown[name] = true
+			}
+			return "(?:x)"
+		}
+		if slices.Contains(expanding, name) {
+			err = fmt.Errorf("circular reference to pattern %q", name)
+			return ""
+		}
+		definition, err = expandGrok(definition, definitions, unknown, append(expanding, name))
+		return "(?:" + definition + ")"
+	})
+	return expanded, err
+}
+
+func isGrokBuiltin(name string) bool {
+	if slices.Contains(grokBuiltinPatterns, name) {
+		return true
+	}
+	for _, prefix := range grokBuiltinPrefixes {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// compileGrok compiles an expanded grok expression, and only reports errors that are also errors
+// in Oniguruma.
+func compileGrok(expression string) error {
+	_, err := syntax.Parse(expression, syntax.Perl)
+	var syntaxErr *syntax.Error
+	if errors.As(err, &syntaxErr) && slices.Contains(grokStructuralErrors, syntaxErr.Code) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package pipelinelint
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckGrok(t *testing.T) {
+	cases := []struct {
+		title       string
+		patterns    []string
+		definitions map[string]string
+		errors      []string
+		warnings    []string
+	}{
+		{
+			title:    "builtin patterns",
+			patterns: []string{`^%{IPORHOST:source.address} - %{DATA:user.name} \[%{HTTPDATE:_tmp.timestamp}\] %{NUMBER:http.response.status_code:long}$`},
+		},
+		{
+			title:       "custom patterns",
+			patterns:    []string{`%{VENDOR_LINE}`},
+			definitions: map[string]string{"VENDOR_LINE": `%{VENDOR_LEVEL:log.level} %{GREEDYDATA:message}`, "VENDOR_LEVEL": `(?:INFO|WARN|ERROR)`},
+		},
+		{
+			title:    "unsupported features in Go",
+			patterns: []string{`(?<source.ip>%{IP}) (?>atomic)(?=lookahead)`},
+		},
+		{
+			title:    "unknown patterns",
+			patterns: []string{`%{VENDOR_LEVEL:log.level} %{WORD}`, `%{VENDOR_LEVEL}`},
+			warnings: []string{`unknown grok pattern "VENDOR_LEVEL"`},
+		},
+		{
+			title:    "structural errors",
+			patterns: []string{`(%{WORD:event.action}`, `[a-`, `%{NUMBER:event.code:integer}`},
+			errors: []string{
+				"grok pattern \"(%{WORD:event.action}\" doesn't compile: error parsing regexp: missing closing ): `((?:x)`",
+				"grok pattern \"[a-\" doesn't compile: error parsing regexp: missing closing ]: `[a-`",
+				`grok pattern "%{NUMBER:event.code:integer}" is invalid: unsupported type "integer" in %{NUMBER:event.code:integer}`,
+			},
+		},
+		{
+			title:       "errors in custom patterns",
+			patterns:    []string{`%{VENDOR_A}`},
+			definitions: map[string]string{"VENDOR_A": `%{VENDOR_B}`, "VENDOR_B": `%{VENDOR_A}+`},
+			errors:      []string{`grok pattern "%{VENDOR_A}" is invalid: circular reference to pattern "VENDOR_A"`},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.title, func(t *testing.T) {
+			check := checkGrok(c.patterns, c.definitions)
+			assert.Equal(t, c.errors, check.errors)
+			assert.Equal(t, c.warnings, check.warnings)
+		})
+	}
+}
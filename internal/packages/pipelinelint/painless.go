@@ -0,0 +1,105 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package pipelinelint
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+var closingBrackets = map[rune]rune{')': '(', ']': '[', '}': '{'}
+
+type openBracket struct {
+	bracket rune
+	line    int
+}
+
+// checkPainless checks the structure of a painless script: brackets must be balanced, and strings,
+// regular expressions and comments must be terminated.
+func checkPainless(source string) error {
+	var stack []openBracket
+	line := 1
+	runes := []rune(source)
+
+	// previous is the last rune of code before the current one, used to tell regular expressions
+	// from divisions.
+	previous := rune(0)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		next := rune(0)
+		if i+1 < len(runes) {
+			next = runes[i+1]
+		}
+
+		switch {
+		case r == '\n':
+			line++
+		case unicode.IsSpace(r):
+		case r == '/' && next == '/':
+			for i < len(runes) && runes[i] != '\n' {
+				i++
+			}
+			line++
+		case r == '/' && next == '*':
+			start := line
+			i += 2
+			for ; i < len(runes) && (runes[i] != '*' || i+1 >= len(runes) || runes[i+1] != '/'); i++ {
+				if runes[i] == '\n' {
+					line++
+				}
+			}
+			if i >= len(runes) {
+				return fmt.Errorf("unterminated comment opened in line %d of the script", start)
+			}
+			i++
+		case r == '/' && !endsValue(previous):
+			i++
+			for ; i < len(runes) && runes[i] != '/' && runes[i] != '\n'; i++ {
+				if runes[i] == '\\' {
+					i++
+				}
+			}
+			if i >= len(runes) || runes[i] == '\n' {
+				return fmt.Errorf("unterminated regular expression in line %d of the script", line)
+			}
+		case r == '"' || r == '\'':
+			start := line
+			i++
+			for ; i < len(runes) && runes[i] != r; i++ {
+				switch runes[i] {
+				case '\\':
+					i++
+				case '\n':
+					line++
+				}
+			}
+			if i >= len(runes) {
+				return fmt.Errorf("unterminated string opened in line %d of the script", start)
+			}
+		case strings.ContainsRune("([{", r):
+			stack = append(stack, openBracket{bracket: r, line: line})
+		case strings.ContainsRune(")]}", r):
+			if len(stack) == 0 || stack[len(stack)-1].bracket != closingBrackets[r] {
+				return fmt.Errorf("unexpected %q in line %d of the script", r, line)
+			}
+			stack = stack[:len(stack)-1]
+		}
+
+		if !unicode.IsSpace(r) {
+			previous = r
+		}
+	}
+	if len(stack) > 0 {
+		open := stack[len(stack)-1]
+		return fmt.Errorf("unclosed %q opened in line %d of the script", open.bracket, open.line)
+	}
+	return nil
+}
+
+// endsValue checks if a rune can be the end of a value, so a slash after it is a division.
+func endsValue(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || strings.ContainsRune(`_)]"'`, r)
+}
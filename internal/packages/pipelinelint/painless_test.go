@@ -0,0 +1,71 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package pipelinelint
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckPainless(t *testing.T) {
+	cases := []struct {
+		title    string
+		source   string
+		expected string
+	}{
+		{
+			title:  "valid condition",
+			source: `ctx.event?.kind == "event" && ["a", "b"].contains(ctx.tags[0])`,
+		},
+		{
+			title: "brackets in strings and comments",
+			source: `// Ignore ( in comments
+/* and ] in
+   block comments */
+ctx.message = 'it\'s (not) closed' + "{";`,
+		},
+		{
+			title:  "regular expressions and divisions",
+			source: `Pattern p = /\([^*\)]*\)/; def ratio = ctx.a / ctx.b; if (ctx.c ==~ /^)$/) { ctx.d = (ctx.e) / 2 }`,
+		},
+		{
+			title:    "unclosed bracket",
+			source:   "if (ctx.a != null) {\n  ctx.b = ctx.a;\n",
+			expected: `unclosed '{' opened in line 1 of the script`,
+		},
+		{
+			title:    "unexpected bracket",
+			source:   "ctx.a = [1, 2);",
+			expected: `unexpected ')' in line 1 of the script`,
+		},
+		{
+			title:    "unterminated string",
+			source:   "ctx.a = 'b;\n",
+			expected: `unterminated string opened in line 1 of the script`,
+		},
+		{
+			title:    "unterminated comment",
+			source:   "ctx.a = 1;\n/* ctx.b = 2;",
+			expected: `unterminated comment opened in line 2 of the script`,
+		},
+		{
+			title:    "unterminated regular expression",
+			source:   "ctx.a ==~ /b\n",
+			expected: `unterminated regular expression in line 1 of the script`,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.title, func(t *testing.T) {
+			err := checkPainless(c.source)
+			if c.expected == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, c.expected)
+			}
+		})
+	}
+}
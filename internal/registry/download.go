@@ -0,0 +1,36 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package registry
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+)
+
+// DownloadPackage downloads the zip archive of the given package revision to the destination
+// directory, and returns the path to the downloaded file.
+func (c *Client) DownloadPackage(name, version, destinationDir string) (string, error) {
+	fileName := fmt.Sprintf("%s-%s.zip", name, version)
+	statusCode, respBody, err := c.get(path.Join(downloadAPI, name, fileName))
+	if err != nil {
+		return "", fmt.Errorf("could not download package: %w", err)
+	}
+	if statusCode == http.StatusNotFound {
+		return "", fmt.Errorf("package %s-%s not found in the registry", name, version)
+	}
+	if statusCode != http.StatusOK {
+		return "", fmt.Errorf("could not download package; API status code = %d; response body = %s", statusCode, respBody)
+	}
+
+	zipPath := filepath.Join(destinationDir, fileName)
+	err = os.WriteFile(zipPath, respBody, 0o644)
+	if err != nil {
+		return "", fmt.Errorf("could not write package archive: %w", err)
+	}
+	return zipPath, nil
+}
@@ -7,4 +7,7 @@ package registry
 const (
 	// searchAPI is the endpoint for filtering package registry packages
 	searchAPI = "/search"
+
+	// downloadAPI is the endpoint for downloading package archives
+	downloadAPI = "/epr"
 )
@@ -11,6 +11,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/elastic/go-resource"
@@ -211,6 +212,30 @@ func (tsd TerraformServiceDeployer) installDockerfile(folder string) (string, er
 	return tfDir, nil
 }
 
+// TerraformDeployerImages returns the Docker images the Terraform deployer is built from.
+func TerraformDeployerImages() []string {
+	return dockerfileBaseImages(terraformDeployerDockerfileContent)
+}
+
+// dockerfileBaseImages returns the images referenced by the FROM instructions of a Dockerfile.
+func dockerfileBaseImages(dockerfile string) []string {
+	var images []string
+	for _, line := range strings.Split(dockerfile, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 || !strings.EqualFold(fields[0], "FROM") {
+			continue
+		}
+		for _, field := range fields[1:] {
+			if strings.HasPrefix(field, "--") {
+				continue
+			}
+			images = append(images, field)
+			break
+		}
+	}
+	return images
+}
+
 func CreateOutputDir(locationManager *locations.LocationManager, runID string) (string, error) {
 	outputDir := filepath.Join(locationManager.ServiceOutputDir(), runID)
 	if err := os.MkdirAll(outputDir, 0755); err != nil {
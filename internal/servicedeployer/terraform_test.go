@@ -178,3 +178,12 @@ func TestAddTerraformOutputs(t *testing.T) {
 		})
 	}
 }
+
+func TestDockerfileBaseImages(t *testing.T) {
+	dockerfile := `FROM --platform=linux/amd64 ubuntu:24.04 AS base
+RUN echo "FROM is not an instruction here"
+from golang:1.24
+`
+	assert.Equal(t, []string{"ubuntu:24.04", "golang:1.24"}, dockerfileBaseImages(dockerfile))
+	assert.Equal(t, []string{"ubuntu:24.04"}, TerraformDeployerImages())
+}
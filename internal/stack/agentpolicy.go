@@ -6,12 +6,15 @@ package stack
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"net/http"
 	"os"
 	"path/filepath"
 	"strings"
 
+	"github.com/elastic/elastic-package/internal/elasticsearch"
 	"github.com/elastic/elastic-package/internal/kibana"
 	"github.com/elastic/elastic-package/internal/logger"
 	"github.com/elastic/elastic-package/internal/profile"
@@ -22,6 +25,19 @@ const (
 	managedAgentPolicyID     = "elastic-agent-managed-ep"
 	fleetLogstashOutput      = "fleet-logstash-output"
 	fleetElasticsearchOutput = "fleet-elasticsearch-output"
+
+	// FleetKafkaOutput is the ID of the Fleet output that publishes to the local Kafka broker.
+	FleetKafkaOutput = "fleet-kafka-output"
+
+	// FleetRemoteElasticsearchOutput is the ID of the Fleet output that publishes to the
+	// remote Elasticsearch cluster.
+	FleetRemoteElasticsearchOutput = "fleet-remote-elasticsearch-output"
+
+	// FleetLogstashOutput is the ID of the Fleet output that publishes to the local Logstash.
+	FleetLogstashOutput = fleetLogstashOutput
+
+	kafkaOutputTopic             = "elastic-package"
+	remoteElasticsearchTokenName = "elastic-package-remote-output"
 )
 
 // createAgentPolicy creates an agent policy with the initial configuration used for
@@ -142,6 +158,104 @@ func addElasticsearchFleetOutput(ctx context.Context, client *kibana.Client, hos
 	return addFleetOutput(ctx, client, "elasticsearch", host, fleetElasticsearchOutput)
 }
 
+func addKafkaFleetOutput(ctx context.Context, client *kibana.Client) error {
+	output := kibana.FleetOutput{
+		Name:     FleetKafkaOutput,
+		ID:       FleetKafkaOutput,
+		Type:     "kafka",
+		Hosts:    []string{"kafka:9092"},
+		Topic:    kafkaOutputTopic,
+		AuthType: "none",
+	}
+
+	err := client.AddFleetOutput(ctx, output)
+	if errors.Is(err, kibana.ErrConflict) {
+		// Output already exists.
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to add %s fleet output: %w", FleetKafkaOutput, err)
+	}
+	return nil
+}
+
+// addRemoteElasticsearchFleetOutput adds an output to the remote Elasticsearch cluster. A new
+// service token is created in the remote cluster each time, so the output is recreated.
+func addRemoteElasticsearchFleetOutput(ctx context.Context, profile *profile.Profile, client *kibana.Client) error {
+	remoteClient, err := NewRemoteElasticsearchClientFromProfile(profile)
+	if err != nil {
+		return fmt.Errorf("failed to create remote Elasticsearch client: %w", err)
+	}
+	token, err := createRemoteServiceToken(ctx, remoteClient.API)
+	if err != nil {
+		return err
+	}
+
+	caFile, err := os.ReadFile(profile.Path(CACertificateFile))
+	if err != nil {
+		return fmt.Errorf("failed to read ca certificate: %w", err)
+	}
+
+	err = client.RemoveFleetOutput(ctx, FleetRemoteElasticsearchOutput)
+	if err != nil {
+		return fmt.Errorf("failed to remove previous %s fleet output: %w", FleetRemoteElasticsearchOutput, err)
+	}
+
+	output := kibana.FleetOutput{
+		Name:         FleetRemoteElasticsearchOutput,
+		ID:           FleetRemoteElasticsearchOutput,
+		Type:         "remote_elasticsearch",
+		Hosts:        []string{"https://elasticsearch-remote:9200"},
+		ServiceToken: token,
+		SSL: &kibana.AgentSSL{
+			CertificateAuthorities: []string{string(caFile)},
+		},
+	}
+	err = client.AddFleetOutput(ctx, output)
+	if err != nil {
+		return fmt.Errorf("failed to add %s fleet output: %w", FleetRemoteElasticsearchOutput, err)
+	}
+	return nil
+}
+
+// createRemoteServiceToken creates a Fleet Server service token in the remote cluster, replacing
+// the one created in previous executions.
+func createRemoteServiceToken(ctx context.Context, api *elasticsearch.API) (string, error) {
+	deleteResp, err := api.Security.DeleteServiceToken("elastic", "fleet-server", remoteElasticsearchTokenName,
+		api.Security.DeleteServiceToken.WithContext(ctx),
+	)
+	if err != nil {
+		return "", fmt.Errorf("failed to delete previous service token: %w", err)
+	}
+	deleteResp.Body.Close()
+	if deleteResp.IsError() && deleteResp.StatusCode != http.StatusNotFound {
+		return "", fmt.Errorf("failed to delete previous service token: %s", deleteResp.String())
+	}
+
+	resp, err := api.Security.CreateServiceToken("elastic", "fleet-server",
+		api.Security.CreateServiceToken.WithContext(ctx),
+		api.Security.CreateServiceToken.WithName(remoteElasticsearchTokenName),
+	)
+	if err != nil {
+		return "", fmt.Errorf("failed to create service token: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.IsError() {
+		return "", fmt.Errorf("failed to create service token: %s", resp.String())
+	}
+
+	var tokenResponse struct {
+		Token struct {
+			Value string `json:"value"`
+		} `json:"token"`
+	}
+	err = json.NewDecoder(resp.Body).Decode(&tokenResponse)
+	if err != nil {
+		return "", fmt.Errorf("failed to decode service token: %w", err)
+	}
+	return tokenResponse.Token.Value, nil
+}
+
 func updateLogstashFleetOutput(ctx context.Context, profile *profile.Profile, kibanaClient *kibana.Client) error {
 	certsDir := filepath.Join(profile.ProfilePath, "certs", "elastic-agent")
 
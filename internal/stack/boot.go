@@ -43,6 +43,9 @@ func BootUp(ctx context.Context, options Options) error {
 		KibanaHost:            "https://127.0.0.1:5601",
 		CACertFile:            options.Profile.Path(CACertificateFile),
 	}
+	if options.Profile.Config(configRemoteESEnabled, "false") == "true" {
+		config.RemoteElasticsearchHost = "https://127.0.0.1:9201"
+	}
 	printUserConfig(options.Printer, config)
 
 	buildPackagesPath, found, err := builder.FindBuildPackagesDirectory()
@@ -75,11 +78,27 @@ func BootUp(ctx context.Context, options Options) error {
 		options.Printer.Printf("- Local directory %s\n", buildPackagesPath)
 	}
 
+	offlineImages, found, err := LoadOfflineImages(options.Profile)
+	if err != nil {
+		return fmt.Errorf("checking offline images failed: %w", err)
+	}
+	if found {
+		options.Printer.Printf("Using offline images imported for stack version %s, images won't be pulled.\n", offlineImages.StackVersion)
+		if offlineImages.StackVersion != options.StackVersion {
+			options.Printer.Printf("Warning: requested stack version %s doesn't match the imported images.\n", options.StackVersion)
+		}
+	}
+
 	err = applyResources(options.Profile, options.StackVersion)
 	if err != nil {
 		return fmt.Errorf("creating stack files failed: %w", err)
 	}
 
+	err = validateComposeOverrides(ctx, options)
+	if err != nil {
+		return err
+	}
+
 	err = dockerComposeBuild(ctx, options)
 	if err != nil {
 		return fmt.Errorf("building docker images failed: %w", err)
@@ -113,6 +132,40 @@ func BootUp(ctx context.Context, options Options) error {
 		return fmt.Errorf("failed to store config: %w", err)
 	}
 
+	err = addComposeFleetOutputs(ctx, options)
+	if err != nil {
+		return fmt.Errorf("failed to configure Fleet outputs: %w", err)
+	}
+
+	return nil
+}
+
+// addComposeFleetOutputs registers the Fleet outputs for the optional services
+// enabled in the profile.
+func addComposeFleetOutputs(ctx context.Context, options Options) error {
+	kafkaEnabled := options.Profile.Config(configKafkaEnabled, "false") == "true"
+	remoteESEnabled := options.Profile.Config(configRemoteESEnabled, "false") == "true"
+	if !kafkaEnabled && !remoteESEnabled {
+		return nil
+	}
+
+	kibanaClient, err := NewKibanaClientFromProfile(options.Profile)
+	if err != nil {
+		return fmt.Errorf("failed to create Kibana client: %w", err)
+	}
+
+	if kafkaEnabled {
+		err := addKafkaFleetOutput(ctx, kibanaClient)
+		if err != nil {
+			return err
+		}
+	}
+	if remoteESEnabled {
+		err := addRemoteElasticsearchFleetOutput(ctx, options.Profile, kibanaClient)
+		if err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
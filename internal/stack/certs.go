@@ -25,6 +25,7 @@ type tlsService struct {
 // created in the given path.
 var tlsServices = []tlsService{
 	{Name: "elasticsearch"},
+	{Name: "elasticsearch-remote"},
 	{Name: "kibana"},
 	{Name: "package-registry"},
 	{Name: "fleet-server"},
@@ -83,6 +83,28 @@ func NewElasticsearchClientFromProfile(profile *profile.Profile, customOptions .
 	return elasticsearch.NewClient(options...)
 }
 
+// NewRemoteElasticsearchClientFromProfile creates a client for the remote Elasticsearch cluster
+// started in the profile when the remote Elasticsearch output is enabled. It uses the same
+// credentials as the main cluster.
+func NewRemoteElasticsearchClientFromProfile(profile *profile.Profile, customOptions ...elasticsearch.ClientOption) (*elasticsearch.Client, error) {
+	config, err := LoadConfig(profile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load config from profile: %w", err)
+	}
+	if config.RemoteElasticsearchHost == "" {
+		return nil, fmt.Errorf("remote Elasticsearch is not available in profile %s, enable it with %s", profile.ProfileName, configRemoteESEnabled)
+	}
+
+	options := []elasticsearch.ClientOption{
+		elasticsearch.OptionWithAddress(config.RemoteElasticsearchHost),
+		elasticsearch.OptionWithPassword(config.ElasticsearchPassword),
+		elasticsearch.OptionWithUsername(config.ElasticsearchUsername),
+		elasticsearch.OptionWithCertificateAuthority(config.CACertFile),
+	}
+	options = append(options, customOptions...)
+	return elasticsearch.NewClient(options...)
+}
+
 // NewKibanaClient creates a kibana client with the settings provided by the shellinit
 // environment variables.
 func NewKibanaClient(customOptions ...kibana.ClientOption) (*kibana.Client, error) {
@@ -51,7 +51,7 @@ func (eb *envBuilder) build() []string {
 }
 
 func dockerComposeBuild(ctx context.Context, options Options) error {
-	c, err := compose.NewProject(DockerComposeProjectName(options.Profile), options.Profile.Path(ProfileStackPath, ComposeFile))
+	c, err := newComposeProject(options.Profile)
 	if err != nil {
 		return fmt.Errorf("could not create docker compose project: %w", err)
 	}
@@ -77,7 +77,7 @@ func dockerComposeBuild(ctx context.Context, options Options) error {
 }
 
 func dockerComposePull(ctx context.Context, options Options) error {
-	c, err := compose.NewProject(DockerComposeProjectName(options.Profile), options.Profile.Path(ProfileStackPath, ComposeFile))
+	c, err := newComposeProject(options.Profile)
 	if err != nil {
 		return fmt.Errorf("could not create docker compose project: %w", err)
 	}
@@ -103,7 +103,7 @@ func dockerComposePull(ctx context.Context, options Options) error {
 }
 
 func dockerComposeUp(ctx context.Context, options Options) error {
-	c, err := compose.NewProject(DockerComposeProjectName(options.Profile), options.Profile.Path(ProfileStackPath, ComposeFile))
+	c, err := newComposeProject(options.Profile)
 	if err != nil {
 		return fmt.Errorf("could not create docker compose project: %w", err)
 	}
@@ -113,6 +113,12 @@ func dockerComposeUp(ctx context.Context, options Options) error {
 		args = append(args, "-d")
 	}
 
+	pullArgs, err := pullNeverArgs(options.Profile)
+	if err != nil {
+		return fmt.Errorf("can't check offline images: %w", err)
+	}
+	args = append(args, pullArgs...)
+
 	appConfig, err := install.Configuration(install.OptionWithStackVersion(options.StackVersion))
 	if err != nil {
 		return fmt.Errorf("can't read application configuration: %w", err)
@@ -135,7 +141,7 @@ func dockerComposeUp(ctx context.Context, options Options) error {
 }
 
 func dockerComposeDown(ctx context.Context, options Options) error {
-	c, err := compose.NewProject(DockerComposeProjectName(options.Profile), options.Profile.Path(ProfileStackPath, ComposeFile))
+	c, err := newComposeProject(options.Profile)
 	if err != nil {
 		return fmt.Errorf("could not create docker compose project: %w", err)
 	}
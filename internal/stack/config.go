@@ -27,6 +27,10 @@ type Config struct {
 	KibanaHost            string `json:"kibana_host,omitempty"`
 	CACertFile            string `json:"ca_cert_file,omitempty"`
 
+	// RemoteElasticsearchHost is the address of the remote Elasticsearch cluster
+	// started when the remote Elasticsearch output is enabled.
+	RemoteElasticsearchHost string `json:"remote_elasticsearch_host,omitempty"`
+
 	OutputID      string `json:"output_id,omitempty"`
 	FleetServerID string `json:"fleet_server_id,omitempty"`
 
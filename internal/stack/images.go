@@ -0,0 +1,351 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package stack
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/elastic/elastic-package/internal/docker"
+	"github.com/elastic/elastic-package/internal/install"
+	"github.com/elastic/elastic-package/internal/logger"
+	"github.com/elastic/elastic-package/internal/profile"
+	"github.com/elastic/elastic-package/internal/version"
+)
+
+const (
+	// OfflineImagesFile is the file in the profile stack directory that records the images
+	// imported from a bundle. When present, the stack never pulls images.
+	OfflineImagesFile = "offline-images.json"
+
+	imagesBundleManifestFile = "manifest.json"
+	imagesBundleImagesFile   = "images.tar"
+)
+
+// agentBaseImages are the variants of the Elastic Agent image that can be used by the
+// compose stack and the agent deployers.
+var agentBaseImages = []string{"", "complete", "systemd"}
+
+// ImagesManifest describes the contents of an images bundle.
+type ImagesManifest struct {
+	StackVersion          string    `json:"stack_version"`
+	ElasticPackageVersion string    `json:"elastic_package_version,omitempty"`
+	CreatedAt             time.Time `json:"created_at"`
+	Images                []string  `json:"images"`
+}
+
+// ExportImagesOptions defines the options to export the images of a stack version.
+type ExportImagesOptions struct {
+	StackVersion string
+
+	// Output is the path of the bundle to create.
+	Output string
+
+	// ExtraImages are images needed by other components, such as service deployers,
+	// that are included in the bundle along with the stack images.
+	ExtraImages []string
+
+	Printer Printer
+}
+
+// ImportImagesOptions defines the options to import an images bundle.
+type ImportImagesOptions struct {
+	// Input is the path of the bundle to import.
+	Input string
+
+	Profile *profile.Profile
+	Printer Printer
+}
+
+// StackImages returns the Docker images needed to run the compose stack and the
+// agent deployers for the given stack version.
+func StackImages(stackVersion string) ([]string, error) {
+	images := []string{PackageRegistryBaseImage, KafkaImage}
+	for _, baseImage := range agentBaseImages {
+		appConfig, err := install.Configuration(
+			install.OptionWithStackVersion(stackVersion),
+			install.OptionWithAgentBaseImage(baseImage),
+		)
+		if err != nil {
+			return nil, fmt.Errorf("can't read application configuration: %w", err)
+		}
+		refs := appConfig.StackImageRefs()
+		images = append(images,
+			refs.ElasticAgent,
+			refs.Elasticsearch,
+			refs.Kibana,
+			refs.Logstash,
+			refs.IsReady,
+		)
+	}
+	return uniqueImages(images), nil
+}
+
+// ExportImages pulls the images required for a stack version and writes them to a
+// bundle, along with a manifest describing its contents.
+func ExportImages(options ExportImagesOptions) (*ImagesManifest, error) {
+	images, err := StackImages(options.StackVersion)
+	if err != nil {
+		return nil, err
+	}
+	images = uniqueImages(append(images, options.ExtraImages...))
+
+	for _, image := range images {
+		options.Printer.Printf("Pulling %s\n", image)
+		err := docker.Pull(image)
+		if err != nil {
+			return nil, fmt.Errorf("pulling image %s failed: %w", image, err)
+		}
+	}
+
+	tempDir, err := os.MkdirTemp("", "elastic-package-images-")
+	if err != nil {
+		return nil, fmt.Errorf("can't create temporary directory: %w", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	imagesPath := filepath.Join(tempDir, imagesBundleImagesFile)
+	options.Printer.Printf("Saving %d images\n", len(images))
+	err = docker.Save(imagesPath, images...)
+	if err != nil {
+		return nil, err
+	}
+
+	manifest := ImagesManifest{
+		StackVersion:          options.StackVersion,
+		ElasticPackageVersion: version.Tag,
+		CreatedAt:             time.Now().UTC(),
+		Images:                images,
+	}
+	err = writeImagesBundle(options.Output, manifest, imagesPath)
+	if err != nil {
+		return nil, fmt.Errorf("can't write images bundle: %w", err)
+	}
+	return &manifest, nil
+}
+
+// ImportImages loads the images contained in a bundle and marks the profile so the
+// stack uses them without pulling from any registry.
+func ImportImages(options ImportImagesOptions) (*ImagesManifest, error) {
+	tempDir, err := os.MkdirTemp("", "elastic-package-images-")
+	if err != nil {
+		return nil, fmt.Errorf("can't create temporary directory: %w", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	imagesPath := filepath.Join(tempDir, imagesBundleImagesFile)
+	manifest, err := readImagesBundle(options.Input, imagesPath)
+	if err != nil {
+		return nil, fmt.Errorf("can't read images bundle: %w", err)
+	}
+
+	options.Printer.Printf("Loading %d images for stack version %s\n", len(manifest.Images), manifest.StackVersion)
+	err = docker.Load(imagesPath)
+	if err != nil {
+		return nil, err
+	}
+
+	err = storeOfflineImages(options.Profile, *manifest)
+	if err != nil {
+		return nil, fmt.Errorf("can't mark profile to use offline images: %w", err)
+	}
+	return manifest, nil
+}
+
+// LoadOfflineImages returns the manifest of the images imported in the profile, if any.
+func LoadOfflineImages(profile *profile.Profile) (*ImagesManifest, bool, error) {
+	d, err := os.ReadFile(profile.Path(ProfileStackPath, OfflineImagesFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to read offline images file: %w", err)
+	}
+
+	var manifest ImagesManifest
+	err = json.Unmarshal(d, &manifest)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to decode offline images file: %w", err)
+	}
+	return &manifest, true, nil
+}
+
+func storeOfflineImages(profile *profile.Profile, manifest ImagesManifest) error {
+	d, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode offline images: %w", err)
+	}
+
+	path := profile.Path(ProfileStackPath, OfflineImagesFile)
+	err = os.MkdirAll(filepath.Dir(path), 0755)
+	if err != nil {
+		return fmt.Errorf("failed to create parent directory: %w", err)
+	}
+
+	err = os.WriteFile(path, d, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to write offline images file: %w", err)
+	}
+	return nil
+}
+
+// pullNeverArgs returns the arguments to pass to docker compose up so images are
+// never pulled, when the profile uses offline images.
+func pullNeverArgs(profile *profile.Profile) ([]string, error) {
+	manifest, found, err := LoadOfflineImages(profile)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, nil
+	}
+	logger.Debugf("Using offline images imported for stack version %s, images won't be pulled", manifest.StackVersion)
+	return []string{"--pull", "never"}, nil
+}
+
+func writeImagesBundle(output string, manifest ImagesManifest, imagesPath string) error {
+	manifestContent, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode manifest: %w", err)
+	}
+
+	f, err := os.Create(output)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	gw := gzip.NewWriter(f)
+	tw := tar.NewWriter(gw)
+
+	err = tw.WriteHeader(&tar.Header{
+		Name:    imagesBundleManifestFile,
+		Mode:    0644,
+		Size:    int64(len(manifestContent)),
+		ModTime: manifest.CreatedAt,
+	})
+	if err != nil {
+		return err
+	}
+	if _, err := tw.Write(manifestContent); err != nil {
+		return err
+	}
+
+	images, err := os.Open(imagesPath)
+	if err != nil {
+		return err
+	}
+	defer images.Close()
+	info, err := images.Stat()
+	if err != nil {
+		return err
+	}
+	err = tw.WriteHeader(&tar.Header{
+		Name:    imagesBundleImagesFile,
+		Mode:    0644,
+		Size:    info.Size(),
+		ModTime: manifest.CreatedAt,
+	})
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(tw, images); err != nil {
+		return err
+	}
+
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	if err := gw.Close(); err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+// readImagesBundle reads the manifest of the bundle and extracts the images archive
+// to the given path.
+func readImagesBundle(input string, imagesPath string) (*ImagesManifest, error) {
+	f, err := os.Open(input)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	gr, err := gzip.NewReader(f)
+	if err != nil {
+		return nil, err
+	}
+	defer gr.Close()
+
+	var manifest *ImagesManifest
+	imagesFound := false
+	tr := tar.NewReader(gr)
+	for {
+		header, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		switch header.Name {
+		case imagesBundleManifestFile:
+			manifest = new(ImagesManifest)
+			if err := json.NewDecoder(tr).Decode(manifest); err != nil {
+				return nil, fmt.Errorf("failed to decode manifest: %w", err)
+			}
+		case imagesBundleImagesFile:
+			err := extractTarEntry(tr, imagesPath)
+			if err != nil {
+				return nil, fmt.Errorf("failed to extract images: %w", err)
+			}
+			imagesFound = true
+		default:
+			logger.Debugf("Ignoring unexpected file in images bundle: %s", header.Name)
+		}
+	}
+
+	if manifest == nil {
+		return nil, fmt.Errorf("%s not found in bundle", imagesBundleManifestFile)
+	}
+	if !imagesFound {
+		return nil, fmt.Errorf("%s not found in bundle", imagesBundleImagesFile)
+	}
+	return manifest, nil
+}
+
+func extractTarEntry(r io.Reader, path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if _, err := io.Copy(f, r); err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+func uniqueImages(images []string) []string {
+	var result []string
+	for _, image := range images {
+		if image == "" {
+			continue
+		}
+		result = append(result, image)
+	}
+	slices.Sort(result)
+	return slices.Compact(result)
+}
@@ -0,0 +1,65 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package stack
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestImagesBundle(t *testing.T) {
+	dir := t.TempDir()
+
+	imagesPath := filepath.Join(dir, "source.tar")
+	err := os.WriteFile(imagesPath, []byte("some image layers"), 0644)
+	require.NoError(t, err)
+
+	manifest := ImagesManifest{
+		StackVersion: "8.17.0",
+		CreatedAt:    time.Date(2024, 12, 1, 0, 0, 0, 0, time.UTC),
+		Images:       []string{"docker.elastic.co/kibana/kibana:8.17.0"},
+	}
+	bundlePath := filepath.Join(dir, "bundle.tar.gz")
+	err = writeImagesBundle(bundlePath, manifest, imagesPath)
+	require.NoError(t, err)
+
+	extractedPath := filepath.Join(dir, "extracted.tar")
+	found, err := readImagesBundle(bundlePath, extractedPath)
+	require.NoError(t, err)
+	assert.Equal(t, manifest, *found)
+
+	content, err := os.ReadFile(extractedPath)
+	require.NoError(t, err)
+	assert.Equal(t, "some image layers", string(content))
+}
+
+func TestStackImages(t *testing.T) {
+	dataHome := t.TempDir()
+	t.Setenv("ELASTIC_PACKAGE_DATA_HOME", dataHome)
+	t.Setenv("ELASTIC_PACKAGE_DISABLE_ELASTIC_AGENT_WOLFI", "false")
+	err := os.WriteFile(filepath.Join(dataHome, "config.yml"), []byte("profile:\n  current: default\n"), 0644)
+	require.NoError(t, err)
+
+	images, err := StackImages("8.17.0")
+	require.NoError(t, err)
+
+	expected := []string{
+		"docker.elastic.co/elastic-agent/elastic-agent-complete-wolfi:8.17.0",
+		"docker.elastic.co/elastic-agent/elastic-agent-wolfi:8.17.0",
+		"docker.elastic.co/elastic-agent/elastic-agent:8.17.0",
+		"docker.elastic.co/elasticsearch/elasticsearch:8.17.0",
+		"docker.elastic.co/kibana/kibana:8.17.0",
+		"docker.elastic.co/logstash/logstash:8.17.0",
+		PackageRegistryBaseImage,
+		KafkaImage,
+		"tianon/true:multiarch",
+	}
+	assert.ElementsMatch(t, expected, images)
+}
@@ -41,6 +41,11 @@ func (m *localServicesManager) start(ctx context.Context, options Options, confi
 	if options.DaemonMode {
 		opts.ExtraArgs = append(opts.ExtraArgs, "-d")
 	}
+	pullArgs, err := pullNeverArgs(m.profile)
+	if err != nil {
+		return fmt.Errorf("can't check offline images: %w", err)
+	}
+	opts.ExtraArgs = append(opts.ExtraArgs, pullArgs...)
 	if err := project.Up(ctx, opts); err != nil {
 		// At least starting on 8.6.0, fleet-server may be reconfigured or
 		// restarted after being healthy. If elastic-agent tries to enroll at
@@ -22,9 +22,21 @@ func dockerComposeLogsSince(ctx context.Context, serviceName string, profile *pr
 		return nil, fmt.Errorf("can't read application configuration: %w", err)
 	}
 
-	composeFile := profile.Path(ProfileStackPath, ComposeFile)
+	config, err := LoadConfig(profile)
+	if err != nil {
+		return nil, fmt.Errorf("can't read stack configuration: %w", err)
+	}
+
+	// Overrides are only applied to the stack managed by the compose provider.
+	files := []string{profile.Path(ProfileStackPath, ComposeFile)}
+	if config.Provider == ProviderCompose {
+		files, err = composeFiles(profile)
+		if err != nil {
+			return nil, err
+		}
+	}
 
-	p, err := compose.NewProject(DockerComposeProjectName(profile), composeFile)
+	p, err := compose.NewProject(DockerComposeProjectName(profile), files...)
 	if err != nil {
 		return nil, fmt.Errorf("could not create docker compose project: %w", err)
 	}
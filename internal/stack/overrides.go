@@ -0,0 +1,84 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package stack
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sort"
+
+	"github.com/elastic/elastic-package/internal/compose"
+	"github.com/elastic/elastic-package/internal/install"
+	"github.com/elastic/elastic-package/internal/profile"
+)
+
+// ComposeOverridesFolder is the folder in the profile stack directory containing
+// Docker Compose fragments that are merged over the generated compose file.
+const ComposeOverridesFolder = "overrides"
+
+// ComposeOverrides returns the paths of the Docker Compose overrides defined in the
+// profile, in the order they are applied.
+func ComposeOverrides(profile *profile.Profile) ([]string, error) {
+	overrides, err := filepath.Glob(profile.Path(ProfileStackPath, ComposeOverridesFolder, "*.yml"))
+	if err != nil {
+		return nil, fmt.Errorf("can't list compose overrides: %w", err)
+	}
+	sort.Strings(overrides)
+	return overrides, nil
+}
+
+// composeFiles returns the Docker Compose files of the stack, the generated one followed
+// by the overrides defined in the profile.
+func composeFiles(profile *profile.Profile) ([]string, error) {
+	overrides, err := ComposeOverrides(profile)
+	if err != nil {
+		return nil, err
+	}
+	return append([]string{profile.Path(ProfileStackPath, ComposeFile)}, overrides...), nil
+}
+
+func newComposeProject(profile *profile.Profile) (*compose.Project, error) {
+	files, err := composeFiles(profile)
+	if err != nil {
+		return nil, err
+	}
+	return compose.NewProject(DockerComposeProjectName(profile), files...)
+}
+
+// validateComposeOverrides checks that the result of merging the overrides of the profile
+// over the generated compose file is a valid configuration.
+func validateComposeOverrides(ctx context.Context, options Options) error {
+	overrides, err := ComposeOverrides(options.Profile)
+	if err != nil {
+		return err
+	}
+	if len(overrides) == 0 {
+		return nil
+	}
+
+	c, err := newComposeProject(options.Profile)
+	if err != nil {
+		return fmt.Errorf("could not create docker compose project: %w", err)
+	}
+
+	appConfig, err := install.Configuration(install.OptionWithStackVersion(options.StackVersion))
+	if err != nil {
+		return fmt.Errorf("can't read application configuration: %w", err)
+	}
+
+	opts := compose.CommandOptions{
+		Env: newEnvBuilder().
+			withEnvs(appConfig.StackImageRefs().AsEnv()).
+			withEnv(stackVariantAsEnv(options.StackVersion)).
+			withEnvs(options.Profile.ComposeEnvVars()).
+			build(),
+	}
+	_, err = c.Config(ctx, opts)
+	if err != nil {
+		return fmt.Errorf("invalid compose overrides (%s): %w", filepath.Join(ProfileStackPath, ComposeOverridesFolder), err)
+	}
+	return nil
+}
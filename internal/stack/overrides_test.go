@@ -0,0 +1,38 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package stack
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/elastic-package/internal/profile"
+)
+
+func TestComposeFiles(t *testing.T) {
+	p := &profile.Profile{ProfilePath: t.TempDir(), ProfileName: "test"}
+
+	files, err := composeFiles(p)
+	require.NoError(t, err)
+	assert.Equal(t, []string{p.Path(ProfileStackPath, ComposeFile)}, files)
+
+	overridesDir := p.Path(ProfileStackPath, ComposeOverridesFolder)
+	require.NoError(t, os.MkdirAll(overridesDir, 0755))
+	for _, name := range []string{"20-kafka.yml", "10-elasticsearch.yml", "notes.txt"} {
+		require.NoError(t, os.WriteFile(filepath.Join(overridesDir, name), []byte("services: {}\n"), 0644))
+	}
+
+	files, err = composeFiles(p)
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		p.Path(ProfileStackPath, ComposeFile),
+		filepath.Join(overridesDir, "10-elasticsearch.yml"),
+		filepath.Join(overridesDir, "20-kafka.yml"),
+	}, files)
+}
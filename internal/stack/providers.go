@@ -86,3 +86,7 @@ func (*composeProvider) Dump(ctx context.Context, options DumpOptions) ([]DumpRe
 func (*composeProvider) Status(ctx context.Context, options Options) ([]ServiceStatus, error) {
 	return Status(ctx, options)
 }
+
+func (*composeProvider) Upgrade(ctx context.Context, options Options) (*UpgradeReport, error) {
+	return Upgrade(ctx, options)
+}
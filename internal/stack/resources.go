@@ -37,6 +37,12 @@ const (
 	// LogstashConfigFile is the logstash config file.
 	LogstashConfigFile = "logstash.conf"
 
+	// KafkaForwarderConfigFile is the config file of the pipeline that forwards events from Kafka to Elasticsearch.
+	KafkaForwarderConfigFile = "kafka-forwarder.conf"
+
+	// RemoteElasticsearchConfigFile is the config file of the remote Elasticsearch cluster.
+	RemoteElasticsearchConfigFile = "elasticsearch-remote.yml"
+
 	// KibanaHealthcheckFile is the kibana healthcheck.
 	KibanaHealthcheckFile = "kibana-healthcheck.sh"
 
@@ -63,6 +69,8 @@ const (
 	configKibanaHTTP2Enabled  = "stack.kibana_http2_enabled"
 	configLogsDBEnabled       = "stack.logsdb_enabled"
 	configLogstashEnabled     = "stack.logstash_enabled"
+	configKafkaEnabled        = "stack.kafka_enabled"
+	configRemoteESEnabled     = "stack.remote_es_enabled"
 	configSelfMonitorEnabled  = "stack.self_monitor_enabled"
 	configElasticSubscription = "stack.elastic_subscription"
 )
@@ -138,6 +146,20 @@ var (
 		},
 	}
 
+	kafkaResources = []resource.Resource{
+		&resource.File{
+			Path:    KafkaForwarderConfigFile,
+			Content: staticSource.Template("_static/kafka-forwarder.conf.tmpl"),
+		},
+	}
+
+	remoteElasticsearchResources = []resource.Resource{
+		&resource.File{
+			Path:    RemoteElasticsearchConfigFile,
+			Content: staticSource.Template("_static/elasticsearch-remote.yml.tmpl"),
+		},
+	}
+
 	elasticSubscriptionsSupported = []string{
 		"basic",
 		"trial",
@@ -160,6 +182,7 @@ func applyResources(profile *profile.Profile, stackVersion string) error {
 	resourceManager := resource.NewManager()
 	resourceManager.AddFacter(resource.StaticFacter{
 		"registry_base_image":   PackageRegistryBaseImage,
+		"kafka_image":           KafkaImage,
 		"elasticsearch_version": stackVersion,
 		"kibana_version":        stackVersion,
 		"agent_version":         stackVersion,
@@ -179,6 +202,8 @@ func applyResources(profile *profile.Profile, stackVersion string) error {
 		"kibana_http2_enabled": profile.Config(configKibanaHTTP2Enabled, "true"),
 		"logsdb_enabled":       profile.Config(configLogsDBEnabled, "false"),
 		"logstash_enabled":     profile.Config(configLogstashEnabled, "false"),
+		"kafka_enabled":        profile.Config(configKafkaEnabled, "false"),
+		"remote_es_enabled":    profile.Config(configRemoteESEnabled, "false"),
 		"self_monitor_enabled": profile.Config(configSelfMonitorEnabled, "false"),
 		"elastic_subscription": elasticSubscriptionProfile,
 	})
@@ -209,6 +234,13 @@ func applyResources(profile *profile.Profile, stackVersion string) error {
 		}
 	}
 
+	if profile.Config(configKafkaEnabled, "false") == "true" {
+		resources = append(resources, kafkaResources...)
+	}
+	if profile.Config(configRemoteESEnabled, "false") == "true" {
+		resources = append(resources, remoteElasticsearchResources...)
+	}
+
 	results, err := resourceManager.Apply(resources)
 	if err != nil {
 		var errors []string
@@ -13,7 +13,15 @@ import (
 
 // Update pulls down the most recent versions of the Docker images.
 func Update(ctx context.Context, options Options) error {
-	err := applyResources(options.Profile, options.StackVersion)
+	manifest, offline, err := LoadOfflineImages(options.Profile)
+	if err != nil {
+		return fmt.Errorf("can't check offline images: %w", err)
+	}
+	if offline {
+		return fmt.Errorf("profile %q uses offline images imported for stack version %s, images are not pulled", options.Profile.ProfileName, manifest.StackVersion)
+	}
+
+	err = applyResources(options.Profile, options.StackVersion)
 	if err != nil {
 		return fmt.Errorf("creating stack files failed: %w", err)
 	}
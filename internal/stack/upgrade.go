@@ -0,0 +1,353 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package stack
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+
+	"github.com/elastic/elastic-package/internal/compose"
+	"github.com/elastic/elastic-package/internal/elasticsearch"
+	"github.com/elastic/elastic-package/internal/install"
+	"github.com/elastic/elastic-package/internal/kibana"
+	"github.com/elastic/elastic-package/internal/logger"
+)
+
+// upgradeOrder is the order in which services are upgraded. Elasticsearch goes first
+// as the rest of components don't support newer versions of Elasticsearch.
+var upgradeOrder = []string{
+	"elasticsearch",
+	"elasticsearch-remote",
+	"kibana",
+	"package-registry",
+	fleetServerService,
+	elasticAgentService,
+	"logstash",
+	"kafka-forwarder",
+}
+
+// majorUpgradeMinimumVersions contains the minimum versions that can be directly
+// upgraded to a new major.
+var majorUpgradeMinimumVersions = map[uint64]*semver.Version{
+	8: semver.MustParse("7.17.0"),
+	9: semver.MustParse("8.18.0"),
+}
+
+// Upgrader is implemented by the providers that can upgrade a running stack.
+type Upgrader interface {
+	// Upgrade upgrades the stack to the version in the options, keeping its state.
+	Upgrade(context.Context, Options) (*UpgradeReport, error)
+}
+
+// UpgradeReport summarizes the result of an upgrade.
+type UpgradeReport struct {
+	FromVersion string
+	ToVersion   string
+
+	// Deprecations are the deprecations reported by Elasticsearch after the upgrade.
+	Deprecations []Deprecation
+
+	// FailedPackages are the packages that couldn't be reinstalled after the upgrade.
+	FailedPackages []FailedPackage
+}
+
+// Deprecation is a deprecation reported by the Elasticsearch deprecation info API.
+type Deprecation struct {
+	Level    string `json:"level"`
+	Message  string `json:"message"`
+	URL      string `json:"url"`
+	Details  string `json:"details"`
+	Resource string `json:"-"`
+}
+
+// FailedPackage is a package whose installation status is not healthy.
+type FailedPackage struct {
+	Name    string
+	Version string
+	Status  string
+}
+
+// Upgrade performs a rolling upgrade of the services of the stack to a new version.
+// Containers are recreated with the new images, keeping the data in their volumes.
+func Upgrade(ctx context.Context, options Options) (*UpgradeReport, error) {
+	esClient, err := NewElasticsearchClientFromProfile(options.Profile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize Elasticsearch client: %w", err)
+	}
+	info, err := esClient.Info(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get current stack version: %w", err)
+	}
+	currentVersion := info.Version.Number
+
+	err = checkUpgradePath(currentVersion, options.StackVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	deprecations, err := getDeprecations(ctx, esClient.API)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check deprecations before upgrading: %w", err)
+	}
+	if critical := criticalDeprecations(deprecations); len(critical) > 0 {
+		var messages []string
+		for _, d := range critical {
+			messages = append(messages, fmt.Sprintf("%s: %s", d.Resource, d.Message))
+		}
+		return nil, fmt.Errorf("critical deprecations must be resolved before upgrading:\n%s", strings.Join(messages, "\n"))
+	}
+
+	err = applyResources(options.Profile, options.StackVersion)
+	if err != nil {
+		return nil, fmt.Errorf("creating stack files failed: %w", err)
+	}
+
+	err = validateComposeOverrides(ctx, options)
+	if err != nil {
+		return nil, err
+	}
+
+	err = dockerComposeBuild(ctx, options)
+	if err != nil {
+		return nil, fmt.Errorf("building docker images failed: %w", err)
+	}
+
+	status, err := dockerComposeStatus(ctx, options)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get status of the stack: %w", err)
+	}
+	for _, service := range upgradeOrder {
+		running := slices.ContainsFunc(status, func(s ServiceStatus) bool { return s.Name == service })
+		if !running {
+			logger.Debugf("Skipping upgrade of %s, service is not running", service)
+			continue
+		}
+
+		options.Printer.Printf("Upgrading %s to %s\n", service, options.StackVersion)
+		err = upgradeService(ctx, options, service)
+		if err != nil {
+			return nil, fmt.Errorf("upgrading %s failed: %w", service, err)
+		}
+	}
+
+	report := UpgradeReport{
+		FromVersion: currentVersion,
+		ToVersion:   options.StackVersion,
+	}
+
+	report.FailedPackages, err = failedPackages(ctx, options)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check installed packages: %w", err)
+	}
+
+	report.Deprecations, err = getDeprecations(ctx, esClient.API)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check deprecations after upgrading: %w", err)
+	}
+
+	return &report, nil
+}
+
+// upgradeService recreates the container of a service with the images of the new version,
+// and waits for it to be healthy.
+func upgradeService(ctx context.Context, options Options, service string) error {
+	c, err := newComposeProject(options.Profile)
+	if err != nil {
+		return fmt.Errorf("could not create docker compose project: %w", err)
+	}
+
+	appConfig, err := install.Configuration(install.OptionWithStackVersion(options.StackVersion))
+	if err != nil {
+		return fmt.Errorf("can't read application configuration: %w", err)
+	}
+
+	pullArgs, err := pullNeverArgs(options.Profile)
+	if err != nil {
+		return fmt.Errorf("can't check offline images: %w", err)
+	}
+
+	opts := compose.CommandOptions{
+		Env: newEnvBuilder().
+			withEnvs(appConfig.StackImageRefs().AsEnv()).
+			withEnv(stackVariantAsEnv(options.StackVersion)).
+			withEnvs(options.Profile.ComposeEnvVars()).
+			build(),
+		ExtraArgs: append([]string{"-d", "--no-deps"}, pullArgs...),
+		Services:  []string{service},
+	}
+	err = c.Up(ctx, opts)
+	if err != nil {
+		return err
+	}
+
+	return c.WaitForHealthy(ctx, opts)
+}
+
+func checkUpgradePath(from, to string) error {
+	fromVersion, err := semver.NewVersion(from)
+	if err != nil {
+		return fmt.Errorf("invalid current version %q: %w", from, err)
+	}
+	toVersion, err := semver.NewVersion(to)
+	if err != nil {
+		return fmt.Errorf("invalid target version %q: %w", to, err)
+	}
+
+	if toVersion.LessThan(fromVersion) {
+		return fmt.Errorf("can't downgrade the stack from %s to %s", from, to)
+	}
+	if toVersion.Major() > fromVersion.Major()+1 {
+		return fmt.Errorf("can't upgrade the stack from %s to %s, upgrade first to version %d", from, to, fromVersion.Major()+1)
+	}
+	if toVersion.Major() > fromVersion.Major() {
+		minimum, found := majorUpgradeMinimumVersions[toVersion.Major()]
+		if found && fromVersion.LessThan(minimum) {
+			return fmt.Errorf("can't upgrade the stack from %s to %s, upgrade first to version %s or later", from, to, minimum)
+		}
+	}
+	return nil
+}
+
+// getDeprecations queries the deprecation info API of Elasticsearch.
+func getDeprecations(ctx context.Context, api *elasticsearch.API) ([]Deprecation, error) {
+	resp, err := api.Migration.Deprecations(api.Migration.Deprecations.WithContext(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get deprecations: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.IsError() {
+		return nil, fmt.Errorf("failed to get deprecations: %s", resp.String())
+	}
+
+	var body map[string]json.RawMessage
+	err = json.NewDecoder(resp.Body).Decode(&body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode deprecations: %w", err)
+	}
+	return parseDeprecations(body), nil
+}
+
+// parseDeprecations flattens the response of the deprecation info API. Depending on the
+// section, deprecations are reported as lists, or as lists grouped by resource.
+func parseDeprecations(body map[string]json.RawMessage) []Deprecation {
+	var deprecations []Deprecation
+	for section, raw := range body {
+		var list []Deprecation
+		if err := json.Unmarshal(raw, &list); err == nil {
+			for _, d := range list {
+				d.Resource = section
+				deprecations = append(deprecations, d)
+			}
+			continue
+		}
+
+		var grouped map[string][]Deprecation
+		if err := json.Unmarshal(raw, &grouped); err == nil {
+			for resource, list := range grouped {
+				for _, d := range list {
+					d.Resource = section + "/" + resource
+					deprecations = append(deprecations, d)
+				}
+			}
+			continue
+		}
+		logger.Debugf("Ignoring unknown deprecations section %q", section)
+	}
+
+	sort.Slice(deprecations, func(i, j int) bool {
+		if deprecations[i].Resource != deprecations[j].Resource {
+			return deprecations[i].Resource < deprecations[j].Resource
+		}
+		return deprecations[i].Message < deprecations[j].Message
+	})
+	return deprecations
+}
+
+func criticalDeprecations(deprecations []Deprecation) []Deprecation {
+	var critical []Deprecation
+	for _, d := range deprecations {
+		if d.Level == "critical" {
+			critical = append(critical, d)
+		}
+	}
+	return critical
+}
+
+// failedPackages runs the Fleet setup, which reinstalls packages after upgrades,
+// and returns the packages whose installation is not healthy.
+func failedPackages(ctx context.Context, options Options) ([]FailedPackage, error) {
+	kibanaClient, err := NewKibanaClientFromProfile(options.Profile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize Kibana client: %w", err)
+	}
+
+	statusCode, body, err := kibanaClient.SendRequest(ctx, http.MethodPost, kibana.FleetAPI+"/setup", nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to run Fleet setup: %w", err)
+	}
+	if statusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to run Fleet setup (status code %d): %s", statusCode, string(body))
+	}
+
+	statusCode, body, err = kibanaClient.SendRequest(ctx, http.MethodGet, kibana.FleetAPI+"/epm/packages?prerelease=true", nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list packages: %w", err)
+	}
+	if statusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to list packages (status code %d): %s", statusCode, string(body))
+	}
+	return parseFailedPackages(body)
+}
+
+func parseFailedPackages(body []byte) ([]FailedPackage, error) {
+	var resp struct {
+		Items []struct {
+			Name             string `json:"name"`
+			Version          string `json:"version"`
+			Status           string `json:"status"`
+			InstallationInfo *struct {
+				Version       string `json:"version"`
+				InstallStatus string `json:"install_status"`
+			} `json:"installationInfo"`
+			SavedObject *struct {
+				Attributes struct {
+					Version       string `json:"version"`
+					InstallStatus string `json:"install_status"`
+				} `json:"attributes"`
+			} `json:"savedObject"`
+		} `json:"items"`
+	}
+	err := json.Unmarshal(body, &resp)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode packages: %w", err)
+	}
+
+	var failed []FailedPackage
+	for _, item := range resp.Items {
+		var version, status string
+		switch {
+		case item.InstallationInfo != nil:
+			version, status = item.InstallationInfo.Version, item.InstallationInfo.InstallStatus
+		case item.SavedObject != nil:
+			version, status = item.SavedObject.Attributes.Version, item.SavedObject.Attributes.InstallStatus
+		default:
+			// Package not installed.
+			continue
+		}
+		if status == "installed" {
+			continue
+		}
+		failed = append(failed, FailedPackage{Name: item.Name, Version: version, Status: status})
+	}
+	return failed, nil
+}
@@ -0,0 +1,82 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package stack
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckUpgradePath(t *testing.T) {
+	cases := []struct {
+		from, to string
+		valid    bool
+	}{
+		{"8.15.0", "8.17.1", true},
+		{"8.17.1", "8.17.1", true},
+		{"8.18.0", "9.0.0", true},
+		{"8.19.2", "9.1.0", true},
+		{"8.17.0", "9.0.0", false},
+		{"8.17.0", "8.15.0", false},
+		{"7.17.0", "9.0.0", false},
+		{"7.17.10", "8.0.0", true},
+		{"8.17.0", "latest", false},
+	}
+
+	for _, c := range cases {
+		t.Run(c.from+" to "+c.to, func(t *testing.T) {
+			err := checkUpgradePath(c.from, c.to)
+			if c.valid {
+				assert.NoError(t, err)
+			} else {
+				assert.Error(t, err)
+			}
+		})
+	}
+}
+
+func TestParseDeprecations(t *testing.T) {
+	var body map[string]json.RawMessage
+	err := json.Unmarshal([]byte(`{
+		"cluster_settings": [
+			{"level": "critical", "message": "Cluster setting removed", "url": "https://example.com/cluster"}
+		],
+		"node_settings": [],
+		"index_settings": {
+			"logs-foo": [
+				{"level": "warning", "message": "Index created in old version"}
+			]
+		},
+		"ml_settings": []
+	}`), &body)
+	require.NoError(t, err)
+
+	deprecations := parseDeprecations(body)
+	expected := []Deprecation{
+		{Level: "critical", Message: "Cluster setting removed", URL: "https://example.com/cluster", Resource: "cluster_settings"},
+		{Level: "warning", Message: "Index created in old version", Resource: "index_settings/logs-foo"},
+	}
+	assert.Equal(t, expected, deprecations)
+	assert.Equal(t, expected[:1], criticalDeprecations(deprecations))
+}
+
+func TestParseFailedPackages(t *testing.T) {
+	body := []byte(`{"items": [
+		{"name": "nginx", "version": "1.20.0", "installationInfo": {"version": "1.19.0", "install_status": "installed"}},
+		{"name": "apache", "version": "1.5.0", "installationInfo": {"version": "1.4.0", "install_status": "install_failed"}},
+		{"name": "mysql", "version": "1.3.0", "savedObject": {"attributes": {"version": "1.3.0", "install_status": "installing"}}},
+		{"name": "redis", "version": "1.1.0", "status": "not_installed"}
+	]}`)
+
+	failed, err := parseFailedPackages(body)
+	require.NoError(t, err)
+	assert.Equal(t, []FailedPackage{
+		{Name: "apache", Version: "1.4.0", Status: "install_failed"},
+		{Name: "mysql", Version: "1.3.0", Status: "installing"},
+	}, failed)
+}
@@ -7,4 +7,7 @@ package stack
 const (
 	// PackageRegistryBaseImage is the base Docker image of the Elastic Package Registry.
 	PackageRegistryBaseImage = "docker.elastic.co/package-registry/package-registry:v1.32.1"
+
+	// KafkaImage is the Docker image of the Kafka broker started when the Kafka output is enabled.
+	KafkaImage = "apache/kafka:3.9.0"
 )
@@ -0,0 +1,80 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package testrunner
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/elastic/elastic-package/internal/packages/indextemplate"
+)
+
+// largestGroupsReported is the number of largest field groups included in budget reports.
+const largestGroupsReported = 5
+
+// FieldsBudgetConfig contains the budgets for the number of fields mapped in the data streams
+// of a package.
+type FieldsBudgetConfig struct {
+	FieldsBudget `config:",inline"`
+
+	// DataStreams contains budgets that override the default ones for specific data streams.
+	DataStreams map[string]FieldsBudget `config:"data_streams"`
+}
+
+// FieldsBudget contains the thresholds for the number of fields mapped in a data stream.
+type FieldsBudget struct {
+	// Warning is the number of fields over which a warning is reported.
+	Warning int `config:"warning"`
+
+	// Limit is the number of fields over which the test fails.
+	Limit int `config:"limit"`
+}
+
+// ForDataStream returns the budget for the given data stream.
+func (c FieldsBudgetConfig) ForDataStream(dataStream string) FieldsBudget {
+	budget := c.FieldsBudget
+	if override, found := c.DataStreams[dataStream]; found {
+		if override.Warning > 0 {
+			budget.Warning = override.Warning
+		}
+		if override.Limit > 0 {
+			budget.Limit = override.Limit
+		}
+	}
+	return budget
+}
+
+// CheckFieldsBudget checks the number of mapped fields against the budget. If no warning threshold
+// is configured, the total fields limit of the index is used. It returns a warning message if the
+// warning threshold is exceeded, and an error if the limit is exceeded.
+func CheckFieldsBudget(budget FieldsBudget, count indextemplate.FieldsCount, totalFieldsLimit int) (string, error) {
+	warning := budget.Warning
+	if warning == 0 {
+		warning = totalFieldsLimit
+	}
+
+	switch {
+	case budget.Limit > 0 && count.Total > budget.Limit:
+		return "", ErrTestCaseFailed{
+			Reason:  fmt.Sprintf("%d fields mapped, over the budget of %d fields", count.Total, budget.Limit),
+			Details: describeFieldsCount(count),
+		}
+	case warning > 0 && count.Total > warning:
+		return fmt.Sprintf("%d fields mapped, over the warning threshold of %d fields (%s)", count.Total, warning, describeFieldsCount(count)), nil
+	}
+	return "", nil
+}
+
+func describeFieldsCount(count indextemplate.FieldsCount) string {
+	var groups []string
+	for _, group := range count.LargestGroups(largestGroupsReported) {
+		groups = append(groups, fmt.Sprintf("%s: %d", group.Name, group.Count))
+	}
+	description := "largest field groups: " + strings.Join(groups, ", ")
+	if count.DynamicTemplates > 0 {
+		description += fmt.Sprintf("; %d dynamic template(s) can map additional fields", count.DynamicTemplates)
+	}
+	return description
+}
@@ -0,0 +1,53 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package testrunner
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/elastic-package/internal/packages/indextemplate"
+)
+
+func TestFieldsBudgetForDataStream(t *testing.T) {
+	config := FieldsBudgetConfig{
+		FieldsBudget: FieldsBudget{Warning: 800, Limit: 1000},
+		DataStreams: map[string]FieldsBudget{
+			"wide": {Limit: 3000},
+		},
+	}
+
+	assert.Equal(t, FieldsBudget{Warning: 800, Limit: 1000}, config.ForDataStream("other"))
+	assert.Equal(t, FieldsBudget{Warning: 800, Limit: 3000}, config.ForDataStream("wide"))
+}
+
+func TestCheckFieldsBudget(t *testing.T) {
+	count := indextemplate.FieldsCount{
+		Total:            120,
+		Groups:           map[string]int{"aws": 100, "cloud": 15, "event": 5},
+		DynamicTemplates: 2,
+	}
+
+	t.Run("under budget", func(t *testing.T) {
+		warning, err := CheckFieldsBudget(FieldsBudget{Limit: 200}, count, 1000)
+		require.NoError(t, err)
+		assert.Empty(t, warning)
+	})
+
+	t.Run("over default warning", func(t *testing.T) {
+		warning, err := CheckFieldsBudget(FieldsBudget{}, count, 100)
+		require.NoError(t, err)
+		assert.Equal(t, "120 fields mapped, over the warning threshold of 100 fields (largest field groups: aws: 100, cloud: 15, event: 5; 2 dynamic template(s) can map additional fields)", warning)
+	})
+
+	t.Run("over limit", func(t *testing.T) {
+		_, err := CheckFieldsBudget(FieldsBudget{Warning: 50, Limit: 110}, count, 1000)
+		var failure ErrTestCaseFailed
+		require.ErrorAs(t, err, &failure)
+		assert.Equal(t, "120 fields mapped, over the budget of 110 fields", failure.Reason)
+	})
+}
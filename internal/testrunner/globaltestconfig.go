@@ -15,11 +15,14 @@ import (
 )
 
 type globalTestConfig struct {
-	Asset    GlobalRunnerTestConfig `config:"asset"`
-	Pipeline GlobalRunnerTestConfig `config:"pipeline"`
-	Policy   GlobalRunnerTestConfig `config:"policy"`
-	Static   GlobalRunnerTestConfig `config:"static"`
-	System   GlobalRunnerTestConfig `config:"system"`
+	Asset         GlobalRunnerTestConfig `config:"asset"`
+	Pipeline      GlobalRunnerTestConfig `config:"pipeline"`
+	Policy        GlobalRunnerTestConfig `config:"policy"`
+	Static        GlobalRunnerTestConfig `config:"static"`
+	System        GlobalRunnerTestConfig `config:"system"`
+	UpgradeCompat GlobalRunnerTestConfig `config:"upgrade_compat"`
+
+	FieldsBudget FieldsBudgetConfig `config:"fields_budget"`
 }
 
 type GlobalRunnerTestConfig struct {
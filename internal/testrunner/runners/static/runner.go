@@ -26,6 +26,7 @@ type runner struct {
 	failOnMissingTests bool
 	dataStreams        []string
 	globalTestConfig   testrunner.GlobalRunnerTestConfig
+	fieldsBudget       testrunner.FieldsBudgetConfig
 	withCoverage       bool
 	coverageType       string
 }
@@ -35,6 +36,7 @@ type StaticTestRunnerOptions struct {
 	FailOnMissingTests bool
 	DataStreams        []string
 	GlobalTestConfig   testrunner.GlobalRunnerTestConfig
+	FieldsBudget       testrunner.FieldsBudgetConfig
 	WithCoverage       bool
 	CoverageType       string
 }
@@ -45,6 +47,7 @@ func NewStaticTestRunner(options StaticTestRunnerOptions) *runner {
 		failOnMissingTests: options.FailOnMissingTests,
 		dataStreams:        options.DataStreams,
 		globalTestConfig:   options.GlobalTestConfig,
+		fieldsBudget:       options.FieldsBudget,
 		withCoverage:       options.WithCoverage,
 		coverageType:       options.CoverageType,
 	}
@@ -101,6 +104,7 @@ func (r *runner) GetTests(ctx context.Context) ([]testrunner.Tester, error) {
 			PackageRootPath:  r.packageRootPath,
 			TestFolder:       t,
 			GlobalTestConfig: r.globalTestConfig,
+			FieldsBudget:     r.fieldsBudget,
 			WithCoverage:     r.withCoverage,
 			CoverageType:     r.coverageType,
 		}))
@@ -15,6 +15,7 @@ import (
 	"github.com/elastic/elastic-package/internal/fields"
 	"github.com/elastic/elastic-package/internal/logger"
 	"github.com/elastic/elastic-package/internal/packages"
+	"github.com/elastic/elastic-package/internal/packages/indextemplate"
 	"github.com/elastic/elastic-package/internal/signal"
 	"github.com/elastic/elastic-package/internal/testrunner"
 )
@@ -23,6 +24,7 @@ type tester struct {
 	testFolder       testrunner.TestFolder
 	packageRootPath  string
 	globalTestConfig testrunner.GlobalRunnerTestConfig
+	fieldsBudget     testrunner.FieldsBudgetConfig
 	withCoverage     bool
 	coverageType     string
 }
@@ -31,6 +33,7 @@ type StaticTesterOptions struct {
 	TestFolder       testrunner.TestFolder
 	PackageRootPath  string
 	GlobalTestConfig testrunner.GlobalRunnerTestConfig
+	FieldsBudget     testrunner.FieldsBudgetConfig
 	WithCoverage     bool
 	CoverageType     string
 }
@@ -40,6 +43,7 @@ func NewStaticTester(options StaticTesterOptions) *tester {
 		testFolder:       options.TestFolder,
 		packageRootPath:  options.PackageRootPath,
 		globalTestConfig: options.GlobalTestConfig,
+		fieldsBudget:     options.FieldsBudget,
 		withCoverage:     options.WithCoverage,
 		coverageType:     options.CoverageType,
 	}
@@ -96,8 +100,50 @@ func (r tester) run(ctx context.Context) ([]testrunner.TestResult, error) {
 		return result.WithError(fmt.Errorf("failed to read manifest: %w", err))
 	}
 
-	// join together results from verifyStreamConfig and verifySampleEvent
-	return append(r.verifyStreamConfig(ctx, r.packageRootPath), r.verifySampleEvent(pkgManifest)...), nil
+	// join together results from verifyStreamConfig, verifySampleEvent and verifyFieldsBudget
+	results := append(r.verifyStreamConfig(ctx, r.packageRootPath), r.verifySampleEvent(pkgManifest)...)
+	return append(results, r.verifyFieldsBudget()...), nil
+}
+
+func (r tester) verifyFieldsBudget() []testrunner.TestResult {
+	resultComposer := testrunner.NewResultComposer(testrunner.TestResult{
+		Name:       "Verify fields budget",
+		TestType:   TestType,
+		Package:    r.testFolder.Package,
+		DataStream: r.testFolder.DataStream,
+	})
+
+	var previews []indextemplate.Preview
+	if r.testFolder.DataStream != "" {
+		preview, err := indextemplate.GenerateForDataStream(r.packageRootPath, r.testFolder.DataStream)
+		if err != nil {
+			results, _ := resultComposer.WithError(fmt.Errorf("failed to compute index template: %w", err))
+			return results
+		}
+		previews = append(previews, *preview)
+	} else {
+		var err error
+		previews, err = indextemplate.Generate(r.packageRootPath)
+		if err != nil {
+			results, _ := resultComposer.WithError(fmt.Errorf("failed to compute index templates: %w", err))
+			return results
+		}
+	}
+
+	for _, preview := range previews {
+		budget := r.fieldsBudget.ForDataStream(preview.DataStream)
+		warning, err := testrunner.CheckFieldsBudget(budget, preview.CountFields(), preview.TotalFieldsLimit())
+		if err != nil {
+			results, _ := resultComposer.WithError(err)
+			return results
+		}
+		if warning != "" {
+			logger.Warnf("Index template %s: %s", preview.Name, warning)
+		}
+	}
+
+	results, _ := resultComposer.WithSuccess()
+	return results
 }
 
 func (r tester) verifyStreamConfig(ctx context.Context, packageRootPath string) []testrunner.TestResult {
@@ -0,0 +1,243 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package system
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/elastic/go-elasticsearch/v7/esapi"
+
+	"github.com/elastic/elastic-package/internal/elasticsearch"
+	"github.com/elastic/elastic-package/internal/logger"
+	"github.com/elastic/elastic-package/internal/profile"
+	"github.com/elastic/elastic-package/internal/stack"
+)
+
+const (
+	defaultOutput             = "default"
+	logstashOutput            = "logstash"
+	kafkaOutput               = "kafka"
+	remoteElasticsearchOutput = "remote_elasticsearch"
+)
+
+// testOutputs are the outputs that can be selected in system tests, with the profile setting
+// that enables them and the ID of the Fleet output configured by the stack.
+var testOutputs = map[string]struct {
+	profileSetting string
+	outputID       string
+}{
+	logstashOutput:            {"stack.logstash_enabled", stack.FleetLogstashOutput},
+	kafkaOutput:               {"stack.kafka_enabled", stack.FleetKafkaOutput},
+	remoteElasticsearchOutput: {"stack.remote_es_enabled", stack.FleetRemoteElasticsearchOutput},
+}
+
+// selectOutputID returns the ID of the Fleet output to use for the given output in the test config.
+// An empty ID means that the output configured by the stack is used.
+func selectOutputID(profile *profile.Profile, output string) (string, error) {
+	if output == "" || output == defaultOutput {
+		return "", nil
+	}
+	testOutput, found := testOutputs[output]
+	if !found {
+		return "", fmt.Errorf("unknown output %q", output)
+	}
+	if profile.Config(testOutput.profileSetting, "false") != "true" {
+		return "", fmt.Errorf("output %q requires enabling %s in the profile", output, testOutput.profileSetting)
+	}
+	return testOutput.outputID, nil
+}
+
+// copyDataStreamAssets copies the index template of a data stream, and the component templates and
+// ingest pipelines it uses, from one cluster to another. Fleet only installs the assets of packages
+// in the main cluster, so they need to be copied to validate data sent to remote clusters.
+func copyDataStreamAssets(ctx context.Context, from, to *elasticsearch.API, indexTemplateName string) error {
+	body, err := esGet(from.Indices.GetIndexTemplate(
+		from.Indices.GetIndexTemplate.WithContext(ctx),
+		from.Indices.GetIndexTemplate.WithName(indexTemplateName),
+	))
+	if err != nil {
+		return fmt.Errorf("failed to get index template %s: %w", indexTemplateName, err)
+	}
+	var templates struct {
+		IndexTemplates []struct {
+			IndexTemplate map[string]any `json:"index_template"`
+		} `json:"index_templates"`
+	}
+	err = json.Unmarshal(body, &templates)
+	if err != nil {
+		return fmt.Errorf("failed to decode index template %s: %w", indexTemplateName, err)
+	}
+	if len(templates.IndexTemplates) != 1 {
+		return fmt.Errorf("unexpected number of index templates found for %s: %d", indexTemplateName, len(templates.IndexTemplates))
+	}
+	indexTemplate := templates.IndexTemplates[0].IndexTemplate
+
+	for _, name := range stringsFromList(indexTemplate["composed_of"]) {
+		err := copyComponentTemplate(ctx, from, to, name)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, pipeline := range templatePipelines(indexTemplate) {
+		err := copyPipelines(ctx, from, to, pipeline)
+		if err != nil {
+			return err
+		}
+	}
+
+	logger.Debugf("Copying index template %s to remote cluster", indexTemplateName)
+	return esPut(indexTemplate, func(body io.Reader) (*esapi.Response, error) {
+		return to.Indices.PutIndexTemplate(indexTemplateName, body, to.Indices.PutIndexTemplate.WithContext(ctx))
+	})
+}
+
+func copyComponentTemplate(ctx context.Context, from, to *elasticsearch.API, name string) error {
+	// Don't override the component templates of the target cluster, excepting the ones
+	// of the package, that may have changed.
+	if !strings.HasSuffix(name, "@package") {
+		resp, err := to.Cluster.ExistsComponentTemplate(name, to.Cluster.ExistsComponentTemplate.WithContext(ctx))
+		if err != nil {
+			return fmt.Errorf("failed to check component template %s: %w", name, err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode == http.StatusOK {
+			return nil
+		}
+	}
+
+	resp, err := from.Cluster.GetComponentTemplate(
+		from.Cluster.GetComponentTemplate.WithContext(ctx),
+		from.Cluster.GetComponentTemplate.WithName(name),
+	)
+	if err == nil && resp.StatusCode == http.StatusNotFound {
+		// Templates can reference optional component templates, such as the @custom ones.
+		resp.Body.Close()
+		return nil
+	}
+	body, err := esGet(resp, err)
+	if err != nil {
+		return fmt.Errorf("failed to get component template %s: %w", name, err)
+	}
+	var templates struct {
+		ComponentTemplates []struct {
+			ComponentTemplate map[string]any `json:"component_template"`
+		} `json:"component_templates"`
+	}
+	err = json.Unmarshal(body, &templates)
+	if err != nil {
+		return fmt.Errorf("failed to decode component template %s: %w", name, err)
+	}
+
+	for _, template := range templates.ComponentTemplates {
+		logger.Debugf("Copying component template %s to remote cluster", name)
+		err := esPut(template.ComponentTemplate, func(body io.Reader) (*esapi.Response, error) {
+			return to.Cluster.PutComponentTemplate(name, body, to.Cluster.PutComponentTemplate.WithContext(ctx))
+		})
+		if err != nil {
+			return fmt.Errorf("failed to copy component template %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// copyPipelines copies the given pipeline, and the ones with the same prefix, that are
+// the ones of the same data stream used by pipeline processors.
+func copyPipelines(ctx context.Context, from, to *elasticsearch.API, pipeline string) error {
+	pattern := pipeline
+	if !strings.HasPrefix(pipeline, ".") {
+		pattern = pipeline + "*"
+	}
+	resp, err := from.Ingest.GetPipeline(
+		from.Ingest.GetPipeline.WithContext(ctx),
+		from.Ingest.GetPipeline.WithPipelineID(pattern),
+	)
+	if err == nil && resp.StatusCode == http.StatusNotFound {
+		resp.Body.Close()
+		return nil
+	}
+	body, err := esGet(resp, err)
+	if err != nil {
+		return fmt.Errorf("failed to get pipelines %s: %w", pattern, err)
+	}
+	var pipelines map[string]map[string]any
+	err = json.Unmarshal(body, &pipelines)
+	if err != nil {
+		return fmt.Errorf("failed to decode pipelines %s: %w", pattern, err)
+	}
+
+	for id, definition := range pipelines {
+		logger.Debugf("Copying ingest pipeline %s to remote cluster", id)
+		err := esPut(definition, func(body io.Reader) (*esapi.Response, error) {
+			return to.Ingest.PutPipeline(id, body, to.Ingest.PutPipeline.WithContext(ctx))
+		})
+		if err != nil {
+			return fmt.Errorf("failed to copy pipeline %s: %w", id, err)
+		}
+	}
+	return nil
+}
+
+// templatePipelines returns the default and final pipelines configured in an index template.
+func templatePipelines(indexTemplate map[string]any) []string {
+	var pipelines []string
+	template, _ := indexTemplate["template"].(map[string]any)
+	settings, _ := template["settings"].(map[string]any)
+	index, _ := settings["index"].(map[string]any)
+	for _, key := range []string{"default_pipeline", "final_pipeline"} {
+		if pipeline, ok := index[key].(string); ok && pipeline != "" {
+			pipelines = append(pipelines, pipeline)
+		}
+	}
+	return pipelines
+}
+
+func stringsFromList(value any) []string {
+	list, _ := value.([]any)
+	var result []string
+	for _, elem := range list {
+		if s, ok := elem.(string); ok {
+			result = append(result, s)
+		}
+	}
+	return result
+}
+
+func esGet(resp *esapi.Response, err error) ([]byte, error) {
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.IsError() {
+		return nil, fmt.Errorf("unexpected response: %s", resp.String())
+	}
+	return io.ReadAll(resp.Body)
+}
+
+// esPut sends a resource obtained from a GET request, removing the read-only fields.
+func esPut(resource map[string]any, put func(io.Reader) (*esapi.Response, error)) error {
+	for _, key := range []string{"created_date", "created_date_millis", "modified_date", "modified_date_millis"} {
+		delete(resource, key)
+	}
+	d, err := json.Marshal(resource)
+	if err != nil {
+		return fmt.Errorf("failed to encode resource: %w", err)
+	}
+	resp, err := put(bytes.NewReader(d))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.IsError() {
+		return fmt.Errorf("unexpected response: %s", resp.String())
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package system
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/elastic-package/internal/profile"
+	"github.com/elastic/elastic-package/internal/stack"
+)
+
+func TestSelectOutputID(t *testing.T) {
+	p := &profile.Profile{ProfileName: "test"}
+	p.RuntimeOverrides(map[string]string{
+		"stack.kafka_enabled": "true",
+	})
+
+	outputID, err := selectOutputID(p, "")
+	require.NoError(t, err)
+	assert.Empty(t, outputID)
+
+	outputID, err = selectOutputID(p, defaultOutput)
+	require.NoError(t, err)
+	assert.Empty(t, outputID)
+
+	outputID, err = selectOutputID(p, kafkaOutput)
+	require.NoError(t, err)
+	assert.Equal(t, stack.FleetKafkaOutput, outputID)
+
+	_, err = selectOutputID(p, remoteElasticsearchOutput)
+	assert.ErrorContains(t, err, "stack.remote_es_enabled")
+
+	_, err = selectOutputID(p, "unknown")
+	assert.Error(t, err)
+}
+
+func TestTemplatePipelines(t *testing.T) {
+	indexTemplate := map[string]any{
+		"template": map[string]any{
+			"settings": map[string]any{
+				"index": map[string]any{
+					"default_pipeline": "logs-nginx.access-1.20.0",
+					"final_pipeline":   ".fleet_final_pipeline-1",
+				},
+			},
+		},
+	}
+	assert.Equal(t, []string{"logs-nginx.access-1.20.0", ".fleet_final_pipeline-1"}, templatePipelines(indexTemplate))
+	assert.Empty(t, templatePipelines(map[string]any{}))
+}
@@ -34,6 +34,7 @@ type runner struct {
 	serviceVariant string
 
 	globalTestConfig   testrunner.GlobalRunnerTestConfig
+	fieldsBudget       testrunner.FieldsBudgetConfig
 	failOnMissingTests bool
 	deferCleanup       time.Duration
 	generateTestResult bool
@@ -70,6 +71,7 @@ type SystemTestRunnerOptions struct {
 	ConfigFilePath string
 
 	GlobalTestConfig testrunner.GlobalRunnerTestConfig
+	FieldsBudget     testrunner.FieldsBudgetConfig
 
 	FailOnMissingTests bool
 	GenerateTestResult bool
@@ -95,6 +97,7 @@ func NewSystemTestRunner(options SystemTestRunnerOptions) *runner {
 		generateTestResult: options.GenerateTestResult,
 		deferCleanup:       options.DeferCleanup,
 		globalTestConfig:   options.GlobalTestConfig,
+		fieldsBudget:       options.FieldsBudget,
 		withCoverage:       options.WithCoverage,
 		coverageType:       options.CoverageType,
 	}
@@ -260,6 +263,7 @@ func (r *runner) GetTests(ctx context.Context) ([]testrunner.Tester, error) {
 					RunTearDown:        r.runTearDown,
 					ConfigFileName:     config,
 					GlobalTestConfig:   r.globalTestConfig,
+					FieldsBudget:       r.fieldsBudget,
 					WithCoverage:       r.withCoverage,
 					CoverageType:       r.coverageType,
 				})
@@ -43,6 +43,9 @@ type testConfig struct {
 
 	Deployer string `config:"deployer"` // Name of the service deployer to use for this test.
 
+	// Output is the Fleet output used to send data (default, logstash, kafka or remote_elasticsearch).
+	Output string `config:"output"`
+
 	Vars       common.MapStr `config:"vars"`
 	DataStream struct {
 		Vars common.MapStr `config:"vars"`
@@ -33,6 +33,7 @@ import (
 	"github.com/elastic/elastic-package/internal/logger"
 	"github.com/elastic/elastic-package/internal/multierror"
 	"github.com/elastic/elastic-package/internal/packages"
+	"github.com/elastic/elastic-package/internal/packages/indextemplate"
 	"github.com/elastic/elastic-package/internal/profile"
 	"github.com/elastic/elastic-package/internal/resources"
 	"github.com/elastic/elastic-package/internal/servicedeployer"
@@ -164,6 +165,11 @@ type tester struct {
 	esClient           *elasticsearch.Client
 	kibanaClient       *kibana.Client
 
+	// dataESAPI and dataESClient are the clients of the cluster where the data is sent,
+	// it is only different to the main cluster when testing with remote outputs.
+	dataESAPI    *elasticsearch.API
+	dataESClient *elasticsearch.Client
+
 	runIndependentElasticAgent bool
 
 	fieldValidationMethod fieldValidationMethod
@@ -190,6 +196,7 @@ type tester struct {
 	serviceStateFilePath string
 
 	globalTestConfig testrunner.GlobalRunnerTestConfig
+	fieldsBudget     testrunner.FieldsBudgetConfig
 
 	// Execution order of following handlers is defined in runner.TearDown() method.
 	removeAgentHandler        func(context.Context) error
@@ -216,6 +223,7 @@ type SystemTesterOptions struct {
 	ServiceVariant   string
 	ConfigFileName   string
 	GlobalTestConfig testrunner.GlobalRunnerTestConfig
+	FieldsBudget     testrunner.FieldsBudgetConfig
 	WithCoverage     bool
 	CoverageType     string
 
@@ -232,6 +240,8 @@ func NewSystemTester(options SystemTesterOptions) (*tester, error) {
 		generateTestResult:         options.GenerateTestResult,
 		esAPI:                      options.API,
 		esClient:                   options.ESClient,
+		dataESAPI:                  options.API,
+		dataESClient:               options.ESClient,
 		kibanaClient:               options.KibanaClient,
 		deferCleanup:               options.DeferCleanup,
 		serviceVariant:             options.ServiceVariant,
@@ -240,6 +250,7 @@ func NewSystemTester(options SystemTesterOptions) (*tester, error) {
 		runTestsOnly:               options.RunTestsOnly,
 		runTearDown:                options.RunTearDown,
 		globalTestConfig:           options.GlobalTestConfig,
+		fieldsBudget:               options.FieldsBudget,
 		withCoverage:               options.WithCoverage,
 		coverageType:               options.CoverageType,
 		runIndependentElasticAgent: true,
@@ -758,14 +769,14 @@ func (h hits) size() int {
 }
 
 func (r *tester) getDocs(ctx context.Context, dataStream string) (*hits, error) {
-	resp, err := r.esAPI.Search(
-		r.esAPI.Search.WithContext(ctx),
-		r.esAPI.Search.WithIndex(dataStream),
-		r.esAPI.Search.WithSort("@timestamp:asc"),
-		r.esAPI.Search.WithSize(elasticsearchQuerySize),
-		r.esAPI.Search.WithSource("true"),
-		r.esAPI.Search.WithBody(strings.NewReader(checkFieldsBody)),
-		r.esAPI.Search.WithIgnoreUnavailable(true),
+	resp, err := r.dataESAPI.Search(
+		r.dataESAPI.Search.WithContext(ctx),
+		r.dataESAPI.Search.WithIndex(dataStream),
+		r.dataESAPI.Search.WithSort("@timestamp:asc"),
+		r.dataESAPI.Search.WithSize(elasticsearchQuerySize),
+		r.dataESAPI.Search.WithSource("true"),
+		r.dataESAPI.Search.WithBody(strings.NewReader(checkFieldsBody)),
+		r.dataESAPI.Search.WithIgnoreUnavailable(true),
 	)
 	if err != nil {
 		return nil, fmt.Errorf("could not search data stream: %w", err)
@@ -860,9 +871,9 @@ func (r *tester) getDeprecationWarnings(ctx context.Context, dataStream string)
 		// Example of response: [400 Bad Request] {"error":"no handler found for uri [/metrics-elastic_package_registry.metrics-62481/_migration/deprecations] and method [GET]"}
 		return []deprecationWarning{}, nil
 	}
-	resp, err := r.esAPI.Migration.Deprecations(
-		r.esAPI.Migration.Deprecations.WithContext(ctx),
-		r.esAPI.Migration.Deprecations.WithIndex(dataStream),
+	resp, err := r.dataESAPI.Migration.Deprecations(
+		r.dataESAPI.Migration.Deprecations.WithContext(ctx),
+		r.dataESAPI.Migration.Deprecations.WithIndex(dataStream),
 	)
 	if err != nil {
 		return nil, fmt.Errorf("request failed: %w", err)
@@ -975,8 +986,8 @@ type scenarioTest struct {
 }
 
 func (r *tester) deleteDataStream(ctx context.Context, dataStream string) error {
-	resp, err := r.esAPI.Indices.DeleteDataStream([]string{dataStream},
-		r.esAPI.Indices.DeleteDataStream.WithContext(ctx),
+	resp, err := r.dataESAPI.Indices.DeleteDataStream([]string{dataStream},
+		r.dataESAPI.Indices.DeleteDataStream.WithContext(ctx),
 	)
 	if err != nil {
 		return fmt.Errorf("delete request failed for data stream %s: %w", dataStream, err)
@@ -1029,7 +1040,19 @@ func (r *tester) prepareScenario(ctx context.Context, config *testConfig, stackC
 	}
 	scenario.policyTemplateInput = policyTemplate.Input
 
-	policyToEnrollOrCurrent, policyToTest, err := r.createOrGetKibanaPolicies(ctx, serviceStateData, stackConfig)
+	outputID, err := selectOutputID(r.profile, config.Output)
+	if err != nil {
+		return nil, fmt.Errorf("failed to select output: %w", err)
+	}
+	if config.Output == remoteElasticsearchOutput {
+		r.dataESClient, err = stack.NewRemoteElasticsearchClientFromProfile(r.profile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create client for remote Elasticsearch: %w", err)
+		}
+		r.dataESAPI = r.dataESClient.API
+	}
+
+	policyToEnrollOrCurrent, policyToTest, err := r.createOrGetKibanaPolicies(ctx, serviceStateData, stackConfig, outputID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create kibana policies: %w", err)
 	}
@@ -1090,6 +1113,14 @@ func (r *tester) prepareScenario(ctx context.Context, config *testConfig, stackC
 	scenario.indexTemplateName = r.buildIndexTemplateName(ds, config)
 	scenario.dataStream = r.buildDataStreamName(scenario.policyTemplateInput, ds, config)
 
+	if config.Output == remoteElasticsearchOutput && !r.runTearDown {
+		logger.Debug("copying data stream assets to the remote cluster...")
+		err := copyDataStreamAssets(ctx, r.esAPI, r.dataESAPI, scenario.indexTemplateName)
+		if err != nil {
+			return nil, fmt.Errorf("could not copy data stream assets to the remote cluster: %w", err)
+		}
+	}
+
 	r.cleanTestScenarioHandler = func(ctx context.Context) error {
 		logger.Debugf("Deleting data stream for testing %s", scenario.dataStream)
 		err := r.deleteDataStream(ctx, scenario.dataStream)
@@ -1226,7 +1257,7 @@ func (r *tester) prepareScenario(ctx context.Context, config *testConfig, stackC
 	logger.Debugf("Found %d deprecation warnings for data stream %s", len(scenario.deprecationWarnings), scenario.dataStream)
 
 	logger.Debugf("Check whether or not synthetic source mode is enabled (data stream %s)...", scenario.dataStream)
-	scenario.syntheticEnabled, err = isSyntheticSourceModeEnabled(ctx, r.esAPI, scenario.dataStream)
+	scenario.syntheticEnabled, err = isSyntheticSourceModeEnabled(ctx, r.dataESAPI, scenario.dataStream)
 	if err != nil {
 		return nil, fmt.Errorf("failed to check if synthetic source mode is enabled for data stream %s: %w", scenario.dataStream, err)
 	}
@@ -1301,7 +1332,7 @@ func getExpectedDatasetForTest(pkgType, dataset string, config *testConfig) stri
 // for testing purposes (policyToTest) where the package data stream is added.
 // In case the tester is running with --teardown or --no-provision flags, then the policies
 // are read from the service state file created in the setup stage.
-func (r *tester) createOrGetKibanaPolicies(ctx context.Context, serviceStateData ServiceState, stackConfig stack.Config) (*kibana.Policy, *kibana.Policy, error) {
+func (r *tester) createOrGetKibanaPolicies(ctx context.Context, serviceStateData ServiceState, stackConfig stack.Config, outputID string) (*kibana.Policy, *kibana.Policy, error) {
 	// Configure package (single data stream) via Fleet APIs.
 	testTime := time.Now().Format("20060102T15:04:05Z")
 	var policyToTest, policyCurrent, policyToEnroll *kibana.Policy
@@ -1366,6 +1397,10 @@ func (r *tester) createOrGetKibanaPolicies(ctx context.Context, serviceStateData
 		if stackConfig.OutputID != "" {
 			policy.DataOutputID = stackConfig.OutputID
 		}
+		// Output selected in the test configuration.
+		if outputID != "" {
+			policy.DataOutputID = outputID
+		}
 		policyToTest, err = r.kibanaClient.CreatePolicy(ctx, policy)
 		if err != nil {
 			return nil, nil, fmt.Errorf("could not create test policy: %w", err)
@@ -1614,6 +1649,40 @@ func (r *tester) waitForDocs(ctx context.Context, config *testConfig, dataStream
 	return hits, nil
 }
 
+// checkFieldsBudget checks the number of fields mapped in the data stream after ingesting the
+// documents of the test against the fields budget of the package.
+func (r *tester) checkFieldsBudget(ctx context.Context, scenario *scenarioTest) error {
+	mappings, err := r.dataESClient.DataStreamMappings(ctx, scenario.dataStream)
+	if err != nil {
+		return fmt.Errorf("failed to load mappings from ES (data stream %s): %w", scenario.dataStream, err)
+	}
+	count, err := indextemplate.CountMappedFields(mappings.Properties, mappings.DynamicTemplates)
+	if err != nil {
+		return fmt.Errorf("failed to count mapped fields (data stream %s): %w", scenario.dataStream, err)
+	}
+
+	dataStream := r.testFolder.DataStream
+	totalFieldsLimit := indextemplate.DefaultTotalFieldsLimit
+	if r.dataStreamPath != "" {
+		preview, err := indextemplate.GenerateForDataStream(r.packageRootPath, filepath.Base(r.dataStreamPath))
+		if err != nil {
+			return fmt.Errorf("failed to compute index template: %w", err)
+		}
+		totalFieldsLimit = preview.TotalFieldsLimit()
+	} else {
+		dataStream = scenario.policyTemplateName
+	}
+
+	warning, err := testrunner.CheckFieldsBudget(r.fieldsBudget.ForDataStream(dataStream), count, totalFieldsLimit)
+	if err != nil {
+		return err
+	}
+	if warning != "" {
+		logger.Warnf("Data stream %s: %s", scenario.dataStream, warning)
+	}
+	return nil
+}
+
 func (r *tester) validateTestScenario(ctx context.Context, result *testrunner.ResultComposer, scenario *scenarioTest, config *testConfig) ([]testrunner.TestResult, error) {
 	logger.Info("Validating test case...")
 	expectedDatasets, err := r.expectedDatasets(scenario, config)
@@ -1650,11 +1719,18 @@ func (r *tester) validateTestScenario(ctx context.Context, result *testrunner.Re
 		logger.Debug("Performing validation based on mappings")
 		exceptionFields := listExceptionFields(scenario.docs, fieldsValidator)
 
-		mappingsValidator, err := fields.CreateValidatorForMappings(r.esClient,
+		mappingsValidator, err := fields.CreateValidatorForMappings(r.dataESClient,
 			fields.WithMappingValidatorFallbackSchema(fieldsValidator.Schema),
 			fields.WithMappingValidatorIndexTemplate(scenario.indexTemplateName),
 			fields.WithMappingValidatorDataStream(scenario.dataStream),
 			fields.WithMappingValidatorExceptionFields(exceptionFields),
+			fields.WithMappingValidatorOfflinePreview(func() (*elasticsearch.Mappings, error) {
+				preview, err := indextemplate.GenerateForDataStream(r.packageRootPath, filepath.Base(r.dataStreamPath))
+				if err != nil {
+					return nil, err
+				}
+				return preview.Mappings()
+			}),
 		)
 		if err != nil {
 			return result.WithErrorf("creating mappings validator for data stream failed (data stream: %s): %w", scenario.dataStream, err)
@@ -1668,6 +1744,13 @@ func (r *tester) validateTestScenario(ctx context.Context, result *testrunner.Re
 		}
 	}
 
+	if !r.isTestUsingOTELCollectorInput(scenario.policyTemplateInput) {
+		err = r.checkFieldsBudget(ctx, scenario)
+		if err != nil {
+			return result.WithError(err)
+		}
+	}
+
 	stackVersion, err := semver.NewVersion(r.stackVersion.Number)
 	if err != nil {
 		return result.WithErrorf("failed to parse stack version: %w", err)
@@ -0,0 +1,159 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package upgradecompat
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/elastic/elastic-package/internal/elasticsearch"
+	"github.com/elastic/elastic-package/internal/elasticsearch/ingest"
+	"github.com/elastic/elastic-package/internal/logger"
+)
+
+// maxSampleDocuments is the maximum number of documents ingested on each phase of the test.
+const maxSampleDocuments = 10
+
+// sampleDocuments returns the documents to ingest in the tested data stream. They are taken from
+// the events used in pipeline tests, or a minimal document is used if there are none, as happens
+// with packages downloaded from the registry, which don't include development files.
+func sampleDocuments(dataStreamPath string) ([]map[string]any, error) {
+	paths, err := filepath.Glob(filepath.Join(dataStreamPath, "_dev", "test", "pipeline", "test-*.json"))
+	if err != nil {
+		return nil, err
+	}
+
+	var documents []map[string]any
+	for _, path := range paths {
+		if strings.HasSuffix(path, "-expected.json") {
+			continue
+		}
+		d, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read pipeline test events: %w", err)
+		}
+		var testCase struct {
+			Events []map[string]any `json:"events"`
+		}
+		err = json.Unmarshal(d, &testCase)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode pipeline test events from %s: %w", path, err)
+		}
+		documents = append(documents, testCase.Events...)
+		if len(documents) >= maxSampleDocuments {
+			return documents[:maxSampleDocuments], nil
+		}
+	}
+
+	if len(documents) == 0 {
+		documents = append(documents, map[string]any{
+			"message": "elastic-package upgrade compatibility test",
+		})
+	}
+	return documents, nil
+}
+
+// ingestDocuments indexes the documents in the data stream.
+func ingestDocuments(ctx context.Context, api *elasticsearch.API, info *dataStreamInfo, namespace string, documents []map[string]any) error {
+	dataStream := info.dataStreamName(namespace)
+
+	var body strings.Builder
+	for _, document := range documents {
+		doc := make(map[string]any, len(document)+2)
+		for k, v := range document {
+			doc[k] = v
+		}
+		// Recent timestamps are required by time series data streams.
+		doc["@timestamp"] = time.Now().UTC().Format(time.RFC3339Nano)
+		doc["data_stream"] = map[string]any{
+			"type":      info.dataType,
+			"dataset":   info.dataset,
+			"namespace": namespace,
+		}
+		d, err := json.Marshal(doc)
+		if err != nil {
+			return fmt.Errorf("failed to encode document: %w", err)
+		}
+		body.WriteString(`{"create":{}}` + "\n")
+		body.Write(d)
+		body.WriteString("\n")
+	}
+
+	resp, err := api.Bulk(strings.NewReader(body.String()),
+		api.Bulk.WithContext(ctx),
+		api.Bulk.WithIndex(dataStream),
+		api.Bulk.WithRefresh("true"),
+	)
+	if err != nil {
+		return fmt.Errorf("bulk request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.IsError() {
+		return fmt.Errorf("bulk request failed: %s", resp.String())
+	}
+
+	var bulkResponse struct {
+		Errors bool `json:"errors"`
+		Items  []map[string]struct {
+			Status int             `json:"status"`
+			Error  json.RawMessage `json:"error"`
+		} `json:"items"`
+	}
+	err = json.NewDecoder(resp.Body).Decode(&bulkResponse)
+	if err != nil {
+		return fmt.Errorf("failed to decode bulk response: %w", err)
+	}
+	if bulkResponse.Errors {
+		for _, item := range bulkResponse.Items {
+			for _, result := range item {
+				if len(result.Error) > 0 {
+					return fmt.Errorf("failed to index document (status %d): %s", result.Status, string(result.Error))
+				}
+			}
+		}
+		return fmt.Errorf("failed to index documents")
+	}
+
+	return nil
+}
+
+// pipelineCount returns the number of documents processed by the ingest pipeline in all the nodes.
+func pipelineCount(api *elasticsearch.API, pipeline string) (int64, error) {
+	nodesStats, err := ingest.GetPipelineStatsByPrefix(api, pipeline)
+	if err != nil {
+		return 0, err
+	}
+	var count int64
+	for _, pipelinesStats := range nodesStats {
+		if stats, found := pipelinesStats[pipeline]; found {
+			count += stats.Count
+		}
+	}
+	return count, nil
+}
+
+func deleteDataStream(ctx context.Context, api *elasticsearch.API, dataStream string) error {
+	resp, err := api.Indices.DeleteDataStream([]string{dataStream},
+		api.Indices.DeleteDataStream.WithContext(ctx),
+	)
+	if err != nil {
+		return fmt.Errorf("delete request failed for data stream %s: %w", dataStream, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		logger.Debugf("Data stream %s not found", dataStream)
+		return nil
+	}
+	if resp.IsError() {
+		return fmt.Errorf("delete request failed for data stream %s: %s", dataStream, resp.String())
+	}
+	return nil
+}
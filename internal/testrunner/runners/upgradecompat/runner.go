@@ -0,0 +1,291 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package upgradecompat
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+
+	"github.com/elastic/elastic-package/internal/elasticsearch"
+	"github.com/elastic/elastic-package/internal/files"
+	"github.com/elastic/elastic-package/internal/kibana"
+	"github.com/elastic/elastic-package/internal/logger"
+	"github.com/elastic/elastic-package/internal/packages"
+	"github.com/elastic/elastic-package/internal/registry"
+	"github.com/elastic/elastic-package/internal/resources"
+	"github.com/elastic/elastic-package/internal/testrunner"
+)
+
+const (
+	TestType testrunner.TestType = "upgrade-compat"
+
+	// systemTestType is the type of the tests whose configurations are used to create
+	// the package policies.
+	systemTestType testrunner.TestType = "system"
+)
+
+type runner struct {
+	packageRootPath string
+	kibanaClient    *kibana.Client
+	esAPI           *elasticsearch.API
+	registryClient  *registry.Client
+
+	dataStreams        []string
+	failOnMissingTests bool
+	globalTestConfig   testrunner.GlobalRunnerTestConfig
+	fromVersion        string
+	fromZip            string
+
+	manifest         *packages.PackageManifest
+	previous         *previousPackage
+	tempDir          string
+	resourcesManager *resources.Manager
+}
+
+// Ensures that runner implements testrunner.TestRunner interface
+var _ testrunner.TestRunner = new(runner)
+
+type UpgradeCompatTestRunnerOptions struct {
+	KibanaClient       *kibana.Client
+	API                *elasticsearch.API
+	PackageRootPath    string
+	DataStreams        []string
+	FailOnMissingTests bool
+	GlobalTestConfig   testrunner.GlobalRunnerTestConfig
+
+	// FromVersion is the version to upgrade from, it defaults to the latest version
+	// published in the registry older than the version being tested.
+	FromVersion string
+
+	// FromZip is a local package archive to upgrade from, instead of a version in the registry.
+	FromZip string
+
+	// RegistryClient is the client used to look for previous versions, it defaults to
+	// the production registry.
+	RegistryClient *registry.Client
+}
+
+// previousPackage is the version of the package installed before the upgrade.
+type previousPackage struct {
+	Name     string
+	Version  string
+	RootPath string
+
+	// ZipPath is set when the package is installed from a local archive.
+	ZipPath string
+}
+
+func NewUpgradeCompatTestRunner(options UpgradeCompatTestRunnerOptions) *runner {
+	runner := runner{
+		packageRootPath:    options.PackageRootPath,
+		kibanaClient:       options.KibanaClient,
+		esAPI:              options.API,
+		registryClient:     options.RegistryClient,
+		dataStreams:        options.DataStreams,
+		failOnMissingTests: options.FailOnMissingTests,
+		globalTestConfig:   options.GlobalTestConfig,
+		fromVersion:        options.FromVersion,
+		fromZip:            options.FromZip,
+	}
+	if runner.registryClient == nil {
+		runner.registryClient = registry.Production
+	}
+	runner.resourcesManager = resources.NewManager()
+	runner.resourcesManager.RegisterProvider(resources.DefaultKibanaProviderName, &resources.KibanaProvider{Client: runner.kibanaClient})
+	return &runner
+}
+
+// SetupRunner prepares the source of the previous version of the package.
+func (r *runner) SetupRunner(ctx context.Context) error {
+	manifest, err := packages.ReadPackageManifestFromPackageRoot(r.packageRootPath)
+	if err != nil {
+		return fmt.Errorf("reading package manifest failed (path: %s): %w", r.packageRootPath, err)
+	}
+	r.manifest = manifest
+
+	r.tempDir, err = os.MkdirTemp("", "elastic-package-upgrade-")
+	if err != nil {
+		return fmt.Errorf("can't prepare a temporary directory: %w", err)
+	}
+
+	r.previous, err = r.preparePreviousPackage()
+	if err != nil {
+		return fmt.Errorf("failed to prepare previous version of the package: %w", err)
+	}
+	return nil
+}
+
+func (r *runner) preparePreviousPackage() (*previousPackage, error) {
+	previous := previousPackage{
+		Name:    r.manifest.Name,
+		ZipPath: r.fromZip,
+	}
+
+	zipPath := r.fromZip
+	if zipPath == "" {
+		version := r.fromVersion
+		if version == "" {
+			revisions, err := r.registryClient.Revisions(r.manifest.Name, registry.SearchOptions{
+				All:        true,
+				Prerelease: true,
+			})
+			if err != nil {
+				return nil, fmt.Errorf("failed to get published versions of the package: %w", err)
+			}
+			version, err = previousVersion(r.manifest.Version, revisions)
+			if err != nil {
+				return nil, err
+			}
+			if version == "" {
+				logger.Warnf("No previous version of package %s found in the registry, upgrade compatibility tests will be skipped", r.manifest.Name)
+				return nil, nil
+			}
+		}
+
+		logger.Debugf("Downloading %s-%s from the registry", r.manifest.Name, version)
+		var err error
+		zipPath, err = r.registryClient.DownloadPackage(r.manifest.Name, version, r.tempDir)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	extractDir := filepath.Join(r.tempDir, "previous")
+	err := files.Unzip(zipPath, extractDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to extract package: %w", err)
+	}
+	previous.RootPath, err = packages.FindExtractedPackageRoot(extractDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find package in %s: %w", zipPath, err)
+	}
+
+	manifest, err := packages.ReadPackageManifestFromPackageRoot(previous.RootPath)
+	if err != nil {
+		return nil, fmt.Errorf("reading manifest of previous version failed: %w", err)
+	}
+	if manifest.Name != r.manifest.Name {
+		return nil, fmt.Errorf("previous package %q doesn't match the tested package %q", manifest.Name, r.manifest.Name)
+	}
+	previous.Version = manifest.Version
+
+	current, err := semver.NewVersion(r.manifest.Version)
+	if err != nil {
+		return nil, fmt.Errorf("invalid version of the package %q: %w", r.manifest.Version, err)
+	}
+	from, err := semver.NewVersion(previous.Version)
+	if err != nil {
+		return nil, fmt.Errorf("invalid version of the previous package %q: %w", previous.Version, err)
+	}
+	if !from.LessThan(current) {
+		return nil, fmt.Errorf("can't upgrade from version %s, it is not older than %s", previous.Version, r.manifest.Version)
+	}
+
+	return &previous, nil
+}
+
+// previousVersion returns the latest revision older than the given version, or an empty
+// string if there is none.
+func previousVersion(version string, revisions []packages.PackageManifest) (string, error) {
+	current, err := semver.NewVersion(version)
+	if err != nil {
+		return "", fmt.Errorf("invalid version of the package %q: %w", version, err)
+	}
+
+	var latest *semver.Version
+	for _, revision := range revisions {
+		v, err := semver.NewVersion(revision.Version)
+		if err != nil {
+			logger.Debugf("Ignoring revision with invalid version %q", revision.Version)
+			continue
+		}
+		if !v.LessThan(current) {
+			continue
+		}
+		if latest == nil || v.GreaterThan(latest) {
+			latest = v
+		}
+	}
+	if latest == nil {
+		return "", nil
+	}
+	return latest.Original(), nil
+}
+
+// TearDownRunner uninstalls the package and removes the temporary files.
+func (r *runner) TearDownRunner(ctx context.Context) error {
+	defer os.RemoveAll(r.tempDir)
+
+	if r.previous == nil {
+		return nil
+	}
+
+	logger.Debug("Uninstalling package...")
+	packageResource := resources.FleetPackage{
+		RootPath: r.packageRootPath,
+		Absent:   true,
+	}
+	_, err := r.resourcesManager.ApplyCtx(context.WithoutCancel(ctx), resources.Resources{&packageResource})
+	if err != nil {
+		return fmt.Errorf("failed to clean up test runner: %w", err)
+	}
+	return nil
+}
+
+func (r *runner) GetTests(ctx context.Context) ([]testrunner.Tester, error) {
+	hasDataStreams, err := testrunner.PackageHasDataStreams(r.manifest)
+	if err != nil {
+		return nil, fmt.Errorf("cannot determine if package has data streams: %w", err)
+	}
+	if !hasDataStreams {
+		if r.failOnMissingTests {
+			return nil, fmt.Errorf("%s tests are only supported in packages with data streams", r.Type())
+		}
+		logger.Warnf("Package %s has no data streams, upgrade compatibility tests are skipped", r.manifest.Name)
+		return nil, nil
+	}
+
+	folders, err := testrunner.FindTestFolders(r.packageRootPath, r.dataStreams, systemTestType)
+	if err != nil {
+		return nil, fmt.Errorf("unable to determine test folder paths: %w", err)
+	}
+
+	var testers []testrunner.Tester
+	for _, folder := range folders {
+		configs, err := listConfigFiles(folder.Path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to look for test configurations in %s: %w", folder.Path, err)
+		}
+		for _, config := range configs {
+			testers = append(testers, NewUpgradeCompatTester(UpgradeCompatTesterOptions{
+				TestFolder:       folder,
+				ConfigPath:       config,
+				PackageRootPath:  r.packageRootPath,
+				Previous:         r.previous,
+				KibanaClient:     r.kibanaClient,
+				API:              r.esAPI,
+				GlobalTestConfig: r.globalTestConfig,
+			}))
+		}
+	}
+
+	if r.failOnMissingTests && len(testers) == 0 {
+		if len(r.dataStreams) > 0 {
+			return nil, fmt.Errorf("no system test configurations found for %s data stream(s)", strings.Join(r.dataStreams, ","))
+		}
+		return nil, errors.New("no system test configurations found")
+	}
+	return testers, nil
+}
+
+func (r *runner) Type() testrunner.TestType {
+	return TestType
+}
@@ -0,0 +1,110 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package upgradecompat
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/elastic-package/internal/packages"
+)
+
+func TestPreviousVersion(t *testing.T) {
+	revisions := func(versions ...string) []packages.PackageManifest {
+		var manifests []packages.PackageManifest
+		for _, version := range versions {
+			manifests = append(manifests, packages.PackageManifest{Version: version})
+		}
+		return manifests
+	}
+
+	cases := []struct {
+		title     string
+		version   string
+		revisions []packages.PackageManifest
+		expected  string
+	}{
+		{
+			title:     "latest older version",
+			version:   "1.3.0",
+			revisions: revisions("1.0.0", "1.2.0", "1.1.0", "1.3.0"),
+			expected:  "1.2.0",
+		},
+		{
+			title:     "newer versions are ignored",
+			version:   "1.3.0",
+			revisions: revisions("1.2.0", "1.4.0", "2.0.0"),
+			expected:  "1.2.0",
+		},
+		{
+			title:     "prereleases",
+			version:   "2.0.0-preview1",
+			revisions: revisions("1.2.0", "2.0.0-beta1", "2.0.0"),
+			expected:  "2.0.0-beta1",
+		},
+		{
+			title:     "first version",
+			version:   "0.1.0",
+			revisions: revisions("0.1.0"),
+			expected:  "",
+		},
+		{
+			title:    "not published",
+			version:  "0.1.0",
+			expected: "",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.title, func(t *testing.T) {
+			version, err := previousVersion(c.version, c.revisions)
+			require.NoError(t, err)
+			assert.Equal(t, c.expected, version)
+		})
+	}
+}
+
+func TestReadTestConfig(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "test-default-config.yml")
+	config := `input: httpjson
+vars:
+  url: http://{{Hostname}}:{{Port}}
+data_stream:
+  vars:
+    paths:
+      - "{{SERVICE_LOGS_DIR}}/*.log"
+`
+	require.NoError(t, os.WriteFile(path, []byte(config), 0o644))
+
+	c, err := readTestConfig(path)
+	require.NoError(t, err)
+	assert.Equal(t, "default", c.Name())
+	assert.Equal(t, "httpjson", c.Input)
+	assert.Equal(t, "http://localhost:8080", c.Vars["url"])
+	assert.Equal(t, []any{"/*.log"}, c.DataStream.Vars["paths"])
+}
+
+func TestSampleDocuments(t *testing.T) {
+	dir := t.TempDir()
+
+	documents, err := sampleDocuments(dir)
+	require.NoError(t, err)
+	assert.Len(t, documents, 1)
+
+	pipelineTestsDir := filepath.Join(dir, "_dev", "test", "pipeline")
+	require.NoError(t, os.MkdirAll(pipelineTestsDir, 0o755))
+	events := `{"events": [{"message": "foo"}, {"message": "bar"}]}`
+	require.NoError(t, os.WriteFile(filepath.Join(pipelineTestsDir, "test-foo.json"), []byte(events), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(pipelineTestsDir, "test-foo.json-expected.json"), []byte(`{"expected": []}`), 0o644))
+
+	documents, err = sampleDocuments(dir)
+	require.NoError(t, err)
+	assert.Equal(t, []map[string]any{{"message": "foo"}, {"message": "bar"}}, documents)
+}
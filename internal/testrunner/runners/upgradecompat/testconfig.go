@@ -0,0 +1,95 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package upgradecompat
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+
+	"github.com/aymerick/raymond"
+	"github.com/elastic/go-ucfg"
+	"github.com/elastic/go-ucfg/yaml"
+
+	"github.com/elastic/elastic-package/internal/common"
+	"github.com/elastic/elastic-package/internal/servicedeployer"
+	"github.com/elastic/elastic-package/internal/testrunner"
+)
+
+// Upgrade compatibility tests reuse the configuration files of system tests to create the package policies.
+var systemTestConfigFilePattern = regexp.MustCompile(`^test-([a-z0-9_.-]+)-config.yml$`)
+
+type testConfig struct {
+	testrunner.SkippableConfig `config:",inline"`
+
+	Path string
+
+	Input      string        `config:"input"`
+	Vars       common.MapStr `config:"vars"`
+	DataStream struct {
+		Vars common.MapStr `config:"vars"`
+	} `config:"data_stream"`
+}
+
+func (t testConfig) Name() string {
+	name := filepath.Base(t.Path)
+	if matches := systemTestConfigFilePattern.FindStringSubmatch(name); len(matches) > 1 {
+		name = matches[1]
+	}
+	return name
+}
+
+// placeholderServiceInfo is used to render the templates of system test configuration files.
+// Upgrade compatibility tests don't deploy services, so any value is valid as long as the policy can be created.
+var placeholderServiceInfo = servicedeployer.ServiceInfo{
+	Name:     "upgrade-compat",
+	Hostname: "localhost",
+	Ports:    []int{8080},
+	Port:     8080,
+}
+
+func readTestConfig(configFilePath string) (*testConfig, error) {
+	data, err := os.ReadFile(configFilePath)
+	if err != nil {
+		return nil, fmt.Errorf("could not load system test configuration file: %s: %w", configFilePath, err)
+	}
+
+	tmpl, err := raymond.Parse(string(data))
+	if err != nil {
+		return nil, fmt.Errorf("parsing template body failed: %w", err)
+	}
+	info := placeholderServiceInfo
+	tmpl.RegisterHelpers(info.Aliases())
+	rendered, err := tmpl.Exec(info)
+	if err != nil {
+		return nil, fmt.Errorf("could not render system test configuration file: %s: %w", configFilePath, err)
+	}
+
+	var c testConfig
+	cfg, err := yaml.NewConfig([]byte(rendered), ucfg.PathSep("."))
+	if err != nil {
+		return nil, fmt.Errorf("unable to load system test configuration file: %s: %w", configFilePath, err)
+	}
+	if err := cfg.Unpack(&c); err != nil {
+		return nil, fmt.Errorf("unable to unpack system test configuration file: %s: %w", configFilePath, err)
+	}
+	c.Path = configFilePath
+	return &c, nil
+}
+
+func listConfigFiles(systemTestFolderPath string) ([]string, error) {
+	entries, err := os.ReadDir(systemTestFolderPath)
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	for _, entry := range entries {
+		if !entry.IsDir() && systemTestConfigFilePattern.MatchString(entry.Name()) {
+			files = append(files, filepath.Join(systemTestFolderPath, entry.Name()))
+		}
+	}
+	return files, nil
+}
@@ -0,0 +1,406 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package upgradecompat
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/elastic/go-resource"
+
+	"github.com/elastic/elastic-package/internal/elasticsearch"
+	"github.com/elastic/elastic-package/internal/kibana"
+	"github.com/elastic/elastic-package/internal/logger"
+	"github.com/elastic/elastic-package/internal/packages"
+	"github.com/elastic/elastic-package/internal/resources"
+	"github.com/elastic/elastic-package/internal/testrunner"
+)
+
+const testNamespace = "ep"
+
+type tester struct {
+	testFolder       testrunner.TestFolder
+	configPath       string
+	packageRootPath  string
+	previous         *previousPackage
+	kibanaClient     *kibana.Client
+	esAPI            *elasticsearch.API
+	globalTestConfig testrunner.GlobalRunnerTestConfig
+
+	resourcesManager *resources.Manager
+}
+
+// Ensures that runner implements testrunner.Tester interface
+var _ testrunner.Tester = new(tester)
+
+type UpgradeCompatTesterOptions struct {
+	TestFolder       testrunner.TestFolder
+	ConfigPath       string
+	PackageRootPath  string
+	Previous         *previousPackage
+	KibanaClient     *kibana.Client
+	API              *elasticsearch.API
+	GlobalTestConfig testrunner.GlobalRunnerTestConfig
+}
+
+func NewUpgradeCompatTester(options UpgradeCompatTesterOptions) *tester {
+	tester := tester{
+		testFolder:       options.TestFolder,
+		configPath:       options.ConfigPath,
+		packageRootPath:  options.PackageRootPath,
+		previous:         options.Previous,
+		kibanaClient:     options.KibanaClient,
+		esAPI:            options.API,
+		globalTestConfig: options.GlobalTestConfig,
+	}
+	tester.resourcesManager = resources.NewManager()
+	tester.resourcesManager.RegisterProvider(resources.DefaultKibanaProviderName, &resources.KibanaProvider{Client: tester.kibanaClient})
+	return &tester
+}
+
+func (r *tester) Type() testrunner.TestType {
+	return TestType
+}
+
+func (r *tester) String() string {
+	return string(TestType)
+}
+
+// Parallel indicates if this tester can run in parallel or not.
+func (r tester) Parallel() bool {
+	// Tests install different versions of the same package, they cannot run in parallel.
+	return false
+}
+
+func (r *tester) Run(ctx context.Context) ([]testrunner.TestResult, error) {
+	result := testrunner.NewResultComposer(testrunner.TestResult{
+		TestType:   TestType,
+		Name:       filepath.Base(r.configPath),
+		Package:    r.testFolder.Package,
+		DataStream: r.testFolder.DataStream,
+	})
+
+	config, err := readTestConfig(r.configPath)
+	if err != nil {
+		return result.WithErrorf("failed to read test config from %s: %w", r.configPath, err)
+	}
+	result.Name = config.Name()
+
+	if skip := testrunner.AnySkipConfig(config.Skip, r.globalTestConfig.Skip); skip != nil {
+		logger.Warnf("skipping %s test for %s/%s: %s (details: %s)",
+			TestType, r.testFolder.Package, r.testFolder.DataStream,
+			skip.Reason, skip.Link)
+		return result.WithSkip(skip)
+	}
+
+	if r.previous == nil {
+		return result.WithSkip(&testrunner.SkipConfig{Reason: "no previous version of the package found"})
+	}
+
+	previousDataStreamPath := filepath.Join(r.previous.RootPath, "data_stream", r.testFolder.DataStream)
+	if _, err := os.Stat(previousDataStreamPath); errors.Is(err, os.ErrNotExist) {
+		return result.WithSkip(&testrunner.SkipConfig{
+			Reason: fmt.Sprintf("data stream not present in version %s", r.previous.Version),
+		})
+	}
+
+	testErr := r.runTest(ctx, config)
+	if testErr != nil {
+		return result.WithError(testErr)
+	}
+	return result.WithSuccess()
+}
+
+func (r *tester) runTest(ctx context.Context, config *testConfig) error {
+	current, err := r.readDataStream(r.packageRootPath)
+	if err != nil {
+		return err
+	}
+	previous, err := r.readDataStream(r.previous.RootPath)
+	if err != nil {
+		return err
+	}
+
+	err = r.installPreviousPackage(ctx)
+	if err != nil {
+		return err
+	}
+
+	testName := strings.TrimSuffix(filepath.Base(r.configPath), filepath.Ext(r.configPath))
+	policy := resources.FleetAgentPolicy{
+		Name:      fmt.Sprintf("%s-%s-%s", TestType, r.testFolder.Package, testName),
+		Namespace: testNamespace,
+		PackagePolicies: []resources.FleetPackagePolicy{
+			{
+				Name:           fmt.Sprintf("%s-%s-%s", TestType, r.testFolder.Package, testName),
+				RootPath:       r.previous.RootPath,
+				DataStreamName: r.testFolder.DataStream,
+				InputName:      config.Input,
+				Vars:           config.Vars,
+				DataStreamVars: config.DataStream.Vars,
+			},
+		},
+	}
+	policyResources := resource.Resources{&policy}
+	dataStream := previous.dataStreamName(testNamespace)
+
+	testErr := r.upgrade(ctx, policyResources, &policy, config, previous, current)
+
+	// Cleanup
+	policy.Absent = true
+	_, err = r.resourcesManager.ApplyCtx(context.WithoutCancel(ctx), policyResources)
+	if err == nil {
+		err = deleteDataStream(context.WithoutCancel(ctx), r.esAPI, dataStream)
+	}
+	if err != nil {
+		if testErr != nil {
+			return fmt.Errorf("cleanup failed with %w after test failed: %w", err, testErr)
+		}
+		return fmt.Errorf("cleanup failed: %w", err)
+	}
+	return testErr
+}
+
+func (r *tester) upgrade(ctx context.Context, policyResources resource.Resources, policy *resources.FleetAgentPolicy, config *testConfig, previous, current *dataStreamInfo) error {
+	if previous.dataset != current.dataset {
+		return testrunner.ErrTestCaseFailed{
+			Reason: fmt.Sprintf("dataset changed from %q to %q, data would be ingested in a different data stream after the upgrade", previous.dataset, current.dataset),
+		}
+	}
+	if config.Input != "" && !slices.Contains(current.inputs, config.Input) {
+		return testrunner.ErrTestCaseFailed{
+			Reason: fmt.Sprintf("input %q is not available in the data stream after the upgrade", config.Input),
+		}
+	}
+
+	logger.Debugf("Creating test policy with version %s of the package...", r.previous.Version)
+	_, err := r.resourcesManager.ApplyCtx(ctx, policyResources)
+	if err != nil {
+		return fmt.Errorf("failed to create policy with version %s of the package: %w", r.previous.Version, err)
+	}
+
+	// Documents are indexed directly in the data stream, as services and agents are not deployed.
+	// They are taken from each version of the package, and the test checks that they are processed
+	// by the ingest pipeline of that version.
+	documents, err := sampleDocuments(filepath.Join(r.previous.RootPath, "data_stream", r.testFolder.DataStream))
+	if err != nil {
+		return fmt.Errorf("failed to prepare documents to ingest with version %s of the package: %w", r.previous.Version, err)
+	}
+
+	err = r.ingestDocuments(ctx, previous, documents)
+	if err != nil {
+		return fmt.Errorf("failed to ingest data with version %s of the package: %w", r.previous.Version, err)
+	}
+
+	logger.Debugf("Upgrading package to version %s...", current.version)
+	_, err = r.resourcesManager.ApplyCtx(ctx, resource.Resources{&resources.FleetPackage{
+		RootPath: r.packageRootPath,
+		Force:    true,
+	}})
+	if err != nil {
+		return fmt.Errorf("failed to install version %s of the package: %w", current.version, err)
+	}
+
+	err = r.upgradePackagePolicies(ctx, policy.ID, current.version)
+	if err != nil {
+		return err
+	}
+
+	documents, err = sampleDocuments(filepath.Join(r.packageRootPath, "data_stream", r.testFolder.DataStream))
+	if err != nil {
+		return fmt.Errorf("failed to prepare documents to ingest with version %s of the package: %w", current.version, err)
+	}
+
+	err = r.ingestDocuments(ctx, current, documents)
+	var testErr testrunner.ErrTestCaseFailed
+	if errors.As(err, &testErr) {
+		return testErr
+	}
+	if err != nil {
+		return testrunner.ErrTestCaseFailed{
+			Reason:  fmt.Sprintf("data can't be ingested after upgrading to version %s", current.version),
+			Details: err.Error(),
+		}
+	}
+	return nil
+}
+
+// ingestDocuments indexes the documents in the data stream and, if the data stream defines an
+// ingest pipeline, checks that they are processed by the pipeline of the given version.
+func (r *tester) ingestDocuments(ctx context.Context, info *dataStreamInfo, documents []map[string]any) error {
+	if info.pipeline == "" {
+		return ingestDocuments(ctx, r.esAPI, info, testNamespace, documents)
+	}
+
+	countBefore, err := pipelineCount(r.esAPI, info.pipeline)
+	if err != nil {
+		return fmt.Errorf("failed to get stats of pipeline %s: %w", info.pipeline, err)
+	}
+	err = ingestDocuments(ctx, r.esAPI, info, testNamespace, documents)
+	if err != nil {
+		return err
+	}
+	countAfter, err := pipelineCount(r.esAPI, info.pipeline)
+	if err != nil {
+		return fmt.Errorf("failed to get stats of pipeline %s: %w", info.pipeline, err)
+	}
+	if processed := countAfter - countBefore; processed < int64(len(documents)) {
+		return testrunner.ErrTestCaseFailed{
+			Reason:  fmt.Sprintf("documents were not processed by ingest pipeline %s", info.pipeline),
+			Details: fmt.Sprintf("documents ingested: %d, processed by the pipeline: %d", len(documents), processed),
+		}
+	}
+	return nil
+}
+
+// upgradePackagePolicies upgrades the package policies of the agent policy, checking first
+// that they can be upgraded without conflicts.
+func (r *tester) upgradePackagePolicies(ctx context.Context, policyID string, version string) error {
+	packagePolicies, err := r.kibanaClient.ListPolicyPackagePolicies(ctx, policyID)
+	if err != nil {
+		return fmt.Errorf("failed to get package policies: %w", err)
+	}
+	var ids []string
+	for _, packagePolicy := range packagePolicies {
+		if packagePolicy.Package.Name == r.testFolder.Package {
+			ids = append(ids, packagePolicy.ID)
+		}
+	}
+	if len(ids) == 0 {
+		return fmt.Errorf("no package policies found for package %s in policy %s", r.testFolder.Package, policyID)
+	}
+
+	for _, dryRun := range []bool{true, false} {
+		results, err := r.kibanaClient.UpgradePackagePolicies(ctx, ids, dryRun)
+		if err != nil {
+			return err
+		}
+		if failure := upgradeFailure(results, dryRun); failure != nil {
+			return failure
+		}
+	}
+
+	packagePolicies, err = r.kibanaClient.ListPolicyPackagePolicies(ctx, policyID)
+	if err != nil {
+		return fmt.Errorf("failed to get upgraded package policies: %w", err)
+	}
+	for _, packagePolicy := range packagePolicies {
+		if slices.Contains(ids, packagePolicy.ID) && packagePolicy.Package.Version != version {
+			return testrunner.ErrTestCaseFailed{
+				Reason: fmt.Sprintf("package policy %q uses version %s after the upgrade, expected %s", packagePolicy.Name, packagePolicy.Package.Version, version),
+			}
+		}
+	}
+	return nil
+}
+
+func upgradeFailure(results []kibana.PackagePolicyUpgradeResult, dryRun bool) error {
+	var details []string
+	for _, result := range results {
+		if !result.Failed(dryRun) {
+			continue
+		}
+		errs := result.Errors()
+		if len(errs) == 0 {
+			errs = []string{"unknown error"}
+		}
+		details = append(details, fmt.Sprintf("%s: %s", result.Name, strings.Join(errs, "; ")))
+	}
+	if len(details) == 0 {
+		return nil
+	}
+
+	reason := "package policies failed to upgrade"
+	if dryRun {
+		reason = "package policies have conflicts with the new version"
+	}
+	return testrunner.ErrTestCaseFailed{
+		Reason:  reason,
+		Details: strings.Join(details, "\n"),
+	}
+}
+
+func (r *tester) installPreviousPackage(ctx context.Context) error {
+	// Remove any other installed version, Fleet doesn't allow to downgrade packages
+	// installed from archives.
+	_, err := r.resourcesManager.ApplyCtx(ctx, resource.Resources{&resources.FleetPackage{
+		RootPath: r.packageRootPath,
+		Absent:   true,
+	}})
+	if err != nil {
+		return fmt.Errorf("failed to uninstall package: %w", err)
+	}
+
+	logger.Debugf("Installing version %s of the package...", r.previous.Version)
+	if r.previous.ZipPath != "" {
+		_, err = r.kibanaClient.InstallZipPackage(ctx, r.previous.ZipPath)
+	} else {
+		_, err = r.kibanaClient.InstallPackage(ctx, r.previous.Name, r.previous.Version)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to install version %s of the package: %w", r.previous.Version, err)
+	}
+	return nil
+}
+
+// dataStreamInfo contains the details of the tested data stream in a version of the package.
+type dataStreamInfo struct {
+	version  string
+	dataType string
+	dataset  string
+	inputs   []string
+
+	// pipeline is the name of the ingest pipeline installed by Fleet for the data stream, if any.
+	pipeline string
+}
+
+func (i *dataStreamInfo) dataStreamName(namespace string) string {
+	return fmt.Sprintf("%s-%s-%s", i.dataType, i.dataset, namespace)
+}
+
+func (r *tester) readDataStream(packageRootPath string) (*dataStreamInfo, error) {
+	manifest, err := packages.ReadPackageManifestFromPackageRoot(packageRootPath)
+	if err != nil {
+		return nil, fmt.Errorf("reading package manifest failed (path: %s): %w", packageRootPath, err)
+	}
+	dsManifest, err := packages.ReadDataStreamManifestFromPackageRoot(packageRootPath, r.testFolder.DataStream)
+	if err != nil {
+		return nil, fmt.Errorf("reading data stream manifest failed (version: %s): %w", manifest.Version, err)
+	}
+
+	info := dataStreamInfo{
+		version:  manifest.Version,
+		dataType: dsManifest.Type,
+		dataset:  dsManifest.Dataset,
+	}
+	if info.dataset == "" {
+		info.dataset = fmt.Sprintf("%s.%s", manifest.Name, dsManifest.Name)
+	}
+	for _, stream := range dsManifest.Streams {
+		info.inputs = append(info.inputs, stream.Input)
+	}
+
+	pipelines, err := filepath.Glob(filepath.Join(packageRootPath, "data_stream", r.testFolder.DataStream, "elasticsearch", "ingest_pipeline", "*"))
+	if err != nil {
+		return nil, fmt.Errorf("listing ingest pipelines failed (version: %s): %w", manifest.Version, err)
+	}
+	if len(pipelines) > 0 {
+		// Same naming as used by Fleet when installing the pipelines of the data stream.
+		info.pipeline = dsManifest.GetPipelineNameOrDefault()
+		if info.pipeline == "default" {
+			info.pipeline = fmt.Sprintf("%s-%s-%s", info.dataType, info.dataset, info.version)
+		}
+	}
+	return &info, nil
+}
+
+func (r *tester) TearDown(ctx context.Context) error {
+	return nil
+}